@@ -199,6 +199,65 @@ func (s *Server) UpdateTokenCandlesLeft(ctx context.Context, req *pb.UpdateToken
 	}, nil
 }
 
+// UpdateTokenTradesLeft reserves or returns trades a token may still fetch. A
+// decrease only applies when the token has as many trades left, checked in the
+// same statement, so that concurrent requests cannot spend the same trades.
+// Unlike candles, running out of trades does not revoke the token.
+func (s *Server) UpdateTokenTradesLeft(ctx context.Context, req *pb.UpdateTokenTradesLeftRequest) (*pb.UpdateTokenTradesLeftResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+	if req.DecreaseTrades < 0 {
+		return nil, status.Error(codes.InvalidArgument, "decrease_trades must not be negative")
+	}
+	if req.IncreaseTrades < 0 {
+		return nil, status.Error(codes.InvalidArgument, "increase_trades must not be negative")
+	}
+
+	// Check if database connection is valid
+	if s.db == nil {
+		log.Printf("Database connection is nil")
+		return nil, status.Error(codes.Internal, "database connection not available")
+	}
+
+	// Find token in database
+	var token models.Token
+	result := s.db.Where("token_string = ?", req.Token).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			log.Printf("Token not found: %s", req.Token)
+			return nil, status.Error(codes.NotFound, "token not found")
+		}
+		log.Printf("Error finding token: %v", result.Error)
+		return nil, status.Error(codes.Internal, "failed to find token")
+	}
+
+	// Update the value in the database unless the decrease would overdraw it
+	result = s.db.Model(&models.Token{}).
+		Where("id = ? AND trades_left >= ?", token.ID, req.DecreaseTrades).
+		Update("trades_left", gorm.Expr("trades_left - ? + ?", req.DecreaseTrades, req.IncreaseTrades))
+	if result.Error != nil {
+		log.Printf("Error updating trades_left: %v", result.Error)
+		return nil, status.Error(codes.Internal, "failed to update token")
+	}
+	if result.RowsAffected == 0 {
+		return nil, status.Errorf(codes.ResourceExhausted, "token has fewer than %d trades left", req.DecreaseTrades)
+	}
+
+	var newTradesLeft int64
+	err := s.db.Model(&models.Token{}).Where("id = ?", token.ID).Select("trades_left").Scan(&newTradesLeft).Error
+	if err != nil {
+		log.Printf("Error scanning trades_left: %v", err)
+		return nil, status.Error(codes.Internal, "failed to read token")
+	}
+
+	log.Printf("Token trades_left updated successfully: %s, new value: %d (decreased by %d, increased by %d)",
+		req.Token, newTradesLeft, req.DecreaseTrades, req.IncreaseTrades)
+	return &pb.UpdateTokenTradesLeftResponse{
+		TradesLeft: newTradesLeft,
+	}, nil
+}
+
 // GetTokenInfo retrieves information about a token
 func (s *Server) GetTokenInfo(ctx context.Context, req *pb.GetTokenInfoRequest) (*pb.GetTokenInfoResponse, error) {
 	if req.Token == "" {
@@ -236,6 +295,7 @@ func (s *Server) GetTokenInfo(ctx context.Context, req *pb.GetTokenInfoRequest)
 		CandlesLeft: candlesLeft,
 		ExpiresAt:   token.ExpiresAt.Unix(),
 		Permissions: token.Permissions,
+		TradesLeft:  token.TradesLeft,
 	}, nil
 }
 
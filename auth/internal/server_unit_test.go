@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/timakaa/historical-common/database/models"
 	pb "github.com/timakaa/historical-common/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
@@ -384,6 +386,136 @@ func TestUpdateTokenCandlesLeftUnit(t *testing.T) {
 	})
 }
 
+func TestUpdateTokenTradesLeftUnit(t *testing.T) {
+	db := setupInMemoryDB(t)
+	server := authpkg.NewServer(db)
+
+	// Test successful decrease of trades count; candles are left untouched
+	t.Run("DecreaseTrades", func(t *testing.T) {
+		token := createTestToken(t, db, 100)
+
+		resp, err := server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			DecreaseTrades: 2500,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(97500), resp.TradesLeft)
+
+		var updatedToken models.Token
+		result := db.Where("token_string = ?", token.TokenString).First(&updatedToken)
+		assert.NoError(t, result.Error)
+		assert.Equal(t, int64(97500), updatedToken.TradesLeft)
+		assert.Equal(t, int64(100), updatedToken.CandlesLeft)
+	})
+
+	// Test with decrease past zero (token should be left as it is)
+	t.Run("DecreasePastZero", func(t *testing.T) {
+		token := createTestToken(t, db, 100)
+
+		resp, err := server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			DecreaseTrades: 250000,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		var updatedToken models.Token
+		result := db.Where("token_string = ?", token.TokenString).First(&updatedToken)
+		assert.NoError(t, result.Error)
+		assert.Equal(t, int64(100000), updatedToken.TradesLeft)
+	})
+
+	// Test reserving all trades left and returning the unused part
+	t.Run("ReserveAndReturn", func(t *testing.T) {
+		token := createTestToken(t, db, 100)
+
+		resp, err := server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			DecreaseTrades: 100000,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), resp.TradesLeft)
+
+		// A concurrent reservation finds nothing left
+		_, err = server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			DecreaseTrades: 1,
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		resp, err = server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			IncreaseTrades: 99000,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(99000), resp.TradesLeft)
+	})
+
+	// Test with negative IncreaseTrades value
+	t.Run("NegativeIncrease", func(t *testing.T) {
+		token := createTestToken(t, db, 100)
+
+		resp, err := server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			IncreaseTrades: -10,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	// Test with negative DecreaseTrades value
+	t.Run("NegativeDecrease", func(t *testing.T) {
+		token := createTestToken(t, db, 100)
+
+		resp, err := server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          token.TokenString,
+			DecreaseTrades: -10,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	// Test with non-existent token
+	t.Run("NonExistentToken", func(t *testing.T) {
+		resp, err := server.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          "non-existent-token",
+			DecreaseTrades: 10,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	// Test with nil database
+	t.Run("NilDatabase", func(t *testing.T) {
+		nilDBServer := authpkg.NewServer(nil)
+
+		resp, err := nilDBServer.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          "some-token",
+			DecreaseTrades: 10,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Contains(t, err.Error(), "database connection not available")
+	})
+
+	// Test with database error
+	t.Run("DatabaseError", func(t *testing.T) {
+		errorServer := authpkg.NewServer(setupErrorDB(t))
+
+		resp, err := errorServer.UpdateTokenTradesLeft(context.Background(), &pb.UpdateTokenTradesLeftRequest{
+			Token:          "some-token",
+			DecreaseTrades: 10,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Contains(t, err.Error(), "failed to find token")
+	})
+}
+
 func TestGetTokenInfoUnit(t *testing.T) {
 	db := setupInMemoryDB(t)
 	server := authpkg.NewServer(db)
@@ -401,6 +533,7 @@ func TestGetTokenInfoUnit(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, token.TokenString, resp.Token)
 		assert.Equal(t, int64(100), resp.CandlesLeft)
+		assert.Equal(t, int64(100000), resp.TradesLeft)
 		assert.Equal(t, token.ExpiresAt.Unix(), resp.ExpiresAt)
 	})
 
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// CandleDecimals holds the exact decimal strings a candle was sent with
+type CandleDecimals struct {
+	Open                string `json:"o,omitempty"`
+	High                string `json:"h,omitempty"`
+	Low                 string `json:"l,omitempty"`
+	Close               string `json:"c,omitempty"`
+	Volume              string `json:"v,omitempty"`
+	QuoteVolume         string `json:"qv,omitempty"`
+	TakerBuyBaseVolume  string `json:"tbb,omitempty"`
+	TakerBuyQuoteVolume string `json:"tbq,omitempty"`
+}
+
+// Candle is a closed candle of a price series, kept so that it is not fetched
+// from the exchange again
+type Candle struct {
+	ID                  uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Exchange            string          `json:"exchange" gorm:"uniqueIndex:idx_candles_series_time,priority:1"`
+	Market              string          `json:"market" gorm:"uniqueIndex:idx_candles_series_time,priority:2"`
+	Ticker              string          `json:"ticker" gorm:"uniqueIndex:idx_candles_series_time,priority:3"`
+	Interval            string          `json:"interval" gorm:"column:candle_interval;uniqueIndex:idx_candles_series_time,priority:4"`
+	PriceType           string          `json:"priceType" gorm:"uniqueIndex:idx_candles_series_time,priority:5"`
+	OpenTime            int64           `json:"openTime" gorm:"uniqueIndex:idx_candles_series_time,priority:6"` // epoch milliseconds
+	CloseTime           int64           `json:"closeTime"`                                                      // epoch milliseconds, inclusive
+	Open                float64         `json:"open"`
+	High                float64         `json:"high"`
+	Low                 float64         `json:"low"`
+	Close               float64         `json:"close"`
+	Volume              float64         `json:"volume"`
+	QuoteVolume         float64         `json:"quoteVolume"`
+	TradeCount          int64           `json:"tradeCount"`
+	TakerBuyBaseVolume  float64         `json:"takerBuyBaseVolume"`
+	TakerBuyQuoteVolume float64         `json:"takerBuyQuoteVolume"`
+	UnavailableFields   []int32         `json:"unavailableFields" gorm:"-"` // Stored as JSON in UnavailableJSON
+	Decimals            *CandleDecimals `json:"decimals" gorm:"-"`          // Stored as JSON in DecimalsJSON
+	UnavailableJSON     string          `json:"-" gorm:"column:unavailable_fields"`
+	DecimalsJSON        string          `json:"-" gorm:"column:decimals"`
+	SchemaVersion       uint32          `json:"schemaVersion"`
+}
+
+// TableName specifies the table name for the Candle model
+func (Candle) TableName() string {
+	return "candles"
+}
+
+// BeforeSave hook to handle JSON serialization of the unavailable fields and decimals
+func (c *Candle) BeforeSave(tx *gorm.DB) error {
+	unavailable, err := json.Marshal(c.UnavailableFields)
+	if err != nil {
+		return err
+	}
+	c.UnavailableJSON = string(unavailable)
+
+	c.DecimalsJSON = ""
+	if c.Decimals != nil {
+		decimals, err := json.Marshal(c.Decimals)
+		if err != nil {
+			return err
+		}
+		c.DecimalsJSON = string(decimals)
+	}
+	return nil
+}
+
+// AfterFind hook to handle JSON deserialization of the unavailable fields and decimals
+func (c *Candle) AfterFind(tx *gorm.DB) error {
+	if c.UnavailableJSON != "" {
+		if err := json.Unmarshal([]byte(c.UnavailableJSON), &c.UnavailableFields); err != nil {
+			return err
+		}
+	}
+	if c.DecimalsJSON != "" {
+		c.Decimals = &CandleDecimals{}
+		return json.Unmarshal([]byte(c.DecimalsJSON), c.Decimals)
+	}
+	return nil
+}
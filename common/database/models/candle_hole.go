@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CandleHole is a range of candles an exchange does not have, such as during an
+// outage, recorded so that it is not fetched again
+type CandleHole struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Exchange   string    `json:"exchange" gorm:"index:idx_candle_holes_series,priority:1"`
+	Market     string    `json:"market" gorm:"index:idx_candle_holes_series,priority:2"`
+	Ticker     string    `json:"ticker" gorm:"index:idx_candle_holes_series,priority:3"`
+	Interval   string    `json:"interval" gorm:"column:candle_interval;index:idx_candle_holes_series,priority:4"`
+	PriceType  string    `json:"priceType" gorm:"index:idx_candle_holes_series,priority:5"`
+	StartTime  int64     `json:"startTime" gorm:"index:idx_candle_holes_series,priority:6"` // epoch milliseconds open time of the first missing candle
+	EndTime    int64     `json:"endTime"`                                                   // epoch milliseconds open time of the last missing candle
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// TableName specifies the table name for the CandleHole model
+func (CandleHole) TableName() string {
+	return "candle_holes"
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderBookLevel is a price level of a recorded order book
+type OrderBookLevel struct {
+	Price    float64 `json:"p"`
+	Quantity float64 `json:"q"`
+}
+
+// OrderBookSnapshot is the top of an order book recorded at one point in time
+type OrderBookSnapshot struct {
+	ID           uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	Exchange     string           `json:"exchange" gorm:"index:idx_order_book_snapshots_symbol_time,priority:1"`
+	Ticker       string           `json:"ticker" gorm:"index:idx_order_book_snapshots_symbol_time,priority:2"`
+	Market       string           `json:"market" gorm:"index:idx_order_book_snapshots_symbol_time,priority:3"`
+	RecordedAt   time.Time        `json:"recordedAt" gorm:"index:idx_order_book_snapshots_symbol_time,priority:4"`
+	ExchangeTime int64            `json:"exchangeTime"` // epoch milliseconds, 0 when not reported
+	UpdateID     int64            `json:"updateId"`
+	Bids         []OrderBookLevel `json:"bids" gorm:"-"` // Stored as JSON in BidsJSON
+	Asks         []OrderBookLevel `json:"asks" gorm:"-"` // Stored as JSON in AsksJSON
+	BidsJSON     string           `json:"-" gorm:"column:bids"`
+	AsksJSON     string           `json:"-" gorm:"column:asks"`
+}
+
+// TableName specifies the table name for the OrderBookSnapshot model
+func (OrderBookSnapshot) TableName() string {
+	return "order_book_snapshots"
+}
+
+// BeforeSave hook to handle JSON serialization of the price levels
+func (s *OrderBookSnapshot) BeforeSave(tx *gorm.DB) error {
+	bids, err := json.Marshal(s.Bids)
+	if err != nil {
+		return err
+	}
+	asks, err := json.Marshal(s.Asks)
+	if err != nil {
+		return err
+	}
+	s.BidsJSON = string(bids)
+	s.AsksJSON = string(asks)
+	return nil
+}
+
+// AfterFind hook to handle JSON deserialization of the price levels
+func (s *OrderBookSnapshot) AfterFind(tx *gorm.DB) error {
+	if s.BidsJSON != "" {
+		if err := json.Unmarshal([]byte(s.BidsJSON), &s.Bids); err != nil {
+			return err
+		}
+	}
+	if s.AsksJSON != "" {
+		return json.Unmarshal([]byte(s.AsksJSON), &s.Asks)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ type Token struct {
 	TokenString     string    `json:"tokenString" gorm:"uniqueIndex"`
 	ExpiresAt       time.Time `json:"expiresAt"`
 	CandlesLeft     int64
+	TradesLeft      int64     `gorm:"default:100000"`
 	Permissions     []string  `json:"permissions" gorm:"-"` // Stored as JSON in PermissionsJSON
 	PermissionsJSON string    `json:"-" gorm:"column:permissions"`
 	CreatedAt       time.Time `json:"createdAt" gorm:"autoCreateTime"`
@@ -31,6 +32,7 @@ func NewToken(permissions []string, expiresIn int64) *Token {
 		TokenString: generateTokenString(),
 		Permissions: permissions,
 		CandlesLeft: 5000,
+		TradesLeft:  100000,
 		ExpiresAt:   now.Add(time.Duration(expiresIn) * time.Second),
 		CreatedAt:   now,
 	}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// WatchlistEntry is a candle series that is kept up to date in the candles table
+// by the ingestion scheduler
+type WatchlistEntry struct {
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Exchange      string    `json:"exchange" gorm:"uniqueIndex:idx_watchlist_entries_series,priority:1"`
+	Market        string    `json:"market" gorm:"uniqueIndex:idx_watchlist_entries_series,priority:2"`
+	Ticker        string    `json:"ticker" gorm:"uniqueIndex:idx_watchlist_entries_series,priority:3"`
+	Interval      string    `json:"interval" gorm:"column:candle_interval;uniqueIndex:idx_watchlist_entries_series,priority:4"`
+	PriceType     string    `json:"priceType" gorm:"uniqueIndex:idx_watchlist_entries_series,priority:5"`
+	IngestedUntil int64     `json:"ingestedUntil"` // epoch milliseconds open time of the next candle to ingest
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// TableName specifies the table name for the WatchlistEntry model
+func (WatchlistEntry) TableName() string {
+	return "watchlist_entries"
+}
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/timakaa/historical-common/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type PricesHandler struct {
@@ -23,9 +30,20 @@ func NewPricesHandler(pricesClient proto.PricesClient, authClient proto.AuthClie
 
 func (h *PricesHandler) HandleGetHistoricalPrices(c *gin.Context) {
 	exchange := c.Param("exchange")
-	ticker := c.Param("ticker")
+	// The ticker is a catch-all so that canonical symbols such as BTC/USDT can be
+	// written as they are
+	ticker := strings.TrimPrefix(c.Param("ticker"), "/")
 	token := c.GetHeader("x-api-key")
 	limitStr := c.Query("limit")
+	interval := c.Query("interval")
+	market := c.Query("market")
+	priceType := c.Query("price_type")
+	utcOffset := utcOffsetParam(c)
+
+	if ticker == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing ticker"})
+		return
+	}
 
 	var limit int64 = 100
 	if limitStr != "" {
@@ -37,11 +55,50 @@ func (h *PricesHandler) HandleGetHistoricalPrices(c *gin.Context) {
 		limit = parsedLimit
 	}
 
+	// Optional time range in epoch milliseconds; start_time=listing starts at the
+	// first candle of the symbol
+	var startTime, endTime int64
+	var ok bool
+	fromListing := c.Query("start_time") == "listing"
+	if fromListing {
+		endTime, ok = parseEpochMillis(c, "end_time")
+	} else {
+		startTime, endTime, ok = parseTimeRange(c)
+	}
+	if !ok {
+		return
+	}
+
+	// A range is bounded by the range itself unless a limit was given
+	if (startTime != 0 || fromListing) && limitStr == "" {
+		limit = 0
+	}
+
+	// Optionally return the exact decimal strings next to the float values
+	var includeDecimals bool
+	if decimalsStr := c.Query("decimals"); decimalsStr != "" {
+		parsedDecimals, err := strconv.ParseBool(decimalsStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid decimals parameter"})
+			return
+		}
+		includeDecimals = parsedDecimals
+	}
+
 	// Create gRPC request
 	req := &proto.PricesRequest{
-		Exchange: exchange,
-		Ticker:   ticker,
-		Limit:    limit,
+		Exchange:        exchange,
+		Ticker:          ticker,
+		Limit:           limit,
+		Interval:        interval,
+		Market:          market,
+		PriceType:       priceType,
+		StartTime:       startTime,
+		EndTime:         endTime,
+		IncludeDecimals: includeDecimals,
+		FromListing:     fromListing,
+		UtcOffset:       utcOffset,
+		ConvertTo:       c.Query("convert_to"),
 	}
 
 	// Call gRPC service
@@ -51,12 +108,53 @@ func (h *PricesHandler) HandleGetHistoricalPrices(c *gin.Context) {
 		return
 	}
 
+	type PriceDecimals struct {
+		Open                string `json:"open"`
+		High                string `json:"high"`
+		Low                 string `json:"low"`
+		Close               string `json:"close"`
+		Volume              string `json:"volume"`
+		QuoteVolume         string `json:"quoteVolume,omitempty"`
+		TakerBuyBaseVolume  string `json:"takerBuyBaseVolume,omitempty"`
+		TakerBuyQuoteVolume string `json:"takerBuyQuoteVolume,omitempty"`
+	}
+
+	// PriceVenue is a venue a composite candle was merged from
+	type PriceVenue struct {
+		Exchange string  `json:"exchange"`
+		Close    float64 `json:"close"`
+		Volume   float64 `json:"volume"`
+		Share    float64 `json:"share"`
+		Excluded string  `json:"excluded,omitempty"`
+	}
+
+	// Conversion describes prices converted into another quote asset; the high
+	// and low are bounds unless the rate stood still during the candle
+	type Conversion struct {
+		To                 string  `json:"to"`
+		RateTicker         string  `json:"rateTicker,omitempty"`
+		Inverted           bool    `json:"inverted"`
+		RateClose          float64 `json:"rateClose"`
+		ApproximateHighLow bool    `json:"approximateHighLow"`
+	}
+
 	type Price struct {
-		Open   float64 `json:"open"`
-		High   float64 `json:"high"`
-		Low    float64 `json:"low"`
-		Close  float64 `json:"close"`
-		Volume float64 `json:"volume"`
+		OpenTime            int64          `json:"openTime"`
+		CloseTime           int64          `json:"closeTime"`
+		Open                float64        `json:"open"`
+		High                float64        `json:"high"`
+		Low                 float64        `json:"low"`
+		Close               float64        `json:"close"`
+		Volume              float64        `json:"volume"`
+		QuoteVolume         float64        `json:"quoteVolume"`
+		TradeCount          int64          `json:"tradeCount"`
+		TakerBuyBaseVolume  float64        `json:"takerBuyBaseVolume"`
+		TakerBuyQuoteVolume float64        `json:"takerBuyQuoteVolume"`
+		UnavailableFields   []string       `json:"unavailableFields,omitempty"`
+		SchemaVersion       uint32         `json:"schemaVersion"`
+		Decimals            *PriceDecimals `json:"decimals,omitempty"`
+		Venues              []PriceVenue   `json:"venues,omitempty"`
+		Conversion          *Conversion    `json:"conversion,omitempty"`
 	}
 
 	// Collect all prices in an array
@@ -67,18 +165,69 @@ func (h *PricesHandler) HandleGetHistoricalPrices(c *gin.Context) {
 			if err.Error() == "EOF" {
 				break
 			}
+			if code, message, ok := requestError(err); ok {
+				c.JSON(code, gin.H{"error": message})
+				return
+			}
 			log.Printf("Error receiving price: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error receiving prices"})
 			return
 		}
 
+		var decimals *PriceDecimals
+		if d := resp.GetDecimals(); d != nil {
+			decimals = &PriceDecimals{
+				Open:                d.Open,
+				High:                d.High,
+				Low:                 d.Low,
+				Close:               d.Close,
+				Volume:              d.Volume,
+				QuoteVolume:         d.QuoteVolume,
+				TakerBuyBaseVolume:  d.TakerBuyBaseVolume,
+				TakerBuyQuoteVolume: d.TakerBuyQuoteVolume,
+			}
+		}
+
+		var venues []PriceVenue
+		for _, venue := range resp.GetVenues() {
+			venues = append(venues, PriceVenue{
+				Exchange: venue.Exchange,
+				Close:    venue.Close,
+				Volume:   venue.Volume,
+				Share:    venue.Share,
+				Excluded: venue.Excluded,
+			})
+		}
+
+		var conversion *Conversion
+		if conv := resp.GetConversion(); conv != nil {
+			conversion = &Conversion{
+				To:                 conv.To,
+				RateTicker:         conv.RateTicker,
+				Inverted:           conv.Inverted,
+				RateClose:          conv.RateClose,
+				ApproximateHighLow: conv.ApproximateHighLow,
+			}
+		}
+
 		// Add price to array
 		prices = append(prices, Price{
-			Open:   resp.Open,
-			High:   resp.High,
-			Low:    resp.Low,
-			Close:  resp.Close,
-			Volume: resp.Volume,
+			OpenTime:            resp.OpenTime,
+			CloseTime:           resp.CloseTime,
+			Open:                resp.Open,
+			High:                resp.High,
+			Low:                 resp.Low,
+			Close:               resp.Close,
+			Volume:              resp.Volume,
+			QuoteVolume:         resp.QuoteVolume,
+			TradeCount:          resp.TradeCount,
+			TakerBuyBaseVolume:  resp.TakerBuyBaseVolume,
+			TakerBuyQuoteVolume: resp.TakerBuyQuoteVolume,
+			UnavailableFields:   candleFieldNames(resp.UnavailableFields),
+			SchemaVersion:       resp.SchemaVersion,
+			Decimals:            decimals,
+			Venues:              venues,
+			Conversion:          conversion,
 		})
 	}
 
@@ -99,6 +248,590 @@ func (h *PricesHandler) HandleGetHistoricalPrices(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"prices": prices})
 }
 
+// utcOffsetParam reads the utc_offset parameter. A + in a query string decodes
+// to a space, so +05:30 may arrive as " 05:30".
+func utcOffsetParam(c *gin.Context) string {
+	utcOffset := c.Query("utc_offset")
+	if strings.HasPrefix(utcOffset, " ") {
+		utcOffset = "+" + utcOffset[1:]
+	}
+	return utcOffset
+}
+
+// parseTimeRange reads the optional start_time and end_time parameters in epoch
+// milliseconds, responding with 400 when one is malformed
+func parseTimeRange(c *gin.Context) (int64, int64, bool) {
+	startTime, ok := parseEpochMillis(c, "start_time")
+	if !ok {
+		return 0, 0, false
+	}
+	endTime, ok := parseEpochMillis(c, "end_time")
+	if !ok {
+		return 0, 0, false
+	}
+	return startTime, endTime, true
+}
+
+// parseEpochMillis reads an optional epoch milliseconds parameter, responding with
+// 400 when it is malformed
+func parseEpochMillis(c *gin.Context, name string) (int64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, true
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s parameter", name)})
+		return 0, false
+	}
+	return parsed, true
+}
+
+func (h *PricesHandler) HandleGetFundingRates(c *gin.Context) {
+	exchange := c.Param("exchange")
+	ticker := c.Param("ticker")
+	token := c.GetHeader("x-api-key")
+	limitStr := c.Query("limit")
+
+	var limit int64 = 100
+	if limitStr != "" {
+		parsedLimit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		limit = parsedLimit
+	}
+
+	// Optional time range in epoch milliseconds
+	startTime, endTime, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	// A range is bounded by the range itself unless a limit was given
+	if startTime != 0 && limitStr == "" {
+		limit = 0
+	}
+
+	// Call gRPC service
+	stream, err := h.pricesClient.GetFundingRates(c.Request.Context(), &proto.FundingRatesRequest{
+		Exchange:  exchange,
+		Ticker:    ticker,
+		Limit:     limit,
+		Market:    c.Query("market"),
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get funding rates"})
+		return
+	}
+
+	type FundingRate struct {
+		FundingTime int64    `json:"fundingTime"`
+		Rate        float64  `json:"rate"`
+		MarkPrice   *float64 `json:"markPrice"`
+	}
+
+	// Collect all funding rates in an array
+	rates := []FundingRate{}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			if code, message, ok := requestError(err); ok {
+				c.JSON(code, gin.H{"error": message})
+				return
+			}
+			log.Printf("Error receiving funding rate: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error receiving funding rates"})
+			return
+		}
+
+		// Settlements without a mark price are returned with a null one
+		rate := FundingRate{
+			FundingTime: resp.FundingTime,
+			Rate:        resp.Rate,
+		}
+		if resp.HasMarkPrice {
+			markPrice := resp.MarkPrice
+			rate.MarkPrice = &markPrice
+		}
+		rates = append(rates, rate)
+	}
+
+	// Funding rates are billed like candles, one per settlement
+	if token != "" {
+		updateReq := &proto.UpdateTokenCandlesLeftRequest{
+			Token:           token,
+			DecreaseCandles: int64(len(rates)),
+		}
+
+		_, err := h.authClient.UpdateTokenCandlesLeft(c.Request.Context(), updateReq)
+		if err != nil {
+			log.Printf("Error updating candles left: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fundingRates": rates})
+}
+
+// tradesFlushInterval is the number of trades written between flushes of a trades response
+const tradesFlushInterval = 500
+
+// HandleGetTrades streams the trades of a time window as newline-delimited JSON,
+// one trade per line, so that windows of any size pass through with bounded memory
+func (h *PricesHandler) HandleGetTrades(c *gin.Context) {
+	exchange := c.Param("exchange")
+	ticker := c.Param("ticker")
+	token := c.GetHeader("x-api-key")
+
+	// Without a limit the whole window is streamed
+	var limit int64
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsedLimit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsedLimit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		limit = parsedLimit
+	}
+
+	// Trades require a time range in epoch milliseconds
+	startTime, endTime, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+	if startTime == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time is required"})
+		return
+	}
+
+	// Trades are billed in their own unit. A request is capped at what the token
+	// has left, which is reserved up front so that concurrent requests cannot
+	// spend the same trades; what was not sent is returned once the stream ends,
+	// even when the client went away mid-stream.
+	var sent int64
+	if token != "" {
+		reserved, err := h.reserveTrades(c.Request.Context(), token, limit)
+		if errors.Is(err, errNoTradesLeft) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "no trades left for this token"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error reserving trades: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reserve trades"})
+			return
+		}
+		limit = reserved
+		defer func() {
+			h.returnTrades(context.WithoutCancel(c.Request.Context()), token, reserved-sent)
+		}()
+	}
+
+	// Call gRPC service
+	stream, err := h.pricesClient.GetTrades(c.Request.Context(), &proto.TradesRequest{
+		Exchange:  exchange,
+		Ticker:    ticker,
+		Limit:     limit,
+		Market:    c.Query("market"),
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get trades"})
+		return
+	}
+
+	// The first trade is received before the response starts, so that invalid
+	// requests are still answered with an error status
+	resp, err := stream.Recv()
+	if err != nil && err.Error() != "EOF" {
+		if code, message, ok := requestError(err); ok {
+			c.JSON(code, gin.H{"error": message})
+			return
+		}
+		log.Printf("Error receiving trade: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error receiving trades"})
+		return
+	}
+
+	type Trade struct {
+		TradeID    string  `json:"tradeId"`
+		Timestamp  int64   `json:"timestamp"`
+		Price      float64 `json:"price"`
+		Quantity   float64 `json:"quantity"`
+		Side       string  `json:"side"`
+		TradeCount int64   `json:"tradeCount"`
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+
+	for err == nil {
+		if err = encoder.Encode(Trade{
+			TradeID:    resp.TradeId,
+			Timestamp:  resp.Timestamp,
+			Price:      resp.Price,
+			Quantity:   resp.Quantity,
+			Side:       resp.Side,
+			TradeCount: resp.TradeCount,
+		}); err != nil {
+			log.Printf("Error writing trade: %v", err)
+			break
+		}
+		sent++
+		if sent%tradesFlushInterval == 0 {
+			c.Writer.Flush()
+		}
+
+		resp, err = stream.Recv()
+		if err != nil && err.Error() != "EOF" {
+			// The status line is already sent, so a failure ends the stream with an error line
+			log.Printf("Error receiving trade: %v", err)
+			encoder.Encode(gin.H{"error": "error receiving trades"})
+		}
+	}
+	c.Writer.Flush()
+}
+
+// requestErrorStatuses maps the gRPC codes the prices service rejects a request
+// with to the HTTP status the rejection is passed on with
+var requestErrorStatuses = map[codes.Code]int{
+	codes.InvalidArgument: http.StatusBadRequest,
+	codes.NotFound:        http.StatusNotFound,
+}
+
+// requestError returns the HTTP status and message to answer with when the
+// prices service rejected a request; other errors are internal
+func requestError(err error) (int, string, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, "", false
+	}
+	code, ok := requestErrorStatuses[st.Code()]
+	return code, st.Message(), ok
+}
+
+// errNoTradesLeft is returned when a token has no trades left to reserve
+var errNoTradesLeft = errors.New("no trades left")
+
+// tradeReservationAttempts is how often a reservation is retried when concurrent
+// requests spend the trades of a token between reading and reserving them
+const tradeReservationAttempts = 3
+
+// reserveTrades reserves up to limit trades of a token, or all it has left
+// without a limit, returning the number reserved
+func (h *PricesHandler) reserveTrades(ctx context.Context, token string, limit int64) (int64, error) {
+	for attempt := 0; attempt < tradeReservationAttempts; attempt++ {
+		info, err := h.authClient.GetTokenInfo(ctx, &proto.GetTokenInfoRequest{Token: token})
+		if err != nil {
+			return 0, fmt.Errorf("error getting token info: %w", err)
+		}
+		if info.TradesLeft <= 0 {
+			return 0, errNoTradesLeft
+		}
+
+		reserved := limit
+		if limit == 0 || limit > info.TradesLeft {
+			reserved = info.TradesLeft
+		}
+		_, err = h.authClient.UpdateTokenTradesLeft(ctx, &proto.UpdateTokenTradesLeftRequest{
+			Token:          token,
+			DecreaseTrades: reserved,
+		})
+		if status.Code(err) == codes.ResourceExhausted {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error decreasing trades left: %w", err)
+		}
+		return reserved, nil
+	}
+	return 0, errNoTradesLeft
+}
+
+// returnTrades gives reserved trades that were not sent back to a token
+func (h *PricesHandler) returnTrades(ctx context.Context, token string, unused int64) {
+	if unused <= 0 {
+		return
+	}
+	_, err := h.authClient.UpdateTokenTradesLeft(ctx, &proto.UpdateTokenTradesLeftRequest{
+		Token:          token,
+		IncreaseTrades: unused,
+	})
+	if err != nil {
+		log.Printf("Error returning %d unused trades: %v", unused, err)
+	}
+}
+
+// candleFieldJSONNames maps candle fields to the JSON keys they are returned under
+var candleFieldJSONNames = map[proto.CandleField]string{
+	proto.CandleField_CANDLE_FIELD_VOLUME:                 "volume",
+	proto.CandleField_CANDLE_FIELD_QUOTE_VOLUME:           "quoteVolume",
+	proto.CandleField_CANDLE_FIELD_TRADE_COUNT:            "tradeCount",
+	proto.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME:  "takerBuyBaseVolume",
+	proto.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME: "takerBuyQuoteVolume",
+}
+
+// candleFieldNames converts the fields an exchange does not provide to their JSON keys
+func candleFieldNames(fields []proto.CandleField) []string {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if name, ok := candleFieldJSONNames[field]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// HandleListExchanges describes the supported exchanges
+func (h *PricesHandler) HandleListExchanges(c *gin.Context) {
+	resp, err := h.pricesClient.ListExchanges(c.Request.Context(), &proto.ListExchangesRequest{})
+	if err != nil {
+		log.Printf("Error listing exchanges: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list exchanges"})
+		return
+	}
+
+	type Exchange struct {
+		Name        string   `json:"name"`
+		Markets     []string `json:"markets"`
+		Intervals   []string `json:"intervals"`
+		PriceTypes  []string `json:"priceTypes"`
+		MaxPageSize int32    `json:"maxPageSize"`
+	}
+
+	exchanges := make([]Exchange, 0, len(resp.Exchanges))
+	for _, exchange := range resp.Exchanges {
+		exchanges = append(exchanges, Exchange{
+			Name:        exchange.Name,
+			Markets:     exchange.Markets,
+			Intervals:   exchange.Intervals,
+			PriceTypes:  exchange.PriceTypes,
+			MaxPageSize: exchange.MaxPageSize,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exchanges": exchanges})
+}
+
+// HandleListSymbols lists the symbols of an exchange market
+func (h *PricesHandler) HandleListSymbols(c *gin.Context) {
+	resp, err := h.pricesClient.ListSymbols(c.Request.Context(), &proto.ListSymbolsRequest{
+		Exchange: c.Param("exchange"),
+		Market:   c.Query("market"),
+	})
+	if err != nil {
+		if code, message, ok := requestError(err); ok {
+			c.JSON(code, gin.H{"error": message})
+			return
+		}
+		log.Printf("Error listing symbols: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list symbols"})
+		return
+	}
+
+	type Symbol struct {
+		Symbol          string `json:"symbol"`
+		CanonicalSymbol string `json:"canonicalSymbol,omitempty"`
+		Base            string `json:"base"`
+		Quote           string `json:"quote"`
+		Status          string `json:"status"`
+		TickSize        string `json:"tickSize"`
+		ListingTime     int64  `json:"listingTime,omitempty"`
+	}
+
+	symbols := make([]Symbol, 0, len(resp.Symbols))
+	for _, symbol := range resp.Symbols {
+		symbols = append(symbols, Symbol{
+			Symbol:          symbol.Symbol,
+			CanonicalSymbol: symbol.CanonicalSymbol,
+			Base:            symbol.Base,
+			Quote:           symbol.Quote,
+			Status:          symbol.Status,
+			TickSize:        symbol.TickSize,
+			ListingTime:     symbol.ListingTime,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"symbols": symbols})
+}
+
+// HandleGetIndicators returns technical indicators at the candles of a symbol,
+// such as indicators=sma(20),rsi,macd(12,26,9)
+func (h *PricesHandler) HandleGetIndicators(c *gin.Context) {
+	exchange := c.Param("exchange")
+	ticker := strings.TrimPrefix(c.Param("ticker"), "/")
+	token := c.GetHeader("x-api-key")
+	limitStr := c.Query("limit")
+
+	if ticker == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing ticker"})
+		return
+	}
+
+	specs, err := parseIndicatorSpecs(c.Query("indicators"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var limit int64 = 100
+	if limitStr != "" {
+		parsedLimit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		limit = parsedLimit
+	}
+
+	startTime, endTime, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	// A range is bounded by the range itself unless a limit was given
+	if startTime != 0 && limitStr == "" {
+		limit = 0
+	}
+
+	stream, err := h.pricesClient.GetIndicators(c.Request.Context(), &proto.IndicatorsRequest{
+		Exchange:   exchange,
+		Ticker:     ticker,
+		Market:     c.Query("market"),
+		Interval:   c.Query("interval"),
+		Limit:      limit,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Indicators: specs,
+		UtcOffset:  utcOffsetParam(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get indicators"})
+		return
+	}
+
+	type Indicators struct {
+		OpenTime  int64              `json:"openTime"`
+		CloseTime int64              `json:"closeTime"`
+		Close     float64            `json:"close"`
+		Values    map[string]float64 `json:"values"`
+	}
+
+	var values []Indicators
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			if code, message, ok := requestError(err); ok {
+				c.JSON(code, gin.H{"error": message})
+				return
+			}
+			log.Printf("Error receiving indicators: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error receiving indicators"})
+			return
+		}
+
+		values = append(values, Indicators{
+			OpenTime:  resp.OpenTime,
+			CloseTime: resp.CloseTime,
+			Close:     resp.Close,
+			Values:    resp.Values,
+		})
+	}
+
+	// Indicators are billed by the candles they are returned at
+	if token != "" {
+		updateReq := &proto.UpdateTokenCandlesLeftRequest{
+			Token:           token,
+			DecreaseCandles: int64(len(values)),
+		}
+
+		_, err := h.authClient.UpdateTokenCandlesLeft(c.Request.Context(), updateReq)
+		if err != nil {
+			log.Printf("Error updating candles left: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"indicators": values})
+}
+
+// parseIndicatorSpecs parses a comma separated list of indicators with optional
+// parameters in parentheses, such as sma(20),rsi,bollinger(20,2.5)
+func parseIndicatorSpecs(value string) ([]*proto.IndicatorSpec, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing indicators parameter")
+	}
+
+	// Commas separate indicators only outside of parentheses
+	var specs []*proto.IndicatorSpec
+	depth, begin := 0, 0
+	for i := 0; i <= len(value); i++ {
+		if i < len(value) {
+			switch value[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth < 0 || depth > 1 {
+				return nil, fmt.Errorf("invalid indicators parameter: unbalanced parentheses")
+			}
+			if value[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		if depth != 0 {
+			return nil, fmt.Errorf("invalid indicators parameter: unbalanced parentheses")
+		}
+
+		spec, err := parseIndicatorSpec(strings.TrimSpace(value[begin:i]))
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+		begin = i + 1
+	}
+	return specs, nil
+}
+
+// parseIndicatorSpec parses a single indicator such as macd(12,26,9)
+func parseIndicatorSpec(value string) (*proto.IndicatorSpec, error) {
+	name, params, hasParams := strings.Cut(value, "(")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid indicator %q: missing name", value)
+	}
+
+	spec := &proto.IndicatorSpec{Name: name}
+	if !hasParams {
+		return spec, nil
+	}
+	if !strings.HasSuffix(params, ")") {
+		return nil, fmt.Errorf("invalid indicator %q: expected parameters in parentheses", value)
+	}
+	params = strings.TrimSuffix(params, ")")
+	if strings.TrimSpace(params) == "" {
+		return spec, nil
+	}
+	for _, param := range strings.Split(params, ",") {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(param), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid indicator %q: parameters must be numbers", value)
+		}
+		spec.Params = append(spec.Params, parsed)
+	}
+	return spec, nil
+}
+
 func (h *PricesHandler) RegisterRoutes(router *gin.RouterGroup, middlewares ...gin.HandlerFunc) {
 	pricesGroup := router.Group("/prices")
 
@@ -106,5 +839,38 @@ func (h *PricesHandler) RegisterRoutes(router *gin.RouterGroup, middlewares ...g
 		pricesGroup.Use(middlewares...)
 	}
 
-	pricesGroup.GET("/:exchange/:ticker", h.HandleGetHistoricalPrices)
+	pricesGroup.GET("/:exchange/*ticker", h.HandleGetHistoricalPrices)
+
+	fundingGroup := router.Group("/funding-rates")
+
+	if len(middlewares) > 0 {
+		fundingGroup.Use(middlewares...)
+	}
+
+	fundingGroup.GET("/:exchange/:ticker", h.HandleGetFundingRates)
+
+	tradesGroup := router.Group("/trades")
+
+	if len(middlewares) > 0 {
+		tradesGroup.Use(middlewares...)
+	}
+
+	tradesGroup.GET("/:exchange/:ticker", h.HandleGetTrades)
+
+	exchangesGroup := router.Group("/exchanges")
+
+	if len(middlewares) > 0 {
+		exchangesGroup.Use(middlewares...)
+	}
+
+	exchangesGroup.GET("", h.HandleListExchanges)
+	exchangesGroup.GET("/:exchange/symbols", h.HandleListSymbols)
+
+	indicatorsGroup := router.Group("/indicators")
+
+	if len(middlewares) > 0 {
+		indicatorsGroup.Use(middlewares...)
+	}
+
+	indicatorsGroup.GET("/:exchange/*ticker", h.HandleGetIndicators)
 }
@@ -0,0 +1,689 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timakaa/historical-common/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubStream serves fixed responses, then fails with err or ends the stream
+type stubStream[T any] struct {
+	grpc.ClientStream
+	responses []*T
+	err       error
+}
+
+func (s *stubStream[T]) Recv() (*T, error) {
+	if len(s.responses) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	response := s.responses[0]
+	s.responses = s.responses[1:]
+	return response, nil
+}
+
+// stubPricesClient serves fixed responses, failing streams with err once they
+// are sent, and records the requests it receives
+type stubPricesClient struct {
+	proto.PricesClient
+	prices       []*proto.PricesResponse
+	fundingRates []*proto.FundingRate
+	trades       []*proto.Trade
+	exchanges    []*proto.ExchangeInfo
+	symbols      []*proto.SymbolInfo
+	indicators   []*proto.IndicatorValues
+	err          error
+	openErr      error // fails opening a stream
+
+	pricesRequest     *proto.PricesRequest
+	fundingRequest    *proto.FundingRatesRequest
+	tradesRequest     *proto.TradesRequest
+	symbolsRequest    *proto.ListSymbolsRequest
+	indicatorsRequest *proto.IndicatorsRequest
+	onTrades          func()
+}
+
+func (c *stubPricesClient) GetPrices(ctx context.Context, in *proto.PricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[proto.PricesResponse], error) {
+	c.pricesRequest = in
+	if c.openErr != nil {
+		return nil, c.openErr
+	}
+	return &stubStream[proto.PricesResponse]{responses: c.prices, err: c.err}, nil
+}
+
+func (c *stubPricesClient) GetFundingRates(ctx context.Context, in *proto.FundingRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[proto.FundingRate], error) {
+	c.fundingRequest = in
+	if c.openErr != nil {
+		return nil, c.openErr
+	}
+	return &stubStream[proto.FundingRate]{responses: c.fundingRates, err: c.err}, nil
+}
+
+func (c *stubPricesClient) GetTrades(ctx context.Context, in *proto.TradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[proto.Trade], error) {
+	c.tradesRequest = in
+	if c.onTrades != nil {
+		c.onTrades()
+	}
+	trades := c.trades
+	if in.Limit > 0 && int64(len(trades)) > in.Limit {
+		trades = trades[:in.Limit]
+	}
+	return &stubStream[proto.Trade]{responses: trades, err: c.err}, nil
+}
+
+func (c *stubPricesClient) GetIndicators(ctx context.Context, in *proto.IndicatorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[proto.IndicatorValues], error) {
+	c.indicatorsRequest = in
+	if c.openErr != nil {
+		return nil, c.openErr
+	}
+	return &stubStream[proto.IndicatorValues]{responses: c.indicators, err: c.err}, nil
+}
+
+func (c *stubPricesClient) ListExchanges(ctx context.Context, in *proto.ListExchangesRequest, opts ...grpc.CallOption) (*proto.ListExchangesResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &proto.ListExchangesResponse{Exchanges: c.exchanges}, nil
+}
+
+func (c *stubPricesClient) ListSymbols(ctx context.Context, in *proto.ListSymbolsRequest, opts ...grpc.CallOption) (*proto.ListSymbolsResponse, error) {
+	c.symbolsRequest = in
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &proto.ListSymbolsResponse{Symbols: c.symbols}, nil
+}
+
+// stubAuthClient keeps the trade balance of a single token and the candles billed to it
+type stubAuthClient struct {
+	proto.AuthClient
+	tradesLeft    int64
+	candlesBilled int64
+}
+
+func (c *stubAuthClient) GetTokenInfo(ctx context.Context, in *proto.GetTokenInfoRequest, opts ...grpc.CallOption) (*proto.GetTokenInfoResponse, error) {
+	return &proto.GetTokenInfoResponse{Token: in.Token, TradesLeft: c.tradesLeft}, nil
+}
+
+func (c *stubAuthClient) UpdateTokenTradesLeft(ctx context.Context, in *proto.UpdateTokenTradesLeftRequest, opts ...grpc.CallOption) (*proto.UpdateTokenTradesLeftResponse, error) {
+	if in.DecreaseTrades > c.tradesLeft {
+		return nil, status.Error(codes.ResourceExhausted, "not enough trades left")
+	}
+	c.tradesLeft += in.IncreaseTrades - in.DecreaseTrades
+	return &proto.UpdateTokenTradesLeftResponse{TradesLeft: c.tradesLeft}, nil
+}
+
+func (c *stubAuthClient) UpdateTokenCandlesLeft(ctx context.Context, in *proto.UpdateTokenCandlesLeftRequest, opts ...grpc.CallOption) (*proto.UpdateTokenCandlesLeftResponse, error) {
+	c.candlesBilled += in.DecreaseCandles
+	return &proto.UpdateTokenCandlesLeftResponse{}, nil
+}
+
+// newTestRouter serves the routes of a prices handler without authentication
+func newTestRouter(handler *PricesHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/api/v1"))
+	return router
+}
+
+// get requests a path with an API key
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set("x-api-key", "token")
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// ndjsonLines decodes a newline-delimited JSON body
+func ndjsonLines(t *testing.T, body string) []map[string]interface{} {
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// newTrades creates count buy trades
+func newTrades(count int) []*proto.Trade {
+	trades := make([]*proto.Trade, count)
+	for i := range trades {
+		trades[i] = &proto.Trade{TradeId: strconv.Itoa(i), Timestamp: int64(i), Price: 100, Quantity: 1, Side: "buy", TradeCount: 1}
+	}
+	return trades
+}
+
+// TestHandleGetTrades_Quota tests reserving the trades of a token up front and
+// returning what was not sent
+func TestHandleGetTrades_Quota(t *testing.T) {
+	const path = "/api/v1/trades/binance/BTCUSDT?start_time=1"
+
+	t.Run("no trades left", func(t *testing.T) {
+		prices := &stubPricesClient{}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, path)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		assert.Nil(t, prices.tradesRequest)
+	})
+
+	t.Run("unused trades are returned", func(t *testing.T) {
+		auth := &stubAuthClient{tradesLeft: 1000}
+		prices := &stubPricesClient{trades: newTrades(3)}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		// Everything the token has left is reserved while the trades stream
+		var reservedLeft int64
+		prices.onTrades = func() { reservedLeft = auth.tradesLeft }
+
+		response := get(router, path)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Len(t, ndjsonLines(t, response.Body.String()), 3)
+		assert.Equal(t, int64(1000), prices.tradesRequest.Limit)
+		assert.Zero(t, reservedLeft)
+		assert.Equal(t, int64(997), auth.tradesLeft)
+	})
+
+	t.Run("limit below the balance", func(t *testing.T) {
+		auth := &stubAuthClient{tradesLeft: 1000}
+		prices := &stubPricesClient{trades: newTrades(3)}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		response := get(router, path+"&limit=2")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Len(t, ndjsonLines(t, response.Body.String()), 2)
+		assert.Equal(t, int64(2), prices.tradesRequest.Limit)
+		assert.Equal(t, int64(998), auth.tradesLeft)
+	})
+
+	t.Run("stream cut off partway", func(t *testing.T) {
+		auth := &stubAuthClient{tradesLeft: 1000}
+		prices := &stubPricesClient{trades: newTrades(2), err: status.Error(codes.Unavailable, "exchange unavailable")}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		response := get(router, path)
+
+		// The status line is already sent, so the stream ends with an error line
+		assert.Equal(t, http.StatusOK, response.Code)
+		lines := ndjsonLines(t, response.Body.String())
+		require.Len(t, lines, 3)
+		assert.Equal(t, "error receiving trades", lines[2]["error"])
+		assert.Equal(t, int64(998), auth.tradesLeft)
+	})
+
+	t.Run("rejected requests are not billed", func(t *testing.T) {
+		auth := &stubAuthClient{tradesLeft: 1000}
+		prices := &stubPricesClient{err: status.Error(codes.InvalidArgument, "unsupported exchange: nowhere")}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		response := get(router, path)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, int64(1000), auth.tradesLeft)
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		auth := &stubAuthClient{tradesLeft: 1000}
+		prices := &stubPricesClient{trades: newTrades(3)}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		// A second request while the first streams finds its trades reserved
+		var concurrent *httptest.ResponseRecorder
+		prices.onTrades = func() {
+			prices.onTrades = nil
+			concurrent = get(router, path)
+		}
+
+		response := get(router, path)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, http.StatusForbidden, concurrent.Code)
+		assert.Equal(t, int64(997), auth.tradesLeft)
+	})
+}
+
+// TestHandleGetTrades_Errors tests answering the errors of the prices service
+// with the HTTP status they stand for
+func TestHandleGetTrades_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"invalid argument", status.Error(codes.InvalidArgument, "unsupported exchange: nowhere"), http.StatusBadRequest, "unsupported exchange: nowhere"},
+		{"not archived yet", status.Error(codes.NotFound, "trades not archived yet"), http.StatusNotFound, "trades not archived yet"},
+		{"internal", status.Error(codes.Internal, "database is down"), http.StatusInternalServerError, "error receiving trades"},
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), http.StatusInternalServerError, "error receiving trades"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &stubAuthClient{tradesLeft: 1000}
+			router := newTestRouter(NewPricesHandler(&stubPricesClient{err: tt.err}, auth))
+
+			response := get(router, "/api/v1/trades/bybit/BTCUSDT?start_time=1")
+
+			assert.Equal(t, tt.status, response.Code)
+			assert.JSONEq(t, `{"error":"`+tt.message+`"}`, response.Body.String())
+			assert.Equal(t, int64(1000), auth.tradesLeft)
+		})
+	}
+}
+
+// newPrices creates count hourly candles
+func newPrices(count int) []*proto.PricesResponse {
+	prices := make([]*proto.PricesResponse, count)
+	for i := range prices {
+		openTime := int64(i) * 3600000
+		prices[i] = &proto.PricesResponse{OpenTime: openTime, CloseTime: openTime + 3599999, Open: 100, High: 101, Low: 99, Close: 100, Volume: 1}
+	}
+	return prices
+}
+
+// TestHandleGetHistoricalPrices_Params tests validating the query parameters and
+// passing them on to the prices service
+func TestHandleGetHistoricalPrices_Params(t *testing.T) {
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []struct {
+			query   string
+			message string
+		}{
+			{"limit=ten", "invalid limit parameter"},
+			{"start_time=yesterday", "invalid start_time parameter"},
+			{"start_time=1&end_time=now", "invalid end_time parameter"},
+			{"start_time=listing&end_time=now", "invalid end_time parameter"},
+			{"decimals=maybe", "invalid decimals parameter"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.query, func(t *testing.T) {
+				prices := &stubPricesClient{}
+				auth := &stubAuthClient{}
+				router := newTestRouter(NewPricesHandler(prices, auth))
+
+				response := get(router, "/api/v1/prices/binance/BTCUSDT?"+tt.query)
+
+				assert.Equal(t, http.StatusBadRequest, response.Code)
+				assert.JSONEq(t, `{"error":"`+tt.message+`"}`, response.Body.String())
+				assert.Nil(t, prices.pricesRequest)
+				assert.Zero(t, auth.candlesBilled)
+			})
+		}
+	})
+
+	t.Run("missing ticker", func(t *testing.T) {
+		prices := &stubPricesClient{}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/prices/binance/")
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Nil(t, prices.pricesRequest)
+	})
+
+	t.Run("request", func(t *testing.T) {
+		prices := &stubPricesClient{}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/prices/binance/BTC/USDT?interval=4h&market=linear&price_type=mark"+
+			"&start_time=1000&end_time=2000&decimals=true&utc_offset=+05:30&convert_to=EUR")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, &proto.PricesRequest{
+			Exchange:        "binance",
+			Ticker:          "BTC/USDT",
+			Interval:        "4h",
+			Market:          "linear",
+			PriceType:       "mark",
+			StartTime:       1000,
+			EndTime:         2000,
+			IncludeDecimals: true,
+			UtcOffset:       "+05:30",
+			ConvertTo:       "EUR",
+		}, prices.pricesRequest)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			query string
+			limit int64
+		}{
+			{"latest candles", "", 100},
+			{"given limit", "limit=5", 5},
+			{"range", "start_time=1000", 0},
+			{"range with limit", "start_time=1000&limit=5", 5},
+			{"listing", "start_time=listing", 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				prices := &stubPricesClient{}
+				router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+				response := get(router, "/api/v1/prices/binance/BTCUSDT?"+tt.query)
+
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Equal(t, tt.limit, prices.pricesRequest.Limit)
+				assert.Equal(t, tt.query == "start_time=listing", prices.pricesRequest.FromListing)
+			})
+		}
+	})
+}
+
+// TestHandleGetHistoricalPrices_Errors tests answering the errors of the prices
+// service with the HTTP status they stand for, billing nothing
+func TestHandleGetHistoricalPrices_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		prices  *stubPricesClient
+		status  int
+		message string
+	}{
+		{"invalid argument", &stubPricesClient{err: status.Error(codes.InvalidArgument, "unsupported interval: 7m")}, http.StatusBadRequest, "unsupported interval: 7m"},
+		{"listing not found", &stubPricesClient{err: status.Error(codes.NotFound, "listing not found")}, http.StatusNotFound, "listing not found"},
+		{"internal", &stubPricesClient{err: status.Error(codes.Internal, "database is down")}, http.StatusInternalServerError, "error receiving prices"},
+		{"opening the stream", &stubPricesClient{openErr: status.Error(codes.Unavailable, "connection refused")}, http.StatusInternalServerError, "failed to get prices"},
+		// Candles already received are dropped with the failed request, so none are billed
+		{"stream cut off partway", &stubPricesClient{prices: newPrices(3), err: status.Error(codes.Unavailable, "exchange unavailable")}, http.StatusInternalServerError, "error receiving prices"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &stubAuthClient{}
+			router := newTestRouter(NewPricesHandler(tt.prices, auth))
+
+			response := get(router, "/api/v1/prices/binance/BTCUSDT")
+
+			assert.Equal(t, tt.status, response.Code)
+			assert.JSONEq(t, `{"error":"`+tt.message+`"}`, response.Body.String())
+			assert.Zero(t, auth.candlesBilled)
+		})
+	}
+}
+
+// TestHandleGetHistoricalPrices_Billing tests billing the candles returned to a token
+func TestHandleGetHistoricalPrices_Billing(t *testing.T) {
+	auth := &stubAuthClient{}
+	router := newTestRouter(NewPricesHandler(&stubPricesClient{prices: newPrices(3)}, auth))
+
+	response := get(router, "/api/v1/prices/binance/BTCUSDT")
+
+	require.Equal(t, http.StatusOK, response.Code)
+	var body struct {
+		Prices []map[string]interface{} `json:"prices"`
+	}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+	assert.Len(t, body.Prices, 3)
+	assert.Equal(t, float64(3599999), body.Prices[0]["closeTime"])
+	assert.Equal(t, int64(3), auth.candlesBilled)
+}
+
+// TestHandleGetFundingRates tests validating the parameters of a funding rate
+// request, answering errors and billing the settlements returned
+func TestHandleGetFundingRates(t *testing.T) {
+	t.Run("invalid parameters", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"limit=ten":            "invalid limit parameter",
+			"start_time=yesterday": "invalid start_time parameter",
+			"end_time=now":         "invalid end_time parameter",
+		} {
+			prices := &stubPricesClient{}
+			router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+			response := get(router, "/api/v1/funding-rates/bybit/BTCUSDT?"+query)
+
+			assert.Equal(t, http.StatusBadRequest, response.Code, query)
+			assert.JSONEq(t, `{"error":"`+message+`"}`, response.Body.String(), query)
+			assert.Nil(t, prices.fundingRequest, query)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		prices := &stubPricesClient{}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/funding-rates/bybit/BTCUSDT?market=inverse&start_time=1000&end_time=2000")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"fundingRates":[]}`, response.Body.String())
+		assert.Equal(t, &proto.FundingRatesRequest{
+			Exchange:  "bybit",
+			Ticker:    "BTCUSDT",
+			Market:    "inverse",
+			StartTime: 1000,
+			EndTime:   2000,
+		}, prices.fundingRequest)
+	})
+
+	t.Run("mark prices", func(t *testing.T) {
+		auth := &stubAuthClient{}
+		prices := &stubPricesClient{fundingRates: []*proto.FundingRate{
+			{FundingTime: 0, Rate: 0.0001, MarkPrice: 100, HasMarkPrice: true},
+			{FundingTime: 28800000, Rate: -0.0002},
+		}}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		response := get(router, "/api/v1/funding-rates/bybit/BTCUSDT")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"fundingRates":[
+			{"fundingTime":0,"rate":0.0001,"markPrice":100},
+			{"fundingTime":28800000,"rate":-0.0002,"markPrice":null}
+		]}`, response.Body.String())
+		assert.Equal(t, int64(100), prices.fundingRequest.Limit)
+		assert.Equal(t, int64(2), auth.candlesBilled)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			prices  *stubPricesClient
+			status  int
+			message string
+		}{
+			{"invalid argument", &stubPricesClient{err: status.Error(codes.InvalidArgument, "unsupported market: options")}, http.StatusBadRequest, "unsupported market: options"},
+			{"opening the stream", &stubPricesClient{openErr: status.Error(codes.Unavailable, "connection refused")}, http.StatusInternalServerError, "failed to get funding rates"},
+			{"stream cut off partway", &stubPricesClient{fundingRates: []*proto.FundingRate{{Rate: 0.0001}}, err: status.Error(codes.Unavailable, "exchange unavailable")}, http.StatusInternalServerError, "error receiving funding rates"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				auth := &stubAuthClient{}
+				router := newTestRouter(NewPricesHandler(tt.prices, auth))
+
+				response := get(router, "/api/v1/funding-rates/bybit/BTCUSDT")
+
+				assert.Equal(t, tt.status, response.Code)
+				assert.JSONEq(t, `{"error":"`+tt.message+`"}`, response.Body.String())
+				assert.Zero(t, auth.candlesBilled)
+			})
+		}
+	})
+}
+
+// TestHandleListExchanges tests describing the supported exchanges
+func TestHandleListExchanges(t *testing.T) {
+	t.Run("exchanges", func(t *testing.T) {
+		prices := &stubPricesClient{exchanges: []*proto.ExchangeInfo{
+			{Name: "binance", Markets: []string{"spot", "linear"}, Intervals: []string{"1m", "1h"}, PriceTypes: []string{"last", "mark"}, MaxPageSize: 1000},
+		}}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/exchanges")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"exchanges":[
+			{"name":"binance","markets":["spot","linear"],"intervals":["1m","1h"],"priceTypes":["last","mark"],"maxPageSize":1000}
+		]}`, response.Body.String())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		prices := &stubPricesClient{err: status.Error(codes.Unavailable, "connection refused")}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/exchanges")
+
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.JSONEq(t, `{"error":"failed to list exchanges"}`, response.Body.String())
+	})
+}
+
+// TestHandleListSymbols tests listing the symbols of an exchange market
+func TestHandleListSymbols(t *testing.T) {
+	t.Run("symbols", func(t *testing.T) {
+		prices := &stubPricesClient{symbols: []*proto.SymbolInfo{
+			{Symbol: "BTCUSDT", CanonicalSymbol: "BTC/USDT", Base: "BTC", Quote: "USDT", Status: "trading", TickSize: "0.01", ListingTime: 1502942400000},
+			{Symbol: "LUNAUSDT", Base: "LUNA", Quote: "USDT", Status: "delisted", TickSize: "0.0001"},
+		}}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/exchanges/binance/symbols?market=linear")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"symbols":[
+			{"symbol":"BTCUSDT","canonicalSymbol":"BTC/USDT","base":"BTC","quote":"USDT","status":"trading","tickSize":"0.01","listingTime":1502942400000},
+			{"symbol":"LUNAUSDT","base":"LUNA","quote":"USDT","status":"delisted","tickSize":"0.0001"}
+		]}`, response.Body.String())
+		assert.Equal(t, &proto.ListSymbolsRequest{Exchange: "binance", Market: "linear"}, prices.symbolsRequest)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			err     error
+			status  int
+			message string
+		}{
+			{"invalid argument", status.Error(codes.InvalidArgument, "unsupported exchange: nowhere"), http.StatusBadRequest, "unsupported exchange: nowhere"},
+			{"internal", status.Error(codes.Internal, "exchange unavailable"), http.StatusInternalServerError, "failed to list symbols"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				router := newTestRouter(NewPricesHandler(&stubPricesClient{err: tt.err}, &stubAuthClient{}))
+
+				response := get(router, "/api/v1/exchanges/nowhere/symbols")
+
+				assert.Equal(t, tt.status, response.Code)
+				assert.JSONEq(t, `{"error":"`+tt.message+`"}`, response.Body.String())
+			})
+		}
+	})
+}
+
+// TestHandleGetIndicators tests validating the parameters of an indicators
+// request, answering errors and billing the candles returned
+func TestHandleGetIndicators(t *testing.T) {
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []struct {
+			query   string
+			message string
+		}{
+			{"", "missing indicators parameter"},
+			{"indicators=sma(20", "invalid indicators parameter: unbalanced parentheses"},
+			{"indicators=sma((20))", "invalid indicators parameter: unbalanced parentheses"},
+			{"indicators=rsi,(14)", `invalid indicator "(14)": missing name`},
+			{"indicators=sma(twenty)", `invalid indicator "sma(twenty)": parameters must be numbers`},
+			{"indicators=sma(20)x", `invalid indicator "sma(20)x": expected parameters in parentheses`},
+			{"indicators=rsi&limit=ten", "invalid limit parameter"},
+			{"indicators=rsi&start_time=yesterday", "invalid start_time parameter"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.query, func(t *testing.T) {
+				prices := &stubPricesClient{}
+				router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+				response := get(router, "/api/v1/indicators/binance/BTCUSDT?"+tt.query)
+
+				assert.Equal(t, http.StatusBadRequest, response.Code)
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+				assert.Equal(t, tt.message, body["error"])
+				assert.Nil(t, prices.indicatorsRequest)
+			})
+		}
+	})
+
+	t.Run("missing ticker", func(t *testing.T) {
+		prices := &stubPricesClient{}
+		router := newTestRouter(NewPricesHandler(prices, &stubAuthClient{}))
+
+		response := get(router, "/api/v1/indicators/binance/?indicators=rsi")
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Nil(t, prices.indicatorsRequest)
+	})
+
+	t.Run("request", func(t *testing.T) {
+		auth := &stubAuthClient{}
+		prices := &stubPricesClient{indicators: []*proto.IndicatorValues{
+			{OpenTime: 0, CloseTime: 3599999, Close: 100, Values: map[string]float64{"sma(2)": 99.5}},
+			{OpenTime: 3600000, CloseTime: 7199999, Close: 101, Values: map[string]float64{"sma(2)": 100.5}},
+		}}
+		router := newTestRouter(NewPricesHandler(prices, auth))
+
+		response := get(router, "/api/v1/indicators/binance/BTC/USDT?indicators=sma(2),%20rsi%20,bollinger(20,%202.5)&interval=1h&start_time=1000")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"indicators":[
+			{"openTime":0,"closeTime":3599999,"close":100,"values":{"sma(2)":99.5}},
+			{"openTime":3600000,"closeTime":7199999,"close":101,"values":{"sma(2)":100.5}}
+		]}`, response.Body.String())
+		assert.Equal(t, &proto.IndicatorsRequest{
+			Exchange:  "binance",
+			Ticker:    "BTC/USDT",
+			Interval:  "1h",
+			StartTime: 1000,
+			Indicators: []*proto.IndicatorSpec{
+				{Name: "sma", Params: []float64{2}},
+				{Name: "rsi"},
+				{Name: "bollinger", Params: []float64{20, 2.5}},
+			},
+		}, prices.indicatorsRequest)
+		assert.Equal(t, int64(2), auth.candlesBilled)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			prices  *stubPricesClient
+			status  int
+			message string
+		}{
+			{"invalid argument", &stubPricesClient{err: status.Error(codes.InvalidArgument, "unknown indicator: foo")}, http.StatusBadRequest, "unknown indicator: foo"},
+			{"opening the stream", &stubPricesClient{openErr: status.Error(codes.Unavailable, "connection refused")}, http.StatusInternalServerError, "failed to get indicators"},
+			{"stream cut off partway", &stubPricesClient{indicators: []*proto.IndicatorValues{{Close: 100}}, err: status.Error(codes.Unavailable, "exchange unavailable")}, http.StatusInternalServerError, "error receiving indicators"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				auth := &stubAuthClient{}
+				router := newTestRouter(NewPricesHandler(tt.prices, auth))
+
+				response := get(router, "/api/v1/indicators/binance/BTCUSDT?indicators=rsi")
+
+				assert.Equal(t, tt.status, response.Code)
+				assert.JSONEq(t, `{"error":"`+tt.message+`"}`, response.Body.String())
+				assert.Zero(t, auth.candlesBilled)
+			})
+		}
+	})
+}
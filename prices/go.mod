@@ -2,14 +2,18 @@ module github.com/timakaa/historical-prices
 
 go 1.23.4
 
-require google.golang.org/grpc v1.71.0
+require (
+	github.com/gorilla/websocket v1.5.3
+	google.golang.org/grpc v1.71.0
+	gorm.io/driver/sqlite v1.5.7
+	gorm.io/gorm v1.25.12
+)
 
 require (
 	github.com/adshao/go-binance/v2 v2.8.1 // indirect
 	github.com/bitly/go-simplejson v0.5.0 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/hirokisan/bybit/v2 v2.37.0 // indirect
 	github.com/jpillora/backoff v1.0.0 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
@@ -0,0 +1,185 @@
+// Package candles keeps the closed candles served by the exchanges, so that a
+// range of history is fetched from an exchange only once.
+package candles
+
+import (
+	"context"
+	"log"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// storePageSize is the number of candles read from the store at once
+const storePageSize = 1000
+
+// maxCachedInterval is the longest interval whose candles are stored. Candles up to
+// a day open at multiples of their length since the epoch on every exchange, which
+// is what tells the missing ranges of a series apart from the stored ones; weeks and
+// months do not.
+const maxCachedInterval = 24 * time.Hour
+
+// Cacheable reports whether candles of an interval are kept in the store
+func Cacheable(interval exchanges.Interval) bool {
+	return interval.Duration() <= maxCachedInterval
+}
+
+// CachedAdapter reads the candles of range queries from a store first, fetching
+// only the ranges it is missing from the exchange and storing the closed candles
+// it fetches. Queries for the most recent candles always go to the exchange.
+type CachedAdapter struct {
+	exchanges.ExchangeAdapter
+	store Store
+
+	// now tells the time, deciding which fetched candles are closed
+	now func() time.Time
+}
+
+// NewCachedAdapter wraps an adapter to read candles through a store
+func NewCachedAdapter(adapter exchanges.ExchangeAdapter, store Store) *CachedAdapter {
+	return &CachedAdapter{ExchangeAdapter: adapter, store: store, now: time.Now}
+}
+
+// GetHistoricalPrices passes the candles matching the query to handle in
+// chronological order, taking them from the store where it has them
+func (a *CachedAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	if query.StartTime.IsZero() || !Cacheable(query.Interval) {
+		return a.ExchangeAdapter.GetHistoricalPrices(ctx, query, handle)
+	}
+
+	now := a.now()
+	end := query.EndTime
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+
+	series := Series{
+		Exchange:  a.GetName(),
+		Market:    query.Market,
+		Ticker:    query.Ticker,
+		Interval:  query.Interval,
+		PriceType: query.PriceType,
+	}
+
+	// The first candle in range opens at the first multiple of the interval
+	step := query.Interval.Duration()
+	cursor := query.StartTime.Truncate(step)
+	if cursor.Before(query.StartTime) {
+		cursor = cursor.Add(step)
+	}
+
+	// Ranges the exchange is known to have no candles for are not fetched again
+	holes, err := a.store.Holes(ctx, series, cursor, end)
+	if err != nil {
+		log.Printf("Error reading candle holes of %s %s: %v", series.Exchange, series.Ticker, err)
+	}
+	reader := &readThrough{adapter: a, series: series, query: query, handle: handle, holes: holes, now: now}
+
+	for !cursor.After(end) && !reader.done() {
+		stored, err := a.store.Candles(ctx, series, cursor, end, storePageSize)
+		if err != nil {
+			// Without the store the exchange still serves what is left of the query
+			if reader.sent == 0 {
+				log.Printf("Error reading stored candles, fetching them from %s: %v", a.GetName(), err)
+				return a.ExchangeAdapter.GetHistoricalPrices(ctx, query, handle)
+			}
+			return err
+		}
+
+		// Stored candles are handed over in contiguous runs, fetching the gaps between them
+		var run []*pb.PricesResponse
+		for _, candle := range stored {
+			if candle.OpenTime > cursor.UnixMilli() {
+				if err := reader.send(run); err != nil {
+					return err
+				}
+				run = nil
+				if err := reader.fetch(ctx, cursor, time.UnixMilli(candle.OpenTime-1).UTC()); err != nil {
+					return err
+				}
+			}
+			run = append(run, candle)
+			cursor = time.UnixMilli(candle.CloseTime + 1).UTC()
+		}
+		if err := reader.send(run); err != nil {
+			return err
+		}
+
+		if len(stored) < storePageSize {
+			break
+		}
+	}
+
+	if cursor.After(end) || reader.done() {
+		return nil
+	}
+	return reader.fetch(ctx, cursor, end)
+}
+
+// readThrough hands the candles of one query over, counting them against its limit
+type readThrough struct {
+	adapter *CachedAdapter
+	series  Series
+	query   exchanges.PriceQuery
+	handle  exchanges.PageHandler
+	holes   []Range
+	now     time.Time
+	sent    int64
+}
+
+// done reports whether the limit of the query has been reached
+func (r *readThrough) done() bool {
+	return r.query.Limit > 0 && r.sent >= r.query.Limit
+}
+
+// send hands candles over, leaving out those past the limit
+func (r *readThrough) send(candles []*pb.PricesResponse) error {
+	if r.query.Limit > 0 && int64(len(candles)) > r.query.Limit-r.sent {
+		candles = candles[:r.query.Limit-r.sent]
+	}
+	if len(candles) == 0 {
+		return nil
+	}
+	r.sent += int64(len(candles))
+	return r.handle(candles)
+}
+
+// fetch retrieves the candles opening in [start, end] from the exchange, skipping
+// known holes
+func (r *readThrough) fetch(ctx context.Context, start, end time.Time) error {
+	for _, missing := range uncovered(start, end, r.holes, r.query.Interval.Duration()) {
+		if err := r.fetchRange(ctx, missing.Start, missing.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchRange retrieves the candles opening in [start, end] from the exchange,
+// storing the closed ones before handing them over. Failing to store them is
+// logged, as the candles are still served.
+func (r *readThrough) fetchRange(ctx context.Context, start, end time.Time) error {
+	if r.done() {
+		return nil
+	}
+
+	query := r.query
+	query.StartTime, query.EndTime = start, end
+	if query.Limit > 0 {
+		query.Limit -= r.sent
+	}
+
+	return r.adapter.ExchangeAdapter.GetHistoricalPrices(ctx, query, func(prices []*pb.PricesResponse) error {
+		var closed []*pb.PricesResponse
+		for _, price := range prices {
+			if price.CloseTime < r.now.UnixMilli() {
+				closed = append(closed, price)
+			}
+		}
+		if err := r.adapter.store.Save(ctx, r.series, closed); err != nil {
+			log.Printf("Error storing candles of %s %s: %v", r.series.Exchange, r.series.Ticker, err)
+		}
+		return r.send(prices)
+	})
+}
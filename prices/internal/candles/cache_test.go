@@ -0,0 +1,221 @@
+package candles
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// hourlyAdapter serves an hourly candle for every hour in the queried range but
+// those opening during an outage, recording the queries it was asked
+type hourlyAdapter struct {
+	queries []exchanges.PriceQuery
+	outage  map[int64]bool
+}
+
+func (a *hourlyAdapter) GetName() string {
+	return "hourly"
+}
+
+func (a *hourlyAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	a.queries = append(a.queries, query)
+
+	var page []*pb.PricesResponse
+	for open := query.StartTime; !open.After(query.EndTime); open = open.Add(time.Hour) {
+		if query.Limit > 0 && int64(len(page)) >= query.Limit {
+			break
+		}
+		if !a.outage[open.UnixMilli()] {
+			page = append(page, hourlyCandle(open))
+		}
+	}
+	if len(page) == 0 {
+		return nil
+	}
+	return handle(page)
+}
+
+// failingStore is a store whose reads fail
+type failingStore struct {
+	Store
+}
+
+func (failingStore) Candles(ctx context.Context, series Series, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingStore) Holes(ctx context.Context, series Series, start, end time.Time) ([]Range, error) {
+	return nil, errors.New("connection refused")
+}
+
+// openTimes collects the open times of the candles passed to a handler
+func openTimes(handle *[]int64) exchanges.PageHandler {
+	return func(prices []*pb.PricesResponse) error {
+		for _, price := range prices {
+			*handle = append(*handle, price.OpenTime)
+		}
+		return nil
+	}
+}
+
+// hours returns the open times of consecutive hourly candles
+func hours(start time.Time, count int) []int64 {
+	var times []int64
+	for i := 0; i < count; i++ {
+		times = append(times, start.Add(time.Duration(i)*time.Hour).UnixMilli())
+	}
+	return times
+}
+
+// TestCachedAdapter tests reading candles through the store
+func TestCachedAdapter(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCached := func(t *testing.T) (*CachedAdapter, *hourlyAdapter, *GormStore) {
+		adapter := &hourlyAdapter{}
+		store := newTestStore(t)
+		cached := NewCachedAdapter(adapter, store)
+		cached.now = func() time.Time { return start.Add(48 * time.Hour) }
+		return cached, adapter, store
+	}
+	query := exchanges.PriceQuery{
+		Ticker:    "BTCUSDT",
+		Market:    exchanges.MarketSpot,
+		Interval:  exchanges.Interval1h,
+		PriceType: exchanges.PriceTypeLast,
+		StartTime: start,
+		EndTime:   start.Add(9 * time.Hour),
+	}
+
+	t.Run("fetched candles are stored and served from the store afterwards", func(t *testing.T) {
+		cached, adapter, _ := newCached(t)
+
+		var first, second []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&first)))
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&second)))
+
+		assert.Equal(t, hours(start, 10), first)
+		assert.Equal(t, first, second)
+		assert.Len(t, adapter.queries, 1)
+	})
+
+	t.Run("only missing ranges are fetched", func(t *testing.T) {
+		cached, adapter, store := newCached(t)
+		series := Series{Exchange: "hourly", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}
+		require.NoError(t, store.Save(ctx, series, []*pb.PricesResponse{
+			hourlyCandle(start.Add(2 * time.Hour)),
+			hourlyCandle(start.Add(3 * time.Hour)),
+			hourlyCandle(start.Add(6 * time.Hour)),
+		}))
+
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&sent)))
+
+		assert.Equal(t, hours(start, 10), sent)
+		require.Len(t, adapter.queries, 3)
+		assert.Equal(t, [2]time.Time{start, start.Add(2*time.Hour - time.Millisecond)},
+			[2]time.Time{adapter.queries[0].StartTime, adapter.queries[0].EndTime})
+		assert.Equal(t, [2]time.Time{start.Add(4 * time.Hour), start.Add(6*time.Hour - time.Millisecond)},
+			[2]time.Time{adapter.queries[1].StartTime, adapter.queries[1].EndTime})
+		assert.Equal(t, [2]time.Time{start.Add(7 * time.Hour), start.Add(9 * time.Hour)},
+			[2]time.Time{adapter.queries[2].StartTime, adapter.queries[2].EndTime})
+	})
+
+	t.Run("known holes are not fetched", func(t *testing.T) {
+		cached, adapter, store := newCached(t)
+		series := Series{Exchange: "hourly", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}
+		require.NoError(t, store.Save(ctx, series, []*pb.PricesResponse{hourlyCandle(start.Add(9 * time.Hour))}))
+		require.NoError(t, store.SaveHoles(ctx, series, []Range{newRange(start.Add(2*time.Hour), start.Add(4*time.Hour), time.Hour)}))
+
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&sent)))
+
+		assert.Equal(t, append(hours(start, 2), hours(start.Add(5*time.Hour), 5)...), sent)
+		require.Len(t, adapter.queries, 2)
+		assert.Equal(t, [2]time.Time{start, start.Add(time.Hour)},
+			[2]time.Time{adapter.queries[0].StartTime, adapter.queries[0].EndTime})
+		assert.Equal(t, [2]time.Time{start.Add(5 * time.Hour), start.Add(9*time.Hour - time.Millisecond)},
+			[2]time.Time{adapter.queries[1].StartTime, adapter.queries[1].EndTime})
+	})
+
+	t.Run("open candles are not stored", func(t *testing.T) {
+		cached, adapter, _ := newCached(t)
+		cached.now = func() time.Time { return start.Add(5*time.Hour + 30*time.Minute) }
+
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&sent)))
+		assert.Equal(t, hours(start, 6), sent)
+
+		sent = nil
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&sent)))
+		assert.Equal(t, hours(start, 6), sent)
+		require.Len(t, adapter.queries, 2)
+		assert.Equal(t, start.Add(5*time.Hour), adapter.queries[1].StartTime)
+	})
+
+	t.Run("limit counts stored and fetched candles", func(t *testing.T) {
+		cached, adapter, store := newCached(t)
+		series := Series{Exchange: "hourly", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}
+		require.NoError(t, store.Save(ctx, series, []*pb.PricesResponse{
+			hourlyCandle(start.Add(2 * time.Hour)),
+			hourlyCandle(start.Add(3 * time.Hour)),
+		}))
+
+		limited := query
+		limited.Limit = 5
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, limited, openTimes(&sent)))
+
+		assert.Equal(t, hours(start, 5), sent)
+		require.Len(t, adapter.queries, 2)
+		assert.Equal(t, int64(5), adapter.queries[0].Limit)
+		assert.Equal(t, int64(1), adapter.queries[1].Limit)
+	})
+
+	t.Run("an unaligned start begins at the next candle", func(t *testing.T) {
+		cached, adapter, _ := newCached(t)
+		unaligned := query
+		unaligned.StartTime = start.Add(30 * time.Minute)
+
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, unaligned, openTimes(&sent)))
+
+		assert.Equal(t, hours(start.Add(time.Hour), 9), sent)
+		require.Len(t, adapter.queries, 1)
+		assert.Equal(t, start.Add(time.Hour), adapter.queries[0].StartTime)
+	})
+
+	t.Run("latest candles and long intervals go to the exchange", func(t *testing.T) {
+		cached, adapter, _ := newCached(t)
+		latest := query
+		latest.StartTime, latest.EndTime, latest.Limit = time.Time{}, time.Time{}, 3
+		weekly := query
+		weekly.Interval = exchanges.Interval1w
+
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, latest, openTimes(&sent)))
+		require.NoError(t, cached.GetHistoricalPrices(ctx, weekly, openTimes(&sent)))
+
+		require.Len(t, adapter.queries, 2)
+		assert.Equal(t, latest, adapter.queries[0])
+		assert.Equal(t, weekly, adapter.queries[1])
+	})
+
+	t.Run("a failing store falls back to the exchange", func(t *testing.T) {
+		adapter := &hourlyAdapter{}
+		cached := NewCachedAdapter(adapter, failingStore{})
+
+		var sent []int64
+		require.NoError(t, cached.GetHistoricalPrices(ctx, query, openTimes(&sent)))
+
+		assert.Equal(t, hours(start, 10), sent)
+		require.Len(t, adapter.queries, 1)
+		assert.Equal(t, query, adapter.queries[0])
+	})
+}
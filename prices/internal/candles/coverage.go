@@ -0,0 +1,111 @@
+package candles
+
+import (
+	"context"
+	"time"
+)
+
+// Range is a run of candles of a series given by the open times of its first and
+// last candle, both inclusive
+type Range struct {
+	Start time.Time
+	End   time.Time
+
+	// Count is the number of candles in the range
+	Count int64
+}
+
+// newRange returns the range of candles of an interval step opening in [start, end]
+func newRange(start, end time.Time, step time.Duration) Range {
+	return Range{Start: start.UTC(), End: end.UTC(), Count: int64(end.Sub(start)/step) + 1}
+}
+
+// uncovered returns the ranges of [start, end] that none of the holes cover.
+// Holes must be in chronological order.
+func uncovered(start, end time.Time, holes []Range, step time.Duration) []Range {
+	var ranges []Range
+	cursor := start
+	for _, hole := range holes {
+		if hole.End.Before(cursor) {
+			continue
+		}
+		if hole.Start.After(end) {
+			break
+		}
+		if hole.Start.After(cursor) {
+			ranges = append(ranges, newRange(cursor, hole.Start.Add(-step), step))
+		}
+		cursor = hole.End.Add(step)
+	}
+	if !cursor.After(end) {
+		ranges = append(ranges, newRange(cursor, end, step))
+	}
+	return ranges
+}
+
+// Auditor is a store whose series can be checked for gaps and repaired
+type Auditor interface {
+	Store
+
+	// Series returns every series with stored candles
+	Series(ctx context.Context) ([]Series, error)
+
+	// Windows returns the runs of consecutive candles stored for a series, in
+	// chronological order
+	Windows(ctx context.Context, series Series) ([]Range, error)
+
+	// SaveHoles records ranges of a series the exchange has no candles for
+	SaveHoles(ctx context.Context, series Series, holes []Range) error
+
+	// RemoveInvalid deletes misaligned and duplicate candles of a series,
+	// returning the number deleted
+	RemoveInvalid(ctx context.Context, series Series) (int64, error)
+}
+
+// SeriesCoverage describes which candles of a series are stored
+type SeriesCoverage struct {
+	Series
+
+	// Candles is the number of candles stored
+	Candles int64
+
+	// Windows are the runs of consecutive stored candles
+	Windows []Range
+
+	// Holes are the known ranges between windows the exchange has no candles for
+	Holes []Range
+
+	// Missing are the ranges between windows that are neither stored nor known
+	// holes, waiting to be repaired
+	Missing []Range
+}
+
+// Coverage describes which candles of a series are stored, known to be missing
+// on the exchange or still to be fetched. Only ranges between the first and last
+// stored candle count as missing.
+func Coverage(ctx context.Context, store Auditor, series Series) (SeriesCoverage, error) {
+	coverage := SeriesCoverage{Series: series}
+
+	windows, err := store.Windows(ctx, series)
+	if err != nil || len(windows) == 0 {
+		return coverage, err
+	}
+	coverage.Windows = windows
+
+	holes, err := store.Holes(ctx, series, windows[0].Start, windows[len(windows)-1].End)
+	if err != nil {
+		return coverage, err
+	}
+	coverage.Holes = holes
+
+	step := series.Interval.Duration()
+	for i, window := range windows {
+		coverage.Candles += window.Count
+		if i > 0 {
+			gapStart := windows[i-1].End.Add(step)
+			gapEnd := window.Start.Add(-step)
+			coverage.Missing = append(coverage.Missing, uncovered(gapStart, gapEnd, holes, step)...)
+		}
+	}
+	return coverage, nil
+}
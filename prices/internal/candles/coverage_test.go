@@ -0,0 +1,73 @@
+package candles
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// TestUncovered tests cutting known holes out of a range
+func TestUncovered(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return start.Add(time.Duration(hour) * time.Hour) }
+	span := func(from, to int) Range { return newRange(at(from), at(to), time.Hour) }
+
+	tests := []struct {
+		name  string
+		holes []Range
+		want  []Range
+	}{
+		{name: "no holes", want: []Range{span(0, 9)}},
+		{name: "hole inside", holes: []Range{span(3, 4)}, want: []Range{span(0, 2), span(5, 9)}},
+		{name: "holes at both ends", holes: []Range{span(0, 1), span(8, 12)}, want: []Range{span(2, 7)}},
+		{name: "holes outside", holes: []Range{newRange(at(-5), at(-1), time.Hour), span(10, 11)}, want: []Range{span(0, 9)}},
+		{name: "fully covered", holes: []Range{newRange(at(-1), at(10), time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, uncovered(at(0), at(9), tt.holes, time.Hour))
+		})
+	}
+}
+
+// TestCoverage tests describing the stored windows, known holes and missing ranges of a series
+func TestCoverage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return start.Add(time.Duration(hour) * time.Hour) }
+	series := Series{Exchange: "hourly", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}
+
+	empty, err := Coverage(ctx, store, series)
+	require.NoError(t, err)
+	assert.Zero(t, empty.Candles)
+	assert.Empty(t, empty.Windows)
+
+	var stored []*pb.PricesResponse
+	for _, hour := range []int{0, 1, 2, 6, 7, 12} {
+		stored = append(stored, hourlyCandle(at(hour)))
+	}
+	require.NoError(t, store.Save(ctx, series, stored))
+	require.NoError(t, store.SaveHoles(ctx, series, []Range{newRange(at(4), at(5), time.Hour)}))
+
+	coverage, err := Coverage(ctx, store, series)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), coverage.Candles)
+	assert.Equal(t, []Range{
+		newRange(at(0), at(2), time.Hour),
+		newRange(at(6), at(7), time.Hour),
+		newRange(at(12), at(12), time.Hour),
+	}, coverage.Windows)
+	assert.Equal(t, []Range{newRange(at(4), at(5), time.Hour)}, coverage.Holes)
+	assert.Equal(t, []Range{
+		newRange(at(3), at(3), time.Hour),
+		newRange(at(8), at(11), time.Hour),
+	}, coverage.Missing)
+}
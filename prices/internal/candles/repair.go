@@ -0,0 +1,143 @@
+package candles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/clock"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// DefaultRepairInterval is the time between repairs when none is configured
+const DefaultRepairInterval = time.Hour
+
+// Repairer periodically checks the stored series for invalid rows and missing
+// candles, fetching the missing ranges again from the exchange they came from
+type Repairer struct {
+	factory  *exchanges.ExchangeFactory
+	store    Auditor
+	clock    clock.Clock
+	interval time.Duration
+}
+
+// NewRepairer creates a repairer of the series of a store, run every interval
+func NewRepairer(factory *exchanges.ExchangeFactory, store Auditor, clock clock.Clock, interval time.Duration) (*Repairer, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("candle repair interval must be positive")
+	}
+
+	return &Repairer{
+		factory:  factory,
+		store:    store,
+		clock:    clock,
+		interval: interval,
+	}, nil
+}
+
+// Run repairs every stored series each interval until the context is cancelled.
+// Failures are logged and retried at the next round.
+func (r *Repairer) Run(ctx context.Context) error {
+	log.Printf("Repairing stored candles every %s", r.interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.clock.After(r.interval):
+		}
+
+		if err := r.RepairOnce(ctx); err != nil {
+			log.Printf("Error repairing candles: %v", err)
+		}
+	}
+}
+
+// RepairOnce repairs every stored series. A failing series does not keep the
+// others from being repaired; all failures are returned together.
+func (r *Repairer) RepairOnce(ctx context.Context) error {
+	series, err := r.store.Series(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range series {
+		if err := r.RepairSeries(ctx, s); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s %s (%s): %w", s.Exchange, s.Ticker, s.Interval, s.Market, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RepairSeries deletes the invalid rows of a series and fetches its missing
+// ranges again, recording the parts the exchange has no candles for as holes
+func (r *Repairer) RepairSeries(ctx context.Context, series Series) error {
+	adapter, ok := r.factory.GetAdapter(series.Exchange)
+	if !ok {
+		return fmt.Errorf("unsupported exchange: %s", series.Exchange)
+	}
+
+	removed, err := r.store.RemoveInvalid(ctx, series)
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		log.Printf("Removed %d invalid candles of %s %s %s (%s)", removed, series.Exchange, series.Ticker, series.Interval, series.Market)
+	}
+
+	coverage, err := Coverage(ctx, r.store, series)
+	if err != nil {
+		return err
+	}
+	for _, gap := range coverage.Missing {
+		if err := r.repairGap(ctx, adapter, series, gap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repairGap fetches the candles of a missing range and stores them. The parts of
+// the range the exchange sent no candles for are recorded as holes, but only
+// once the exchange has answered for the whole range.
+func (r *Repairer) repairGap(ctx context.Context, adapter exchanges.ExchangeAdapter, series Series, gap Range) error {
+	step := series.Interval.Duration()
+	now := r.clock.Now().UnixMilli()
+
+	var holes []Range
+	expected := gap.Start
+	err := adapter.GetHistoricalPrices(ctx, exchanges.PriceQuery{
+		Ticker:    series.Ticker,
+		Market:    series.Market,
+		Interval:  series.Interval,
+		PriceType: series.PriceType,
+		StartTime: gap.Start,
+		EndTime:   gap.End,
+	}, func(prices []*pb.PricesResponse) error {
+		var closed []*pb.PricesResponse
+		for _, price := range prices {
+			if price.CloseTime >= now {
+				continue
+			}
+			open := time.UnixMilli(price.OpenTime).UTC()
+			if open.After(expected) {
+				holes = append(holes, newRange(expected, open.Add(-step), step))
+			}
+			expected = open.Add(step)
+			closed = append(closed, price)
+		}
+		return r.store.Save(ctx, series, closed)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !expected.After(gap.End) {
+		holes = append(holes, newRange(expected, gap.End, step))
+	}
+	return r.store.SaveHoles(ctx, series, holes)
+}
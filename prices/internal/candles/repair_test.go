@@ -0,0 +1,93 @@
+package candles
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timakaa/historical-common/database/models"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/clock"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// TestRepairer_RepairOnce tests refetching missing ranges and recording exchange outages
+func TestRepairer_RepairOnce(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return start.Add(time.Duration(hour) * time.Hour) }
+	series := Series{Exchange: "hourly", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}.Normalize()
+
+	var stored []*pb.PricesResponse
+	for _, hour := range []int{0, 1, 6, 9} {
+		stored = append(stored, hourlyCandle(at(hour)))
+	}
+	require.NoError(t, store.Save(ctx, series, stored))
+
+	// A candle that does not open on the hour is removed
+	misaligned := hourlyCandle(at(7).Add(30 * time.Minute))
+	require.NoError(t, store.Save(ctx, series, []*pb.PricesResponse{misaligned}))
+
+	adapter := &hourlyAdapter{outage: map[int64]bool{at(3).UnixMilli(): true, at(4).UnixMilli(): true}}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	repairer, err := NewRepairer(factory, store, clock.System{}, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, repairer.RepairOnce(ctx))
+
+	require.Len(t, adapter.queries, 2)
+	assert.Equal(t, [2]time.Time{at(2), at(5)}, [2]time.Time{adapter.queries[0].StartTime, adapter.queries[0].EndTime})
+	assert.Equal(t, [2]time.Time{at(7), at(8)}, [2]time.Time{adapter.queries[1].StartTime, adapter.queries[1].EndTime})
+
+	coverage, err := Coverage(ctx, store, series)
+	require.NoError(t, err)
+	assert.Equal(t, []Range{newRange(at(0), at(2), time.Hour), newRange(at(5), at(9), time.Hour)}, coverage.Windows)
+	assert.Equal(t, []Range{newRange(at(3), at(4), time.Hour)}, coverage.Holes)
+	assert.Empty(t, coverage.Missing)
+
+	// Known holes are not retried
+	require.NoError(t, repairer.RepairOnce(ctx))
+	assert.Len(t, adapter.queries, 2)
+}
+
+// TestGormStore_RemoveInvalid tests removing misaligned and duplicate candles
+func TestGormStore_RemoveInvalid(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := Series{Exchange: "hourly", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}.Normalize()
+
+	// Tables created before the unique index could hold the same candle twice
+	require.NoError(t, store.db.Migrator().DropIndex(&models.Candle{}, "idx_candles_series_time"))
+	for _, candle := range []*pb.PricesResponse{
+		hourlyCandle(start),
+		hourlyCandle(start.Add(time.Hour)),
+		hourlyCandle(start.Add(time.Hour)),
+		hourlyCandle(start.Add(90 * time.Minute)),
+	} {
+		record := fromCandle(series, candle)
+		require.NoError(t, store.db.Create(&record).Error)
+	}
+	wrongClose := fromCandle(series, hourlyCandle(start.Add(2*time.Hour)))
+	wrongClose.CloseTime += time.Hour.Milliseconds()
+	require.NoError(t, store.db.Create(&wrongClose).Error)
+
+	removed, err := store.RemoveInvalid(ctx, series)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+	candles, err := store.Candles(ctx, series, start, start.Add(24*time.Hour), 100)
+	require.NoError(t, err)
+	require.Len(t, candles, 2)
+	assert.Equal(t, start.Add(time.Hour).UnixMilli(), candles[1].OpenTime)
+}
+
+// TestNewRepairer tests validating the repair interval
+func TestNewRepairer(t *testing.T) {
+	_, err := NewRepairer(exchanges.NewExchangeFactory(), newTestStore(t), clock.System{}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,324 @@
+package candles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timakaa/historical-common/database/models"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Series identifies the candles of one interval and price type of a symbol on an
+// exchange market
+type Series struct {
+	Exchange  string
+	Market    exchanges.Market
+	Ticker    string
+	Interval  exchanges.Interval
+	PriceType exchanges.PriceType
+}
+
+// Normalize upper-cases the ticker and defaults the market and price type, so that
+// a series is stored under one key however it was requested
+func (s Series) Normalize() Series {
+	s.Ticker = strings.ToUpper(s.Ticker)
+	if s.Market == "" {
+		s.Market = exchanges.DefaultMarket
+	}
+	if s.PriceType == "" {
+		s.PriceType = exchanges.DefaultPriceType
+	}
+	return s
+}
+
+// Store persists closed candles
+type Store interface {
+	// Candles returns up to limit stored candles of a series opening in [start, end],
+	// in chronological order
+	Candles(ctx context.Context, series Series, start, end time.Time, limit int) ([]*pb.PricesResponse, error)
+
+	// Save stores candles of a series, replacing those already stored with the same open time
+	Save(ctx context.Context, series Series, candles []*pb.PricesResponse) error
+
+	// Holes returns the known holes of a series overlapping [start, end], in
+	// chronological order
+	Holes(ctx context.Context, series Series, start, end time.Time) ([]Range, error)
+}
+
+// GormStore stores candles in a relational database
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a store on a database connection
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Migrate creates or updates the candles table
+func (s *GormStore) Migrate() error {
+	return s.db.AutoMigrate(&models.Candle{}, &models.CandleHole{})
+}
+
+// Candles returns up to limit stored candles of a series opening in [start, end].
+// Candles stored under an older schema version are left out, so that they are
+// fetched again with the fields they lack.
+func (s *GormStore) Candles(ctx context.Context, series Series, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+	var records []models.Candle
+	err := s.seriesQuery(ctx, &models.Candle{}, series).
+		Where("open_time >= ? AND open_time <= ? AND schema_version = ?", start.UnixMilli(), end.UnixMilli(), exchanges.CandleSchemaVersion).
+		Order("open_time ASC").
+		Limit(limit).
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading candles: %v", err)
+	}
+
+	candles := make([]*pb.PricesResponse, 0, len(records))
+	for _, record := range records {
+		candles = append(candles, toCandle(record))
+	}
+	return candles, nil
+}
+
+// Save stores candles of a series, replacing those already stored with the same open time
+func (s *GormStore) Save(ctx context.Context, series Series, candles []*pb.PricesResponse) error {
+	if len(candles) == 0 {
+		return nil
+	}
+	series = series.Normalize()
+
+	records := make([]models.Candle, 0, len(candles))
+	for _, candle := range candles {
+		records = append(records, fromCandle(series, candle))
+	}
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "exchange"}, {Name: "market"}, {Name: "ticker"},
+			{Name: "candle_interval"}, {Name: "price_type"}, {Name: "open_time"},
+		},
+		UpdateAll: true,
+	}).Create(&records).Error
+	if err != nil {
+		return fmt.Errorf("error saving candles: %v", err)
+	}
+	return nil
+}
+
+// Holes returns the known holes of a series overlapping [start, end], in
+// chronological order
+func (s *GormStore) Holes(ctx context.Context, series Series, start, end time.Time) ([]Range, error) {
+	var records []models.CandleHole
+	err := s.seriesQuery(ctx, &models.CandleHole{}, series).
+		Where("start_time <= ? AND end_time >= ?", end.UnixMilli(), start.UnixMilli()).
+		Order("start_time ASC").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading candle holes: %v", err)
+	}
+
+	step := series.Interval.Duration()
+	holes := make([]Range, 0, len(records))
+	for _, record := range records {
+		holes = append(holes, newRange(time.UnixMilli(record.StartTime), time.UnixMilli(record.EndTime), step))
+	}
+	return holes, nil
+}
+
+// SaveHoles records ranges of a series the exchange has no candles for
+func (s *GormStore) SaveHoles(ctx context.Context, series Series, holes []Range) error {
+	if len(holes) == 0 {
+		return nil
+	}
+	series = series.Normalize()
+
+	now := time.Now().UTC()
+	records := make([]models.CandleHole, 0, len(holes))
+	for _, hole := range holes {
+		records = append(records, models.CandleHole{
+			Exchange:   series.Exchange,
+			Market:     string(series.Market),
+			Ticker:     series.Ticker,
+			Interval:   string(series.Interval),
+			PriceType:  string(series.PriceType),
+			StartTime:  hole.Start.UnixMilli(),
+			EndTime:    hole.End.UnixMilli(),
+			DetectedAt: now,
+		})
+	}
+	if err := s.db.WithContext(ctx).Create(&records).Error; err != nil {
+		return fmt.Errorf("error saving candle holes: %v", err)
+	}
+	return nil
+}
+
+// Series returns every series with stored candles
+func (s *GormStore) Series(ctx context.Context) ([]Series, error) {
+	var records []models.Candle
+	err := s.db.WithContext(ctx).Model(&models.Candle{}).
+		Distinct("exchange", "market", "ticker", "candle_interval", "price_type").
+		Order("exchange, market, ticker, candle_interval, price_type").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading candle series: %v", err)
+	}
+
+	series := make([]Series, 0, len(records))
+	for _, record := range records {
+		series = append(series, Series{
+			Exchange:  record.Exchange,
+			Market:    exchanges.Market(record.Market),
+			Ticker:    record.Ticker,
+			Interval:  exchanges.Interval(record.Interval),
+			PriceType: exchanges.PriceType(record.PriceType),
+		})
+	}
+	return series, nil
+}
+
+// Windows returns the runs of consecutive candles stored for a series, in
+// chronological order. Consecutive open times are one interval apart, so every
+// candle of a run has the same open time minus its position times the interval.
+func (s *GormStore) Windows(ctx context.Context, series Series) ([]Range, error) {
+	step := series.Interval.Duration()
+	islands := s.seriesQuery(ctx, &models.Candle{}, series).
+		Where("schema_version = ?", exchanges.CandleSchemaVersion).
+		Select("open_time, open_time - ROW_NUMBER() OVER (ORDER BY open_time) * ? AS island", step.Milliseconds())
+
+	var rows []struct {
+		StartTime   int64
+		EndTime     int64
+		CandleCount int64
+	}
+	err := s.db.WithContext(ctx).
+		Table("(?) AS islands", islands).
+		Select("MIN(open_time) AS start_time, MAX(open_time) AS end_time, COUNT(*) AS candle_count").
+		Group("island").
+		Order("start_time ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading candle windows: %v", err)
+	}
+
+	windows := make([]Range, 0, len(rows))
+	for _, row := range rows {
+		windows = append(windows, Range{
+			Start: time.UnixMilli(row.StartTime).UTC(),
+			End:   time.UnixMilli(row.EndTime).UTC(),
+			Count: row.CandleCount,
+		})
+	}
+	return windows, nil
+}
+
+// RemoveInvalid deletes the rows of a series that would corrupt it: candles that
+// do not open on an interval boundary or do not close one interval later, and all
+// but the latest of candles stored twice. It returns the number of rows deleted.
+func (s *GormStore) RemoveInvalid(ctx context.Context, series Series) (int64, error) {
+	step := series.Interval.Duration().Milliseconds()
+
+	misaligned := s.seriesQuery(ctx, &models.Candle{}, series).
+		Where("open_time % ? <> 0 OR close_time <> open_time + ?", step, step-1).
+		Delete(&models.Candle{})
+	if misaligned.Error != nil {
+		return 0, fmt.Errorf("error removing misaligned candles: %v", misaligned.Error)
+	}
+
+	latest := s.seriesQuery(ctx, &models.Candle{}, series).Select("MAX(id)").Group("open_time")
+	duplicates := s.seriesQuery(ctx, &models.Candle{}, series).
+		Where("id NOT IN (?)", latest).
+		Delete(&models.Candle{})
+	if duplicates.Error != nil {
+		return 0, fmt.Errorf("error removing duplicate candles: %v", duplicates.Error)
+	}
+
+	return misaligned.RowsAffected + duplicates.RowsAffected, nil
+}
+
+// seriesQuery starts a query on the rows of a series in the table of a model
+func (s *GormStore) seriesQuery(ctx context.Context, model interface{}, series Series) *gorm.DB {
+	series = series.Normalize()
+	return s.db.WithContext(ctx).Model(model).
+		Where("exchange = ? AND market = ? AND ticker = ? AND candle_interval = ? AND price_type = ?",
+			series.Exchange, string(series.Market), series.Ticker, string(series.Interval), string(series.PriceType))
+}
+
+// fromCandle converts a candle of a series into its stored form
+func fromCandle(series Series, candle *pb.PricesResponse) models.Candle {
+	record := models.Candle{
+		Exchange:            series.Exchange,
+		Market:              string(series.Market),
+		Ticker:              series.Ticker,
+		Interval:            string(series.Interval),
+		PriceType:           string(series.PriceType),
+		OpenTime:            candle.OpenTime,
+		CloseTime:           candle.CloseTime,
+		Open:                candle.Open,
+		High:                candle.High,
+		Low:                 candle.Low,
+		Close:               candle.Close,
+		Volume:              candle.Volume,
+		QuoteVolume:         candle.QuoteVolume,
+		TradeCount:          candle.TradeCount,
+		TakerBuyBaseVolume:  candle.TakerBuyBaseVolume,
+		TakerBuyQuoteVolume: candle.TakerBuyQuoteVolume,
+		SchemaVersion:       candle.SchemaVersion,
+	}
+	for _, field := range candle.UnavailableFields {
+		record.UnavailableFields = append(record.UnavailableFields, int32(field))
+	}
+	if decimals := candle.Decimals; decimals != nil {
+		record.Decimals = &models.CandleDecimals{
+			Open:                decimals.Open,
+			High:                decimals.High,
+			Low:                 decimals.Low,
+			Close:               decimals.Close,
+			Volume:              decimals.Volume,
+			QuoteVolume:         decimals.QuoteVolume,
+			TakerBuyBaseVolume:  decimals.TakerBuyBaseVolume,
+			TakerBuyQuoteVolume: decimals.TakerBuyQuoteVolume,
+		}
+	}
+	return record
+}
+
+// toCandle converts a stored candle back into its response form
+func toCandle(record models.Candle) *pb.PricesResponse {
+	candle := &pb.PricesResponse{
+		Date:                time.UnixMilli(record.OpenTime).UTC().Format("2006-01-02"),
+		OpenTime:            record.OpenTime,
+		CloseTime:           record.CloseTime,
+		Open:                record.Open,
+		High:                record.High,
+		Low:                 record.Low,
+		Close:               record.Close,
+		Volume:              record.Volume,
+		QuoteVolume:         record.QuoteVolume,
+		TradeCount:          record.TradeCount,
+		TakerBuyBaseVolume:  record.TakerBuyBaseVolume,
+		TakerBuyQuoteVolume: record.TakerBuyQuoteVolume,
+		SchemaVersion:       record.SchemaVersion,
+	}
+	for _, field := range record.UnavailableFields {
+		candle.UnavailableFields = append(candle.UnavailableFields, pb.CandleField(field))
+	}
+	if decimals := record.Decimals; decimals != nil {
+		candle.Decimals = &pb.DecimalValues{
+			Open:                decimals.Open,
+			High:                decimals.High,
+			Low:                 decimals.Low,
+			Close:               decimals.Close,
+			Volume:              decimals.Volume,
+			QuoteVolume:         decimals.QuoteVolume,
+			TakerBuyBaseVolume:  decimals.TakerBuyBaseVolume,
+			TakerBuyQuoteVolume: decimals.TakerBuyQuoteVolume,
+		}
+	}
+	return candle
+}
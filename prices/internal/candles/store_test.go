@@ -0,0 +1,117 @@
+package candles
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestStore creates a store on an in-memory SQLite database private to the test
+func newTestStore(t *testing.T) *GormStore {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := NewGormStore(db)
+	require.NoError(t, store.Migrate())
+	return store
+}
+
+// hourlyCandle returns the hourly candle opening at a time, priced after its open time
+func hourlyCandle(open time.Time) *pb.PricesResponse {
+	price := float64(open.Unix() / 3600)
+	return &pb.PricesResponse{
+		Date:          open.UTC().Format("2006-01-02"),
+		OpenTime:      open.UnixMilli(),
+		CloseTime:     exchanges.Interval1h.CloseTime(open).UnixMilli(),
+		Open:          price,
+		High:          price + 1,
+		Low:           price - 1,
+		Close:         price + 0.5,
+		Volume:        10,
+		SchemaVersion: exchanges.CandleSchemaVersion,
+		Decimals:      &pb.DecimalValues{Open: fmt.Sprintf("%.1f", price), Volume: "10.00"},
+	}
+}
+
+// TestGormStore tests storing and reading back the candles of a series
+func TestGormStore(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := Series{Exchange: "binance", Ticker: "btcusdt", Interval: exchanges.Interval1h}
+
+	var saved []*pb.PricesResponse
+	for i := 0; i < 5; i++ {
+		saved = append(saved, hourlyCandle(start.Add(time.Duration(i)*time.Hour)))
+	}
+	require.NoError(t, store.Save(ctx, series, saved))
+
+	t.Run("range is read in order with all fields", func(t *testing.T) {
+		candles, err := store.Candles(ctx, series, start.Add(time.Hour), start.Add(3*time.Hour), 100)
+
+		require.NoError(t, err)
+		require.Len(t, candles, 3)
+		assert.Equal(t, saved[1].String(), candles[0].String())
+		assert.Equal(t, saved[3].OpenTime, candles[2].OpenTime)
+	})
+
+	t.Run("limit caps the candles read", func(t *testing.T) {
+		candles, err := store.Candles(ctx, series, start, start.Add(24*time.Hour), 2)
+
+		require.NoError(t, err)
+		require.Len(t, candles, 2)
+		assert.Equal(t, saved[1].OpenTime, candles[1].OpenTime)
+	})
+
+	t.Run("series are kept apart", func(t *testing.T) {
+		for _, other := range []Series{
+			{Exchange: "binance", Ticker: "BTCUSDT", Interval: exchanges.Interval1h, Market: exchanges.MarketLinearPerp},
+			{Exchange: "binance", Ticker: "BTCUSDT", Interval: exchanges.Interval1h, PriceType: exchanges.PriceTypeMark},
+			{Exchange: "binance", Ticker: "BTCUSDT", Interval: exchanges.Interval4h},
+			{Exchange: "bybit", Ticker: "BTCUSDT", Interval: exchanges.Interval1h},
+		} {
+			candles, err := store.Candles(ctx, other, start, start.Add(24*time.Hour), 100)
+			require.NoError(t, err)
+			assert.Empty(t, candles, "%+v", other)
+		}
+	})
+
+	t.Run("saving again replaces stored candles", func(t *testing.T) {
+		revised := hourlyCandle(start)
+		revised.Close = 42
+		require.NoError(t, store.Save(ctx, series, []*pb.PricesResponse{revised}))
+
+		candles, err := store.Candles(ctx, series, start, start, 100)
+
+		require.NoError(t, err)
+		require.Len(t, candles, 1)
+		assert.Equal(t, 42.0, candles[0].Close)
+	})
+
+	t.Run("candles of an older schema are left out", func(t *testing.T) {
+		outdated := hourlyCandle(start.Add(10 * time.Hour))
+		outdated.SchemaVersion = exchanges.CandleSchemaVersion - 1
+		require.NoError(t, store.Save(ctx, series, []*pb.PricesResponse{outdated}))
+
+		candles, err := store.Candles(ctx, series, start.Add(10*time.Hour), start.Add(10*time.Hour), 100)
+
+		require.NoError(t, err)
+		assert.Empty(t, candles)
+	})
+}
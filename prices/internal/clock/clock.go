@@ -0,0 +1,24 @@
+// Package clock abstracts telling the time and waiting, so that background jobs
+// can be driven by a fake clock in tests.
+package clock
+
+import "time"
+
+// Clock tells the time and waits
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// System is the wall clock
+type System struct{}
+
+// Now returns the current time
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for a duration to elapse
+func (System) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,145 @@
+package exchanges
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// errArchiveNotFound is returned when an exchange has not published an archive
+var errArchiveNotFound = errors.New("archive not found")
+
+// errStopLines stops a walk over the lines of an archive without an error
+var errStopLines = errors.New("stop reading lines")
+
+// archiveBlockSize is the number of bytes read at once when walking an archive backwards
+const archiveBlockSize = 64 << 10
+
+// downloadArchive fetches a gzip-compressed archive and decompresses it into a
+// temporary file, so that archives of any size never sit in memory. The caller
+// closes and removes the file.
+func downloadArchive(ctx context.Context, client *http.Client, endpoint string) (*os.File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errArchiveNotFound
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing archive: %v", err)
+	}
+	defer reader.Close()
+
+	file, err := os.CreateTemp("", "archive-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		removeArchive(file)
+		return nil, fmt.Errorf("error decompressing archive: %v", err)
+	}
+	return file, nil
+}
+
+// removeArchive closes and deletes a downloaded archive
+func removeArchive(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// archiveLines calls fn for every non-empty line of a file, from the first to the
+// last or, when reverse is set, from the last to the first. A walk stopped by fn
+// returning errStopLines ends without an error.
+func archiveLines(file *os.File, reverse bool, fn func(line string) error) error {
+	var err error
+	if reverse {
+		err = archiveLinesBackward(file, fn)
+	} else {
+		err = archiveLinesForward(file, fn)
+	}
+	if errors.Is(err, errStopLines) {
+		return nil
+	}
+	return err
+}
+
+// archiveLinesForward reads a file line by line from its start
+func archiveLinesForward(file *os.File, fn func(line string) error) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// archiveLinesBackward reads a file block by block from its end, keeping the
+// partial line at the start of each block until the preceding block completes it
+func archiveLinesBackward(file *os.File, fn func(line string) error) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var partial []byte
+	for offset := info.Size(); offset > 0; {
+		size := min(int64(archiveBlockSize), offset)
+		offset -= size
+
+		block := make([]byte, size, size+int64(len(partial)))
+		if _, err := file.ReadAt(block, offset); err != nil {
+			return err
+		}
+		block = append(block, partial...)
+
+		for {
+			i := bytes.LastIndexByte(block, '\n')
+			if i < 0 {
+				break
+			}
+			if line := strings.TrimRight(string(block[i+1:]), "\r"); line != "" {
+				if err := fn(line); err != nil {
+					return err
+				}
+			}
+			block = block[:i]
+		}
+		partial = block
+	}
+
+	if line := strings.TrimRight(string(partial), "\r"); line != "" {
+		return fn(line)
+	}
+	return nil
+}
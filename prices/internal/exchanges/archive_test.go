@@ -0,0 +1,68 @@
+package exchanges
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDownloadArchive tests decompressing an archive into a temporary file and
+// reading its lines in both directions across block boundaries
+func TestDownloadArchive(t *testing.T) {
+	var lines []string
+	var content bytes.Buffer
+	writer := gzip.NewWriter(&content)
+	for i := 0; i < 20000; i++ {
+		line := fmt.Sprintf("%d,line", i)
+		lines = append(lines, line)
+		fmt.Fprintf(writer, "%s\r\n", line)
+	}
+	require.NoError(t, writer.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/archive.csv.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content.Bytes())
+	}))
+	defer server.Close()
+
+	file, err := downloadArchive(context.Background(), server.Client(), server.URL+"/archive.csv.gz")
+	require.NoError(t, err)
+
+	var forward []string
+	require.NoError(t, archiveLines(file, false, func(line string) error {
+		forward = append(forward, line)
+		return nil
+	}))
+	assert.Equal(t, lines, forward)
+
+	var backward []string
+	require.NoError(t, archiveLines(file, true, func(line string) error {
+		backward = append(backward, line)
+		if len(backward) == 15000 {
+			return errStopLines
+		}
+		return nil
+	}))
+	require.Len(t, backward, 15000)
+	assert.Equal(t, "19999,line", backward[0])
+	assert.Equal(t, "5000,line", backward[14999])
+
+	_, err = downloadArchive(context.Background(), server.Client(), server.URL+"/missing.csv.gz")
+	assert.ErrorIs(t, err, errArchiveNotFound)
+
+	// Temporary files are removed once done with
+	removeArchive(file)
+	_, err = os.Stat(file.Name())
+	assert.True(t, os.IsNotExist(err))
+}
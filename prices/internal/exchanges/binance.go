@@ -2,24 +2,82 @@ package exchanges
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
 	pb "github.com/timakaa/historical-common/proto"
 )
 
+// binanceIntervals maps API intervals to Binance kline intervals
+var binanceIntervals = map[Interval]string{
+	Interval1m:  "1m",
+	Interval5m:  "5m",
+	Interval15m: "15m",
+	Interval1h:  "1h",
+	Interval4h:  "4h",
+	Interval1d:  "1d",
+	Interval1w:  "1w",
+	Interval1M:  "1M",
+}
+
+// binanceMaxPageSize is the largest number of klines Binance returns per request
+const binanceMaxPageSize = 1000
+
+// binanceFuturesMaxPageSize is the largest number of klines the Binance futures APIs return per request
+const binanceFuturesMaxPageSize = 1500
+
+// binanceCoinMMaxRange is the longest time range a COIN-M klines request may span
+const binanceCoinMMaxRange = 200 * 24 * time.Hour
+
+// Binance APIs serving the supported markets
+const (
+	binanceSpot  = "spot"
+	binanceUSDM  = "usdm"
+	binanceCoinM = "coinm"
+)
+
+// binanceMarkets maps markets to the Binance API serving them. Dated futures are
+// served by USD-M unless their symbol is a COIN-M one.
+var binanceMarkets = map[Market]string{
+	MarketSpot:        binanceSpot,
+	MarketLinearPerp:  binanceUSDM,
+	MarketInversePerp: binanceCoinM,
+	MarketDatedFuture: binanceUSDM,
+}
+
+// binanceStreamURLs are the websocket endpoints of the Binance APIs
+var binanceStreamURLs = map[string]string{
+	binanceSpot:  "wss://stream.binance.com:9443/ws",
+	binanceUSDM:  "wss://fstream.binance.com/ws",
+	binanceCoinM: "wss://dstream.binance.com/ws",
+}
+
 // BinanceAdapter implements the adapter for Binance exchange
 type BinanceAdapter struct {
-	client *binance.Client
+	client         *binance.Client
+	futuresClient  *futures.Client
+	deliveryClient *delivery.Client
+
+	// streamURLs are the websocket endpoints of the APIs, for live candles
+	streamURLs map[string]string
 }
 
 // NewBinanceAdapter creates a new adapter for Binance
 func NewBinanceAdapter() *BinanceAdapter {
+	// API keys not needed for public endpoints
 	return &BinanceAdapter{
-		client: binance.NewClient("", ""), // API keys not needed for public endpoints
+		client:         binance.NewClient("", ""),
+		futuresClient:  binance.NewFuturesClient("", ""),
+		deliveryClient: binance.NewDeliveryClient("", ""),
+		streamURLs:     binanceStreamURLs,
 	}
 }
 
@@ -28,53 +86,1073 @@ func (a *BinanceAdapter) GetName() string {
 	return "binance"
 }
 
+// SupportedPriceTypes returns the price types Binance serves; all but last
+// traded prices are available for USD-M futures only
+func (a *BinanceAdapter) SupportedPriceTypes() []PriceType {
+	return []PriceType{PriceTypeLast, PriceTypeMark, PriceTypeIndex, PriceTypePremiumIndex}
+}
+
 // GetHistoricalPrices retrieves historical price data from Binance
-func (a *BinanceAdapter) GetHistoricalPrices(ctx context.Context, ticker string, limit int64) ([]*pb.PricesResponse, error) {
-	log.Printf("Getting historical prices from Binance for %s", ticker)
+func (a *BinanceAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	log.Printf("Getting historical prices from Binance for %s (%s, %s)", query.Ticker, query.Market, query.Interval)
+
+	binanceInterval, err := mapInterval(a.GetName(), binanceIntervals, query.Interval)
+	if err != nil {
+		return err
+	}
+	api, err := mapMarket(a.GetName(), binanceMarkets, query.Market)
+	if err != nil {
+		return err
+	}
 
 	// Set default limit if not specified
-	if limit <= 0 {
-		limit = 100
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
 	}
 
-	// Fetch data from Binance API
-	klines, err := a.client.NewKlinesService().
-		Symbol(ticker).
-		Interval("1d"). // Daily candles
-		Limit(int(limit)).
-		Do(ctx)
+	// COIN-M dated futures are quoted in USD, such as BTCUSD_250328
+	symbol := binanceSymbol(query.Ticker, query.Market)
+	if query.Market == MarketDatedFuture && isBinanceCoinMSymbol(symbol) {
+		api = binanceCoinM
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("error fetching data from Binance: %v", err)
+	// Mark, index and premium index klines are served by the USD-M API only
+	priceType := query.PriceType
+	if priceType == "" {
+		priceType = DefaultPriceType
+	}
+	if priceType != PriceTypeLast && api != binanceUSDM {
+		return unsupportedPriceType(a.GetName(), priceType, query.Market)
+	}
+
+	p := pager{interval: query.Interval, pageSize: binanceMaxPageSize}
+	switch api {
+	case binanceUSDM:
+		p.pageSize = binanceFuturesMaxPageSize
+		switch priceType {
+		case PriceTypeMark:
+			p.fetch = a.fetchKlines(a.usdmMarkKlines, binancePriceSeriesKlineToCandle, symbol, query.Interval, binanceInterval)
+		case PriceTypeIndex:
+			// Index prices are published per underlying pair, such as BTCUSDT for BTCUSDT_250328
+			pair, _, _ := strings.Cut(symbol, "_")
+			p.fetch = a.fetchKlines(a.usdmIndexKlines, binancePriceSeriesKlineToCandle, pair, query.Interval, binanceInterval)
+		case PriceTypePremiumIndex:
+			p.fetch = a.fetchKlines(a.usdmPremiumIndexKlines, binancePriceSeriesKlineToCandle, symbol, query.Interval, binanceInterval)
+		default:
+			p.fetch = a.fetchKlines(a.usdmKlines, binanceKlineToCandle, symbol, query.Interval, binanceInterval)
+		}
+	case binanceCoinM:
+		// COIN-M requests may span at most 200 days, so ranges are walked in windows
+		p.pageSize = min(binanceFuturesMaxPageSize, int(binanceCoinMMaxRange/query.Interval.Duration()))
+		p.windowed = true
+		p.fetch = a.fetchKlines(a.coinmKlines, binanceCoinMKlineToCandle, symbol, query.Interval, binanceInterval)
+	default:
+		p.fetch = a.fetchKlines(a.spotKlines, binanceKlineToCandle, symbol, query.Interval, binanceInterval)
+	}
+	return p.walk(ctx, query, handle)
+}
+
+// binanceKlineSource requests up to limit klines with open times in [start, end]
+// from one of the Binance APIs. A zero start asks for the most recent klines.
+type binanceKlineSource func(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error)
+
+// fetchKlines returns a page fetcher for a Binance klines endpoint
+func (a *BinanceAdapter) fetchKlines(source binanceKlineSource, convert func(*binance.Kline, Interval) (*pb.PricesResponse, error), symbol string, interval Interval, binanceInterval string) pageFetcher {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+		// Fetch data from Binance API; klines are returned oldest first from
+		// the start time, or as the most recent ones before the end time
+		klines, err := source(ctx, symbol, binanceInterval, start, end, limit)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching data from Binance: %v", err)
+		}
+
+		// Convert data to response format
+		prices := make([]*pb.PricesResponse, 0, len(klines))
+		for _, k := range klines {
+			candle, err := convert(k, interval)
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, candle)
+		}
+
+		return prices, nil
+	}
+}
+
+// spotKlines requests klines from the Binance spot API
+func (a *BinanceAdapter) spotKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := a.client.NewKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
 	}
+	return service.Do(ctx)
+}
+
+// usdmKlines requests klines from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := a.futuresClient.NewKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+
+	klines, err := service.Do(ctx)
+	return fromFuturesKlines(klines), err
+}
+
+// usdmMarkKlines requests mark price klines from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmMarkKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := a.futuresClient.NewMarkPriceKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+
+	klines, err := service.Do(ctx)
+	return fromFuturesKlines(klines), err
+}
+
+// usdmIndexKlines requests index price klines of an underlying pair from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmIndexKlines(ctx context.Context, pair, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := a.futuresClient.NewIndexPriceKlinesService().
+		Pair(pair).
+		Interval(interval).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+
+	klines, err := service.Do(ctx)
+	return fromFuturesKlines(klines), err
+}
+
+// usdmPremiumIndexKlines requests premium index klines from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmPremiumIndexKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := a.futuresClient.NewPremiumIndexKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+
+	klines, err := service.Do(ctx)
+	return fromFuturesKlines(klines), err
+}
 
-	// Convert data to response format
-	prices := make([]*pb.PricesResponse, 0, len(klines))
+// fromFuturesKlines converts USD-M klines, which share the spot kline layout
+func fromFuturesKlines(klines []*futures.Kline) []*binance.Kline {
+	result := make([]*binance.Kline, 0, len(klines))
 	for _, k := range klines {
-		// Convert string values to float64
-		open, _ := strconv.ParseFloat(k.Open, 64)
-		high, _ := strconv.ParseFloat(k.High, 64)
-		low, _ := strconv.ParseFloat(k.Low, 64)
-		close, _ := strconv.ParseFloat(k.Close, 64)
-		volume, _ := strconv.ParseFloat(k.Volume, 64)
-
-		// Convert timestamp to date
-		date := time.Unix(k.OpenTime/1000, 0).Format("2006-01-02")
-
-		prices = append(prices, &pb.PricesResponse{
-			Date:   date,
-			Open:   open,
-			High:   high,
-			Low:    low,
-			Close:  close,
-			Volume: volume,
+		result = append(result, (*binance.Kline)(k))
+	}
+	return result
+}
+
+// coinmKlines requests klines from the Binance COIN-M futures API
+func (a *BinanceAdapter) coinmKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := a.deliveryClient.NewKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+
+	klines, err := service.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*binance.Kline, 0, len(klines))
+	for _, k := range klines {
+		result = append(result, (*binance.Kline)(k))
+	}
+	return result, nil
+}
+
+// binanceFundingMaxPageSize is the largest number of funding rates Binance returns per request
+const binanceFundingMaxPageSize = 1000
+
+// binanceFundingMarkets maps perpetual markets to the Binance API serving their funding rates
+var binanceFundingMarkets = map[Market]string{
+	MarketLinearPerp:  binanceUSDM,
+	MarketInversePerp: binanceCoinM,
+}
+
+// binanceFundingRate is a funding settlement as returned by the Binance futures
+// APIs, laid out like futures.FundingRate. COIN-M settlements carry no mark price.
+type binanceFundingRate struct {
+	Symbol      string `json:"symbol"`
+	FundingRate string `json:"fundingRate"`
+	FundingTime int64  `json:"fundingTime"`
+	MarkPrice   string `json:"markPrice"`
+}
+
+// GetFundingRates retrieves the funding rate history of a Binance perpetual contract
+func (a *BinanceAdapter) GetFundingRates(ctx context.Context, query FundingRateQuery, handle FundingRateHandler) error {
+	log.Printf("Getting funding rates from Binance for %s (%s)", query.Ticker, query.Market)
+
+	market := query.Market
+	if market == "" {
+		market = DefaultFundingMarket
+	}
+	api, err := mapFundingMarket(a.GetName(), binanceFundingMarkets, market)
+	if err != nil {
+		return err
+	}
+
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	source := a.usdmFundingRates
+	if api == binanceCoinM {
+		source = a.coinmFundingRates
+	}
+	symbol := binanceSymbol(query.Ticker, market)
+
+	// Funding rates are returned oldest first from the start time
+	p := seriesPager[*pb.FundingRate]{
+		pageSize:  binanceFundingMaxPageSize,
+		timestamp: fundingRateTimestamp,
+		fetch: func(ctx context.Context, start, end time.Time, limit int) ([]*pb.FundingRate, error) {
+			rows, err := source(ctx, symbol, start, end, limit)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching funding rates from Binance: %v", err)
+			}
+
+			rates := make([]*pb.FundingRate, 0, len(rows))
+			for _, row := range rows {
+				rate, err := newFundingRate("binance", row.FundingTime, row.FundingRate, row.MarkPrice)
+				if err != nil {
+					return nil, err
+				}
+				rates = append(rates, rate)
+			}
+			return rates, nil
+		},
+	}
+	return p.walk(ctx, query.StartTime, query.EndTime, query.Limit, handle)
+}
+
+// usdmFundingRates requests funding rates from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmFundingRates(ctx context.Context, symbol string, start, end time.Time, limit int) ([]binanceFundingRate, error) {
+	service := a.futuresClient.NewFundingRateService().
+		Symbol(symbol).
+		EndTime(end.UnixMilli()).
+		Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+
+	rates, err := service.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]binanceFundingRate, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, binanceFundingRate(*rate))
+	}
+	return result, nil
+}
+
+// coinmFundingRates requests funding rates from the Binance COIN-M futures API,
+// which the delivery client does not cover
+func (a *BinanceAdapter) coinmFundingRates(ctx context.Context, symbol string, start, end time.Time, limit int) ([]binanceFundingRate, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	params.Set("limit", strconv.Itoa(limit))
+	if !start.IsZero() {
+		params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+
+	var rates []binanceFundingRate
+	err := getJSON(ctx, a.deliveryClient.HTTPClient, a.deliveryClient.BaseURL+"/dapi/v1/fundingRate", params, &rates)
+	return rates, err
+}
+
+// binanceStatsMaxPageSize is the largest number of statistic samples Binance returns per request
+const binanceStatsMaxPageSize = 500
+
+// binanceStatsMarkets maps markets to the Binance API serving their statistics.
+// Binance keeps the last 30 days of samples only.
+var binanceStatsMarkets = map[Market]string{
+	MarketLinearPerp: binanceUSDM,
+}
+
+// binanceStatPeriods maps statistic periods to Binance periods
+var binanceStatPeriods = map[StatPeriod]string{
+	StatPeriod5m:  "5m",
+	StatPeriod15m: "15m",
+	StatPeriod30m: "30m",
+	StatPeriod1h:  "1h",
+	StatPeriod2h:  "2h",
+	StatPeriod4h:  "4h",
+	StatPeriod6h:  "6h",
+	StatPeriod12h: "12h",
+	StatPeriod1d:  "1d",
+}
+
+// GetDerivativesStats retrieves the open interest or long/short ratio history of a
+// Binance USD-M perpetual contract
+func (a *BinanceAdapter) GetDerivativesStats(ctx context.Context, query DerivativesStatsQuery, handle DerivativesStatsHandler) error {
+	log.Printf("Getting %s from Binance for %s (%s, %s)", query.Stat, query.Ticker, query.Market, query.Period)
+
+	if query.Market == "" {
+		query.Market = DefaultStatsMarket
+	}
+	if _, err := mapMarket(a.GetName(), binanceStatsMarkets, query.Market); err != nil {
+		return err
+	}
+	period, err := mapStatPeriod(a.GetName(), binanceStatPeriods, query.Period)
+	if err != nil {
+		return err
+	}
+
+	var fetch seriesFetcher[*pb.DerivativesStat]
+	symbol := binanceSymbol(query.Ticker, query.Market)
+	switch query.Stat {
+	case StatOpenInterest:
+		fetch = a.fetchOpenInterest(symbol, period)
+	case StatTopTraderLongShortRatio:
+		fetch = a.fetchTopTraderLongShortRatios(symbol, period)
+	case StatGlobalLongShortRatio:
+		fetch = a.fetchGlobalLongShortRatios(symbol, period)
+	default:
+		return unsupportedDerivativesStat(a.GetName(), query.Stat)
+	}
+
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	p := seriesPager[*pb.DerivativesStat]{
+		pageSize:  binanceStatsMaxPageSize,
+		period:    query.Period.Duration(),
+		timestamp: derivativesStatTimestamp,
+		fetch:     fetch,
+	}
+	return p.walk(ctx, query.StartTime, query.EndTime, query.Limit, handle)
+}
+
+// fetchOpenInterest returns a page fetcher for the Binance open interest history endpoint
+func (a *BinanceAdapter) fetchOpenInterest(symbol, period string) seriesFetcher[*pb.DerivativesStat] {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.DerivativesStat, error) {
+		service := a.futuresClient.NewOpenInterestStatisticsService().
+			Symbol(symbol).
+			Period(period).
+			EndTime(end.UnixMilli()).
+			Limit(limit)
+		if !start.IsZero() {
+			service.StartTime(start.UnixMilli())
+		}
+
+		rows, err := service.Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching open interest from Binance: %v", err)
+		}
+		stats := make([]*pb.DerivativesStat, 0, len(rows))
+		for _, row := range rows {
+			stat, err := newOpenInterestStat("binance", row.Timestamp, row.SumOpenInterest, row.SumOpenInterestValue)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, stat)
+		}
+		return stats, nil
+	}
+}
+
+// fetchTopTraderLongShortRatios returns a page fetcher for the Binance top trader
+// long/short account ratio endpoint
+func (a *BinanceAdapter) fetchTopTraderLongShortRatios(symbol, period string) seriesFetcher[*pb.DerivativesStat] {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.DerivativesStat, error) {
+		service := a.futuresClient.NewTopLongShortAccountRatioService().
+			Symbol(symbol).
+			Period(period).
+			EndTime(uint64(end.UnixMilli())).
+			Limit(uint32(limit))
+		if !start.IsZero() {
+			service.StartTime(uint64(start.UnixMilli()))
+		}
+
+		rows, err := service.Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching top trader long/short ratios from Binance: %v", err)
+		}
+		stats := make([]*pb.DerivativesStat, 0, len(rows))
+		for _, row := range rows {
+			stat, err := newLongShortRatioStat("binance", int64(row.Timestamp), row.LongShortRatio, row.LongAccount, row.ShortAccount)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, stat)
+		}
+		return stats, nil
+	}
+}
+
+// fetchGlobalLongShortRatios returns a page fetcher for the Binance global
+// long/short account ratio endpoint
+func (a *BinanceAdapter) fetchGlobalLongShortRatios(symbol, period string) seriesFetcher[*pb.DerivativesStat] {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.DerivativesStat, error) {
+		service := a.futuresClient.NewLongShortRatioService().
+			Symbol(symbol).
+			Period(period).
+			EndTime(end.UnixMilli()).
+			Limit(limit)
+		if !start.IsZero() {
+			service.StartTime(start.UnixMilli())
+		}
+
+		rows, err := service.Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching global long/short ratios from Binance: %v", err)
+		}
+		stats := make([]*pb.DerivativesStat, 0, len(rows))
+		for _, row := range rows {
+			stat, err := newLongShortRatioStat("binance", row.Timestamp, row.LongShortRatio, row.LongAccount, row.ShortAccount)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, stat)
+		}
+		return stats, nil
+	}
+}
+
+// binanceAggTradesMaxPageSize is the largest number of aggregated trades Binance returns per request
+const binanceAggTradesMaxPageSize = 1000
+
+// binanceAggTradesMaxRange is the longest time range an aggregated trades request may span
+const binanceAggTradesMaxRange = time.Hour
+
+// binanceAggTrade is a group of fills at one price and time as returned by the
+// Binance APIs, laid out like futures.AggTrade
+type binanceAggTrade struct {
+	AggTradeID   int64  `json:"a"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	FirstTradeID int64  `json:"f"`
+	LastTradeID  int64  `json:"l"`
+	Timestamp    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+}
+
+// binanceAggTradeSource requests a page of aggregated trades, from fromID when it
+// is positive and from the time range [start, end] otherwise
+type binanceAggTradeSource func(ctx context.Context, symbol string, fromID int64, start, end time.Time, limit int) ([]binanceAggTrade, error)
+
+// GetTrades retrieves the aggregated trades of a Binance symbol for a time window.
+// Binance only searches aggregated trades by time within an hour, so the window is
+// scanned hour by hour up to the first trade and then paged through by trade id.
+func (a *BinanceAdapter) GetTrades(ctx context.Context, query TradeQuery, handle TradeHandler) error {
+	log.Printf("Getting trades from Binance for %s (%s)", query.Ticker, query.Market)
+
+	start, end, err := tradeWindow(query)
+	if err != nil {
+		return err
+	}
+	api, err := mapMarket(a.GetName(), binanceMarkets, query.Market)
+	if err != nil {
+		return err
+	}
+
+	symbol := binanceSymbol(query.Ticker, query.Market)
+	if query.Market == MarketDatedFuture && isBinanceCoinMSymbol(symbol) {
+		api = binanceCoinM
+	}
+	source := a.spotAggTrades
+	switch api {
+	case binanceUSDM:
+		source = a.usdmAggTrades
+	case binanceCoinM:
+		source = a.coinmAggTrades
+	}
+
+	limiter := tradeLimiter{limit: query.Limit}
+	fromID := int64(0)
+	cursor := start
+	for !limiter.done() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var rows []binanceAggTrade
+		if fromID > 0 {
+			rows, err = source(ctx, symbol, fromID, time.Time{}, time.Time{}, binanceAggTradesMaxPageSize)
+		} else {
+			if cursor.After(end) {
+				return nil
+			}
+			windowEnd := cursor.Add(binanceAggTradesMaxRange - time.Millisecond)
+			if windowEnd.After(end) {
+				windowEnd = end
+			}
+			rows, err = source(ctx, symbol, 0, cursor, windowEnd, binanceAggTradesMaxPageSize)
+			cursor = windowEnd.Add(time.Millisecond)
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching trades from Binance: %v", err)
+		}
+		if len(rows) == 0 {
+			if fromID > 0 {
+				return nil
+			}
+			continue
+		}
+
+		page := make([]*pb.Trade, 0, len(rows))
+		for _, row := range rows {
+			if row.Timestamp > end.UnixMilli() {
+				break
+			}
+			trade, err := newBinanceTrade(row)
+			if err != nil {
+				return err
+			}
+			page = append(page, trade)
+		}
+		if page = limiter.take(page); len(page) > 0 {
+			if err := handle(page); err != nil {
+				return err
+			}
+		}
+
+		// The window ends within this page, or the exchange has no newer trades
+		if len(page) < len(rows) || (fromID > 0 && len(rows) < binanceAggTradesMaxPageSize) {
+			return nil
+		}
+		fromID = rows[len(rows)-1].AggTradeID + 1
+	}
+
+	return nil
+}
+
+// spotAggTrades requests aggregated trades from the Binance spot API
+func (a *BinanceAdapter) spotAggTrades(ctx context.Context, symbol string, fromID int64, start, end time.Time, limit int) ([]binanceAggTrade, error) {
+	service := a.client.NewAggTradesService().
+		Symbol(symbol).
+		Limit(limit)
+	if fromID > 0 {
+		service.FromID(fromID)
+	} else {
+		service.StartTime(start.UnixMilli()).EndTime(end.UnixMilli())
+	}
+
+	trades, err := service.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]binanceAggTrade, 0, len(trades))
+	for _, trade := range trades {
+		result = append(result, binanceAggTrade{
+			AggTradeID:   trade.AggTradeID,
+			Price:        trade.Price,
+			Quantity:     trade.Quantity,
+			FirstTradeID: trade.FirstTradeID,
+			LastTradeID:  trade.LastTradeID,
+			Timestamp:    trade.Timestamp,
+			IsBuyerMaker: trade.IsBuyerMaker,
 		})
 	}
+	return result, nil
+}
+
+// usdmAggTrades requests aggregated trades from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmAggTrades(ctx context.Context, symbol string, fromID int64, start, end time.Time, limit int) ([]binanceAggTrade, error) {
+	service := a.futuresClient.NewAggTradesService().
+		Symbol(symbol).
+		Limit(limit)
+	if fromID > 0 {
+		service.FromID(fromID)
+	} else {
+		service.StartTime(start.UnixMilli()).EndTime(end.UnixMilli())
+	}
+
+	trades, err := service.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]binanceAggTrade, 0, len(trades))
+	for _, trade := range trades {
+		result = append(result, binanceAggTrade(*trade))
+	}
+	return result, nil
+}
+
+// coinmAggTrades requests aggregated trades from the Binance COIN-M futures API,
+// which the delivery client does not cover
+func (a *BinanceAdapter) coinmAggTrades(ctx context.Context, symbol string, fromID int64, start, end time.Time, limit int) ([]binanceAggTrade, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(limit))
+	if fromID > 0 {
+		params.Set("fromId", strconv.FormatInt(fromID, 10))
+	} else {
+		params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+
+	var trades []binanceAggTrade
+	err := getJSON(ctx, a.deliveryClient.HTTPClient, a.deliveryClient.BaseURL+"/dapi/v1/aggTrades", params, &trades)
+	return trades, err
+}
+
+// newBinanceTrade converts an aggregated trade; a buyer maker means the seller took liquidity
+func newBinanceTrade(row binanceAggTrade) (*pb.Trade, error) {
+	side := TradeSideBuy
+	if row.IsBuyerMaker {
+		side = TradeSideSell
+	}
+	return newTrade("binance", strconv.FormatInt(row.AggTradeID, 10), row.Timestamp, row.Price, row.Quantity, side, row.LastTradeID-row.FirstTradeID+1)
+}
+
+// binanceDepthLimits are the order book depths the Binance APIs serve
+var binanceDepthLimits = []int{5, 10, 20, 50, 100, 500}
+
+// binanceDepth is an order book as returned by the Binance APIs; spot order books
+// carry no exchange time
+type binanceDepth struct {
+	LastUpdateID int64
+	Time         int64
+	Bids         []binance.Bid
+	Asks         []binance.Ask
+}
+
+// GetOrderBook retrieves the current top of a Binance order book
+func (a *BinanceAdapter) GetOrderBook(ctx context.Context, query OrderBookQuery) (*pb.OrderBookSnapshot, error) {
+	depth, err := ParseOrderBookDepth(query.Depth)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := orderBookLimit(a.GetName(), binanceDepthLimits, depth)
+	if err != nil {
+		return nil, err
+	}
+	api, err := mapMarket(a.GetName(), binanceMarkets, query.Market)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := binanceSymbol(query.Ticker, query.Market)
+	if query.Market == MarketDatedFuture && isBinanceCoinMSymbol(symbol) {
+		api = binanceCoinM
+	}
+
+	var book *binanceDepth
+	switch api {
+	case binanceUSDM:
+		book, err = a.usdmDepth(ctx, symbol, limit)
+	case binanceCoinM:
+		book, err = a.coinmDepth(ctx, symbol, limit)
+	default:
+		book, err = a.spotDepth(ctx, symbol, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching order book from Binance: %v", err)
+	}
+
+	parser := decimalParser{exchange: "binance"}
+	bids, asks := newOrderBookSides(&parser, depth)
+	for _, level := range book.Bids {
+		bids.add(level.Price, level.Quantity)
+	}
+	for _, level := range book.Asks {
+		asks.add(level.Price, level.Quantity)
+	}
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (order book %d)", parser.err, book.LastUpdateID)
+	}
+
+	return &pb.OrderBookSnapshot{
+		ExchangeTime: book.Time,
+		UpdateId:     book.LastUpdateID,
+		Bids:         bids.levels,
+		Asks:         asks.levels,
+	}, nil
+}
+
+// spotDepth requests an order book from the Binance spot API
+func (a *BinanceAdapter) spotDepth(ctx context.Context, symbol string, limit int) (*binanceDepth, error) {
+	book, err := a.client.NewDepthService().Symbol(symbol).Limit(limit).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &binanceDepth{LastUpdateID: book.LastUpdateID, Bids: book.Bids, Asks: book.Asks}, nil
+}
+
+// usdmDepth requests an order book from the Binance USD-M futures API
+func (a *BinanceAdapter) usdmDepth(ctx context.Context, symbol string, limit int) (*binanceDepth, error) {
+	book, err := a.futuresClient.NewDepthService().Symbol(symbol).Limit(limit).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &binanceDepth{LastUpdateID: book.LastUpdateID, Time: book.Time, Bids: book.Bids, Asks: book.Asks}, nil
+}
+
+// coinmDepth requests an order book from the Binance COIN-M futures API, which
+// the delivery client does not cover
+func (a *BinanceAdapter) coinmDepth(ctx context.Context, symbol string, limit int) (*binanceDepth, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(limit))
+
+	var book struct {
+		LastUpdateID int64       `json:"lastUpdateId"`
+		Time         int64       `json:"E"`
+		Bids         [][2]string `json:"bids"`
+		Asks         [][2]string `json:"asks"`
+	}
+	if err := getJSON(ctx, a.deliveryClient.HTTPClient, a.deliveryClient.BaseURL+"/dapi/v1/depth", params, &book); err != nil {
+		return nil, err
+	}
+
+	depth := &binanceDepth{LastUpdateID: book.LastUpdateID, Time: book.Time}
+	for _, level := range book.Bids {
+		depth.Bids = append(depth.Bids, binance.Bid{Price: level[0], Quantity: level[1]})
+	}
+	for _, level := range book.Asks {
+		depth.Asks = append(depth.Asks, binance.Ask{Price: level[0], Quantity: level[1]})
+	}
+	return depth, nil
+}
+
+// binanceSymbol converts a ticker into a Binance symbol. Inverse perpetuals are
+// listed with a _PERP suffix, so BTCUSD becomes BTCUSD_PERP.
+func binanceSymbol(ticker string, market Market) string {
+	symbol := strings.ToUpper(ticker)
+	if market == MarketInversePerp && !strings.Contains(symbol, "_") {
+		symbol += "_PERP"
+	}
+	return symbol
+}
+
+// isBinanceCoinMSymbol reports whether a futures symbol is margined in its base
+// asset, which Binance lists for USD-quoted contracts only
+func isBinanceCoinMSymbol(symbol string) bool {
+	pair, _, _ := strings.Cut(symbol, "_")
+	_, quote, ok := splitTicker(pair)
+	return ok && quote == "USD"
+}
+
+// binanceKlineToCandle converts a Binance kline into a candle; Binance provides every candle field
+func binanceKlineToCandle(k *binance.Kline, interval Interval) (*pb.PricesResponse, error) {
+	// Convert string values to float64, keeping the exact strings alongside
+	parser := decimalParser{exchange: "binance"}
+	candle := newCandle(k.OpenTime, interval)
+	if k.CloseTime > 0 {
+		candle.CloseTime = k.CloseTime
+	}
+	candle.Open = parser.float("open", k.Open)
+	candle.High = parser.float("high", k.High)
+	candle.Low = parser.float("low", k.Low)
+	candle.Close = parser.float("close", k.Close)
+	candle.Volume = parser.float("volume", k.Volume)
+	candle.QuoteVolume = parser.float("quote volume", k.QuoteAssetVolume)
+	candle.TradeCount = k.TradeNum
+	candle.TakerBuyBaseVolume = parser.float("taker buy base volume", k.TakerBuyBaseAssetVolume)
+	candle.TakerBuyQuoteVolume = parser.float("taker buy quote volume", k.TakerBuyQuoteAssetVolume)
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (kline opening at %d)", parser.err, k.OpenTime)
+	}
+
+	candle.Decimals = &pb.DecimalValues{
+		Open:                k.Open,
+		High:                k.High,
+		Low:                 k.Low,
+		Close:               k.Close,
+		Volume:              k.Volume,
+		QuoteVolume:         k.QuoteAssetVolume,
+		TakerBuyBaseVolume:  k.TakerBuyBaseAssetVolume,
+		TakerBuyQuoteVolume: k.TakerBuyQuoteAssetVolume,
+	}
+
+	return candle, nil
+}
+
+// binanceCoinMUnavailableFields lists the candle fields COIN-M klines do not carry;
+// their volumes are counted in contracts rather than in the quote asset
+var binanceCoinMUnavailableFields = []pb.CandleField{
+	pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+}
+
+// binanceCoinMKlineToCandle converts a COIN-M kline into a candle. COIN-M klines
+// report volumes in contracts, with the base asset volumes in the fields that
+// hold quote asset volumes elsewhere.
+func binanceCoinMKlineToCandle(k *binance.Kline, interval Interval) (*pb.PricesResponse, error) {
+	// Convert string values to float64, keeping the exact strings alongside
+	parser := decimalParser{exchange: "binance"}
+	candle := newCandle(k.OpenTime, interval)
+	if k.CloseTime > 0 {
+		candle.CloseTime = k.CloseTime
+	}
+	candle.Open = parser.float("open", k.Open)
+	candle.High = parser.float("high", k.High)
+	candle.Low = parser.float("low", k.Low)
+	candle.Close = parser.float("close", k.Close)
+	candle.Volume = parser.float("base asset volume", k.QuoteAssetVolume)
+	candle.TradeCount = k.TradeNum
+	candle.TakerBuyBaseVolume = parser.float("taker buy base asset volume", k.TakerBuyQuoteAssetVolume)
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (kline opening at %d)", parser.err, k.OpenTime)
+	}
+
+	candle.UnavailableFields = binanceCoinMUnavailableFields
+	candle.Decimals = &pb.DecimalValues{
+		Open:               k.Open,
+		High:               k.High,
+		Low:                k.Low,
+		Close:              k.Close,
+		Volume:             k.QuoteAssetVolume,
+		TakerBuyBaseVolume: k.TakerBuyQuoteAssetVolume,
+	}
+
+	return candle, nil
+}
+
+// binancePriceSeriesKlineToCandle converts a mark, index or premium index kline,
+// whose volume fields are always zero, into a candle
+func binancePriceSeriesKlineToCandle(k *binance.Kline, interval Interval) (*pb.PricesResponse, error) {
+	candle, err := newPriceSeriesCandle("binance", k.OpenTime, interval, k.Open, k.High, k.Low, k.Close)
+	if err != nil {
+		return nil, err
+	}
+	if k.CloseTime > 0 {
+		candle.CloseTime = k.CloseTime
+	}
+	return candle, nil
+}
+
+// GetListingTime finds the open time of the first daily kline of a symbol
+func (a *BinanceAdapter) GetListingTime(ctx context.Context, query ListingQuery) (time.Time, error) {
+	return searchListingTime(ctx, a, query)
+}
+
+// SupportedMarkets returns the markets Binance serves klines for
+func (a *BinanceAdapter) SupportedMarkets() []Market {
+	return marketsOf(binanceMarkets)
+}
+
+// SupportedIntervals returns the kline intervals Binance serves
+func (a *BinanceAdapter) SupportedIntervals() []Interval {
+	return intervalsOf(binanceIntervals)
+}
+
+// MaxPageSize returns the largest number of spot klines Binance returns per request
+func (a *BinanceAdapter) MaxPageSize() int {
+	return binanceMaxPageSize
+}
+
+// binanceSymbolStatuses maps Binance symbol and contract statuses
+var binanceSymbolStatuses = map[string]SymbolStatus{
+	"TRADING":         SymbolStatusTrading,
+	"PRE_TRADING":     SymbolStatusPending,
+	"PENDING_TRADING": SymbolStatusPending,
+	"BREAK":           SymbolStatusHalted,
+	"HALT":            SymbolStatusHalted,
+	"END_OF_DAY":      SymbolStatusHalted,
+	"POST_TRADING":    SymbolStatusHalted,
+	"DELIVERING":      SymbolStatusHalted,
+	"SETTLING":        SymbolStatusHalted,
+	"DELIVERED":       SymbolStatusDelisted,
+	"CLOSE":           SymbolStatusDelisted,
+}
+
+// binanceExchangeInfo is the symbol list of the Binance exchange info endpoints.
+// Spot and USD-M symbols carry a status, COIN-M ones a contract status; only
+// futures carry contract types and onboard dates.
+type binanceExchangeInfo struct {
+	Symbols []struct {
+		Symbol         string `json:"symbol"`
+		Status         string `json:"status"`
+		ContractStatus string `json:"contractStatus"`
+		ContractType   string `json:"contractType"`
+		BaseAsset      string `json:"baseAsset"`
+		QuoteAsset     string `json:"quoteAsset"`
+		OnboardDate    int64  `json:"onboardDate"`
+		Filters        []struct {
+			FilterType string `json:"filterType"`
+			TickSize   string `json:"tickSize"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// GetSymbols lists the symbols of a market from the Binance exchange info
+// endpoints. Dated futures are listed by both futures APIs.
+func (a *BinanceAdapter) GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error) {
+	if _, err := mapMarket(a.GetName(), binanceMarkets, market); err != nil {
+		return nil, err
+	}
 
-	// Reverse the slice to get the correct chronological order
-	for i, j := 0, len(prices)-1; i < j; i, j = i+1, j-1 {
-		prices[i], prices[j] = prices[j], prices[i]
+	usdm := a.futuresClient.BaseURL + "/fapi/v1/exchangeInfo"
+	coinm := a.deliveryClient.BaseURL + "/dapi/v1/exchangeInfo"
+	switch market {
+	case MarketLinearPerp:
+		return a.exchangeInfoSymbols(ctx, usdm, true)
+	case MarketInversePerp:
+		return a.exchangeInfoSymbols(ctx, coinm, true)
+	case MarketDatedFuture:
+		linear, err := a.exchangeInfoSymbols(ctx, usdm, false)
+		if err != nil {
+			return nil, err
+		}
+		inverse, err := a.exchangeInfoSymbols(ctx, coinm, false)
+		if err != nil {
+			return nil, err
+		}
+		return append(linear, inverse...), nil
 	}
 
-	return prices, nil
+	var info binanceExchangeInfo
+	if err := getJSON(ctx, a.client.HTTPClient, a.client.BaseURL+"/api/v3/exchangeInfo", nil, &info); err != nil {
+		return nil, fmt.Errorf("error fetching symbols from Binance: %v", err)
+	}
+	return binanceSymbols(info, func(contractType string) bool { return true }), nil
+}
+
+// exchangeInfoSymbols lists the perpetual or the dated contracts of a Binance
+// futures exchange info endpoint
+func (a *BinanceAdapter) exchangeInfoSymbols(ctx context.Context, endpoint string, perpetual bool) ([]SymbolInfo, error) {
+	var info binanceExchangeInfo
+	if err := getJSON(ctx, a.futuresClient.HTTPClient, endpoint, nil, &info); err != nil {
+		return nil, fmt.Errorf("error fetching symbols from Binance: %v", err)
+	}
+	return binanceSymbols(info, func(contractType string) bool {
+		return (contractType == "PERPETUAL") == perpetual
+	}), nil
+}
+
+// binanceSymbols converts the symbols of an exchange info response whose contract
+// type is kept
+func binanceSymbols(info binanceExchangeInfo, keep func(contractType string) bool) []SymbolInfo {
+	symbols := make([]SymbolInfo, 0, len(info.Symbols))
+	for _, s := range info.Symbols {
+		if !keep(s.ContractType) {
+			continue
+		}
+
+		status := s.Status
+		if status == "" {
+			status = s.ContractStatus
+		}
+		symbol := SymbolInfo{
+			Symbol:      s.Symbol,
+			Base:        s.BaseAsset,
+			Quote:       s.QuoteAsset,
+			Status:      mapSymbolStatus(binanceSymbolStatuses, status),
+			ListingTime: unixMilliOrZero(s.OnboardDate),
+		}
+		for _, filter := range s.Filters {
+			if filter.FilterType == "PRICE_FILTER" {
+				symbol.TickSize = filter.TickSize
+			}
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// binanceKlineEvent is a kline update of the Binance websocket streams; the kline
+// is final once it closed
+type binanceKlineEvent struct {
+	Event string `json:"e"`
+	Kline struct {
+		OpenTime            int64  `json:"t"`
+		CloseTime           int64  `json:"T"`
+		Open                string `json:"o"`
+		High                string `json:"h"`
+		Low                 string `json:"l"`
+		Close               string `json:"c"`
+		Volume              string `json:"v"`
+		QuoteVolume         string `json:"q"`
+		TradeCount          int64  `json:"n"`
+		TakerBuyBaseVolume  string `json:"V"`
+		TakerBuyQuoteVolume string `json:"Q"`
+		Final               bool   `json:"x"`
+	} `json:"k"`
+}
+
+// StreamCandles pushes the live klines of a symbol from the Binance websocket
+// streams. Only last traded prices are streamed.
+func (a *BinanceAdapter) StreamCandles(ctx context.Context, query StreamQuery, handle CandleUpdateHandler) error {
+	binanceInterval, err := mapInterval(a.GetName(), binanceIntervals, query.Interval)
+	if err != nil {
+		return err
+	}
+	api, err := mapMarket(a.GetName(), binanceMarkets, query.Market)
+	if err != nil {
+		return err
+	}
+
+	symbol := binanceSymbol(query.Ticker, query.Market)
+	if query.Market == MarketDatedFuture && isBinanceCoinMSymbol(symbol) {
+		api = binanceCoinM
+	}
+	convert := binanceKlineToCandle
+	if api == binanceCoinM {
+		convert = binanceCoinMKlineToCandle
+	}
+	stream := strings.ToLower(symbol) + "@kline_" + binanceInterval
+
+	return streamCandles(ctx, a, query, func(ctx context.Context, handle CandleUpdateHandler) error {
+		conn, err := dialStream(ctx, a.streamURLs[api])
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		subscribe := map[string]interface{}{"method": "SUBSCRIBE", "params": []string{stream}, "id": 1}
+		if err := conn.WriteJSON(subscribe); err != nil {
+			return err
+		}
+
+		return readStream(ctx, conn, func(message []byte) error {
+			var event binanceKlineEvent
+			if err := json.Unmarshal(message, &event); err != nil {
+				return fmt.Errorf("error decoding Binance kline event: %v", err)
+			}
+			// Subscription results carry no event
+			if event.Event != "kline" {
+				return nil
+			}
+
+			k := event.Kline
+			candle, err := convert(&binance.Kline{
+				OpenTime:                 k.OpenTime,
+				CloseTime:                k.CloseTime,
+				Open:                     k.Open,
+				High:                     k.High,
+				Low:                      k.Low,
+				Close:                    k.Close,
+				Volume:                   k.Volume,
+				QuoteAssetVolume:         k.QuoteVolume,
+				TradeNum:                 k.TradeCount,
+				TakerBuyBaseAssetVolume:  k.TakerBuyBaseVolume,
+				TakerBuyQuoteAssetVolume: k.TakerBuyQuoteVolume,
+			}, query.Interval)
+			if err != nil {
+				return err
+			}
+			return handle(CandleUpdate{Candle: candle, Closed: k.Final})
+		})
+	}, handle)
 }
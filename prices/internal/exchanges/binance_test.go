@@ -2,7 +2,12 @@ package exchanges
 
 import (
 	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -88,6 +93,84 @@ func TestBinanceAdapter_ProcessKlineData(t *testing.T) {
 	assert.Equal(t, 2.0, prices[1].Volume)
 }
 
+// TestBinanceKlineToCandle tests that every Binance kline field reaches the candle
+func TestBinanceKlineToCandle(t *testing.T) {
+	kline := &binance.Kline{
+		OpenTime:                 1672531200000, // 2023-01-01
+		Open:                     "10000.0",
+		High:                     "10100.0",
+		Low:                      "9900.0",
+		Close:                    "10050.0",
+		Volume:                   "1.5",
+		CloseTime:                1672617599999,
+		QuoteAssetVolume:         "15075.0",
+		TradeNum:                 42,
+		TakerBuyBaseAssetVolume:  "0.5",
+		TakerBuyQuoteAssetVolume: "5025.0",
+	}
+
+	candle, err := binanceKlineToCandle(kline, Interval1d)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2023-01-01", candle.Date)
+	assert.Equal(t, int64(1672531200000), candle.OpenTime)
+	assert.Equal(t, int64(1672617599999), candle.CloseTime)
+	assert.Equal(t, 10050.0, candle.Close)
+	assert.Equal(t, 15075.0, candle.QuoteVolume)
+	assert.Equal(t, int64(42), candle.TradeCount)
+	assert.Equal(t, 0.5, candle.TakerBuyBaseVolume)
+	assert.Equal(t, 5025.0, candle.TakerBuyQuoteVolume)
+	assert.Empty(t, candle.UnavailableFields)
+	assert.Equal(t, uint32(CandleSchemaVersion), candle.SchemaVersion)
+	assert.Equal(t, "15075.0", candle.Decimals.QuoteVolume)
+}
+
+// TestBinanceKlineToCandle_Decimals tests that the exact decimal strings are kept
+func TestBinanceKlineToCandle_Decimals(t *testing.T) {
+	kline := &binance.Kline{
+		OpenTime:                 1672531200000,
+		Open:                     "0.00000123",
+		High:                     "0.00000127",
+		Low:                      "0.00000121",
+		Close:                    "0.00000125",
+		Volume:                   "98765432109876.12345678",
+		QuoteAssetVolume:         "121234567.00000001",
+		TakerBuyBaseAssetVolume:  "0",
+		TakerBuyQuoteAssetVolume: "0",
+	}
+
+	candle, err := binanceKlineToCandle(kline, Interval1d)
+	require.NoError(t, err)
+
+	assert.Equal(t, "0.00000123", candle.Decimals.Open)
+	assert.Equal(t, "0.00000127", candle.Decimals.High)
+	assert.Equal(t, "0.00000121", candle.Decimals.Low)
+	assert.Equal(t, "0.00000125", candle.Decimals.Close)
+	assert.Equal(t, "98765432109876.12345678", candle.Decimals.Volume)
+	assert.Equal(t, "121234567.00000001", candle.Decimals.QuoteVolume)
+	assert.Equal(t, 0.00000125, candle.Close)
+}
+
+// TestBinanceKlineToCandle_ParseError tests that unparsable values are reported instead of zeroed
+func TestBinanceKlineToCandle_ParseError(t *testing.T) {
+	kline := &binance.Kline{
+		OpenTime:                 1672531200000,
+		Open:                     "10000.0",
+		High:                     "10100.0",
+		Low:                      "",
+		Close:                    "10050.0",
+		Volume:                   "1.5",
+		QuoteAssetVolume:         "15075.0",
+		TakerBuyBaseAssetVolume:  "0.5",
+		TakerBuyQuoteAssetVolume: "5025.0",
+	}
+
+	candle, err := binanceKlineToCandle(kline, Interval1d)
+	assert.Nil(t, candle)
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+	assert.Contains(t, err.Error(), "low")
+}
+
 // TestBinanceAdapter_GetHistoricalPrices_ErrorHandling tests error handling in GetHistoricalPrices
 func TestBinanceAdapter_GetHistoricalPrices_ErrorHandling(t *testing.T) {
 	// Test handling of invalid float values
@@ -116,7 +199,7 @@ func TestBinanceAdapter_GetHistoricalPrices(t *testing.T) {
 
 	// Call the method with a valid ticker and limit
 	ctx := context.Background()
-	prices, err := adapter.GetHistoricalPrices(ctx, "BTCUSDT", 10)
+	prices, err := CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1d, Limit: 10})
 
 	// If the API call succeeds, verify the results
 	if err == nil {
@@ -137,11 +220,11 @@ func TestBinanceAdapter_GetHistoricalPrices(t *testing.T) {
 	}
 
 	// Test with invalid ticker
-	_, err = adapter.GetHistoricalPrices(ctx, "INVALID_TICKER_12345", 5)
+	_, err = CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "INVALID_TICKER_12345", Interval: Interval1d, Limit: 5})
 	assert.Error(t, err)
 
 	// Test with default limit (0)
-	prices, err = adapter.GetHistoricalPrices(ctx, "BTCUSDT", 0)
+	prices, err = CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1d, Limit: 0})
 	if err == nil {
 		require.NotNil(t, prices)
 		assert.LessOrEqual(t, len(prices), 100) // Default limit is 100
@@ -150,8 +233,624 @@ func TestBinanceAdapter_GetHistoricalPrices(t *testing.T) {
 	}
 }
 
+// TestBinanceAdapter_IntervalMapping tests that every API interval maps to a Binance interval
+func TestBinanceAdapter_IntervalMapping(t *testing.T) {
+	for interval := range supportedIntervals {
+		value, err := mapInterval("binance", binanceIntervals, interval)
+		assert.NoError(t, err, "interval %s should be supported", interval)
+		assert.NotEmpty(t, value)
+	}
+
+	// Unknown intervals are rejected before any request is made
+	adapter := NewBinanceAdapter()
+	_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval("3m"), Limit: 10})
+	assert.ErrorIs(t, err, ErrUnsupportedInterval)
+}
+
+// newBinanceStandIn starts a local stand-in for the Binance klines endpoint serving
+// daily candles from start
+func newBinanceStandIn(t *testing.T, start time.Time, count int) (*httptest.Server, *int) {
+	return newBinanceStandInAt(t, "/api/v3/klines", start, count)
+}
+
+// newBinanceStandInAt starts a local stand-in for a Binance klines endpoint at path
+func newBinanceStandInAt(t *testing.T, path string, start time.Time, count int) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, path, r.URL.Path)
+		require.Equal(t, "1d", r.URL.Query().Get("interval"))
+
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		startTime, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		endTime, err := strconv.ParseInt(query.Get("endTime"), 10, 64)
+		if err != nil {
+			endTime = math.MaxInt64
+		}
+
+		// COIN-M rejects ranges longer than 200 days
+		if strings.HasPrefix(path, "/dapi") && query.Has("startTime") {
+			require.LessOrEqual(t, endTime-startTime, (200 * 24 * time.Hour).Milliseconds())
+		}
+
+		var rows [][]interface{}
+		for i := 0; i < count; i++ {
+			openTime := start.AddDate(0, 0, i).UnixMilli()
+			if openTime < startTime || openTime > endTime {
+				continue
+			}
+			price := strconv.Itoa(10000 + i)
+			rows = append(rows, []interface{}{
+				openTime, price, price, price, price, "1.5",
+				openTime + 86399999, "15000", 100, "0.7", "7000", "0",
+			})
+		}
+
+		// Binance returns the oldest klines from startTime, otherwise the latest ones
+		if len(rows) > limit {
+			if query.Has("startTime") {
+				rows = rows[:limit]
+			} else {
+				rows = rows[len(rows)-limit:]
+			}
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// TestBinanceAdapter_GetHistoricalPricesRange tests paging a range longer than one page
+func TestBinanceAdapter_GetHistoricalPricesRange(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, calls := newBinanceStandIn(t, start, 2500)
+
+	adapter := NewBinanceAdapter()
+	adapter.client.BaseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:    "BTCUSDT",
+		Interval:  Interval1d,
+		StartTime: start,
+		EndTime:   start.AddDate(0, 0, 2199),
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 2200)
+	assert.Equal(t, 3, *calls)
+
+	// Candles are stitched in chronological order without duplicates
+	for i, price := range prices {
+		assert.Equal(t, start.AddDate(0, 0, i).UnixMilli(), price.OpenTime)
+		assert.Equal(t, float64(10000+i), price.Close)
+	}
+}
+
+// TestBinanceAdapter_GetHistoricalPricesLatest tests fetching the latest candles by limit
+func TestBinanceAdapter_GetHistoricalPricesLatest(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, calls := newBinanceStandIn(t, start, 2500)
+
+	adapter := NewBinanceAdapter()
+	adapter.client.BaseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "BTCUSDT",
+		Interval: Interval1d,
+		Limit:    1500,
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 1500)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, start.AddDate(0, 0, 1000).UnixMilli(), prices[0].OpenTime)
+	assert.Equal(t, start.AddDate(0, 0, 2499).UnixMilli(), prices[1499].OpenTime)
+}
+
+// TestBinanceAdapter_Markets tests that derivatives markets are served by the futures APIs
+func TestBinanceAdapter_Markets(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("linear perpetual", func(t *testing.T) {
+		server, calls := newBinanceStandInAt(t, "/fapi/v1/klines", start, 2500)
+
+		adapter := NewBinanceAdapter()
+		adapter.futuresClient.BaseURL = server.URL
+
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+			Ticker:    "BTCUSDT",
+			Market:    MarketLinearPerp,
+			Interval:  Interval1d,
+			StartTime: start,
+			EndTime:   start.AddDate(0, 0, 1999),
+		})
+		require.NoError(t, err)
+		require.Len(t, prices, 2000)
+		assert.Equal(t, 2, *calls)
+		assert.Equal(t, 1.5, prices[0].Volume)
+		assert.Equal(t, 15000.0, prices[0].QuoteVolume)
+	})
+
+	t.Run("inverse perpetual", func(t *testing.T) {
+		server, calls := newBinanceStandInAt(t, "/dapi/v1/klines", start, 2500)
+
+		adapter := NewBinanceAdapter()
+		adapter.deliveryClient.BaseURL = server.URL
+
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+			Ticker:    "BTCUSD",
+			Market:    MarketInversePerp,
+			Interval:  Interval1d,
+			StartTime: start,
+			EndTime:   start.AddDate(0, 0, 499),
+		})
+		require.NoError(t, err)
+		require.Len(t, prices, 500)
+		assert.Equal(t, 3, *calls)
+
+		// COIN-M volumes are reported in the base asset, quote volumes are unknown
+		assert.Equal(t, 15000.0, prices[0].Volume)
+		assert.Equal(t, 7000.0, prices[0].TakerBuyBaseVolume)
+		assert.Zero(t, prices[0].QuoteVolume)
+		assert.Contains(t, prices[0].UnavailableFields, pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME)
+	})
+
+	t.Run("COIN-M dated future", func(t *testing.T) {
+		server, calls := newBinanceStandInAt(t, "/dapi/v1/klines", start, 100)
+
+		adapter := NewBinanceAdapter()
+		adapter.deliveryClient.BaseURL = server.URL
+
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+			Ticker:   "BTCUSD_250328",
+			Market:   MarketDatedFuture,
+			Interval: Interval1d,
+			EndTime:  start.AddDate(0, 0, 99),
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		assert.Len(t, prices, 10)
+		assert.Equal(t, 1, *calls)
+	})
+}
+
+// TestBinanceAdapter_PriceTypes tests mark, index and premium index klines
+func TestBinanceAdapter_PriceTypes(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for priceType, path := range map[PriceType]string{
+		PriceTypeMark:         "/fapi/v1/markPriceKlines",
+		PriceTypeIndex:        "/fapi/v1/indexPriceKlines",
+		PriceTypePremiumIndex: "/fapi/v1/premiumIndexKlines",
+	} {
+		server, calls := newBinanceStandInAt(t, path, start, 100)
+
+		adapter := NewBinanceAdapter()
+		adapter.futuresClient.BaseURL = server.URL
+
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+			Ticker:    "BTCUSDT",
+			Market:    MarketLinearPerp,
+			Interval:  Interval1d,
+			PriceType: priceType,
+			EndTime:   start.AddDate(0, 0, 99),
+			Limit:     5,
+		})
+		require.NoError(t, err, priceType)
+		require.Len(t, prices, 5)
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, float64(10095), prices[0].Close)
+		assert.Zero(t, prices[0].Volume)
+		assert.Contains(t, prices[0].UnavailableFields, pb.CandleField_CANDLE_FIELD_VOLUME)
+	}
+
+	// Spot and COIN-M markets have no mark price klines
+	adapter := NewBinanceAdapter()
+	for _, market := range []Market{MarketSpot, MarketInversePerp} {
+		_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+			Ticker:    "BTCUSD",
+			Market:    market,
+			Interval:  Interval1d,
+			PriceType: PriceTypeMark,
+		})
+		assert.ErrorIs(t, err, ErrUnsupportedPriceType)
+	}
+}
+
+// newBinanceFundingStandIn starts a local stand-in for a Binance funding rate
+// endpoint at path, serving count settlements every 8 hours from start
+func newBinanceFundingStandIn(t *testing.T, path string, start time.Time, count int, markPrices bool) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, path, r.URL.Path)
+
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		startTime, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		endTime, err := strconv.ParseInt(query.Get("endTime"), 10, 64)
+		if err != nil {
+			endTime = math.MaxInt64
+		}
+
+		rows := []map[string]interface{}{}
+		for i := 0; i < count; i++ {
+			fundingTime := start.Add(time.Duration(i) * 8 * time.Hour).UnixMilli()
+			if fundingTime < startTime || fundingTime > endTime {
+				continue
+			}
+			row := map[string]interface{}{
+				"symbol":      query.Get("symbol"),
+				"fundingTime": fundingTime,
+				"fundingRate": "0.00010000",
+			}
+			if markPrices {
+				row["markPrice"] = strconv.Itoa(16000 + i)
+			}
+			rows = append(rows, row)
+		}
+
+		// Binance returns the oldest settlements from startTime, otherwise the latest ones
+		if len(rows) > limit {
+			if query.Has("startTime") {
+				rows = rows[:limit]
+			} else {
+				rows = rows[len(rows)-limit:]
+			}
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// TestBinanceAdapter_GetFundingRates tests paging funding rates of USD-M and COIN-M perpetuals
+func TestBinanceAdapter_GetFundingRates(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	server, calls := newBinanceFundingStandIn(t, "/fapi/v1/fundingRate", start, 1500, true)
+	adapter := NewBinanceAdapter()
+	adapter.futuresClient.BaseURL = server.URL
+
+	rates, err := CollectFundingRates(context.Background(), adapter, FundingRateQuery{
+		Ticker:    "BTCUSDT",
+		StartTime: start,
+		EndTime:   start.Add(1499 * 8 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, rates, 1500)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, &pb.FundingRate{FundingTime: start.UnixMilli(), Rate: 0.0001, MarkPrice: 16000, HasMarkPrice: true}, rates[0])
+	assert.Equal(t, start.Add(1499*8*time.Hour).UnixMilli(), rates[1499].FundingTime)
+
+	// COIN-M settlements carry no mark price
+	server, _ = newBinanceFundingStandIn(t, "/dapi/v1/fundingRate", start, 50, false)
+	adapter.deliveryClient.BaseURL = server.URL
+
+	rates, err = CollectFundingRates(context.Background(), adapter, FundingRateQuery{
+		Ticker:  "BTCUSD",
+		Market:  MarketInversePerp,
+		EndTime: start.Add(49 * 8 * time.Hour),
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rates, 10)
+	assert.Equal(t, start.Add(40*8*time.Hour).UnixMilli(), rates[0].FundingTime)
+	assert.False(t, rates[0].HasMarkPrice)
+
+	// Spot markets have no funding
+	_, err = CollectFundingRates(context.Background(), adapter, FundingRateQuery{Ticker: "BTCUSDT", Market: MarketSpot})
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
+
+// TestBinanceAdapter_GetDerivativesStats tests paging open interest and long/short
+// ratio samples in period-sized windows
+func TestBinanceAdapter_GetDerivativesStats(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	const count = 700
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		query := r.URL.Query()
+		require.Equal(t, "BTCUSDT", query.Get("symbol"))
+		require.Equal(t, "5m", query.Get("period"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		startTime, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		endTime, _ := strconv.ParseInt(query.Get("endTime"), 10, 64)
+
+		// Binance returns the newest samples of the range
+		rows := []map[string]interface{}{}
+		for i := count - 1; i >= 0 && len(rows) < limit; i-- {
+			timestamp := start.Add(time.Duration(i) * 5 * time.Minute).UnixMilli()
+			if timestamp < startTime || timestamp > endTime {
+				continue
+			}
+			rows = append([]map[string]interface{}{{
+				"symbol":               "BTCUSDT",
+				"sumOpenInterest":      strconv.Itoa(80000 + i),
+				"sumOpenInterestValue": "1350000000.5",
+				"longShortRatio":       "1.5000",
+				"longAccount":          "0.6000",
+				"shortAccount":         "0.4000",
+				"timestamp":            timestamp,
+			}}, rows...)
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	defer server.Close()
+
+	adapter := NewBinanceAdapter()
+	adapter.futuresClient.BaseURL = server.URL
+
+	stats, err := CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+		Ticker:    "BTCUSDT",
+		Stat:      StatOpenInterest,
+		Period:    StatPeriod5m,
+		StartTime: start,
+		EndTime:   start.Add((count - 1) * 5 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, stats, count)
+	assert.Equal(t, &pb.DerivativesStat{Timestamp: start.UnixMilli(), OpenInterest: 80000, OpenInterestValue: 1350000000.5, HasOpenInterestValue: true}, stats[0])
+	assert.Equal(t, float64(80000+count-1), stats[count-1].OpenInterest)
+	assert.Equal(t, []string{"/futures/data/openInterestHist", "/futures/data/openInterestHist"}, paths)
+
+	for stat, path := range map[DerivativesStat]string{
+		StatTopTraderLongShortRatio: "/futures/data/topLongShortAccountRatio",
+		StatGlobalLongShortRatio:    "/futures/data/globalLongShortAccountRatio",
+	} {
+		paths = nil
+		stats, err := CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+			Ticker:  "BTCUSDT",
+			Stat:    stat,
+			Period:  StatPeriod5m,
+			EndTime: start.Add((count - 1) * 5 * time.Minute),
+			Limit:   10,
+		})
+		require.NoError(t, err)
+		require.Len(t, stats, 10)
+		assert.Equal(t, []string{path}, paths)
+		assert.Equal(t, start.Add((count-10)*5*time.Minute).UnixMilli(), stats[0].Timestamp)
+		assert.Equal(t, 1.5, stats[0].LongShortRatio)
+		assert.Equal(t, 0.6, stats[0].LongAccount)
+	}
+
+	// Binance publishes statistics for USD-M contracts only
+	_, err = CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+		Ticker: "BTCUSD", Market: MarketInversePerp, Stat: StatOpenInterest, Period: StatPeriod5m,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
+
+// newBinanceAggTradesStandIn serves count aggregated trades two minutes apart from
+// first, searched by time within an hour or by id like the Binance APIs
+func newBinanceAggTradesStandIn(t *testing.T, first time.Time, count int) (*httptest.Server, *[]string) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		fromID, startTime, endTime := int64(0), int64(0), int64(math.MaxInt64)
+		if query.Has("fromId") {
+			fromID, _ = strconv.ParseInt(query.Get("fromId"), 10, 64)
+		} else {
+			startTime, _ = strconv.ParseInt(query.Get("startTime"), 10, 64)
+			endTime, _ = strconv.ParseInt(query.Get("endTime"), 10, 64)
+			require.Less(t, endTime-startTime, time.Hour.Milliseconds())
+		}
+
+		rows := []map[string]interface{}{}
+		for id := int64(0); id < int64(count) && len(rows) < limit; id++ {
+			timestamp := first.Add(time.Duration(id) * 2 * time.Minute).UnixMilli()
+			if id < fromID || timestamp < startTime || timestamp > endTime {
+				continue
+			}
+			rows = append(rows, map[string]interface{}{
+				"a": id,
+				"p": "16500.10",
+				"q": "0.250",
+				"f": id * 3,
+				"l": id*3 + 2,
+				"T": timestamp,
+				"m": id%2 == 1,
+			})
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+// TestBinanceAdapter_GetTrades tests scanning for the first trade of a window and
+// paging through the rest by id
+func TestBinanceAdapter_GetTrades(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := start.Add(150 * time.Minute)
+
+	server, paths := newBinanceAggTradesStandIn(t, first, 2500)
+	adapter := NewBinanceAdapter()
+	adapter.client.BaseURL = server.URL
+	adapter.futuresClient.BaseURL = server.URL
+	adapter.deliveryClient.BaseURL = server.URL
+
+	var pages int
+	var trades []*pb.Trade
+	err := adapter.GetTrades(context.Background(), TradeQuery{
+		Ticker:    "BTCUSDT",
+		StartTime: start,
+		EndTime:   first.Add(2199 * 2 * time.Minute),
+	}, func(page []*pb.Trade) error {
+		pages++
+		trades = append(trades, page...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, trades, 2200)
+	assert.Equal(t, &pb.Trade{TradeId: "0", Timestamp: first.UnixMilli(), Price: 16500.1, Quantity: 0.25, Side: TradeSideBuy, TradeCount: 3}, trades[0])
+	assert.Equal(t, TradeSideSell, trades[1].Side)
+	assert.Equal(t, "2199", trades[2199].TradeId)
+	// Two empty hours, the hour holding the first trades, then pages by id
+	assert.Equal(t, []string{"/api/v3/aggTrades", "/api/v3/aggTrades", "/api/v3/aggTrades", "/api/v3/aggTrades", "/api/v3/aggTrades", "/api/v3/aggTrades"}, *paths)
+	assert.Equal(t, 4, pages)
+
+	for market, path := range map[Market]string{
+		MarketLinearPerp:  "/fapi/v1/aggTrades",
+		MarketInversePerp: "/dapi/v1/aggTrades",
+	} {
+		*paths = nil
+		trades, err := CollectTrades(context.Background(), adapter, TradeQuery{
+			Ticker:    "BTCUSD",
+			Market:    market,
+			StartTime: first,
+			Limit:     1500,
+		})
+		require.NoError(t, err)
+		require.Len(t, trades, 1500)
+		assert.Equal(t, "1499", trades[1499].TradeId)
+		assert.Equal(t, []string{path, path, path}, *paths)
+	}
+
+	// A window without trades ends once every hour of it is scanned
+	*paths = nil
+	trades, err = CollectTrades(context.Background(), adapter, TradeQuery{
+		Ticker:    "BTCUSDT",
+		StartTime: start,
+		EndTime:   first.Add(-time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, trades)
+	assert.Len(t, *paths, 3)
+
+	_, err = CollectTrades(context.Background(), adapter, TradeQuery{Ticker: "BTCUSDT"})
+	assert.ErrorIs(t, err, ErrTradesStartRequired)
+}
+
+// TestBinanceAdapter_GetOrderBook tests requesting the smallest Binance depth
+// covering a query and trimming it to the query depth
+func TestBinanceAdapter_GetOrderBook(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		bids, asks := [][]string{}, [][]string{}
+		for i := 0; i < limit; i++ {
+			bids = append(bids, []string{strconv.Itoa(16500 - i), "1.5"})
+			asks = append(asks, []string{strconv.Itoa(16501 + i), "0.5"})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lastUpdateId": 1027024,
+			"E":            1672531200123,
+			"T":            1672531200120,
+			"bids":         bids,
+			"asks":         asks,
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBinanceAdapter()
+	adapter.client.BaseURL = server.URL
+	adapter.futuresClient.BaseURL = server.URL
+	adapter.deliveryClient.BaseURL = server.URL
+
+	book, err := adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "btcusdt", Depth: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1027024), book.UpdateId)
+	assert.Zero(t, book.ExchangeTime)
+	assert.Equal(t, []*pb.OrderBookLevel{{Price: 16500, Quantity: 1.5}, {Price: 16499, Quantity: 1.5}, {Price: 16498, Quantity: 1.5}}, book.Bids)
+	assert.Equal(t, &pb.OrderBookLevel{Price: 16501, Quantity: 0.5}, book.Asks[0])
+	assert.Len(t, book.Asks, 3)
+
+	book, err = adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "BTCUSDT", Market: MarketLinearPerp, Depth: 60})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1672531200123), book.ExchangeTime)
+	assert.Len(t, book.Bids, 60)
+
+	book, err = adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "BTCUSD", Market: MarketInversePerp})
+	require.NoError(t, err)
+	assert.Len(t, book.Bids, DefaultOrderBookDepth)
+	assert.Equal(t, []string{
+		"/api/v3/depth?limit=5&symbol=BTCUSDT",
+		"/fapi/v1/depth?limit=100&symbol=BTCUSDT",
+		"/dapi/v1/depth?limit=20&symbol=BTCUSD_PERP",
+	}, requests)
+
+	_, err = adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "BTCUSDT", Depth: MaxOrderBookDepth + 1})
+	assert.ErrorIs(t, err, ErrUnsupportedOrderBookDepth)
+}
+
+// TestBinanceSymbol tests conversion of tickers into Binance symbols per market
+func TestBinanceSymbol(t *testing.T) {
+	assert.Equal(t, "BTCUSDT", binanceSymbol("btcusdt", MarketSpot))
+	assert.Equal(t, "BTCUSDT", binanceSymbol("BTCUSDT", MarketLinearPerp))
+	assert.Equal(t, "BTCUSD_PERP", binanceSymbol("BTCUSD", MarketInversePerp))
+	assert.Equal(t, "BTCUSD_PERP", binanceSymbol("BTCUSD_PERP", MarketInversePerp))
+
+	assert.True(t, isBinanceCoinMSymbol("BTCUSD_250328"))
+	assert.False(t, isBinanceCoinMSymbol("BTCUSDT_250328"))
+}
+
 // TestBinanceAdapter_Integration tests the real implementation
 // It's skipped by default to avoid network dependencies during unit testing
 func TestBinanceAdapter_Integration(t *testing.T) {
 	t.Skip("Skipping integration test - requires network access")
 }
+
+// TestBinanceAdapter_GetSymbols tests listing symbols from the exchange info endpoints
+func TestBinanceAdapter_GetSymbols(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priceFilter := []map[string]string{{"filterType": "LOT_SIZE"}, {"filterType": "PRICE_FILTER", "tickSize": "0.10"}}
+		switch r.URL.Path {
+		case "/api/v3/exchangeInfo":
+			json.NewEncoder(w).Encode(map[string]interface{}{"symbols": []map[string]interface{}{
+				{"symbol": "BTCUSDT", "status": "TRADING", "baseAsset": "BTC", "quoteAsset": "USDT", "filters": priceFilter},
+				{"symbol": "LUNAUSDT", "status": "BREAK", "baseAsset": "LUNA", "quoteAsset": "USDT"},
+			}})
+		case "/fapi/v1/exchangeInfo":
+			json.NewEncoder(w).Encode(map[string]interface{}{"symbols": []map[string]interface{}{
+				{"symbol": "BTCUSDT", "status": "TRADING", "contractType": "PERPETUAL", "baseAsset": "BTC", "quoteAsset": "USDT", "onboardDate": 1569398400000, "filters": priceFilter},
+				{"symbol": "BTCUSDT_250328", "status": "TRADING", "contractType": "CURRENT_QUARTER", "baseAsset": "BTC", "quoteAsset": "USDT"},
+			}})
+		case "/dapi/v1/exchangeInfo":
+			json.NewEncoder(w).Encode(map[string]interface{}{"symbols": []map[string]interface{}{
+				{"symbol": "BTCUSD_PERP", "contractStatus": "TRADING", "contractType": "PERPETUAL", "baseAsset": "BTC", "quoteAsset": "USD"},
+				{"symbol": "BTCUSD_240628", "contractStatus": "DELIVERED", "contractType": "CURRENT_QUARTER", "baseAsset": "BTC", "quoteAsset": "USD"},
+			}})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	adapter := NewBinanceAdapter()
+	adapter.client.BaseURL = server.URL
+	adapter.futuresClient.BaseURL = server.URL
+	adapter.deliveryClient.BaseURL = server.URL
+
+	symbols, err := adapter.GetSymbols(context.Background(), MarketSpot)
+	require.NoError(t, err)
+	assert.Equal(t, []SymbolInfo{
+		{Symbol: "BTCUSDT", Base: "BTC", Quote: "USDT", Status: SymbolStatusTrading, TickSize: "0.10"},
+		{Symbol: "LUNAUSDT", Base: "LUNA", Quote: "USDT", Status: SymbolStatusHalted},
+	}, symbols)
+
+	symbols, err = adapter.GetSymbols(context.Background(), MarketLinearPerp)
+	require.NoError(t, err)
+	assert.Equal(t, []SymbolInfo{{
+		Symbol: "BTCUSDT", Base: "BTC", Quote: "USDT", Status: SymbolStatusTrading, TickSize: "0.10",
+		ListingTime: time.Date(2019, 9, 25, 8, 0, 0, 0, time.UTC),
+	}}, symbols)
+
+	symbols, err = adapter.GetSymbols(context.Background(), MarketInversePerp)
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+	assert.Equal(t, "BTCUSD_PERP", symbols[0].Symbol)
+
+	// Dated futures are listed by both futures APIs
+	symbols, err = adapter.GetSymbols(context.Background(), MarketDatedFuture)
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+	assert.Equal(t, "BTCUSDT_250328", symbols[0].Symbol)
+	assert.Equal(t, SymbolStatusDelisted, symbols[1].Status)
+}
@@ -2,25 +2,74 @@ package exchanges
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hirokisan/bybit/v2"
 	pb "github.com/timakaa/historical-common/proto"
 )
 
+// bybitIntervals maps API intervals to Bybit kline intervals
+var bybitIntervals = map[Interval]string{
+	Interval1m:  "1",
+	Interval5m:  "5",
+	Interval15m: "15",
+	Interval1h:  "60",
+	Interval4h:  "240",
+	Interval1d:  "D",
+	Interval1w:  "W",
+	Interval1M:  "M",
+}
+
+// bybitMaxPageSize is the largest number of klines Bybit returns per request
+const bybitMaxPageSize = 1000
+
+// bybitCategories maps markets to Bybit product categories. Dated futures are
+// inverse unless their symbol is quoted in USDT or USDC.
+var bybitCategories = map[Market]string{
+	MarketSpot:        string(bybit.CategoryV5Spot),
+	MarketLinearPerp:  string(bybit.CategoryV5Linear),
+	MarketInversePerp: string(bybit.CategoryV5Inverse),
+	MarketDatedFuture: string(bybit.CategoryV5Inverse),
+}
+
+// bybitBaseURL is the Bybit REST API address, for endpoints the client library does not cover
+const bybitBaseURL = "https://api.bybit.com"
+
+// bybitStreamURL is the address of the Bybit public websocket streams, one per category
+const bybitStreamURL = "wss://stream.bybit.com/v5/public"
+
 // BybitAdapter implements the adapter for Bybit exchange
 type BybitAdapter struct {
 	client *bybit.Client
+
+	// httpClient and baseURL serve endpoints the client library does not cover
+	httpClient *http.Client
+	baseURL    string
+
+	// archiveURL serves the public trade archives
+	archiveURL string
+
+	// streamURL is the address of the public websocket streams, for live candles
+	streamURL string
 }
 
 // NewBybitAdapter creates a new adapter for Bybit
 func NewBybitAdapter() *BybitAdapter {
 	client := bybit.NewClient()
 	return &BybitAdapter{
-		client: client,
+		client:     client,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    bybitBaseURL,
+		archiveURL: bybitArchiveURL,
+		streamURL:  bybitStreamURL,
 	}
 }
 
@@ -29,56 +78,1035 @@ func (a *BybitAdapter) GetName() string {
 	return "bybit"
 }
 
+// SupportedPriceTypes returns the price types Bybit serves; mark and index prices
+// are available for derivatives, premium indexes for linear contracts only
+func (a *BybitAdapter) SupportedPriceTypes() []PriceType {
+	return []PriceType{PriceTypeLast, PriceTypeMark, PriceTypeIndex, PriceTypePremiumIndex}
+}
+
 // GetHistoricalPrices retrieves historical price data from Bybit
-func (a *BybitAdapter) GetHistoricalPrices(ctx context.Context, ticker string, limit int64) ([]*pb.PricesResponse, error) {
-	log.Printf("Getting historical prices from Bybit for %s", ticker)
+func (a *BybitAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	log.Printf("Getting historical prices from Bybit for %s (%s, %s)", query.Ticker, query.Market, query.Interval)
+
+	bybitInterval, err := mapInterval(a.GetName(), bybitIntervals, query.Interval)
+	if err != nil {
+		return err
+	}
+	category, err := mapMarket(a.GetName(), bybitCategories, query.Market)
+	if err != nil {
+		return err
+	}
+
+	symbol := strings.ToUpper(query.Ticker)
+	if query.Market == MarketDatedFuture && (strings.Contains(symbol, "USDT") || strings.Contains(symbol, "USDC")) {
+		category = string(bybit.CategoryV5Linear)
+	}
+
+	// Mark and index prices exist for derivatives, premium indexes for linear contracts only
+	priceType := query.PriceType
+	if priceType == "" {
+		priceType = DefaultPriceType
+	}
+	if (priceType != PriceTypeLast && category == string(bybit.CategoryV5Spot)) ||
+		(priceType == PriceTypePremiumIndex && category != string(bybit.CategoryV5Linear)) {
+		return unsupportedPriceType(a.GetName(), priceType, query.Market)
+	}
 
 	// Set default limit if not specified
-	limitInt := int(limit)
-	if limit <= 0 {
-		limitInt = 100
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	// Bybit returns the newest candles of a range, so ranges are walked in
+	// windows that fit in a single page
+	p := pager{
+		interval: query.Interval,
+		pageSize: bybitMaxPageSize,
+		windowed: true,
+		fetch:    a.fetchKlines(bybit.CategoryV5(category), symbol, priceType, query.Interval, bybitInterval),
+	}
+	return p.walk(ctx, query, handle)
+}
+
+// fetchKlines returns a page fetcher for the Bybit kline endpoint of the price type
+func (a *BybitAdapter) fetchKlines(category bybit.CategoryV5, ticker string, priceType PriceType, interval Interval, bybitInterval string) pageFetcher {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+		param := bybit.V5GetKlineParam{
+			Category: category,
+			Symbol:   bybit.SymbolV5(ticker),
+			Interval: bybit.Interval(bybitInterval),
+			Limit:    &limit,
+		}
+		endMs := end.UnixMilli()
+		param.End = &endMs
+		if !start.IsZero() {
+			startMs := start.UnixMilli()
+			param.Start = &startMs
+		}
+
+		// Fetch data from Bybit API
+		items, err := a.klines(priceType, param)
+		if err != nil {
+			return nil, err
+		}
+
+		// Convert data to response format
+		prices := make([]*pb.PricesResponse, 0, len(items))
+		for _, item := range items {
+			var candle *pb.PricesResponse
+			if priceType == PriceTypeLast {
+				candle, err = bybitKlineToCandle(item, interval, category == bybit.CategoryV5Inverse)
+			} else {
+				candle, err = bybitPriceSeriesToCandle(item, interval)
+			}
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, candle)
+		}
+
+		return prices, nil
+	}
+}
+
+// klines requests a page of the kline series of a price type. Mark, index and
+// premium index klines carry no volumes, so their volume fields stay empty.
+func (a *BybitAdapter) klines(priceType PriceType, param bybit.V5GetKlineParam) ([]bybit.V5GetKlineItem, error) {
+	market := a.client.V5().Market()
+
+	var items []bybit.V5GetKlineItem
+	var common bybit.CommonV5Response
+	switch priceType {
+	case PriceTypeMark:
+		resp, err := market.GetMarkPriceKline(bybit.V5GetMarkPriceKlineParam{
+			Category: param.Category, Symbol: param.Symbol, Interval: param.Interval,
+			Start: param.Start, End: param.End, Limit: param.Limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching data from Bybit: %v", err)
+		}
+		common = resp.CommonV5Response
+		for _, item := range resp.Result.List {
+			items = append(items, bybit.V5GetKlineItem{StartTime: item.StartTime, Open: item.Open, High: item.High, Low: item.Low, Close: item.Close})
+		}
+	case PriceTypeIndex:
+		resp, err := market.GetIndexPriceKline(bybit.V5GetIndexPriceKlineParam{
+			Category: param.Category, Symbol: param.Symbol, Interval: param.Interval,
+			Start: param.Start, End: param.End, Limit: param.Limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching data from Bybit: %v", err)
+		}
+		common = resp.CommonV5Response
+		for _, item := range resp.Result.List {
+			items = append(items, bybit.V5GetKlineItem{StartTime: item.StartTime, Open: item.Open, High: item.High, Low: item.Low, Close: item.Close})
+		}
+	case PriceTypePremiumIndex:
+		resp, err := market.GetPremiumIndexPriceKline(bybit.V5GetPremiumIndexPriceKlineParam{
+			Category: param.Category, Symbol: param.Symbol, Interval: param.Interval,
+			Start: param.Start, End: param.End, Limit: param.Limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching data from Bybit: %v", err)
+		}
+		common = resp.CommonV5Response
+		for _, item := range resp.Result.List {
+			items = append(items, bybit.V5GetKlineItem{StartTime: item.StartTime, Open: item.Open, High: item.High, Low: item.Low, Close: item.Close})
+		}
+	default:
+		resp, err := market.GetKline(param)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching data from Bybit: %v", err)
+		}
+		common = resp.CommonV5Response
+		items = resp.Result.List
+	}
+
+	// Check if request was successful
+	if common.RetCode != 0 {
+		return nil, fmt.Errorf("bybit API error: %s", common.RetMsg)
+	}
+	return items, nil
+}
+
+// bybitFundingMaxPageSize is the largest number of funding rates Bybit returns per request
+const bybitFundingMaxPageSize = 200
+
+// bybitFundingSpacing is the usual time between Bybit funding settlements;
+// contracts settling more often take more requests per window
+const bybitFundingSpacing = 8 * time.Hour
+
+// bybitPerpetualCategories maps perpetual markets to the Bybit categories listing
+// them, for funding rates and derivatives statistics
+var bybitPerpetualCategories = map[Market]string{
+	MarketLinearPerp:  string(bybit.CategoryV5Linear),
+	MarketInversePerp: string(bybit.CategoryV5Inverse),
+}
+
+// GetFundingRates retrieves the funding rate history of a Bybit perpetual contract.
+// Bybit does not report mark prices with funding rates, so they are taken from the
+// opening price of the mark price kline starting at each funding time.
+func (a *BybitAdapter) GetFundingRates(ctx context.Context, query FundingRateQuery, handle FundingRateHandler) error {
+	log.Printf("Getting funding rates from Bybit for %s (%s)", query.Ticker, query.Market)
+
+	category, err := mapFundingMarket(a.GetName(), bybitPerpetualCategories, query.Market)
+	if err != nil {
+		return err
+	}
+
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	symbol := strings.ToUpper(query.Ticker)
+	p := seriesPager[*pb.FundingRate]{
+		pageSize:    bybitFundingMaxPageSize,
+		newestFirst: true,
+		spacing:     bybitFundingSpacing,
+		timestamp:   fundingRateTimestamp,
+		fetch:       a.fetchFundingRates(bybit.CategoryV5(category), symbol),
+	}
+	return p.walk(ctx, query.StartTime, query.EndTime, query.Limit, func(rates []*pb.FundingRate) error {
+		if err := a.fillMarkPrices(ctx, symbol, query.Market, rates); err != nil {
+			return err
+		}
+		return handle(rates)
+	})
+}
+
+// fetchFundingRates returns a page fetcher for the Bybit funding history endpoint
+func (a *BybitAdapter) fetchFundingRates(category bybit.CategoryV5, symbol string) seriesFetcher[*pb.FundingRate] {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.FundingRate, error) {
+		param := bybit.V5GetFundingRateHistoryParam{
+			Category: category,
+			Symbol:   bybit.SymbolV5(symbol),
+			Limit:    &limit,
+		}
+		endMs := end.UnixMilli()
+		param.EndTime = &endMs
+		if !start.IsZero() {
+			startMs := start.UnixMilli()
+			param.StartTime = &startMs
+		}
+
+		resp, err := a.client.V5().Market().GetFundingRateHistory(param)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching funding rates from Bybit: %v", err)
+		}
+		if resp.RetCode != 0 {
+			return nil, fmt.Errorf("bybit API error: %s", resp.RetMsg)
+		}
+
+		rates := make([]*pb.FundingRate, 0, len(resp.Result.List))
+		for _, item := range resp.Result.List {
+			parser := decimalParser{exchange: "bybit"}
+			fundingTime := parser.int("funding time", item.FundingRateTimestamp)
+			if parser.err != nil {
+				return nil, parser.err
+			}
+			rate, err := newFundingRate("bybit", fundingTime, item.FundingRate, "")
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, rate)
+		}
+		return rates, nil
+	}
+}
+
+// bybitMarkPriceIntervals are the kline intervals mark prices of funding rates
+// are read from, longest first
+var bybitMarkPriceIntervals = []Interval{Interval1d, Interval4h, Interval1h}
+
+// markPriceInterval returns the longest interval with a kline opening at every
+// funding time of rates, so that settlements every 8 hours take two klines each
+// rather than eight
+func markPriceInterval(rates []*pb.FundingRate) Interval {
+	for _, interval := range bybitMarkPriceIntervals {
+		aligned := true
+		for _, rate := range rates {
+			if rate.FundingTime%interval.Duration().Milliseconds() != 0 {
+				aligned = false
+				break
+			}
+		}
+		if aligned {
+			return interval
+		}
+	}
+	return Interval1h
+}
+
+// fillMarkPrices sets the mark price of funding rates from the mark price klines
+// opening at their funding times; rates without a matching kline keep no mark price
+func (a *BybitAdapter) fillMarkPrices(ctx context.Context, symbol string, market Market, rates []*pb.FundingRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	if market == "" {
+		market = DefaultFundingMarket
 	}
 
-	// Fetch data from Bybit API
-	resp, err := a.client.V5().Market().GetKline(bybit.V5GetKlineParam{
-		Category: bybit.CategoryV5Spot,
-		Symbol:   bybit.SymbolV5(ticker),
-		Interval: bybit.Interval("D"), // Daily candles
-		Limit:    &limitInt,
+	markPrices := make(map[int64]float64)
+	err := a.GetHistoricalPrices(ctx, PriceQuery{
+		Ticker:    symbol,
+		Market:    market,
+		Interval:  markPriceInterval(rates),
+		PriceType: PriceTypeMark,
+		StartTime: time.UnixMilli(rates[0].FundingTime),
+		EndTime:   time.UnixMilli(rates[len(rates)-1].FundingTime),
+	}, func(prices []*pb.PricesResponse) error {
+		for _, price := range prices {
+			markPrices[price.OpenTime] = price.Open
+		}
+		return nil
 	})
+	if err != nil {
+		return fmt.Errorf("error fetching mark prices from Bybit: %w", err)
+	}
+
+	for _, rate := range rates {
+		if markPrice, ok := markPrices[rate.FundingTime]; ok {
+			rate.MarkPrice = markPrice
+			rate.HasMarkPrice = true
+		}
+	}
+	return nil
+}
+
+// Largest numbers of statistic samples Bybit returns per request
+const (
+	bybitOpenInterestMaxPageSize = 200
+	bybitAccountRatioMaxPageSize = 500
+)
+
+// bybitStatPeriods maps statistic periods to Bybit periods
+var bybitStatPeriods = map[StatPeriod]string{
+	StatPeriod5m:  string(bybit.Period5min),
+	StatPeriod15m: string(bybit.Period15min),
+	StatPeriod30m: string(bybit.Period30min),
+	StatPeriod1h:  string(bybit.Period1h),
+	StatPeriod4h:  string(bybit.Period4h),
+	StatPeriod1d:  string(bybit.Period1d),
+}
 
+// GetDerivativesStats retrieves the open interest or global long/short ratio
+// history of a Bybit perpetual contract. Bybit publishes no top trader ratios.
+func (a *BybitAdapter) GetDerivativesStats(ctx context.Context, query DerivativesStatsQuery, handle DerivativesStatsHandler) error {
+	log.Printf("Getting %s from Bybit for %s (%s, %s)", query.Stat, query.Ticker, query.Market, query.Period)
+
+	if query.Market == "" {
+		query.Market = DefaultStatsMarket
+	}
+	category, err := mapMarket(a.GetName(), bybitPerpetualCategories, query.Market)
+	if err != nil {
+		return err
+	}
+	period, err := mapStatPeriod(a.GetName(), bybitStatPeriods, query.Period)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching data from Bybit: %v", err)
+		return err
 	}
 
-	// Check if request was successful
-	if resp.RetCode != 0 {
-		return nil, fmt.Errorf("bybit API error: %s", resp.RetMsg)
-	}
-
-	// Convert data to response format
-	prices := make([]*pb.PricesResponse, 0, len(resp.Result.List))
-	for _, item := range resp.Result.List {
-		// Convert string values to float64
-		open, _ := strconv.ParseFloat(item.Open, 64)
-		high, _ := strconv.ParseFloat(item.High, 64)
-		low, _ := strconv.ParseFloat(item.Low, 64)
-		close, _ := strconv.ParseFloat(item.Close, 64)
-		volume, _ := strconv.ParseFloat(item.Volume, 64)
-
-		// Convert timestamp to date
-		timestamp, _ := strconv.ParseInt(item.StartTime, 10, 64)
-		date := time.Unix(timestamp/1000, 0).Format("2006-01-02")
-
-		prices = append(prices, &pb.PricesResponse{
-			Date:   date,
-			Open:   open,
-			High:   high,
-			Low:    low,
-			Close:  close,
-			Volume: volume,
-		})
+	symbol := strings.ToUpper(query.Ticker)
+	p := seriesPager[*pb.DerivativesStat]{
+		newestFirst: true,
+		period:      query.Period.Duration(),
+		timestamp:   derivativesStatTimestamp,
+	}
+	switch query.Stat {
+	case StatOpenInterest:
+		p.pageSize = bybitOpenInterestMaxPageSize
+		p.fetch = a.fetchOpenInterest(bybit.CategoryV5(category), symbol, period)
+	case StatGlobalLongShortRatio:
+		p.pageSize = bybitAccountRatioMaxPageSize
+		p.fetch = a.fetchAccountRatios(category, symbol, period)
+	default:
+		return unsupportedDerivativesStat(a.GetName(), query.Stat)
 	}
 
-	return prices, nil
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	return p.walk(ctx, query.StartTime, query.EndTime, query.Limit, handle)
+}
+
+// fetchOpenInterest returns a page fetcher for the Bybit open interest endpoint
+func (a *BybitAdapter) fetchOpenInterest(category bybit.CategoryV5, symbol, period string) seriesFetcher[*pb.DerivativesStat] {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.DerivativesStat, error) {
+		param := bybit.V5GetOpenInterestParam{
+			Category:     category,
+			Symbol:       bybit.SymbolV5(symbol),
+			IntervalTime: bybit.Period(period),
+			Limit:        &limit,
+		}
+		endMs := end.UnixMilli()
+		param.EndTime = &endMs
+		if !start.IsZero() {
+			startMs := start.UnixMilli()
+			param.StartTime = &startMs
+		}
+
+		resp, err := a.client.V5().Market().GetOpenInterest(param)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching open interest from Bybit: %v", err)
+		}
+		if resp.RetCode != 0 {
+			return nil, fmt.Errorf("bybit API error: %s", resp.RetMsg)
+		}
+
+		// Bybit reports open interest in the base asset for linear contracts and
+		// in contracts for inverse ones, without its value
+		stats := make([]*pb.DerivativesStat, 0, len(resp.Result.List))
+		for _, item := range resp.Result.List {
+			parser := decimalParser{exchange: "bybit"}
+			timestamp := parser.int("timestamp", item.Timestamp)
+			if parser.err != nil {
+				return nil, parser.err
+			}
+			stat, err := newOpenInterestStat("bybit", timestamp, item.OpenInterest, "")
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, stat)
+		}
+		return stats, nil
+	}
+}
+
+// bybitAccountRatioResponse is the Bybit long/short account ratio response
+type bybitAccountRatioResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List []struct {
+			Symbol    string `json:"symbol"`
+			BuyRatio  string `json:"buyRatio"`
+			SellRatio string `json:"sellRatio"`
+			Timestamp string `json:"timestamp"`
+		} `json:"list"`
+	} `json:"result"`
+}
+
+// fetchAccountRatios returns a page fetcher for the Bybit long/short account ratio
+// endpoint, which the client library does not cover
+func (a *BybitAdapter) fetchAccountRatios(category, symbol, period string) seriesFetcher[*pb.DerivativesStat] {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.DerivativesStat, error) {
+		params := url.Values{}
+		params.Set("category", category)
+		params.Set("symbol", symbol)
+		params.Set("period", period)
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(limit))
+		if !start.IsZero() {
+			params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+		}
+
+		var resp bybitAccountRatioResponse
+		if err := getJSON(ctx, a.httpClient, a.baseURL+"/v5/market/account-ratio", params, &resp); err != nil {
+			return nil, fmt.Errorf("error fetching long/short ratios from Bybit: %v", err)
+		}
+		if resp.RetCode != 0 {
+			return nil, fmt.Errorf("bybit API error: %s", resp.RetMsg)
+		}
+
+		stats := make([]*pb.DerivativesStat, 0, len(resp.Result.List))
+		for _, item := range resp.Result.List {
+			parser := decimalParser{exchange: "bybit"}
+			timestamp := parser.int("timestamp", item.Timestamp)
+			if parser.err != nil {
+				return nil, parser.err
+			}
+			stat, err := newLongShortRatioStat("bybit", timestamp, "", item.BuyRatio, item.SellRatio)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, stat)
+		}
+		return stats, nil
+	}
+}
+
+// bybitArchiveURL is the address of the Bybit public data archives
+const bybitArchiveURL = "https://public.bybit.com"
+
+// bybitTradesPageSize is the number of archived trades handed over at once
+const bybitTradesPageSize = 1000
+
+// bybitTradeColumns holds the positions of the trade fields in a trade archive.
+// Derivatives archives hold trdMatchID and size columns, spot archives id and volume.
+type bybitTradeColumns struct {
+	id, timestamp, price, quantity, side int
+}
+
+// GetTrades retrieves Bybit trades for a time window from the public trade
+// archives. Bybit publishes one archive per symbol and UTC day once the day is
+// over, so trades of the current day are not available yet. Days without an
+// archive, such as those before a listing, are skipped, but a window without
+// any archive is an error rather than an empty stream.
+func (a *BybitAdapter) GetTrades(ctx context.Context, query TradeQuery, handle TradeHandler) error {
+	log.Printf("Getting trades from Bybit for %s (%s)", query.Ticker, query.Market)
+
+	start, end, err := tradeWindow(query)
+	if err != nil {
+		return err
+	}
+	if _, err := mapMarket(a.GetName(), bybitCategories, query.Market); err != nil {
+		return err
+	}
+
+	symbol := strings.ToUpper(query.Ticker)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	limiter := tradeLimiter{limit: query.Limit}
+	archived := false
+	for day := start.UTC().Truncate(24 * time.Hour); !day.After(end) && day.Before(today) && !limiter.done(); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := a.readTradeArchive(ctx, a.tradeArchiveURL(symbol, query.Market, day), start, end, &limiter, handle)
+		if errors.Is(err, errArchiveNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		archived = true
+	}
+
+	switch {
+	case archived:
+		return nil
+	case !start.UTC().Before(today):
+		return fmt.Errorf("%w: bybit publishes the trades of a UTC day once it is over", ErrTradesNotArchived)
+	}
+	market := query.Market
+	if market == "" {
+		market = DefaultMarket
+	}
+	return fmt.Errorf("%w: bybit has no %s trade archives of %s from %s to %s", ErrUnknownSymbol, market, symbol,
+		start.UTC().Format("2006-01-02"), end.UTC().Format("2006-01-02"))
+}
+
+// tradeArchiveURL returns the address of the trade archive of a symbol for a UTC day
+func (a *BybitAdapter) tradeArchiveURL(symbol string, market Market, day time.Time) string {
+	date := day.Format("2006-01-02")
+	if market == "" || market == MarketSpot {
+		return fmt.Sprintf("%s/spot/%s/%s_%s.csv.gz", a.archiveURL, symbol, symbol, date)
+	}
+	return fmt.Sprintf("%s/trading/%s/%s%s.csv.gz", a.archiveURL, symbol, symbol, date)
+}
+
+// readTradeArchive hands over the trades of an archive that fall in [start, end]
+// in pages. Archives are sorted by time, newest first for some symbols and days,
+// so the order is told from the first and the last trade.
+func (a *BybitAdapter) readTradeArchive(ctx context.Context, endpoint string, start, end time.Time, limiter *tradeLimiter, handle TradeHandler) error {
+	file, err := downloadArchive(ctx, a.httpClient, endpoint)
+	if errors.Is(err, errArchiveNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching trades from Bybit: %v", err)
+	}
+	defer removeArchive(file)
+
+	var header, first, last string
+	err = archiveLines(file, false, func(line string) error {
+		if header == "" {
+			header = line
+			return nil
+		}
+		first = line
+		return errStopLines
+	})
+	if err != nil {
+		return err
+	}
+	if first == "" {
+		return nil
+	}
+	if err := archiveLines(file, true, func(line string) error {
+		last = line
+		return errStopLines
+	}); err != nil {
+		return err
+	}
+
+	columns, err := newBybitTradeColumns(header)
+	if err != nil {
+		return err
+	}
+	firstTrade, err := columns.parse(first)
+	if err != nil {
+		return err
+	}
+	lastTrade, err := columns.parse(last)
+	if err != nil {
+		return err
+	}
+
+	page := make([]*pb.Trade, 0, bybitTradesPageSize)
+	flush := func() error {
+		trades := limiter.take(page)
+		page = page[:0]
+		if len(trades) == 0 {
+			return nil
+		}
+		if err := handle(trades); err != nil {
+			return err
+		}
+		if limiter.done() {
+			return errStopLines
+		}
+		return nil
+	}
+
+	err = archiveLines(file, lastTrade.Timestamp < firstTrade.Timestamp, func(line string) error {
+		if line == header {
+			return nil
+		}
+		trade, err := columns.parse(line)
+		if err != nil {
+			return err
+		}
+		if trade.Timestamp < start.UnixMilli() {
+			return nil
+		}
+		if trade.Timestamp > end.UnixMilli() {
+			return errStopLines
+		}
+
+		page = append(page, trade)
+		if len(page) < bybitTradesPageSize {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	if err := flush(); err != nil && !errors.Is(err, errStopLines) {
+		return err
+	}
+	return nil
+}
+
+// newBybitTradeColumns locates the trade fields in the header of a trade archive
+func newBybitTradeColumns(header string) (bybitTradeColumns, error) {
+	positions := make(map[string]int)
+	for i, name := range strings.Split(header, ",") {
+		positions[strings.TrimSpace(name)] = i
+	}
+	column := func(names ...string) int {
+		for _, name := range names {
+			if i, ok := positions[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	columns := bybitTradeColumns{
+		id:        column("trdMatchID", "id"),
+		timestamp: column("timestamp"),
+		price:     column("price"),
+		quantity:  column("size", "volume"),
+		side:      column("side"),
+	}
+	for _, i := range []int{columns.id, columns.timestamp, columns.price, columns.quantity, columns.side} {
+		if i < 0 {
+			return bybitTradeColumns{}, fmt.Errorf("%w: bybit sent a trade archive with header %q", ErrMalformedTrade, header)
+		}
+	}
+	return columns, nil
+}
+
+// parse converts an archive line into a trade
+func (c bybitTradeColumns) parse(line string) (*pb.Trade, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) <= max(c.id, c.timestamp, c.price, c.quantity, c.side) {
+		return nil, fmt.Errorf("%w: bybit sent a trade archive line %q", ErrMalformedTrade, line)
+	}
+	timestamp, err := parseArchiveTimestamp(fields[c.timestamp])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bybit sent trade time %q", ErrMalformedTrade, fields[c.timestamp])
+	}
+	return newTrade("bybit", fields[c.id], timestamp, fields[c.price], fields[c.quantity], fields[c.side], 1)
+}
+
+// parseArchiveTimestamp converts an archive timestamp into epoch milliseconds.
+// Derivatives archives hold fractional epoch seconds, spot archives epoch milliseconds.
+func parseArchiveTimestamp(value string) (int64, error) {
+	whole, fraction, fractional := strings.Cut(value, ".")
+	timestamp, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if !fractional {
+		if len(whole) > 10 {
+			return timestamp, nil
+		}
+		return timestamp * 1000, nil
+	}
+
+	millis, err := strconv.ParseInt((fraction + "000")[:3], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp*1000 + millis, nil
+}
+
+// Largest order book depths Bybit serves per category
+const (
+	bybitSpotMaxDepth        = 50
+	bybitDerivativesMaxDepth = 200
+)
+
+// GetOrderBook retrieves the current top of a Bybit order book
+func (a *BybitAdapter) GetOrderBook(ctx context.Context, query OrderBookQuery) (*pb.OrderBookSnapshot, error) {
+	depth, err := ParseOrderBookDepth(query.Depth)
+	if err != nil {
+		return nil, err
+	}
+	category, err := mapMarket(a.GetName(), bybitCategories, query.Market)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := strings.ToUpper(query.Ticker)
+	if query.Market == MarketDatedFuture && (strings.Contains(symbol, "USDT") || strings.Contains(symbol, "USDC")) {
+		category = string(bybit.CategoryV5Linear)
+	}
+
+	maxDepth := bybitDerivativesMaxDepth
+	if category == string(bybit.CategoryV5Spot) {
+		maxDepth = bybitSpotMaxDepth
+	}
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: bybit serves at most %d levels for %s", ErrUnsupportedOrderBookDepth, maxDepth, query.Market)
+	}
+
+	response, err := a.client.V5().Market().GetOrderbook(bybit.V5GetOrderbookParam{
+		Category: bybit.CategoryV5(category),
+		Symbol:   bybit.SymbolV5(symbol),
+		Limit:    &depth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching order book from Bybit: %v", err)
+	}
+	if response.RetCode != 0 {
+		return nil, fmt.Errorf("bybit API error: %s", response.RetMsg)
+	}
+
+	book := response.Result
+	parser := decimalParser{exchange: "bybit"}
+	bids, asks := newOrderBookSides(&parser, depth)
+	for _, level := range book.Bids {
+		bids.add(level.Price, level.Quantity)
+	}
+	for _, level := range book.Asks {
+		asks.add(level.Price, level.Quantity)
+	}
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (order book %d)", parser.err, book.UpdateID)
+	}
+
+	return &pb.OrderBookSnapshot{
+		ExchangeTime: book.Timestamp,
+		UpdateId:     int64(book.UpdateID),
+		Bids:         bids.levels,
+		Asks:         asks.levels,
+	}, nil
+}
+
+// bybitUnavailableFields lists the candle fields Bybit klines do not carry
+var bybitUnavailableFields = []pb.CandleField{
+	pb.CandleField_CANDLE_FIELD_TRADE_COUNT,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+}
+
+// bybitKlineToCandle converts a Bybit kline into a candle; the turnover is the quote
+// volume. Inverse contracts report their volume in the quote asset and their
+// turnover in the base asset, so the two are swapped.
+func bybitKlineToCandle(item bybit.V5GetKlineItem, interval Interval, inverse bool) (*pb.PricesResponse, error) {
+	volumeValue, turnoverValue := item.Volume, item.Turnover
+	if inverse {
+		volumeValue, turnoverValue = item.Turnover, item.Volume
+	}
+
+	// Convert string values to float64, keeping the exact strings alongside
+	parser := decimalParser{exchange: "bybit"}
+	timestamp := parser.int("start time", item.StartTime)
+	open := parser.float("open", item.Open)
+	high := parser.float("high", item.High)
+	low := parser.float("low", item.Low)
+	close := parser.float("close", item.Close)
+	volume := parser.float("volume", volumeValue)
+	turnover := parser.float("turnover", turnoverValue)
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (kline starting at %s)", parser.err, item.StartTime)
+	}
+
+	candle := newCandle(timestamp, interval)
+	candle.Open = open
+	candle.High = high
+	candle.Low = low
+	candle.Close = close
+	candle.Volume = volume
+	candle.QuoteVolume = turnover
+	candle.UnavailableFields = bybitUnavailableFields
+	candle.Decimals = &pb.DecimalValues{
+		Open:        item.Open,
+		High:        item.High,
+		Low:         item.Low,
+		Close:       item.Close,
+		Volume:      volumeValue,
+		QuoteVolume: turnoverValue,
+	}
+
+	return candle, nil
+}
+
+// bybitPriceSeriesToCandle converts a mark, index or premium index kline into a candle
+func bybitPriceSeriesToCandle(item bybit.V5GetKlineItem, interval Interval) (*pb.PricesResponse, error) {
+	parser := decimalParser{exchange: "bybit"}
+	timestamp := parser.int("start time", item.StartTime)
+	if parser.err != nil {
+		return nil, parser.err
+	}
+	return newPriceSeriesCandle("bybit", timestamp, interval, item.Open, item.High, item.Low, item.Close)
+}
+
+// GetListingTime finds the open time of the first daily kline of a symbol in its category
+func (a *BybitAdapter) GetListingTime(ctx context.Context, query ListingQuery) (time.Time, error) {
+	return searchListingTime(ctx, a, query)
+}
+
+// SupportedMarkets returns the markets Bybit serves klines for
+func (a *BybitAdapter) SupportedMarkets() []Market {
+	return marketsOf(bybitCategories)
+}
+
+// SupportedIntervals returns the kline intervals Bybit serves
+func (a *BybitAdapter) SupportedIntervals() []Interval {
+	return intervalsOf(bybitIntervals)
+}
+
+// MaxPageSize returns the largest number of klines Bybit returns per request
+func (a *BybitAdapter) MaxPageSize() int {
+	return bybitMaxPageSize
+}
+
+// bybitInstrumentsPageSize is the largest number of instruments Bybit returns per request
+const bybitInstrumentsPageSize = 1000
+
+// bybitSymbolStatuses maps Bybit instrument statuses
+var bybitSymbolStatuses = map[string]SymbolStatus{
+	"Trading":    SymbolStatusTrading,
+	"PreLaunch":  SymbolStatusPending,
+	"Delivering": SymbolStatusHalted,
+	"Closed":     SymbolStatusDelisted,
+}
+
+// bybitInstrumentSource is a Bybit category and the contract type of the
+// instruments listed for a market; spot instruments carry no contract type
+type bybitInstrumentSource struct {
+	category     string
+	contractType string
+}
+
+// bybitInstrumentSources maps markets to the Bybit instruments listed for them
+var bybitInstrumentSources = map[Market][]bybitInstrumentSource{
+	MarketSpot:        {{category: string(bybit.CategoryV5Spot)}},
+	MarketLinearPerp:  {{category: string(bybit.CategoryV5Linear), contractType: "LinearPerpetual"}},
+	MarketInversePerp: {{category: string(bybit.CategoryV5Inverse), contractType: "InversePerpetual"}},
+	MarketDatedFuture: {
+		{category: string(bybit.CategoryV5Linear), contractType: "LinearFutures"},
+		{category: string(bybit.CategoryV5Inverse), contractType: "InverseFutures"},
+	},
+}
+
+// bybitInstrumentsResponse is a page of the Bybit instruments info endpoint
+type bybitInstrumentsResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List []struct {
+			Symbol       string `json:"symbol"`
+			ContractType string `json:"contractType"`
+			Status       string `json:"status"`
+			BaseCoin     string `json:"baseCoin"`
+			QuoteCoin    string `json:"quoteCoin"`
+			LaunchTime   string `json:"launchTime"`
+			PriceFilter  struct {
+				TickSize string `json:"tickSize"`
+			} `json:"priceFilter"`
+		} `json:"list"`
+		NextPageCursor string `json:"nextPageCursor"`
+	} `json:"result"`
+}
+
+// GetSymbols lists the symbols of a market from the Bybit instruments info endpoint
+func (a *BybitAdapter) GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error) {
+	if market == "" {
+		market = DefaultMarket
+	}
+	sources, ok := bybitInstrumentSources[market]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support %s", ErrUnsupportedMarket, a.GetName(), market)
+	}
+
+	var symbols []SymbolInfo
+	for _, source := range sources {
+		cursor := ""
+		for {
+			params := url.Values{}
+			params.Set("category", source.category)
+			params.Set("limit", strconv.Itoa(bybitInstrumentsPageSize))
+			if cursor != "" {
+				params.Set("cursor", cursor)
+			}
+
+			var resp bybitInstrumentsResponse
+			if err := getJSON(ctx, a.httpClient, a.baseURL+"/v5/market/instruments-info", params, &resp); err != nil {
+				return nil, fmt.Errorf("error fetching symbols from Bybit: %v", err)
+			}
+			if resp.RetCode != 0 {
+				return nil, fmt.Errorf("bybit API error: %s", resp.RetMsg)
+			}
+
+			for _, item := range resp.Result.List {
+				if item.ContractType != source.contractType {
+					continue
+				}
+				launchTime, _ := strconv.ParseInt(item.LaunchTime, 10, 64)
+				symbols = append(symbols, SymbolInfo{
+					Symbol:      item.Symbol,
+					Base:        item.BaseCoin,
+					Quote:       item.QuoteCoin,
+					Status:      mapSymbolStatus(bybitSymbolStatuses, item.Status),
+					TickSize:    item.PriceFilter.TickSize,
+					ListingTime: unixMilliOrZero(launchTime),
+				})
+			}
+
+			cursor = resp.Result.NextPageCursor
+			if cursor == "" || len(resp.Result.List) == 0 {
+				break
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// bybitStreamPingInterval is the time between pings Bybit expects to keep a
+// websocket connection open
+const bybitStreamPingInterval = 20 * time.Second
+
+// bybitStreamMessage is a message of the Bybit public websocket streams: either
+// the result of an operation or the kline updates of a topic, where a confirmed
+// kline is closed
+type bybitStreamMessage struct {
+	Op      string `json:"op"`
+	Success *bool  `json:"success"`
+	RetMsg  string `json:"ret_msg"`
+	Topic   string `json:"topic"`
+	Data    []struct {
+		Start    int64  `json:"start"`
+		Open     string `json:"open"`
+		High     string `json:"high"`
+		Low      string `json:"low"`
+		Close    string `json:"close"`
+		Volume   string `json:"volume"`
+		Turnover string `json:"turnover"`
+		Confirm  bool   `json:"confirm"`
+	} `json:"data"`
+}
+
+// StreamCandles pushes the live klines of a symbol from the Bybit public
+// websocket stream of its category. Only last traded prices are streamed.
+func (a *BybitAdapter) StreamCandles(ctx context.Context, query StreamQuery, handle CandleUpdateHandler) error {
+	bybitInterval, err := mapInterval(a.GetName(), bybitIntervals, query.Interval)
+	if err != nil {
+		return err
+	}
+	category, err := mapMarket(a.GetName(), bybitCategories, query.Market)
+	if err != nil {
+		return err
+	}
+
+	symbol := strings.ToUpper(query.Ticker)
+	if query.Market == MarketDatedFuture && (strings.Contains(symbol, "USDT") || strings.Contains(symbol, "USDC")) {
+		category = string(bybit.CategoryV5Linear)
+	}
+	inverse := category == string(bybit.CategoryV5Inverse)
+	topic := "kline." + bybitInterval + "." + symbol
+
+	return streamCandles(ctx, a, query, func(ctx context.Context, handle CandleUpdateHandler) error {
+		conn, err := dialStream(ctx, a.streamURL+"/"+category)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if err := conn.WriteJSON(map[string]interface{}{"op": "subscribe", "args": []string{topic}}); err != nil {
+			return err
+		}
+
+		// Bybit drops connections that are not pinged
+		ping := time.NewTicker(bybitStreamPingInterval)
+		defer ping.Stop()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-ping.C:
+					if err := conn.WriteJSON(map[string]string{"op": "ping"}); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		return readStream(ctx, conn, func(message []byte) error {
+			var msg bybitStreamMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				return fmt.Errorf("error decoding Bybit stream message: %v", err)
+			}
+			if msg.Op == "subscribe" && msg.Success != nil && !*msg.Success {
+				return fmt.Errorf("bybit subscription to %s failed: %s", topic, msg.RetMsg)
+			}
+			if msg.Topic != topic {
+				return nil
+			}
+
+			for _, item := range msg.Data {
+				candle, err := bybitKlineToCandle(bybit.V5GetKlineItem{
+					StartTime: strconv.FormatInt(item.Start, 10),
+					Open:      item.Open,
+					High:      item.High,
+					Low:       item.Low,
+					Close:     item.Close,
+					Volume:    item.Volume,
+					Turnover:  item.Turnover,
+				}, query.Interval, inverse)
+				if err != nil {
+					return err
+				}
+				if err := handle(CandleUpdate{Candle: candle, Closed: item.Confirm}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}, handle)
 }
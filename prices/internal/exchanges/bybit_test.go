@@ -1,11 +1,20 @@
 package exchanges
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/hirokisan/bybit/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	pb "github.com/timakaa/historical-common/proto"
@@ -99,6 +108,58 @@ func TestBybitAdapter_ProcessKlineData(t *testing.T) {
 	assert.Equal(t, 3.0, prices[1].Volume)
 }
 
+// TestBybitKlineToCandle tests the candle fields filled from a Bybit kline
+func TestBybitKlineToCandle(t *testing.T) {
+	item := bybit.V5GetKlineItem{
+		StartTime: "1672531200000", // 2023-01-01 00:00
+		Open:      "20000.0",
+		High:      "20100.0",
+		Low:       "19900.0",
+		Close:     "20050.0",
+		Volume:    "2.5",
+		Turnover:  "50125.0",
+	}
+
+	candle, err := bybitKlineToCandle(item, Interval1h, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2023-01-01", candle.Date)
+	assert.Equal(t, int64(1672531200000), candle.OpenTime)
+	assert.Equal(t, int64(1672534799999), candle.CloseTime)
+	assert.Equal(t, 50125.0, candle.QuoteVolume)
+	assert.Equal(t, []pb.CandleField{
+		pb.CandleField_CANDLE_FIELD_TRADE_COUNT,
+		pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+		pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+	}, candle.UnavailableFields)
+	assert.Equal(t, uint32(CandleSchemaVersion), candle.SchemaVersion)
+	assert.Equal(t, "20050.0", candle.Decimals.Close)
+	assert.Equal(t, "50125.0", candle.Decimals.QuoteVolume)
+}
+
+// TestBybitKlineToCandle_ParseError tests that unparsable values are reported instead of zeroed
+func TestBybitKlineToCandle_ParseError(t *testing.T) {
+	valid := bybit.V5GetKlineItem{
+		StartTime: "1672531200000",
+		Open:      "20000.0",
+		High:      "20100.0",
+		Low:       "19900.0",
+		Close:     "20050.0",
+		Volume:    "2.5",
+		Turnover:  "50125.0",
+	}
+
+	badTime := valid
+	badTime.StartTime = "yesterday"
+	_, err := bybitKlineToCandle(badTime, Interval1h, false)
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+
+	badClose := valid
+	badClose.Close = "NaN"
+	_, err = bybitKlineToCandle(badClose, Interval1h, false)
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+}
+
 // TestBybitAdapter_GetHistoricalPrices tests the GetHistoricalPrices method
 func TestBybitAdapter_GetHistoricalPrices(t *testing.T) {
 	// This test verifies that the method exists and has the correct signature
@@ -106,7 +167,7 @@ func TestBybitAdapter_GetHistoricalPrices(t *testing.T) {
 
 	// Call the method with a valid ticker and limit
 	ctx := context.Background()
-	prices, err := adapter.GetHistoricalPrices(ctx, "BTCUSDT", 10)
+	prices, err := CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1d, Limit: 10})
 
 	// If the API call succeeds, verify the results
 	if err == nil {
@@ -127,11 +188,11 @@ func TestBybitAdapter_GetHistoricalPrices(t *testing.T) {
 	}
 
 	// Test with invalid ticker
-	_, err = adapter.GetHistoricalPrices(ctx, "INVALID_TICKER_12345", 5)
+	_, err = CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "INVALID_TICKER_12345", Interval: Interval1d, Limit: 5})
 	assert.Error(t, err)
 
 	// Test with default limit (0)
-	prices, err = adapter.GetHistoricalPrices(ctx, "BTCUSDT", 0)
+	prices, err = CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1d, Limit: 0})
 	if err == nil {
 		require.NotNil(t, prices)
 		assert.LessOrEqual(t, len(prices), 100) // Default limit is 100
@@ -170,7 +231,7 @@ func TestBybitAdapter_GetHistoricalPrices_Integration(t *testing.T) {
 	ctx := context.Background()
 
 	// Test with valid ticker and limit
-	prices, err := adapter.GetHistoricalPrices(ctx, "BTCUSDT", 5)
+	prices, err := CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1d, Limit: 5})
 	require.NoError(t, err)
 	require.NotNil(t, prices)
 	require.LessOrEqual(t, len(prices), 5)
@@ -185,18 +246,629 @@ func TestBybitAdapter_GetHistoricalPrices_Integration(t *testing.T) {
 	}
 
 	// Test with invalid ticker
-	prices, err = adapter.GetHistoricalPrices(ctx, "INVALID_TICKER", 5)
+	prices, err = CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "INVALID_TICKER", Interval: Interval1d, Limit: 5})
 	assert.Error(t, err)
 
 	// Test with default limit
-	prices, err = adapter.GetHistoricalPrices(ctx, "BTCUSDT", 0)
+	prices, err = CollectHistoricalPrices(ctx, adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1d, Limit: 0})
 	require.NoError(t, err)
 	require.NotNil(t, prices)
 	assert.LessOrEqual(t, len(prices), 100) // Default limit is 100
 }
 
+// TestBybitAdapter_IntervalMapping tests that every API interval maps to a Bybit interval
+func TestBybitAdapter_IntervalMapping(t *testing.T) {
+	for interval := range supportedIntervals {
+		value, err := mapInterval("bybit", bybitIntervals, interval)
+		assert.NoError(t, err, "interval %s should be supported", interval)
+		assert.NotEmpty(t, value)
+	}
+
+	// Unknown intervals are rejected before any request is made
+	adapter := NewBybitAdapter()
+	_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{Ticker: "BTCUSDT", Interval: Interval("3m"), Limit: 10})
+	assert.ErrorIs(t, err, ErrUnsupportedInterval)
+}
+
+// newBybitStandIn starts a local stand-in for the Bybit kline endpoint serving
+// hourly candles from start
+func newBybitStandIn(t *testing.T, start time.Time, count int) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/v5/market/kline", r.URL.Path)
+		require.Equal(t, "60", r.URL.Query().Get("interval"))
+
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		startTime, _ := strconv.ParseInt(query.Get("start"), 10, 64)
+		endTime, err := strconv.ParseInt(query.Get("end"), 10, 64)
+		if err != nil {
+			endTime = math.MaxInt64
+		}
+
+		// Bybit returns the newest klines of the range, newest first
+		rows := [][]string{}
+		for i := count - 1; i >= 0 && len(rows) < limit; i-- {
+			openTime := start.Add(time.Duration(i) * time.Hour).UnixMilli()
+			if openTime < startTime || openTime > endTime {
+				continue
+			}
+			price := strconv.Itoa(20000 + i)
+			rows = append(rows, []string{
+				strconv.FormatInt(openTime, 10), price, price, price, price, "2.5", "50000",
+			})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"retMsg":  "OK",
+			"result": map[string]interface{}{
+				"category": "spot",
+				"symbol":   query.Get("symbol"),
+				"list":     rows,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// TestBybitAdapter_GetHistoricalPricesRange tests paging a range longer than one page
+func TestBybitAdapter_GetHistoricalPricesRange(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, calls := newBybitStandIn(t, start, 2500)
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:    "BTCUSDT",
+		Interval:  Interval1h,
+		StartTime: start,
+		EndTime:   start.Add(2199 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 2200)
+	assert.Equal(t, 3, *calls)
+
+	// Newest-first pages are stitched in chronological order
+	for i, price := range prices {
+		assert.Equal(t, start.Add(time.Duration(i)*time.Hour).UnixMilli(), price.OpenTime)
+		assert.Equal(t, float64(20000+i), price.Close)
+	}
+}
+
+// TestBybitAdapter_GetHistoricalPricesLatest tests fetching the latest candles by limit
+func TestBybitAdapter_GetHistoricalPricesLatest(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, calls := newBybitStandIn(t, start, 2500)
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "BTCUSDT",
+		Interval: Interval1h,
+		Limit:    1500,
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 1500)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, start.Add(1000*time.Hour).UnixMilli(), prices[0].OpenTime)
+	assert.Equal(t, start.Add(2499*time.Hour).UnixMilli(), prices[1499].OpenTime)
+}
+
+// TestBybitAdapter_Markets tests that markets are mapped to Bybit categories
+func TestBybitAdapter_Markets(t *testing.T) {
+	var categories []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		categories = append(categories, r.URL.Query().Get("category"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"retMsg":  "OK",
+			"result": map[string]interface{}{
+				"list": [][]string{{"1672531200000", "16500", "16600", "16400", "16550", "1650000", "100"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+
+	queries := []PriceQuery{
+		{Ticker: "BTCUSDT", Market: MarketSpot},
+		{Ticker: "BTCUSDT", Market: MarketLinearPerp},
+		{Ticker: "BTCUSD", Market: MarketInversePerp},
+		{Ticker: "BTCUSDH25", Market: MarketDatedFuture},
+		{Ticker: "BTC-28MAR25", Market: MarketDatedFuture},
+		{Ticker: "BTCUSDT-28MAR25", Market: MarketDatedFuture},
+	}
+	var inverse []*pb.PricesResponse
+	for _, query := range queries {
+		query.Interval = Interval1d
+		query.Limit = 1
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, query)
+		require.NoError(t, err)
+		if query.Market == MarketInversePerp {
+			inverse = prices
+		}
+	}
+
+	assert.Equal(t, []string{"spot", "linear", "inverse", "inverse", "inverse", "linear"}, categories)
+
+	// Inverse contracts count volume in USD and turnover in the base asset
+	require.Len(t, inverse, 1)
+	assert.Equal(t, 100.0, inverse[0].Volume)
+	assert.Equal(t, 1650000.0, inverse[0].QuoteVolume)
+}
+
+// TestBybitAdapter_PriceTypes tests mark, index and premium index klines
+func TestBybitAdapter_PriceTypes(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"retMsg":  "OK",
+			"result": map[string]interface{}{
+				"list": [][]string{{"1672531200000", "16500", "16600", "16400", "16550"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+
+	for _, priceType := range []PriceType{PriceTypeMark, PriceTypeIndex, PriceTypePremiumIndex} {
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+			Ticker:    "BTCUSDT",
+			Market:    MarketLinearPerp,
+			Interval:  Interval1d,
+			PriceType: priceType,
+			Limit:     1,
+		})
+		require.NoError(t, err)
+		require.Len(t, prices, 1)
+		assert.Equal(t, 16550.0, prices[0].Close)
+		assert.Equal(t, priceSeriesUnavailableFields, prices[0].UnavailableFields)
+	}
+	assert.Equal(t, []string{"/v5/market/mark-price-kline", "/v5/market/index-price-kline", "/v5/market/premium-index-price-kline"}, paths)
+
+	// Spot has no mark prices and inverse contracts have no premium index
+	_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker: "BTCUSDT", Market: MarketSpot, Interval: Interval1d, PriceType: PriceTypeMark,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedPriceType)
+	_, err = CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker: "BTCUSD", Market: MarketInversePerp, Interval: Interval1d, PriceType: PriceTypePremiumIndex,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedPriceType)
+}
+
+// TestBybitAdapter_GetFundingRates tests paging funding rates and filling their
+// mark prices from hourly mark price klines
+func TestBybitAdapter_GetFundingRates(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	const count = 300
+
+	fundingCalls := 0
+	var markIntervals []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		var rows interface{}
+
+		switch r.URL.Path {
+		case "/v5/market/funding/history":
+			fundingCalls++
+			require.Equal(t, "linear", query.Get("category"))
+			startTime, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+			endTime, _ := strconv.ParseInt(query.Get("endTime"), 10, 64)
+
+			// Bybit returns the newest settlements of the range, newest first
+			list := []map[string]string{}
+			for i := count - 1; i >= 0 && len(list) < limit; i-- {
+				fundingTime := start.Add(time.Duration(i) * 8 * time.Hour).UnixMilli()
+				if fundingTime < startTime || fundingTime > endTime {
+					continue
+				}
+				list = append(list, map[string]string{
+					"symbol":               query.Get("symbol"),
+					"fundingRate":          "-0.0001",
+					"fundingRateTimestamp": strconv.FormatInt(fundingTime, 10),
+				})
+			}
+			rows = list
+		case "/v5/market/mark-price-kline":
+			markIntervals = append(markIntervals, query.Get("interval"))
+			minutes, _ := strconv.ParseInt(query.Get("interval"), 10, 64)
+			step := minutes * 60000
+			startTime, _ := strconv.ParseInt(query.Get("start"), 10, 64)
+			endTime, _ := strconv.ParseInt(query.Get("end"), 10, 64)
+
+			// Mark prices are missing for the first settlement
+			list := [][]string{}
+			for openTime := endTime - endTime%step; openTime >= startTime && len(list) < limit; openTime -= step {
+				if openTime == start.UnixMilli() {
+					continue
+				}
+				price := strconv.FormatInt(16000+(openTime-start.UnixMilli())/3600000, 10)
+				list = append(list, []string{strconv.FormatInt(openTime, 10), price, price, price, price})
+			}
+			rows = list
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"retMsg":  "OK",
+			"result":  map[string]interface{}{"list": rows},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+
+	rates, err := CollectFundingRates(context.Background(), adapter, FundingRateQuery{
+		Ticker:    "BTCUSDT",
+		StartTime: start,
+		EndTime:   start.Add((count - 1) * 8 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, rates, count)
+	assert.Equal(t, 2, fundingCalls)
+	assert.Equal(t, &pb.FundingRate{FundingTime: start.UnixMilli(), Rate: -0.0001}, rates[0])
+	assert.Equal(t, &pb.FundingRate{FundingTime: start.Add(8 * time.Hour).UnixMilli(), Rate: -0.0001, MarkPrice: 16008, HasMarkPrice: true}, rates[1])
+	assert.Equal(t, float64(16000+(count-1)*8), rates[count-1].MarkPrice)
+
+	// Settlements every 8 hours are read from 4 hour klines, one request per page of rates
+	assert.Equal(t, []string{"240", "240"}, markIntervals)
+
+	t.Run("mark price intervals", func(t *testing.T) {
+		rate := func(at time.Time) *pb.FundingRate { return &pb.FundingRate{FundingTime: at.UnixMilli()} }
+
+		assert.Equal(t, Interval1d, markPriceInterval([]*pb.FundingRate{rate(start), rate(start.Add(24 * time.Hour))}))
+		assert.Equal(t, Interval4h, markPriceInterval([]*pb.FundingRate{rate(start), rate(start.Add(8 * time.Hour))}))
+		assert.Equal(t, Interval1h, markPriceInterval([]*pb.FundingRate{rate(start), rate(start.Add(time.Hour))}))
+	})
+
+	// Dated futures have no funding
+	_, err = CollectFundingRates(context.Background(), adapter, FundingRateQuery{Ticker: "BTCUSDT", Market: MarketDatedFuture})
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
+
+// TestBybitAdapter_GetTrades tests streaming trades from daily archives, which
+// Bybit sorts newest first for derivatives and oldest first for spot
+func TestBybitAdapter_GetTrades(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		var archive bytes.Buffer
+		writer := gzip.NewWriter(&archive)
+		switch r.URL.Path {
+		case "/trading/BTCUSDT/BTCUSDT2023-01-01.csv.gz", "/trading/BTCUSDT/BTCUSDT2023-01-02.csv.gz":
+			date, _ := time.Parse("2006-01-02", strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/trading/BTCUSDT/BTCUSDT"), ".csv.gz"))
+			fmt.Fprintln(writer, "timestamp,symbol,side,size,price,tickDirection,trdMatchID,grossValue,homeNotional,foreignNotional")
+			for hour := 23; hour >= 0; hour-- {
+				timestamp := date.Add(time.Duration(hour) * time.Hour).Unix()
+				fmt.Fprintf(writer, "%d.5,BTCUSDT,%s,0.010,16500.5,PlusTick,match-%d,1.65e+10,0.01,165.005\n", timestamp, []string{"Buy", "Sell"}[hour%2], timestamp)
+			}
+		case "/spot/BTCUSDT/BTCUSDT_2023-01-01.csv.gz":
+			fmt.Fprintln(writer, "id,timestamp,price,volume,side")
+			for minute := 0; minute < 1440; minute++ {
+				fmt.Fprintf(writer, "%d,%d,16500.5,0.002,buy\n", minute+1, day.Add(time.Duration(minute)*time.Minute).UnixMilli())
+			}
+		case "/spot/ETHUSDT/ETHUSDT_2023-01-01.csv.gz":
+			fmt.Fprintln(writer, "id,timestamp,price,volume,side")
+			fmt.Fprintf(writer, "1,%d,1200.5,0.1,hold\n", day.UnixMilli())
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		writer.Close()
+		w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.archiveURL = server.URL
+
+	trades, err := CollectTrades(context.Background(), adapter, TradeQuery{
+		Ticker:    "btcusdt",
+		Market:    MarketLinearPerp,
+		StartTime: day.Add(12 * time.Hour),
+		EndTime:   day.Add(53 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, trades, 36)
+	first := day.Add(12 * time.Hour)
+	assert.Equal(t, &pb.Trade{
+		TradeId:    fmt.Sprintf("match-%d", first.Unix()),
+		Timestamp:  first.UnixMilli() + 500,
+		Price:      16500.5,
+		Quantity:   0.01,
+		Side:       TradeSideBuy,
+		TradeCount: 1,
+	}, trades[0])
+	assert.Equal(t, TradeSideSell, trades[1].Side)
+	assert.Equal(t, day.Add(47*time.Hour).UnixMilli()+500, trades[35].Timestamp)
+	assert.Equal(t, []string{
+		"/trading/BTCUSDT/BTCUSDT2023-01-01.csv.gz",
+		"/trading/BTCUSDT/BTCUSDT2023-01-02.csv.gz",
+		"/trading/BTCUSDT/BTCUSDT2023-01-03.csv.gz",
+	}, paths)
+
+	// Spot trades are handed over in pages until the limit is reached
+	var pages []int
+	err = adapter.GetTrades(context.Background(), TradeQuery{
+		Ticker:    "BTCUSDT",
+		StartTime: day.Add(time.Minute),
+		Limit:     1200,
+	}, func(page []*pb.Trade) error {
+		pages = append(pages, len(page))
+		if len(pages) == 1 {
+			assert.Equal(t, "2", page[0].TradeId)
+			assert.Equal(t, day.Add(time.Minute).UnixMilli(), page[0].Timestamp)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 200}, pages)
+
+	_, err = CollectTrades(context.Background(), adapter, TradeQuery{Ticker: "BTCUSDT"})
+	assert.ErrorIs(t, err, ErrTradesStartRequired)
+
+	t.Run("malformed trades", func(t *testing.T) {
+		_, err := CollectTrades(context.Background(), adapter, TradeQuery{Ticker: "ETHUSDT", StartTime: day, EndTime: day.Add(time.Hour)})
+
+		assert.ErrorIs(t, err, ErrMalformedTrade)
+		assert.ErrorContains(t, err, `bybit sent side "hold" (trade 1)`)
+	})
+
+	t.Run("no archive in the window", func(t *testing.T) {
+		_, err := CollectTrades(context.Background(), adapter, TradeQuery{
+			Ticker:    "BTCUSTD",
+			StartTime: day,
+			EndTime:   day.Add(36 * time.Hour),
+		})
+
+		assert.ErrorIs(t, err, ErrUnknownSymbol)
+		assert.ErrorContains(t, err, "bybit has no spot trade archives of BTCUSTD from 2023-01-01 to 2023-01-02")
+	})
+
+	t.Run("current day", func(t *testing.T) {
+		paths = nil
+
+		_, err := CollectTrades(context.Background(), adapter, TradeQuery{
+			Ticker:    "BTCUSDT",
+			StartTime: time.Now().Add(-time.Minute),
+		})
+
+		assert.ErrorIs(t, err, ErrTradesNotArchived)
+		assert.Empty(t, paths)
+	})
+}
+
+// TestBybitAdapter_GetOrderBook tests requesting order books per category
+func TestBybitAdapter_GetOrderBook(t *testing.T) {
+	var categories []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v5/market/orderbook", r.URL.Path)
+		query := r.URL.Query()
+		categories = append(categories, query.Get("category"))
+		require.Equal(t, "BTCUSDT", query.Get("symbol"))
+		require.Equal(t, "2", query.Get("limit"))
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"retMsg":  "OK",
+			"result": map[string]interface{}{
+				"s":  "BTCUSDT",
+				"b":  [][]string{{"16500.5", "1.25"}, {"16500.0", "3"}},
+				"a":  [][]string{{"16501.0", "0.5"}, {"16501.5", "2"}},
+				"ts": 1672531200123,
+				"u":  18521288,
+			},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+
+	book, err := adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "btcusdt", Market: MarketLinearPerp, Depth: 2})
+	require.NoError(t, err)
+	assert.Equal(t, &pb.OrderBookSnapshot{
+		ExchangeTime: 1672531200123,
+		UpdateId:     18521288,
+		Bids:         []*pb.OrderBookLevel{{Price: 16500.5, Quantity: 1.25}, {Price: 16500, Quantity: 3}},
+		Asks:         []*pb.OrderBookLevel{{Price: 16501, Quantity: 0.5}, {Price: 16501.5, Quantity: 2}},
+	}, book)
+
+	_, err = adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "BTCUSDT", Depth: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linear", "spot"}, categories)
+
+	// Spot order books are served up to 50 levels
+	_, err = adapter.GetOrderBook(context.Background(), OrderBookQuery{Ticker: "BTCUSDT", Depth: 100})
+	assert.ErrorIs(t, err, ErrUnsupportedOrderBookDepth)
+}
+
+// TestParseArchiveTimestamp tests the timestamp notations of Bybit trade archives
+func TestParseArchiveTimestamp(t *testing.T) {
+	tests := map[string]int64{
+		"1672531200.4848": 1672531200484,
+		"1672531200.5":    1672531200500,
+		"1672531200":      1672531200000,
+		"1672531200123":   1672531200123,
+	}
+	for value, expected := range tests {
+		timestamp, err := parseArchiveTimestamp(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, timestamp, value)
+	}
+
+	_, err := parseArchiveTimestamp("yesterday")
+	assert.Error(t, err)
+}
+
+// TestBybitAdapter_GetDerivativesStats tests paging open interest and global
+// long/short ratio samples
+func TestBybitAdapter_GetDerivativesStats(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	const count = 300
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		query := r.URL.Query()
+		require.Equal(t, "inverse", query.Get("category"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		startTime, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		endTime, _ := strconv.ParseInt(query.Get("endTime"), 10, 64)
+
+		// Bybit returns the newest samples of the range, newest first
+		list := []map[string]string{}
+		for i := count - 1; i >= 0 && len(list) < limit; i-- {
+			timestamp := start.Add(time.Duration(i) * time.Hour).UnixMilli()
+			if timestamp < startTime || timestamp > endTime {
+				continue
+			}
+			item := map[string]string{"timestamp": strconv.FormatInt(timestamp, 10)}
+			switch r.URL.Path {
+			case "/v5/market/open-interest":
+				require.Equal(t, "1h", query.Get("intervalTime"))
+				item["openInterest"] = strconv.Itoa(500000000 + i)
+			case "/v5/market/account-ratio":
+				require.Equal(t, "1h", query.Get("period"))
+				item["symbol"] = query.Get("symbol")
+				item["buyRatio"] = "0.75"
+				item["sellRatio"] = "0.25"
+			default:
+				t.Fatalf("unexpected path %s", r.URL.Path)
+			}
+			list = append(list, item)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"retMsg":  "OK",
+			"result":  map[string]interface{}{"list": list},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(server.URL)
+	adapter.baseURL = server.URL
+
+	stats, err := CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+		Ticker:    "BTCUSD",
+		Market:    MarketInversePerp,
+		Stat:      StatOpenInterest,
+		Period:    StatPeriod1h,
+		StartTime: start,
+		EndTime:   start.Add((count - 1) * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, stats, count)
+	assert.Equal(t, &pb.DerivativesStat{Timestamp: start.UnixMilli(), OpenInterest: 500000000}, stats[0])
+	assert.Equal(t, []string{"/v5/market/open-interest", "/v5/market/open-interest"}, paths)
+
+	paths = nil
+	stats, err = CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+		Ticker:  "BTCUSD",
+		Market:  MarketInversePerp,
+		Stat:    StatGlobalLongShortRatio,
+		Period:  StatPeriod1h,
+		EndTime: start.Add((count - 1) * time.Hour),
+		Limit:   24,
+	})
+	require.NoError(t, err)
+	require.Len(t, stats, 24)
+	assert.Equal(t, []string{"/v5/market/account-ratio"}, paths)
+	assert.Equal(t, start.Add((count-24)*time.Hour).UnixMilli(), stats[0].Timestamp)
+	assert.Equal(t, 3.0, stats[0].LongShortRatio)
+
+	// Bybit has no top trader ratios and no 2 hour period
+	_, err = CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+		Ticker: "BTCUSDT", Stat: StatTopTraderLongShortRatio, Period: StatPeriod1h,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedDerivativesStat)
+	_, err = CollectDerivativesStats(context.Background(), adapter, DerivativesStatsQuery{
+		Ticker: "BTCUSDT", Stat: StatOpenInterest, Period: StatPeriod2h,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedStatPeriod)
+}
+
 // TestBybitAdapter_Integration tests the real implementation
 // It's skipped by default to avoid network dependencies during unit testing
 func TestBybitAdapter_Integration(t *testing.T) {
 	t.Skip("Skipping integration test - requires network access")
 }
+
+// TestBybitAdapter_GetSymbols tests listing symbols page by page from the instruments info endpoint
+func TestBybitAdapter_GetSymbols(t *testing.T) {
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v5/market/instruments-info", r.URL.Path)
+		query := r.URL.Query()
+		cursors = append(cursors, query.Get("category")+":"+query.Get("cursor"))
+
+		instrument := func(symbol, contractType, status, launchTime string) map[string]interface{} {
+			return map[string]interface{}{
+				"symbol": symbol, "contractType": contractType, "status": status,
+				"baseCoin": "BTC", "quoteCoin": "USDT", "launchTime": launchTime,
+				"priceFilter": map[string]string{"tickSize": "0.10"},
+			}
+		}
+		var list []map[string]interface{}
+		next := ""
+		switch query.Get("category") + ":" + query.Get("cursor") {
+		case "linear:":
+			list = []map[string]interface{}{instrument("BTCUSDT", "LinearPerpetual", "Trading", "1584230400000")}
+			next = "page2"
+		case "linear:page2":
+			list = []map[string]interface{}{
+				instrument("BTC-27DEC24", "LinearFutures", "Closed", "1703836800000"),
+				instrument("NEWUSDT", "LinearPerpetual", "PreLaunch", "0"),
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"retCode": 0,
+			"result":  map[string]interface{}{"list": list, "nextPageCursor": next},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewBybitAdapter()
+	adapter.baseURL = server.URL
+
+	symbols, err := adapter.GetSymbols(context.Background(), MarketLinearPerp)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linear:", "linear:page2"}, cursors)
+	assert.Equal(t, []SymbolInfo{
+		{Symbol: "BTCUSDT", Base: "BTC", Quote: "USDT", Status: SymbolStatusTrading, TickSize: "0.10", ListingTime: time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{Symbol: "NEWUSDT", Base: "BTC", Quote: "USDT", Status: SymbolStatusPending, TickSize: "0.10"},
+	}, symbols)
+
+	t.Run("dated futures are listed from both categories", func(t *testing.T) {
+		cursors = nil
+
+		symbols, err := adapter.GetSymbols(context.Background(), MarketDatedFuture)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linear:", "linear:page2", "inverse:"}, cursors)
+		require.Len(t, symbols, 1)
+		assert.Equal(t, SymbolStatusDelisted, symbols[0].Status)
+	})
+}
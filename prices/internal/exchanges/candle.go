@@ -0,0 +1,144 @@
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// CandleSchemaVersion is the version of the candle fields filled in by the adapters
+const CandleSchemaVersion = 2
+
+// ErrMalformedCandle is returned when an exchange sends a candle value that cannot be parsed
+var ErrMalformedCandle = errors.New("malformed candle")
+
+// newCandle creates a candle with its open and close times, legacy date and schema version set
+func newCandle(openTime int64, interval Interval) *pb.PricesResponse {
+	open := time.UnixMilli(openTime).UTC()
+	return &pb.PricesResponse{
+		Date:          open.Format("2006-01-02"),
+		OpenTime:      openTime,
+		CloseTime:     interval.CloseTime(open).UnixMilli(),
+		SchemaVersion: CandleSchemaVersion,
+	}
+}
+
+// priceSeriesUnavailableFields lists the candle fields mark, index and premium
+// index candles never carry, as they are not built from trades
+var priceSeriesUnavailableFields = []pb.CandleField{
+	pb.CandleField_CANDLE_FIELD_VOLUME,
+	pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TRADE_COUNT,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+}
+
+// newPriceSeriesCandle creates a candle of a mark, index or premium index series,
+// which carry prices only
+func newPriceSeriesCandle(exchange string, openTime int64, interval Interval, open, high, low, close string) (*pb.PricesResponse, error) {
+	parser := decimalParser{exchange: exchange}
+	candle := newCandle(openTime, interval)
+	candle.Open = parser.float("open", open)
+	candle.High = parser.float("high", high)
+	candle.Low = parser.float("low", low)
+	candle.Close = parser.float("close", close)
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (candle opening at %d)", parser.err, openTime)
+	}
+
+	candle.UnavailableFields = priceSeriesUnavailableFields
+	candle.Decimals = &pb.DecimalValues{
+		Open:  open,
+		High:  high,
+		Low:   low,
+		Close: close,
+	}
+	return candle, nil
+}
+
+// decimalParser converts the decimal strings of one exchange candle, keeping the
+// first failure so a converter can report it instead of sending a zero value
+type decimalParser struct {
+	exchange string
+	err      error
+
+	// malformed is the error failures wrap; ErrMalformedCandle when nil
+	malformed error
+}
+
+// fail records the failure to parse a field
+func (p *decimalParser) fail(field, value string) {
+	malformed := p.malformed
+	if malformed == nil {
+		malformed = ErrMalformedCandle
+	}
+	p.err = fmt.Errorf("%w: %s sent %s %q", malformed, p.exchange, field, value)
+}
+
+// float parses a decimal value; empty, non-numeric and non-finite values are errors
+func (p *decimalParser) float(field, value string) float64 {
+	if p.err != nil {
+		return 0
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		p.fail(field, value)
+		return 0
+	}
+	return parsed
+}
+
+// int parses an integer value such as a timestamp
+func (p *decimalParser) int(field, value string) int64 {
+	if p.err != nil {
+		return 0
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.fail(field, value)
+		return 0
+	}
+	return parsed
+}
+
+// decimalSum adds decimals exactly, printing the sum with as many fraction digits
+// as the most precise value added
+type decimalSum struct {
+	sum   big.Rat
+	scale int
+}
+
+// add adds a value that has scale fraction digits
+func (s *decimalSum) add(value *big.Rat, scale int) {
+	s.sum.Add(&s.sum, value)
+	if scale > s.scale {
+		s.scale = scale
+	}
+}
+
+// float returns the sum as the nearest float64
+func (s *decimalSum) float() float64 {
+	value, _ := s.sum.Float64()
+	return value
+}
+
+// String returns the exact sum as a decimal string
+func (s *decimalSum) String() string {
+	return s.sum.FloatString(s.scale)
+}
+
+// decimalScale returns the number of fraction digits of a decimal string
+func decimalScale(value string) int {
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		return len(value) - i - 1
+	}
+	return 0
+}
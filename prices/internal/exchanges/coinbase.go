@@ -0,0 +1,230 @@
+package exchanges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// coinbaseGranularities maps API intervals to Coinbase candle granularities in
+// seconds. Coinbase only offers these fixed granularities, so 4h, 1w and 1M are
+// not available.
+var coinbaseGranularities = map[Interval]string{
+	Interval1m:  "60",
+	Interval5m:  "300",
+	Interval15m: "900",
+	Interval1h:  "3600",
+	Interval1d:  "86400",
+}
+
+// coinbaseMarkets lists the markets Coinbase candles are available for
+var coinbaseMarkets = map[Market]string{
+	MarketSpot: "spot",
+}
+
+// coinbaseMaxBuckets is the largest number of candles Coinbase returns per request
+const coinbaseMaxBuckets = 300
+
+// coinbaseBaseURL is the Coinbase Exchange REST API address
+const coinbaseBaseURL = "https://api.exchange.coinbase.com"
+
+// coinbaseUnavailableFields lists the candle fields Coinbase candles do not carry
+var coinbaseUnavailableFields = []pb.CandleField{
+	pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TRADE_COUNT,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+}
+
+// CoinbaseAdapter implements the adapter for Coinbase Exchange
+type CoinbaseAdapter struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewCoinbaseAdapter creates a new adapter for Coinbase
+func NewCoinbaseAdapter() *CoinbaseAdapter {
+	return &CoinbaseAdapter{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: coinbaseBaseURL,
+	}
+}
+
+// GetName returns the name of the exchange
+func (a *CoinbaseAdapter) GetName() string {
+	return "coinbase"
+}
+
+// GetHistoricalPrices retrieves historical price data from Coinbase
+func (a *CoinbaseAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	log.Printf("Getting historical prices from Coinbase for %s (%s, %s)", query.Ticker, query.Market, query.Interval)
+
+	granularity, err := mapInterval(a.GetName(), coinbaseGranularities, query.Interval)
+	if err != nil {
+		return err
+	}
+	if err := requireLastPrices(a.GetName(), query); err != nil {
+		return err
+	}
+	if _, err := mapMarket(a.GetName(), coinbaseMarkets, query.Market); err != nil {
+		return err
+	}
+
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	// Every request covers at most 300 buckets, so ranges are walked in windows
+	// of 300 granularities
+	p := pager{
+		interval: query.Interval,
+		pageSize: coinbaseMaxBuckets,
+		windowed: true,
+		fetch:    a.fetchCandles(hyphenateTicker(query.Ticker), query.Interval, granularity),
+	}
+	return p.walk(ctx, query, handle)
+}
+
+// fetchCandles returns a page fetcher for the Coinbase product candles endpoint
+func (a *CoinbaseAdapter) fetchCandles(productID string, interval Interval, granularity string) pageFetcher {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+		// Coinbase always needs a window; the latest candles are the window of
+		// limit buckets that ends at end. Buckets without trades are left out.
+		if start.IsZero() {
+			start = end.Add(-time.Duration(limit-1) * interval.Duration()).Truncate(interval.Duration())
+		}
+
+		params := url.Values{}
+		params.Set("granularity", granularity)
+		params.Set("start", start.UTC().Format(time.RFC3339))
+		params.Set("end", end.UTC().Format(time.RFC3339))
+
+		// Fetch data from Coinbase API
+		var rows [][]json.Number
+		endpoint := fmt.Sprintf("%s/products/%s/candles", a.baseURL, url.PathEscape(productID))
+		if err := getJSON(ctx, a.client, endpoint, params, &rows); err != nil {
+			return nil, fmt.Errorf("error fetching data from Coinbase: %v", err)
+		}
+
+		// Convert data to response format
+		prices := make([]*pb.PricesResponse, 0, len(rows))
+		for _, row := range rows {
+			candle, err := coinbaseCandleToCandle(row, interval)
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, candle)
+		}
+
+		return prices, nil
+	}
+}
+
+// coinbaseCandleToCandle converts a Coinbase candle row, [time, low, high, open,
+// close, volume] with the time in epoch seconds, into a candle
+func coinbaseCandleToCandle(row []json.Number, interval Interval) (*pb.PricesResponse, error) {
+	if len(row) < 6 {
+		return nil, fmt.Errorf("%w: coinbase sent a candle with %d fields", ErrMalformedCandle, len(row))
+	}
+
+	parser := decimalParser{exchange: "coinbase"}
+	seconds := parser.int("time", row[0].String())
+	low := parser.float("low", row[1].String())
+	high := parser.float("high", row[2].String())
+	open := parser.float("open", row[3].String())
+	close := parser.float("close", row[4].String())
+	volume := parser.float("volume", row[5].String())
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (candle at %s)", parser.err, row[0])
+	}
+
+	candle := newCandle(seconds*1000, interval)
+	candle.Open = open
+	candle.High = high
+	candle.Low = low
+	candle.Close = close
+	candle.Volume = volume
+	candle.UnavailableFields = coinbaseUnavailableFields
+	candle.Decimals = &pb.DecimalValues{
+		Open:   row[3].String(),
+		High:   row[2].String(),
+		Low:    row[1].String(),
+		Close:  row[4].String(),
+		Volume: row[5].String(),
+	}
+
+	return candle, nil
+}
+
+// GetListingTime finds the open time of the first daily candle of a product. As
+// days without trades are left out, a product only looks unlisted at a day when
+// none of the hundred days before it had trades.
+func (a *CoinbaseAdapter) GetListingTime(ctx context.Context, query ListingQuery) (time.Time, error) {
+	return searchListingTime(ctx, a, query)
+}
+
+// SupportedMarkets returns the markets Coinbase serves candles for
+func (a *CoinbaseAdapter) SupportedMarkets() []Market {
+	return marketsOf(coinbaseMarkets)
+}
+
+// SupportedIntervals returns the candle granularities Coinbase serves
+func (a *CoinbaseAdapter) SupportedIntervals() []Interval {
+	return intervalsOf(coinbaseGranularities)
+}
+
+// MaxPageSize returns the largest number of candles Coinbase returns per request
+func (a *CoinbaseAdapter) MaxPageSize() int {
+	return coinbaseMaxBuckets
+}
+
+// coinbaseProduct is a product of the Coinbase products endpoint
+type coinbaseProduct struct {
+	ID              string `json:"id"`
+	BaseCurrency    string `json:"base_currency"`
+	QuoteCurrency   string `json:"quote_currency"`
+	QuoteIncrement  string `json:"quote_increment"`
+	Status          string `json:"status"`
+	TradingDisabled bool   `json:"trading_disabled"`
+}
+
+// GetSymbols lists the products of Coinbase. Coinbase does not publish when a
+// product was listed.
+func (a *CoinbaseAdapter) GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error) {
+	if _, err := mapMarket(a.GetName(), coinbaseMarkets, market); err != nil {
+		return nil, err
+	}
+
+	var products []coinbaseProduct
+	if err := getJSON(ctx, a.client, a.baseURL+"/products", nil, &products); err != nil {
+		return nil, fmt.Errorf("error fetching symbols from Coinbase: %v", err)
+	}
+
+	symbols := make([]SymbolInfo, 0, len(products))
+	for _, product := range products {
+		status := SymbolStatus(product.Status)
+		switch {
+		case product.Status == "online" && product.TradingDisabled:
+			status = SymbolStatusHalted
+		case product.Status == "online":
+			status = SymbolStatusTrading
+		case product.Status == "delisted":
+			status = SymbolStatusDelisted
+		}
+		symbols = append(symbols, SymbolInfo{
+			Symbol:   product.ID,
+			Base:     product.BaseCurrency,
+			Quote:    product.QuoteCurrency,
+			Status:   status,
+			TickSize: product.QuoteIncrement,
+		})
+	}
+	return symbols, nil
+}
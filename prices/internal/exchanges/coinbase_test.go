@@ -0,0 +1,200 @@
+package exchanges
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCoinbaseAdapter_GetName tests the GetName method
+func TestCoinbaseAdapter_GetName(t *testing.T) {
+	adapter := NewCoinbaseAdapter()
+	assert.Equal(t, "coinbase", adapter.GetName())
+}
+
+// TestNewCoinbaseAdapter tests the creation of a new adapter
+func TestNewCoinbaseAdapter(t *testing.T) {
+	adapter := NewCoinbaseAdapter()
+	assert.NotNil(t, adapter)
+	assert.NotNil(t, adapter.client)
+	assert.Equal(t, coinbaseBaseURL, adapter.baseURL)
+}
+
+// TestCoinbaseAdapter_IntervalMapping tests translation of API intervals to Coinbase granularities
+func TestCoinbaseAdapter_IntervalMapping(t *testing.T) {
+	granularity, err := mapInterval("coinbase", coinbaseGranularities, Interval15m)
+	require.NoError(t, err)
+	assert.Equal(t, "900", granularity)
+
+	// Coinbase has no 4 hour, weekly or monthly granularity
+	for _, interval := range []Interval{Interval4h, Interval1w, Interval1M} {
+		_, err = mapInterval("coinbase", coinbaseGranularities, interval)
+		assert.ErrorIs(t, err, ErrUnsupportedInterval)
+	}
+}
+
+// TestCoinbaseCandleToCandle tests the candle fields filled from a Coinbase candle
+func TestCoinbaseCandleToCandle(t *testing.T) {
+	row := []json.Number{"1672531200", "16400.01", "16600.5", "16500", "16550.25", "1234.56789012"}
+
+	candle, err := coinbaseCandleToCandle(row, Interval1d)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1672531200000), candle.OpenTime)
+	assert.Equal(t, int64(1672617599999), candle.CloseTime)
+	assert.Equal(t, 16500.0, candle.Open)
+	assert.Equal(t, 16600.5, candle.High)
+	assert.Equal(t, 16400.01, candle.Low)
+	assert.Equal(t, 16550.25, candle.Close)
+	assert.Equal(t, "1234.56789012", candle.Decimals.Volume)
+	assert.Len(t, candle.UnavailableFields, 4)
+
+	_, err = coinbaseCandleToCandle(row[:3], Interval1d)
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+}
+
+// newCoinbaseStandIn starts a local stand-in for the Coinbase candles endpoint
+// serving count hourly candles from start
+func newCoinbaseStandIn(t *testing.T, start time.Time, count int) (*httptest.Server, *[]time.Duration) {
+	var windows []time.Duration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/products/BTC-USD/candles", r.URL.Path)
+		require.Equal(t, "3600", r.URL.Query().Get("granularity"))
+
+		from, err := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		require.NoError(t, err)
+		to, err := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+		require.NoError(t, err)
+		windows = append(windows, to.Sub(from))
+
+		// Coinbase rejects windows of more than 300 buckets
+		if to.Sub(from) > coinbaseMaxBuckets*time.Hour {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "granularity too small for the requested time range"})
+			return
+		}
+
+		// Candles are returned newest first
+		rows := [][]interface{}{}
+		for i := count - 1; i >= 0; i-- {
+			openTime := start.Add(time.Duration(i) * time.Hour)
+			if openTime.Before(from) || openTime.After(to) {
+				continue
+			}
+			price := 30000 + i
+			rows = append(rows, []interface{}{openTime.Unix(), price - 1, price + 1, price, price, 2.5})
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	t.Cleanup(server.Close)
+	return server, &windows
+}
+
+// TestCoinbaseAdapter_GetHistoricalPricesRange tests splitting a range into 300-bucket windows
+func TestCoinbaseAdapter_GetHistoricalPricesRange(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, windows := newCoinbaseStandIn(t, start, 1000)
+
+	adapter := NewCoinbaseAdapter()
+	adapter.baseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:    "BTCUSD",
+		Interval:  Interval1h,
+		StartTime: start,
+		EndTime:   start.Add(749 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 750)
+	assert.Len(t, *windows, 3)
+
+	// Windows are merged into one chronological series
+	for i, price := range prices {
+		assert.Equal(t, start.Add(time.Duration(i)*time.Hour).UnixMilli(), price.OpenTime)
+		assert.Equal(t, float64(30000+i), price.Close)
+	}
+}
+
+// TestCoinbaseAdapter_GetHistoricalPricesLatest tests fetching the latest candles by limit
+func TestCoinbaseAdapter_GetHistoricalPricesLatest(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, windows := newCoinbaseStandIn(t, start, 1000)
+
+	adapter := NewCoinbaseAdapter()
+	adapter.baseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "BTC-USD",
+		Interval: Interval1h,
+		EndTime:  start.Add(999 * time.Hour),
+		Limit:    400,
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 400)
+	assert.Len(t, *windows, 2)
+	assert.Equal(t, start.Add(600*time.Hour).UnixMilli(), prices[0].OpenTime)
+	assert.Equal(t, start.Add(999*time.Hour).UnixMilli(), prices[399].OpenTime)
+}
+
+// TestCoinbaseAdapter_UnsupportedInterval tests that granularities Coinbase lacks are rejected
+func TestCoinbaseAdapter_UnsupportedInterval(t *testing.T) {
+	adapter := NewCoinbaseAdapter()
+
+	_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "BTCUSD",
+		Interval: Interval4h,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedInterval)
+}
+
+// TestCoinbaseAdapter_UnsupportedMarket tests that derivatives markets are rejected
+func TestCoinbaseAdapter_UnsupportedMarket(t *testing.T) {
+	adapter := NewCoinbaseAdapter()
+
+	_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "BTCUSD",
+		Market:   MarketLinearPerp,
+		Interval: Interval1h,
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
+
+// TestCoinbaseAdapter_Integration tests the real implementation
+// It's skipped by default to avoid network dependencies during unit testing
+func TestCoinbaseAdapter_Integration(t *testing.T) {
+	t.Skip("Skipping integration test - requires network access")
+}
+
+// TestCoinbaseAdapter_GetSymbols tests listing the Coinbase products
+func TestCoinbaseAdapter_GetSymbols(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/products", r.URL.Path)
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"id": "BTC-USD", "base_currency": "BTC", "quote_currency": "USD", "quote_increment": "0.01", "status": "online"},
+			{"id": "ETH-EUR", "base_currency": "ETH", "quote_currency": "EUR", "quote_increment": "0.01", "status": "online", "trading_disabled": true},
+			{"id": "XYZ-USD", "base_currency": "XYZ", "quote_currency": "USD", "quote_increment": "0.0001", "status": "delisted"},
+		})
+	}))
+	defer server.Close()
+
+	adapter := NewCoinbaseAdapter()
+	adapter.baseURL = server.URL
+
+	symbols, err := adapter.GetSymbols(context.Background(), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, []SymbolInfo{
+		{Symbol: "BTC-USD", Base: "BTC", Quote: "USD", Status: SymbolStatusTrading, TickSize: "0.01"},
+		{Symbol: "ETH-EUR", Base: "ETH", Quote: "EUR", Status: SymbolStatusHalted, TickSize: "0.01"},
+		{Symbol: "XYZ-USD", Base: "XYZ", Quote: "USD", Status: SymbolStatusDelisted, TickSize: "0.0001"},
+	}, symbols)
+
+	_, err = adapter.GetSymbols(context.Background(), MarketLinearPerp)
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
@@ -0,0 +1,462 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// CompositeExchange is the name of the synthetic exchange merging the candles of
+// several exchanges into an index
+const CompositeExchange = "composite"
+
+// DefaultMaxDeviation is the default largest relative distance of the close of a
+// venue from the median close of a bar before the venue is left out as an outlier
+const DefaultMaxDeviation = 0.05
+
+// Reasons a venue is left out of a composite candle
+const (
+	// excludedStale marks a venue that traded nothing during the bar, so that its
+	// price was only carried over from an earlier one
+	excludedStale = "stale"
+
+	// excludedOutlier marks a venue whose close strayed too far from the others
+	excludedOutlier = "outlier"
+)
+
+// minOutlierVenues is the number of venues a bar needs before outliers are told
+// apart; with fewer there is no majority to compare a venue with
+const minOutlierVenues = 3
+
+// compositeWindow is the number of candles fetched from every venue at a time,
+// bounding the candles held in memory while they are merged
+const compositeWindow = 500
+
+// Constituent is an exchange contributing to the composite index
+type Constituent struct {
+	Exchange string
+
+	// Weight scales the volume of the exchange in the index
+	Weight float64
+}
+
+// DefaultConstituents returns the constituents of the composite index when none
+// are configured: every exchange, weighted by volume alone
+func DefaultConstituents() []Constituent {
+	return []Constituent{
+		{Exchange: "binance", Weight: 1},
+		{Exchange: "bybit", Weight: 1},
+		{Exchange: "okx", Weight: 1},
+		{Exchange: "coinbase", Weight: 1},
+		{Exchange: "kraken", Weight: 1},
+	}
+}
+
+// ParseConstituents parses a comma-separated list of constituents written as
+// exchange[:weight], such as binance:2,coinbase,kraken:0.5; weights default to 1
+func ParseConstituents(value string) ([]Constituent, error) {
+	var constituents []Constituent
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		exchange, weightValue, hasWeight := strings.Cut(entry, ":")
+		exchange = strings.ToLower(strings.TrimSpace(exchange))
+		if exchange == "" || exchange == CompositeExchange {
+			return nil, fmt.Errorf("invalid constituent %q, expected exchange[:weight]", entry)
+		}
+		if seen[exchange] {
+			return nil, fmt.Errorf("invalid constituent %q: %s is listed twice", entry, exchange)
+		}
+		seen[exchange] = true
+
+		constituent := Constituent{Exchange: exchange, Weight: 1}
+		if hasWeight {
+			weight, err := strconv.ParseFloat(weightValue, 64)
+			if err != nil || weight <= 0 || math.IsInf(weight, 0) {
+				return nil, fmt.Errorf("invalid constituent %q: weight must be a positive number", entry)
+			}
+			constituent.Weight = weight
+		}
+		constituents = append(constituents, constituent)
+	}
+	if len(constituents) == 0 {
+		return nil, errors.New("no constituents listed")
+	}
+	return constituents, nil
+}
+
+// CompositeAdapter merges the candles of several exchanges into a volume-weighted
+// index. Every bar is the average of the venues that traded during it, each
+// weighted by its volume times its constituent weight; venues that traded
+// nothing, or whose close strays from the median of the bar, are left out.
+type CompositeAdapter struct {
+	// factory serves the adapters of the constituents and resolves symbols for them
+	factory *ExchangeFactory
+
+	constituents []Constituent
+
+	// maxDeviation is the largest relative distance of the close of a venue from
+	// the median close of a bar before it is left out as an outlier
+	maxDeviation float64
+
+	// now tells the time, bounding the range of the latest candles
+	now func() time.Time
+}
+
+// NewCompositeAdapter creates a composite adapter over the constituents, looked
+// up in the factory it is registered in
+func NewCompositeAdapter(factory *ExchangeFactory, constituents []Constituent, maxDeviation float64) *CompositeAdapter {
+	return &CompositeAdapter{
+		factory:      factory,
+		constituents: constituents,
+		maxDeviation: maxDeviation,
+		now:          time.Now,
+	}
+}
+
+// GetName returns the name of the exchange
+func (a *CompositeAdapter) GetName() string {
+	return CompositeExchange
+}
+
+// Constituents returns the exchanges merged into the index
+func (a *CompositeAdapter) Constituents() []Constituent {
+	return a.constituents
+}
+
+// SupportedMarkets returns the markets any constituent serves candles for
+func (a *CompositeAdapter) SupportedMarkets() []Market {
+	served := make(map[Market]bool)
+	for _, info := range a.constituentInfos() {
+		for _, market := range info.Markets {
+			served[market] = true
+		}
+	}
+
+	var markets []Market
+	for _, market := range allMarkets {
+		if served[market] {
+			markets = append(markets, market)
+		}
+	}
+	return markets
+}
+
+// SupportedIntervals returns the intervals every constituent serves, so that
+// the bars of all venues line up
+func (a *CompositeAdapter) SupportedIntervals() []Interval {
+	infos := a.constituentInfos()
+	if len(infos) == 0 {
+		return nil
+	}
+
+	counts := make(map[Interval]int)
+	for _, info := range infos {
+		for _, interval := range info.Intervals {
+			counts[interval]++
+		}
+	}
+	intervals := make([]Interval, 0, len(counts))
+	for interval, count := range counts {
+		if count == len(infos) {
+			intervals = append(intervals, interval)
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Duration() < intervals[j].Duration() })
+	return intervals
+}
+
+// MaxPageSize returns the number of candles merged at a time
+func (a *CompositeAdapter) MaxPageSize() int {
+	return compositeWindow
+}
+
+// constituentInfos describes the registered exchanges among the constituents
+func (a *CompositeAdapter) constituentInfos() []ExchangeInfo {
+	var infos []ExchangeInfo
+	for _, constituent := range a.constituents {
+		adapter, ok := a.factory.GetAdapter(constituent.Exchange)
+		if !ok {
+			continue
+		}
+		info := ExchangeInfo{Markets: []Market{DefaultMarket}, Intervals: intervalsOf(supportedIntervals)}
+		if provider, ok := adapter.(CapabilityProvider); ok {
+			info.Markets = provider.SupportedMarkets()
+			info.Intervals = provider.SupportedIntervals()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// venue is a constituent resolved for a query
+type venue struct {
+	Constituent
+	adapter ExchangeAdapter
+	ticker  string
+}
+
+// GetHistoricalPrices fetches the candles of a canonical BASE/QUOTE symbol from
+// every constituent in parallel, passing the merged index candles to handle
+// window by window in chronological order
+func (a *CompositeAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	if err := requireLastPrices(a.GetName(), query); err != nil {
+		return err
+	}
+	if _, ok := supportedIntervals[query.Interval]; !ok {
+		return fmt.Errorf("%w: %s does not support %s", ErrUnsupportedInterval, a.GetName(), query.Interval)
+	}
+	if !IsCanonicalSymbol(query.Ticker) {
+		return fmt.Errorf("%w: %s merges exchanges, so it takes a canonical BASE/QUOTE symbol such as BTC/USDT, not %s", ErrUnknownSymbol, a.GetName(), query.Ticker)
+	}
+
+	venues, err := a.resolve(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	b := newBuckets(query.Interval, 0)
+	end := query.EndTime
+	if end.IsZero() {
+		end = a.now()
+	}
+
+	// Candles open within the range; without a start the latest candles are merged
+	first := b.open(query.StartTime)
+	if query.StartTime.IsZero() {
+		first = b.add(b.open(end), -int(max(query.Limit, 1)-1))
+	} else if first.Before(query.StartTime) {
+		first = b.add(first, 1)
+	}
+
+	var sent int64
+	for from := first; !from.After(end); from = b.add(from, compositeWindow) {
+		to := b.add(from, compositeWindow).Add(-time.Millisecond)
+		if to.After(end) {
+			to = end
+		}
+
+		page, err := a.fetchWindow(ctx, query, &venues, from, to)
+		if err != nil {
+			return err
+		}
+		if query.Limit > 0 && sent+int64(len(page)) > query.Limit {
+			page = page[:query.Limit-sent]
+		}
+		if len(page) > 0 {
+			if err := handle(page); err != nil {
+				return err
+			}
+		}
+		sent += int64(len(page))
+		if query.Limit > 0 && sent == query.Limit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// resolve looks up the ticker of the symbol on every constituent, leaving out
+// the constituents that are not registered or do not list it
+func (a *CompositeAdapter) resolve(ctx context.Context, query PriceQuery) ([]venue, error) {
+	var venues []venue
+	var firstErr error
+	for _, constituent := range a.constituents {
+		adapter, ok := a.factory.GetAdapter(constituent.Exchange)
+		if !ok {
+			continue
+		}
+		ticker, err := a.factory.ResolveSymbol(ctx, constituent.Exchange, query.Market, query.Ticker)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Leaving %s out of the %s index: %v", constituent.Exchange, query.Ticker, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		venues = append(venues, venue{Constituent: constituent, adapter: adapter, ticker: ticker})
+	}
+
+	if len(venues) == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("%w: %s has no registered constituents", ErrUnknownSymbol, a.GetName())
+	}
+	return venues, nil
+}
+
+// fetchWindow fetches the candles opening in [from, to] from every venue in
+// parallel and merges them. Venues that fail are left out for the rest of the
+// request; the window only fails when every venue does.
+func (a *CompositeAdapter) fetchWindow(ctx context.Context, query PriceQuery, venues *[]venue, from, to time.Time) ([]*pb.PricesResponse, error) {
+	candles := make([][]*pb.PricesResponse, len(*venues))
+	errs := make([]error, len(*venues))
+
+	var wg sync.WaitGroup
+	for i, v := range *venues {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candles[i], errs[i] = CollectHistoricalPrices(ctx, v.adapter, PriceQuery{
+				Ticker:    v.ticker,
+				Market:    query.Market,
+				Interval:  query.Interval,
+				StartTime: from,
+				EndTime:   to,
+			})
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	bars := make(map[int64][]venueCandle)
+	var healthy []venue
+	for i, v := range *venues {
+		if errs[i] != nil {
+			log.Printf("Leaving %s out of the %s index: %v", v.Exchange, query.Ticker, errs[i])
+			continue
+		}
+		healthy = append(healthy, v)
+		for _, candle := range candles[i] {
+			bars[candle.OpenTime] = append(bars[candle.OpenTime], venueCandle{exchange: v.Exchange, weight: v.Weight, candle: candle})
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, errs[0]
+	}
+	*venues = healthy
+
+	openTimes := make([]int64, 0, len(bars))
+	for openTime := range bars {
+		openTimes = append(openTimes, openTime)
+	}
+	sort.Slice(openTimes, func(i, j int) bool { return openTimes[i] < openTimes[j] })
+
+	page := make([]*pb.PricesResponse, 0, len(openTimes))
+	for _, openTime := range openTimes {
+		if candle := a.merge(openTime, query.Interval, bars[openTime]); candle != nil {
+			page = append(page, candle)
+		}
+	}
+	return page, nil
+}
+
+// venueCandle is the candle of a venue at a bar
+type venueCandle struct {
+	exchange string
+	weight   float64
+	candle   *pb.PricesResponse
+}
+
+// merge builds the index candle of a bar from the candles of the venues, or
+// returns nil when every venue is left out
+func (a *CompositeAdapter) merge(openTime int64, interval Interval, candles []venueCandle) *pb.PricesResponse {
+	sort.Slice(candles, func(i, j int) bool { return candles[i].exchange < candles[j].exchange })
+
+	excluded := make([]string, len(candles))
+	var closes []float64
+	for i, c := range candles {
+		if c.candle.Volume <= 0 {
+			excluded[i] = excludedStale
+			continue
+		}
+		closes = append(closes, c.candle.Close)
+	}
+
+	if len(closes) >= minOutlierVenues {
+		median := medianOf(closes)
+		for i, c := range candles {
+			if excluded[i] == "" && math.Abs(c.candle.Close-median) > a.maxDeviation*median {
+				excluded[i] = excludedOutlier
+			}
+		}
+	}
+
+	var total float64
+	for i, c := range candles {
+		if excluded[i] == "" {
+			total += c.weight * c.candle.Volume
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	merged := newCandle(openTime, interval)
+	unavailable := make(map[pb.CandleField]bool)
+	for i, c := range candles {
+		venue := &pb.CompositeVenue{Exchange: c.exchange, Close: c.candle.Close, Volume: c.candle.Volume, Excluded: excluded[i]}
+		merged.Venues = append(merged.Venues, venue)
+		if excluded[i] != "" {
+			continue
+		}
+
+		venue.Share = c.weight * c.candle.Volume / total
+		merged.Open += venue.Share * c.candle.Open
+		merged.High += venue.Share * c.candle.High
+		merged.Low += venue.Share * c.candle.Low
+		merged.Close += venue.Share * c.candle.Close
+
+		// Volumes add up across venues; a total is unknown once a venue lacks it
+		merged.Volume += c.candle.Volume
+		merged.QuoteVolume += c.candle.QuoteVolume
+		merged.TradeCount += c.candle.TradeCount
+		merged.TakerBuyBaseVolume += c.candle.TakerBuyBaseVolume
+		merged.TakerBuyQuoteVolume += c.candle.TakerBuyQuoteVolume
+		for _, field := range c.candle.UnavailableFields {
+			unavailable[field] = true
+		}
+	}
+
+	for _, field := range []pb.CandleField{
+		pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME,
+		pb.CandleField_CANDLE_FIELD_TRADE_COUNT,
+		pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+		pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+	} {
+		if !unavailable[field] {
+			continue
+		}
+		merged.UnavailableFields = append(merged.UnavailableFields, field)
+		switch field {
+		case pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME:
+			merged.QuoteVolume = 0
+		case pb.CandleField_CANDLE_FIELD_TRADE_COUNT:
+			merged.TradeCount = 0
+		case pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME:
+			merged.TakerBuyBaseVolume = 0
+		case pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME:
+			merged.TakerBuyQuoteVolume = 0
+		}
+	}
+	return merged
+}
+
+// medianOf returns the median of values, sorting them in place
+func medianOf(values []float64) float64 {
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+	return values[middle]
+}
@@ -0,0 +1,176 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// venueAdapter serves fixed hourly candles of a venue within the queried range
+type venueAdapter struct {
+	name    string
+	candles []*pb.PricesResponse
+	err     error
+	tickers []string
+}
+
+func (a *venueAdapter) GetName() string {
+	return a.name
+}
+
+func (a *venueAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	a.tickers = append(a.tickers, query.Ticker)
+	if a.err != nil {
+		return a.err
+	}
+	var page []*pb.PricesResponse
+	for _, candle := range a.candles {
+		open := time.UnixMilli(candle.OpenTime)
+		if !open.Before(query.StartTime) && !open.After(query.EndTime) {
+			page = append(page, candle)
+		}
+	}
+	return handle(page)
+}
+
+// venueCandles creates hourly candles from start with the given closes and
+// volumes; the other prices sit one above and below the close
+func venueCandles(start time.Time, closes, volumes []float64) []*pb.PricesResponse {
+	candles := make([]*pb.PricesResponse, len(closes))
+	for i := range closes {
+		candle := newCandle(start.Add(time.Duration(i)*time.Hour).UnixMilli(), Interval1h)
+		candle.Open, candle.High, candle.Low, candle.Close = closes[i], closes[i]+1, closes[i]-1, closes[i]
+		candle.Volume = volumes[i]
+		candle.QuoteVolume = closes[i] * volumes[i]
+		candle.TradeCount = 10
+		candles[i] = candle
+	}
+	return candles
+}
+
+// TestParseConstituents tests parsing configured constituents and weights
+func TestParseConstituents(t *testing.T) {
+	constituents, err := ParseConstituents("Binance:2, coinbase ,kraken:0.5")
+
+	require.NoError(t, err)
+	assert.Equal(t, []Constituent{{"binance", 2}, {"coinbase", 1}, {"kraken", 0.5}}, constituents)
+
+	for _, value := range []string{"", " , ", "binance:0", "binance:-1", "binance:x", "binance,binance:2", "composite", ":2"} {
+		_, err := ParseConstituents(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// TestCompositeAdapter_GetHistoricalPrices tests merging the candles of several
+// venues into a volume-weighted index
+func TestCompositeAdapter_GetHistoricalPrices(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	alpha := &venueAdapter{name: "alpha", candles: venueCandles(start, []float64{100, 100, 100, 100}, []float64{1, 1, 1, 0})}
+	beta := &venueAdapter{name: "beta", candles: venueCandles(start, []float64{102, 100}, []float64{1, 1})}
+	gamma := &venueAdapter{name: "gamma", candles: venueCandles(start, []float64{101, 130, 100, 100}, []float64{2, 1, 0, 0})}
+	factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"alpha": alpha, "beta": beta, "gamma": gamma}}
+	composite := NewCompositeAdapter(factory, []Constituent{{"alpha", 1}, {"beta", 2}, {"gamma", 1}, {"unregistered", 1}}, DefaultMaxDeviation)
+	factory.RegisterAdapter(composite)
+
+	query := PriceQuery{Ticker: "BTC/USDT", Interval: Interval1h, StartTime: start, EndTime: start.Add(4 * time.Hour)}
+	candles, err := CollectHistoricalPrices(context.Background(), composite, query)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BTC/USDT"}, alpha.tickers)
+
+	// Every bar with a venue that traded is merged; the last had no trades at all
+	require.Len(t, candles, 3)
+
+	// Shares are volume times weight: alpha 1, beta 2 and gamma 2 out of 5
+	first := candles[0]
+	assert.Equal(t, start.UnixMilli(), first.OpenTime)
+	assert.Equal(t, start.Add(time.Hour-time.Millisecond).UnixMilli(), first.CloseTime)
+	assert.InDelta(t, 0.2*100+0.4*102+0.4*101, first.Close, 1e-9)
+	assert.InDelta(t, first.Close+1, first.High, 1e-9)
+	assert.Equal(t, 4.0, first.Volume)
+	assert.Equal(t, int64(30), first.TradeCount)
+	assert.Equal(t, uint32(CandleSchemaVersion), first.SchemaVersion)
+	require.Len(t, first.Venues, 3)
+	assert.Equal(t, "alpha", first.Venues[0].Exchange)
+	assert.InDelta(t, 0.2, first.Venues[0].Share, 1e-9)
+	assert.InDelta(t, 0.4, first.Venues[1].Share, 1e-9)
+	assert.Empty(t, first.Venues[2].Excluded)
+
+	// A venue straying from the median is an outlier
+	second := candles[1]
+	assert.InDelta(t, 100.0, second.Close, 1e-9)
+	assert.Equal(t, 2.0, second.Volume)
+	assert.Equal(t, "outlier", second.Venues[2].Excluded)
+	assert.Zero(t, second.Venues[2].Share)
+	assert.InDelta(t, 2.0/3, second.Venues[1].Share, 1e-9)
+
+	// A venue that traded nothing only carried its price over
+	third := candles[2]
+	require.Len(t, third.Venues, 2)
+	assert.Equal(t, "stale", third.Venues[1].Excluded)
+	assert.InDelta(t, 1.0, third.Venues[0].Share, 1e-9)
+
+	t.Run("limit", func(t *testing.T) {
+		query := query
+		query.Limit = 2
+
+		candles, err := CollectHistoricalPrices(context.Background(), composite, query)
+
+		require.NoError(t, err)
+		assert.Len(t, candles, 2)
+	})
+
+	t.Run("latest candles", func(t *testing.T) {
+		composite.now = func() time.Time { return start.Add(2*time.Hour + 30*time.Minute) }
+		defer func() { composite.now = time.Now }()
+
+		candles, err := CollectHistoricalPrices(context.Background(), composite, PriceQuery{Ticker: "BTC/USDT", Interval: Interval1h, Limit: 2})
+
+		require.NoError(t, err)
+		require.Len(t, candles, 2)
+		assert.Equal(t, start.Add(time.Hour).UnixMilli(), candles[0].OpenTime)
+	})
+
+	t.Run("failing venues are left out", func(t *testing.T) {
+		beta.err = errors.New("API error")
+		defer func() { beta.err = nil }()
+
+		candles, err := CollectHistoricalPrices(context.Background(), composite, query)
+
+		require.NoError(t, err)
+		require.Len(t, candles, 3)
+		assert.InDelta(t, (100+2*101)/3.0, candles[0].Close, 1e-9)
+		for _, candle := range candles {
+			for _, venue := range candle.Venues {
+				assert.NotEqual(t, "beta", venue.Exchange)
+			}
+		}
+	})
+
+	t.Run("every venue failing", func(t *testing.T) {
+		failing := &venueAdapter{name: "failing", err: errors.New("API error")}
+		factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"failing": failing}}
+		composite := NewCompositeAdapter(factory, []Constituent{{"failing", 1}}, DefaultMaxDeviation)
+
+		_, err := CollectHistoricalPrices(context.Background(), composite, query)
+
+		assert.EqualError(t, err, "API error")
+	})
+
+	t.Run("exchange tickers", func(t *testing.T) {
+		_, err := CollectHistoricalPrices(context.Background(), composite, PriceQuery{Ticker: "BTCUSDT", Interval: Interval1h})
+
+		assert.ErrorIs(t, err, ErrUnknownSymbol)
+	})
+
+	t.Run("mark prices", func(t *testing.T) {
+		_, err := CollectHistoricalPrices(context.Background(), composite, PriceQuery{Ticker: "BTC/USDT", Interval: Interval1h, PriceType: PriceTypeMark})
+
+		assert.ErrorIs(t, err, ErrUnsupportedPriceType)
+	})
+}
@@ -0,0 +1,203 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// ErrNoConversionRate is returned when an exchange lists no spot pair between the
+// quote asset of a symbol and the asset its prices are converted to
+var ErrNoConversionRate = errors.New("no conversion rate")
+
+// Conversion describes how prices quoted in one asset are converted into another
+// through the spot rate an exchange lists between them
+type Conversion struct {
+	From string
+	To   string
+
+	// RateTicker is the spot ticker the rate is read from; empty when From and To
+	// are the same asset
+	RateTicker string
+
+	// Inverted is set when RateTicker quotes To in From, such as EURUSDT when
+	// converting from USDT to EUR, so that prices are divided by it
+	Inverted bool
+}
+
+// ResolveConversion finds the spot pair of an exchange that converts the prices of
+// a ticker, written in the notation of the exchange or as BASE/QUOTE, into another
+// quote asset. Pairs quoting the original quote asset in the target asset are
+// preferred over inverted ones.
+func (f *ExchangeFactory) ResolveConversion(ctx context.Context, exchange string, market Market, ticker, to string) (Conversion, error) {
+	symbol := ticker
+	if !IsCanonicalSymbol(ticker) {
+		var err error
+		symbol, err = f.CanonicalSymbolOf(ctx, exchange, market, ticker)
+		if err != nil {
+			return Conversion{}, err
+		}
+	}
+	_, from, err := ParseCanonicalSymbol(symbol)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	conversion := Conversion{From: from, To: canonicalAsset(strings.TrimSpace(to))}
+	if conversion.To == "" {
+		return Conversion{}, fmt.Errorf("%w: no asset to convert to", ErrNoConversionRate)
+	}
+	if conversion.From == conversion.To {
+		return conversion, nil
+	}
+
+	conversion.RateTicker, err = f.resolveRate(ctx, exchange, conversion.From+"/"+conversion.To)
+	if errors.Is(err, ErrUnknownSymbol) {
+		conversion.Inverted = true
+		conversion.RateTicker, err = f.resolveRate(ctx, exchange, conversion.To+"/"+conversion.From)
+	}
+	if errors.Is(err, ErrUnknownSymbol) {
+		return Conversion{}, fmt.Errorf("%w: %s lists neither %s/%s nor %s/%s on the spot market", ErrNoConversionRate, exchange, conversion.From, conversion.To, conversion.To, conversion.From)
+	}
+	if err != nil {
+		return Conversion{}, err
+	}
+	return conversion, nil
+}
+
+// resolveRate translates a BASE/QUOTE spot pair into the ticker an exchange
+// serves its rate under. ResolveSymbol passes canonical symbols through for
+// exchanges without a symbol registry, which would take every pair as listed, so
+// the composite index looks the pair up on its constituents and keeps it
+// canonical for them to resolve, while other such exchanges cannot be searched.
+func (f *ExchangeFactory) resolveRate(ctx context.Context, exchange, pair string) (string, error) {
+	if _, ok := f.GetSymbolProvider(exchange); ok {
+		return f.ResolveSymbol(ctx, exchange, MarketSpot, pair)
+	}
+	composite, ok := f.adapters[exchange].(*CompositeAdapter)
+	if !ok {
+		return "", fmt.Errorf("%w: %s does not list its symbols to find a rate in", ErrNoConversionRate, exchange)
+	}
+
+	var firstErr error
+	for _, constituent := range composite.Constituents() {
+		if _, ok := f.GetAdapter(constituent.Exchange); !ok {
+			continue
+		}
+		_, err := f.resolveRate(ctx, constituent.Exchange, pair)
+		if err == nil {
+			return pair, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if firstErr == nil || (errors.Is(firstErr, ErrUnknownSymbol) && !errors.Is(err, ErrUnknownSymbol)) {
+			firstErr = err
+		}
+	}
+	if firstErr == nil || errors.Is(firstErr, ErrUnknownSymbol) || errors.Is(firstErr, ErrNoConversionRate) {
+		return "", fmt.Errorf("%w: no constituent of %s lists %s on the spot market", ErrUnknownSymbol, exchange, pair)
+	}
+	return "", firstErr
+}
+
+// ConvertingAdapter converts candles into another quote asset. Every page of
+// candles is matched by open time with the candles of the rate, fetched through
+// the same adapter, so that resampled candles are converted by resampled rates.
+type ConvertingAdapter struct {
+	ExchangeAdapter
+	conversion Conversion
+}
+
+// NewConvertingAdapter wraps an adapter to convert the candles it serves
+func NewConvertingAdapter(adapter ExchangeAdapter, conversion Conversion) *ConvertingAdapter {
+	return &ConvertingAdapter{ExchangeAdapter: adapter, conversion: conversion}
+}
+
+// GetHistoricalPrices passes the converted candles matching the query to handle in
+// chronological order. Candles without a rate candle of the same open time cannot
+// be converted and are left out.
+func (a *ConvertingAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	return a.ExchangeAdapter.GetHistoricalPrices(ctx, query, func(prices []*pb.PricesResponse) error {
+		if len(prices) == 0 {
+			return handle(prices)
+		}
+
+		rates := make(map[int64]*pb.PricesResponse)
+		if a.conversion.RateTicker != "" {
+			page, err := CollectHistoricalPrices(ctx, a.ExchangeAdapter, PriceQuery{
+				Ticker:    a.conversion.RateTicker,
+				Market:    MarketSpot,
+				Interval:  query.Interval,
+				StartTime: time.UnixMilli(prices[0].OpenTime).UTC(),
+				EndTime:   time.UnixMilli(prices[len(prices)-1].OpenTime).UTC(),
+			})
+			if err != nil {
+				return fmt.Errorf("error getting %s conversion rates: %w", a.conversion.RateTicker, err)
+			}
+			for _, rate := range page {
+				rates[rate.OpenTime] = rate
+			}
+		}
+
+		converted := make([]*pb.PricesResponse, 0, len(prices))
+		for _, price := range prices {
+			if candle, ok := a.convert(price, rates[price.OpenTime]); ok {
+				converted = append(converted, candle)
+			}
+		}
+		return handle(converted)
+	})
+}
+
+// convert converts a candle by the rate candle of the same open time, which is
+// nil when no conversion is needed. It reports false when the rate is missing or
+// cannot be divided by.
+func (a *ConvertingAdapter) convert(price, rate *pb.PricesResponse) (*pb.PricesResponse, bool) {
+	// The rate in the target asset per original quote asset; inverting a rate
+	// swaps its high and low
+	open, high, low, close := 1.0, 1.0, 1.0, 1.0
+	switch {
+	case a.conversion.RateTicker == "":
+	case rate == nil:
+		return nil, false
+	case a.conversion.Inverted:
+		if rate.Low <= 0 || rate.Open <= 0 || rate.Close <= 0 {
+			return nil, false
+		}
+		open, high, low, close = 1/rate.Open, 1/rate.Low, 1/rate.High, 1/rate.Close
+	default:
+		open, high, low, close = rate.Open, rate.High, rate.Low, rate.Close
+	}
+	average := (open + high + low + close) / 4
+
+	converted := &pb.PricesResponse{
+		Date:                price.Date,
+		Open:                price.Open * open,
+		High:                price.High * high,
+		Low:                 price.Low * low,
+		Close:               price.Close * close,
+		Volume:              price.Volume,
+		OpenTime:            price.OpenTime,
+		CloseTime:           price.CloseTime,
+		QuoteVolume:         price.QuoteVolume * average,
+		TradeCount:          price.TradeCount,
+		TakerBuyBaseVolume:  price.TakerBuyBaseVolume,
+		TakerBuyQuoteVolume: price.TakerBuyQuoteVolume * average,
+		UnavailableFields:   price.UnavailableFields,
+		SchemaVersion:       price.SchemaVersion,
+		Venues:              price.Venues,
+		Conversion: &pb.Conversion{
+			To:                 a.conversion.To,
+			RateTicker:         a.conversion.RateTicker,
+			Inverted:           a.conversion.Inverted,
+			RateClose:          close,
+			ApproximateHighLow: high != low,
+		},
+	}
+	return converted, true
+}
@@ -0,0 +1,193 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// tickerAdapter serves fixed candles by ticker in pages of two, recording the
+// tickers it is queried for
+type tickerAdapter struct {
+	candles map[string][]*pb.PricesResponse
+	queries []PriceQuery
+	failure error
+}
+
+func (a *tickerAdapter) GetName() string {
+	return "tickers"
+}
+
+func (a *tickerAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	a.queries = append(a.queries, query)
+	if a.failure != nil && query.Ticker != "ETHUSDT" {
+		return a.failure
+	}
+	var page []*pb.PricesResponse
+	for _, candle := range a.candles[query.Ticker] {
+		open := time.UnixMilli(candle.OpenTime)
+		if open.Before(query.StartTime) || (!query.EndTime.IsZero() && open.After(query.EndTime)) {
+			continue
+		}
+		page = append(page, candle)
+		if len(page) == 2 {
+			if err := handle(page); err != nil {
+				return err
+			}
+			page = nil
+		}
+	}
+	if len(page) > 0 {
+		return handle(page)
+	}
+	return nil
+}
+
+// TestExchangeFactory_ResolveConversion tests finding the spot pair converting a quote asset
+func TestExchangeFactory_ResolveConversion(t *testing.T) {
+	adapter := &listingSymbolsAdapter{symbols: []SymbolInfo{
+		{Symbol: "BTCUSDT", Base: "BTC", Quote: "USDT", Status: SymbolStatusTrading},
+		{Symbol: "ETHUSDT", Base: "ETH", Quote: "USDT", Status: SymbolStatusTrading},
+		{Symbol: "EURUSDT", Base: "EUR", Quote: "USDT", Status: SymbolStatusTrading},
+		{Symbol: "USDTTRY", Base: "USDT", Quote: "TRY", Status: SymbolStatusTrading},
+	}}
+	factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"listed": adapter}}
+	ctx := context.Background()
+
+	conversion, err := factory.ResolveConversion(ctx, "listed", MarketSpot, "ETHUSDT", "btc")
+	require.NoError(t, err)
+	assert.Equal(t, Conversion{From: "USDT", To: "BTC", RateTicker: "BTCUSDT", Inverted: true}, conversion)
+
+	conversion, err = factory.ResolveConversion(ctx, "listed", MarketSpot, "BTC/USDT", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, Conversion{From: "USDT", To: "EUR", RateTicker: "EURUSDT", Inverted: true}, conversion)
+
+	conversion, err = factory.ResolveConversion(ctx, "listed", MarketSpot, "BTC/USDT", "TRY")
+	require.NoError(t, err)
+	assert.Equal(t, Conversion{From: "USDT", To: "TRY", RateTicker: "USDTTRY"}, conversion)
+
+	t.Run("same quote asset", func(t *testing.T) {
+		conversion, err := factory.ResolveConversion(ctx, "listed", MarketSpot, "BTCUSDT", "USDT")
+
+		require.NoError(t, err)
+		assert.Empty(t, conversion.RateTicker)
+	})
+
+	t.Run("no pair listed", func(t *testing.T) {
+		_, err := factory.ResolveConversion(ctx, "listed", MarketSpot, "BTCUSDT", "JPY")
+
+		assert.ErrorIs(t, err, ErrNoConversionRate)
+		assert.ErrorContains(t, err, "listed lists neither USDT/JPY nor JPY/USDT")
+	})
+
+	t.Run("unknown ticker", func(t *testing.T) {
+		_, err := factory.ResolveConversion(ctx, "listed", MarketSpot, "DOGEUSDT", "BTC")
+
+		assert.ErrorIs(t, err, ErrUnknownSymbol)
+	})
+
+	t.Run("composite index", func(t *testing.T) {
+		factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"listed": adapter, "tickers": &tickerAdapter{}}}
+		factory.RegisterAdapter(NewCompositeAdapter(factory, []Constituent{{"tickers", 1}, {"listed", 1}}, DefaultMaxDeviation))
+
+		// The rate is looked up on the constituents and left for them to resolve
+		conversion, err := factory.ResolveConversion(ctx, CompositeExchange, MarketSpot, "BTC/USDT", "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, Conversion{From: "USDT", To: "EUR", RateTicker: "EUR/USDT", Inverted: true}, conversion)
+
+		conversion, err = factory.ResolveConversion(ctx, CompositeExchange, MarketSpot, "BTC/USDT", "TRY")
+		require.NoError(t, err)
+		assert.Equal(t, Conversion{From: "USDT", To: "TRY", RateTicker: "USDT/TRY"}, conversion)
+
+		_, err = factory.ResolveConversion(ctx, CompositeExchange, MarketSpot, "BTC/USDT", "JPY")
+		assert.ErrorIs(t, err, ErrNoConversionRate)
+		assert.ErrorContains(t, err, "composite lists neither USDT/JPY nor JPY/USDT")
+	})
+
+	t.Run("no symbol registry", func(t *testing.T) {
+		factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"tickers": &tickerAdapter{}}}
+
+		_, err := factory.ResolveConversion(ctx, "tickers", MarketSpot, "BTC/USDT", "EUR")
+
+		assert.ErrorIs(t, err, ErrNoConversionRate)
+		assert.ErrorContains(t, err, "tickers does not list its symbols")
+	})
+}
+
+// TestConvertingAdapter_GetHistoricalPrices tests converting candles by the rate
+// candles of the same open time
+func TestConvertingAdapter_GetHistoricalPrices(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A pegged rate that did not move during its candles
+	pegged := venueCandles(start, []float64{30, 30, 30}, []float64{1, 1, 1})
+	for _, candle := range pegged {
+		candle.High, candle.Low = 30, 30
+	}
+	adapter := &tickerAdapter{candles: map[string][]*pb.PricesResponse{
+		"ETHUSDT": venueCandles(start, []float64{2000, 2100, 2200}, []float64{1, 2, 3}),
+		"BTCUSDT": venueCandles(start, []float64{40000, 42000}, []float64{1, 1}),
+		"USDTTRY": pegged,
+	}}
+	query := PriceQuery{Ticker: "ETHUSDT", Interval: Interval1h, StartTime: start}
+
+	converting := NewConvertingAdapter(adapter, Conversion{From: "USDT", To: "BTC", RateTicker: "BTCUSDT", Inverted: true})
+	candles, err := CollectHistoricalPrices(context.Background(), converting, query)
+
+	require.NoError(t, err)
+
+	// The last candle has no rate candle to be converted by
+	require.Len(t, candles, 2)
+	first := candles[0]
+	assert.InDelta(t, 2000.0/40000, first.Open, 1e-12)
+	assert.InDelta(t, 2000.0/40000, first.Close, 1e-12)
+	assert.InDelta(t, 2001.0/39999, first.High, 1e-12)
+	assert.InDelta(t, 1999.0/40001, first.Low, 1e-12)
+	assert.Equal(t, 1.0, first.Volume)
+	assert.InDelta(t, 2000*(1/40000.0+1/40001.0+1/39999.0+1/40000.0)/4, first.QuoteVolume, 1e-12)
+	assert.Equal(t, start.UnixMilli(), first.OpenTime)
+	assert.Equal(t, &pb.Conversion{To: "BTC", RateTicker: "BTCUSDT", Inverted: true, RateClose: 1.0 / 40000, ApproximateHighLow: true}, first.Conversion)
+	assert.Nil(t, first.Decimals)
+	assert.InDelta(t, 2100.0/42000, candles[1].Close, 1e-12)
+
+	// Every page of candles fetches the rates of its own range
+	require.Len(t, adapter.queries, 3)
+	assert.Equal(t, PriceQuery{Ticker: "BTCUSDT", Market: MarketSpot, Interval: Interval1h, StartTime: start, EndTime: start.Add(time.Hour)}, adapter.queries[1])
+
+	t.Run("rates that did not move", func(t *testing.T) {
+		converting := NewConvertingAdapter(adapter, Conversion{From: "USDT", To: "TRY", RateTicker: "USDTTRY"})
+
+		candles, err := CollectHistoricalPrices(context.Background(), converting, query)
+
+		require.NoError(t, err)
+		require.Len(t, candles, 3)
+		assert.Equal(t, 2001.0*30, candles[0].High)
+		assert.Equal(t, 1999.0*30, candles[0].Low)
+		assert.False(t, candles[0].Conversion.ApproximateHighLow)
+	})
+
+	t.Run("same quote asset", func(t *testing.T) {
+		converting := NewConvertingAdapter(adapter, Conversion{From: "USDT", To: "USDT"})
+
+		candles, err := CollectHistoricalPrices(context.Background(), converting, query)
+
+		require.NoError(t, err)
+		require.Len(t, candles, 3)
+		assert.Equal(t, 2200.0, candles[2].Close)
+		assert.Equal(t, "USDT", candles[2].Conversion.To)
+	})
+
+	t.Run("failing rates", func(t *testing.T) {
+		adapter.failure = errors.New("API error")
+		defer func() { adapter.failure = nil }()
+
+		_, err := CollectHistoricalPrices(context.Background(), converting, query)
+
+		assert.ErrorContains(t, err, "error getting BTCUSDT conversion rates: API error")
+	})
+}
@@ -2,22 +2,67 @@ package exchanges
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	pb "github.com/timakaa/historical-common/proto"
 )
 
+// PriceQuery describes the candles requested from an exchange
+type PriceQuery struct {
+	Ticker    string
+	Market    Market
+	Interval  Interval
+	PriceType PriceType
+
+	// Limit caps the number of candles returned. Without a start time the most
+	// recent candles are returned; zero means no cap for range queries.
+	Limit int64
+
+	// StartTime and EndTime bound candle open times, inclusive. A zero EndTime
+	// means now.
+	StartTime time.Time
+	EndTime   time.Time
+}
+
 // ExchangeAdapter defines the interface for all exchange adapters
 type ExchangeAdapter interface {
 	// GetName returns the name of the exchange
 	GetName() string
 
-	// GetHistoricalPrices retrieves historical price data for the specified ticker
-	GetHistoricalPrices(ctx context.Context, ticker string, limit int64) ([]*pb.PricesResponse, error)
+	// GetHistoricalPrices retrieves historical price data in chronological order,
+	// passing every page to handle as soon as it is fetched. It stops fetching
+	// when handle returns an error or the context is cancelled.
+	GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error
+}
+
+// PageHandler receives a page of candles in chronological order
+type PageHandler func(prices []*pb.PricesResponse) error
+
+// CollectHistoricalPrices retrieves all candles matching the query into a single slice
+func CollectHistoricalPrices(ctx context.Context, adapter ExchangeAdapter, query PriceQuery) ([]*pb.PricesResponse, error) {
+	var prices []*pb.PricesResponse
+	err := adapter.GetHistoricalPrices(ctx, query, func(page []*pb.PricesResponse) error {
+		prices = append(prices, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prices, nil
 }
 
 // ExchangeFactory is a factory for creating exchange adapters
 type ExchangeFactory struct {
 	adapters map[string]ExchangeAdapter
+
+	// listings caches the listing times of symbols
+	listingsMu sync.Mutex
+	listings   map[listingKey]time.Time
+
+	// symbols caches the instrument lists of exchange markets
+	symbolsMu sync.Mutex
+	symbols   map[symbolsKey]cachedSymbols
 }
 
 // NewExchangeFactory creates a new factory with registered adapters
@@ -29,6 +74,12 @@ func NewExchangeFactory() *ExchangeFactory {
 	// Register adapters for supported exchanges
 	factory.RegisterAdapter(NewBinanceAdapter())
 	factory.RegisterAdapter(NewBybitAdapter())
+	factory.RegisterAdapter(NewOKXAdapter())
+	factory.RegisterAdapter(NewCoinbaseAdapter())
+	factory.RegisterAdapter(NewKrakenAdapter())
+
+	// The composite index merges the adapters registered above
+	factory.RegisterAdapter(NewCompositeAdapter(factory, DefaultConstituents(), DefaultMaxDeviation))
 
 	return factory
 }
@@ -43,3 +94,22 @@ func (f *ExchangeFactory) GetAdapter(exchange string) (ExchangeAdapter, bool) {
 	adapter, exists := f.adapters[exchange]
 	return adapter, exists
 }
+
+// SupportedPriceTypes returns the price types the adapter of an exchange serves
+func (f *ExchangeFactory) SupportedPriceTypes(exchange string) []PriceType {
+	adapter, exists := f.adapters[exchange]
+	if !exists {
+		return nil
+	}
+	return supportedPriceTypes(adapter)
+}
+
+// SupportsPriceType reports whether the adapter of an exchange serves a price type
+func (f *ExchangeFactory) SupportsPriceType(exchange string, priceType PriceType) bool {
+	for _, supported := range f.SupportedPriceTypes(exchange) {
+		if supported == priceType {
+			return true
+		}
+	}
+	return false
+}
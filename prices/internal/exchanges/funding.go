@@ -0,0 +1,87 @@
+package exchanges
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// DefaultFundingMarket is used when a funding rate request does not specify a market
+const DefaultFundingMarket = MarketLinearPerp
+
+// FundingRateQuery describes the funding rates requested from an exchange
+type FundingRateQuery struct {
+	Ticker string
+	Market Market
+
+	// Limit caps the number of funding rates returned. Without a start time the
+	// most recent ones are returned; zero means no cap for range queries.
+	Limit int64
+
+	// StartTime and EndTime bound funding times, inclusive. A zero EndTime means now.
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// FundingRateHandler receives a page of funding rates in chronological order
+type FundingRateHandler func(rates []*pb.FundingRate) error
+
+// FundingRateProvider is implemented by adapters that serve the funding rate
+// history of perpetual contracts
+type FundingRateProvider interface {
+	// GetFundingRates retrieves funding rates in chronological order, passing
+	// every page to handle as soon as it is fetched
+	GetFundingRates(ctx context.Context, query FundingRateQuery, handle FundingRateHandler) error
+}
+
+// CollectFundingRates retrieves all funding rates matching the query into a single slice
+func CollectFundingRates(ctx context.Context, provider FundingRateProvider, query FundingRateQuery) ([]*pb.FundingRate, error) {
+	var rates []*pb.FundingRate
+	err := provider.GetFundingRates(ctx, query, func(page []*pb.FundingRate) error {
+		rates = append(rates, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetFundingRateProvider returns the funding rate provider of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetFundingRateProvider(exchange string) (FundingRateProvider, bool) {
+	provider, ok := f.adapters[exchange].(FundingRateProvider)
+	return provider, ok
+}
+
+// mapFundingMarket converts a perpetual market to an exchange's notation for it,
+// falling back to the default funding market
+func mapFundingMarket(exchange string, notation map[Market]string, market Market) (string, error) {
+	if market == "" {
+		market = DefaultFundingMarket
+	}
+	return mapMarket(exchange, notation, market)
+}
+
+// fundingRateTimestamp orders funding rates by funding time
+func fundingRateTimestamp(rate *pb.FundingRate) int64 {
+	return rate.FundingTime
+}
+
+// newFundingRate parses a funding settlement; an empty mark price is reported as unavailable
+func newFundingRate(exchange string, fundingTime int64, rate, markPrice string) (*pb.FundingRate, error) {
+	parser := decimalParser{exchange: exchange}
+	funding := &pb.FundingRate{
+		FundingTime: fundingTime,
+		Rate:        parser.float("funding rate", rate),
+	}
+	if markPrice != "" {
+		funding.MarkPrice = parser.float("mark price", markPrice)
+		funding.HasMarkPrice = true
+	}
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (funding at %d)", parser.err, fundingTime)
+	}
+	return funding, nil
+}
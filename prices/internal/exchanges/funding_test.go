@@ -0,0 +1,40 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewFundingRate tests parsing funding settlements with and without mark prices
+func TestNewFundingRate(t *testing.T) {
+	rate, err := newFundingRate("binance", 1672531200000, "0.00010000", "16542.10000000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1672531200000), rate.FundingTime)
+	assert.Equal(t, 0.0001, rate.Rate)
+	assert.Equal(t, 16542.1, rate.MarkPrice)
+	assert.True(t, rate.HasMarkPrice)
+
+	rate, err = newFundingRate("bybit", 1672531200000, "-0.000125", "")
+	require.NoError(t, err)
+	assert.Equal(t, -0.000125, rate.Rate)
+	assert.False(t, rate.HasMarkPrice)
+
+	_, err = newFundingRate("binance", 1672531200000, "", "16542.1")
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+}
+
+// TestExchangeFactory_GetFundingRateProvider tests which adapters serve funding rates
+func TestExchangeFactory_GetFundingRateProvider(t *testing.T) {
+	factory := NewExchangeFactory()
+
+	for _, exchange := range []string{"binance", "bybit"} {
+		_, ok := factory.GetFundingRateProvider(exchange)
+		assert.True(t, ok, exchange)
+	}
+	for _, exchange := range []string{"okx", "coinbase", "kraken", "unknown"} {
+		_, ok := factory.GetFundingRateProvider(exchange)
+		assert.False(t, ok, exchange)
+	}
+}
@@ -0,0 +1,200 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSymbolsNotProvided is returned when an exchange cannot list its symbols
+var ErrSymbolsNotProvided = errors.New("symbols not provided")
+
+// symbolsTTL is how long the symbols of an exchange market are served from memory
+// before they are fetched again
+const symbolsTTL = time.Hour
+
+// ExchangeInfo describes what an exchange serves
+type ExchangeInfo struct {
+	Name       string
+	Markets    []Market
+	Intervals  []Interval
+	PriceTypes []PriceType
+
+	// MaxPageSize is the largest number of candles fetched per exchange request;
+	// zero when unknown
+	MaxPageSize int
+}
+
+// CapabilityProvider is implemented by adapters that describe the markets and
+// intervals they serve. Adapters without it are taken to serve every interval of
+// the default market.
+type CapabilityProvider interface {
+	// SupportedMarkets returns the markets the adapter serves candles for
+	SupportedMarkets() []Market
+
+	// SupportedIntervals returns the candle intervals the adapter serves
+	SupportedIntervals() []Interval
+
+	// MaxPageSize returns the largest number of candles of the default market
+	// fetched per exchange request
+	MaxPageSize() int
+}
+
+// Exchanges describes every registered exchange, ordered by name
+func (f *ExchangeFactory) Exchanges() []ExchangeInfo {
+	infos := make([]ExchangeInfo, 0, len(f.adapters))
+	for name, adapter := range f.adapters {
+		info := ExchangeInfo{
+			Name:       name,
+			Markets:    []Market{DefaultMarket},
+			Intervals:  intervalsOf(supportedIntervals),
+			PriceTypes: supportedPriceTypes(adapter),
+		}
+		if provider, ok := adapter.(CapabilityProvider); ok {
+			info.Markets = provider.SupportedMarkets()
+			info.Intervals = provider.SupportedIntervals()
+			info.MaxPageSize = provider.MaxPageSize()
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// SupportedIntervals returns the intervals an exchange serves candles for,
+// shortest first
+func (f *ExchangeFactory) SupportedIntervals(exchange string) []Interval {
+	if provider, ok := f.adapters[exchange].(CapabilityProvider); ok {
+		return provider.SupportedIntervals()
+	}
+	return intervalsOf(supportedIntervals)
+}
+
+// marketsOf returns the markets of an exchange-specific notation in the order
+// they are documented
+func marketsOf[V any](notation map[Market]V) []Market {
+	var markets []Market
+	for _, market := range allMarkets {
+		if _, ok := notation[market]; ok {
+			markets = append(markets, market)
+		}
+	}
+	return markets
+}
+
+// intervalsOf returns the intervals of an exchange-specific notation, shortest first
+func intervalsOf[V any](notation map[Interval]V) []Interval {
+	intervals := make([]Interval, 0, len(notation))
+	for interval := range notation {
+		intervals = append(intervals, interval)
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Duration() < intervals[j].Duration() })
+	return intervals
+}
+
+// SymbolStatus is the exchange-independent trading status of a symbol
+type SymbolStatus string
+
+// Symbol statuses shared by the exchanges. Statuses without an equivalent are
+// passed through in lower case.
+const (
+	SymbolStatusTrading  SymbolStatus = "trading"
+	SymbolStatusHalted   SymbolStatus = "halted"
+	SymbolStatusPending  SymbolStatus = "pending" // announced but not trading yet
+	SymbolStatusDelisted SymbolStatus = "delisted"
+)
+
+// mapSymbolStatus translates an exchange-specific status
+func mapSymbolStatus(notation map[string]SymbolStatus, status string) SymbolStatus {
+	if mapped, ok := notation[status]; ok {
+		return mapped
+	}
+	return SymbolStatus(strings.ToLower(status))
+}
+
+// SymbolInfo describes a symbol an exchange lists
+type SymbolInfo struct {
+	// Symbol is the ticker as the prices API accepts it for the exchange
+	Symbol string
+	Base   string
+	Quote  string
+	Status SymbolStatus
+
+	// TickSize is the price increment exactly as the exchange sent it
+	TickSize string
+
+	// ListingTime is when the symbol started trading; zero when the exchange does
+	// not publish it
+	ListingTime time.Time
+}
+
+// SymbolProvider is implemented by adapters that can list the symbols of a market
+type SymbolProvider interface {
+	// GetSymbols returns the symbols of a market from the instrument info of the exchange
+	GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error)
+}
+
+// GetSymbolProvider returns the symbol provider of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetSymbolProvider(exchange string) (SymbolProvider, bool) {
+	provider, ok := f.adapters[exchange].(SymbolProvider)
+	return provider, ok
+}
+
+// symbolsKey identifies the cached symbols of an exchange market
+type symbolsKey struct {
+	exchange string
+	market   Market
+}
+
+// cachedSymbols are the symbols of an exchange market and when they were fetched
+type cachedSymbols struct {
+	symbols   []SymbolInfo
+	fetchedAt time.Time
+}
+
+// Symbols returns the symbols of an exchange market ordered by symbol. Instrument
+// lists change rarely, so they are kept in memory for a while.
+func (f *ExchangeFactory) Symbols(ctx context.Context, exchange string, market Market) ([]SymbolInfo, error) {
+	if market == "" {
+		market = DefaultMarket
+	}
+	key := symbolsKey{exchange: exchange, market: market}
+
+	f.symbolsMu.Lock()
+	cached, ok := f.symbols[key]
+	f.symbolsMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < symbolsTTL {
+		return cached.symbols, nil
+	}
+
+	provider, ok := f.GetSymbolProvider(exchange)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not list its symbols", ErrSymbolsNotProvided, exchange)
+	}
+	symbols, err := provider.GetSymbols(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+
+	f.symbolsMu.Lock()
+	if f.symbols == nil {
+		f.symbols = make(map[symbolsKey]cachedSymbols)
+	}
+	f.symbols[key] = cachedSymbols{symbols: symbols, fetchedAt: time.Now()}
+	f.symbolsMu.Unlock()
+
+	return symbols, nil
+}
+
+// unixMilliOrZero converts epoch milliseconds into a time, keeping zero as unset
+func unixMilliOrZero(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
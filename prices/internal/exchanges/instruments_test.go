@@ -0,0 +1,108 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listingSymbolsAdapter lists a fixed set of symbols, counting the requests it receives
+type listingSymbolsAdapter struct {
+	listedAdapter
+	symbols []SymbolInfo
+	calls   int
+	failure error
+}
+
+func (a *listingSymbolsAdapter) GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error) {
+	a.calls++
+	if a.failure != nil {
+		return nil, a.failure
+	}
+	return append([]SymbolInfo(nil), a.symbols...), nil
+}
+
+// TestExchangeFactory_Exchanges tests describing the registered exchanges
+func TestExchangeFactory_Exchanges(t *testing.T) {
+	factory := NewExchangeFactory()
+	factory.RegisterAdapter(&listedAdapter{})
+
+	infos := factory.Exchanges()
+
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name)
+	}
+	assert.Equal(t, []string{"binance", "bybit", "coinbase", "composite", "kraken", "listed", "okx"}, names)
+
+	binance := infos[0]
+	assert.Equal(t, []Market{MarketSpot, MarketLinearPerp, MarketInversePerp, MarketDatedFuture}, binance.Markets)
+	assert.Equal(t, []Interval{Interval1m, Interval5m, Interval15m, Interval1h, Interval4h, Interval1d, Interval1w, Interval1M}, binance.Intervals)
+	assert.Equal(t, []PriceType{PriceTypeLast, PriceTypeMark, PriceTypeIndex, PriceTypePremiumIndex}, binance.PriceTypes)
+	assert.Equal(t, binanceMaxPageSize, binance.MaxPageSize)
+
+	kraken := infos[4]
+	assert.Equal(t, []Market{MarketSpot}, kraken.Markets)
+	assert.NotContains(t, kraken.Intervals, Interval1M)
+	assert.Equal(t, []PriceType{PriceTypeLast}, kraken.PriceTypes)
+
+	// Adapters that do not describe themselves serve the default market
+	listed := infos[5]
+	assert.Equal(t, []Market{DefaultMarket}, listed.Markets)
+	assert.Len(t, listed.Intervals, len(supportedIntervals))
+	assert.Zero(t, listed.MaxPageSize)
+
+	// The composite index serves the intervals every constituent serves, which
+	// leaves out those coinbase and kraken lack
+	composite := infos[3]
+	assert.Equal(t, []Interval{Interval1m, Interval5m, Interval15m, Interval1h, Interval1d}, composite.Intervals)
+	assert.Contains(t, composite.Markets, MarketLinearPerp)
+	assert.Equal(t, []PriceType{PriceTypeLast}, composite.PriceTypes)
+}
+
+// TestExchangeFactory_Symbols tests that symbol lists are sorted and served from memory
+func TestExchangeFactory_Symbols(t *testing.T) {
+	adapter := &listingSymbolsAdapter{symbols: []SymbolInfo{{Symbol: "ETHUSDT"}, {Symbol: "BTCUSDT"}}}
+	factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"listed": adapter}}
+
+	symbols, err := factory.Symbols(context.Background(), "listed", "")
+	require.NoError(t, err)
+	assert.Equal(t, []SymbolInfo{{Symbol: "BTCUSDT"}, {Symbol: "ETHUSDT"}}, symbols)
+
+	_, err = factory.Symbols(context.Background(), "listed", MarketSpot)
+	require.NoError(t, err)
+	assert.Equal(t, 1, adapter.calls)
+
+	t.Run("expired lists are fetched again", func(t *testing.T) {
+		key := symbolsKey{exchange: "listed", market: MarketSpot}
+		factory.symbols[key] = cachedSymbols{symbols: symbols, fetchedAt: time.Now().Add(-symbolsTTL)}
+
+		_, err := factory.Symbols(context.Background(), "listed", MarketSpot)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, adapter.calls)
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		adapter.failure = errors.New("rate limited")
+
+		_, err := factory.Symbols(context.Background(), "listed", MarketLinearPerp)
+		assert.EqualError(t, err, "rate limited")
+
+		adapter.failure = nil
+		_, err = factory.Symbols(context.Background(), "listed", MarketLinearPerp)
+		require.NoError(t, err)
+	})
+
+	t.Run("adapters without symbol lists are rejected", func(t *testing.T) {
+		factory.RegisterAdapter(&listedAdapter{})
+
+		_, err := factory.Symbols(context.Background(), "listed", MarketDatedFuture)
+
+		assert.ErrorIs(t, err, ErrSymbolsNotProvided)
+	})
+}
@@ -0,0 +1,139 @@
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Interval is the exchange-independent candle interval accepted by the prices API
+type Interval string
+
+// Supported candle intervals
+const (
+	Interval1m  Interval = "1m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval1h  Interval = "1h"
+	Interval4h  Interval = "4h"
+	Interval1d  Interval = "1d"
+	Interval1w  Interval = "1w"
+	Interval1M  Interval = "1M"
+)
+
+// DefaultInterval is used when a request does not specify an interval
+const DefaultInterval = Interval1d
+
+// ErrUnsupportedInterval is returned when an interval is unknown or an exchange cannot serve it
+var ErrUnsupportedInterval = errors.New("unsupported interval")
+
+// supportedIntervals holds the shortest possible length of each interval. A month
+// counts as 28 days so that a time window sized from it never holds more candles
+// than expected.
+var supportedIntervals = map[Interval]time.Duration{
+	Interval1m:  time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval4h:  4 * time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval1w:  7 * 24 * time.Hour,
+	Interval1M:  28 * 24 * time.Hour,
+}
+
+// intervalUnits holds the shortest possible length of each interval unit, the last
+// character of an interval
+var intervalUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'M': 28 * 24 * time.Hour,
+}
+
+// ParseInterval validates an interval string, falling back to the default when it is empty
+func ParseInterval(value string) (Interval, error) {
+	if value == "" {
+		return DefaultInterval, nil
+	}
+
+	interval := Interval(value)
+	if _, ok := supportedIntervals[interval]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedInterval, value)
+	}
+
+	return interval, nil
+}
+
+// ParseCustomInterval validates an interval string like ParseInterval, also
+// accepting any whole number of minutes, hours, days, weeks or months, such as
+// 10m, 2h, 3d or 2w. Candles of intervals an exchange does not serve are built
+// by resampling.
+func ParseCustomInterval(value string) (Interval, error) {
+	if value == "" {
+		return DefaultInterval, nil
+	}
+
+	interval := Interval(value)
+	if _, _, ok := interval.split(); !ok {
+		return "", fmt.Errorf("%w: %s, expected a number followed by m, h, d, w or M", ErrUnsupportedInterval, value)
+	}
+
+	return interval, nil
+}
+
+// split returns the number of units of an interval and its unit, such as 2 and
+// 'h' for 2h
+func (i Interval) split() (int, byte, bool) {
+	if len(i) < 2 {
+		return 0, 0, false
+	}
+	unit := i[len(i)-1]
+	if _, ok := intervalUnits[unit]; !ok {
+		return 0, 0, false
+	}
+
+	digits := string(i[:len(i)-1])
+	if digits[0] < '1' || digits[0] > '9' {
+		return 0, 0, false
+	}
+	count, err := strconv.Atoi(digits)
+	if err != nil || count > maxIntervalUnits {
+		return 0, 0, false
+	}
+	return count, unit, true
+}
+
+// maxIntervalUnits bounds the number of units of an interval, keeping the
+// length of its candles in range of a duration
+const maxIntervalUnits = 10000
+
+// Duration returns the shortest length of a single candle of the interval
+func (i Interval) Duration() time.Duration {
+	if duration, ok := supportedIntervals[i]; ok {
+		return duration
+	}
+	count, unit, ok := i.split()
+	if !ok {
+		return 0
+	}
+	return time.Duration(count) * intervalUnits[unit]
+}
+
+// CloseTime returns the inclusive close time of the candle opening at openTime
+func (i Interval) CloseTime(openTime time.Time) time.Time {
+	if count, unit, ok := i.split(); ok && unit == 'M' {
+		return openTime.UTC().AddDate(0, count, 0).Add(-time.Millisecond)
+	}
+	return openTime.Add(i.Duration() - time.Millisecond)
+}
+
+// mapInterval translates an interval into an exchange-specific notation
+func mapInterval(exchange string, notation map[Interval]string, interval Interval) (string, error) {
+	value, ok := notation[interval]
+	if !ok {
+		return "", fmt.Errorf("%w: %s does not support %s", ErrUnsupportedInterval, exchange, interval)
+	}
+	return value, nil
+}
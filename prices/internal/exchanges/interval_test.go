@@ -0,0 +1,43 @@
+package exchanges
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseInterval tests validation of API intervals
+func TestParseInterval(t *testing.T) {
+	t.Run("empty interval uses default", func(t *testing.T) {
+		interval, err := ParseInterval("")
+		require.NoError(t, err)
+		assert.Equal(t, DefaultInterval, interval)
+	})
+
+	t.Run("supported intervals", func(t *testing.T) {
+		for _, value := range []string{"1m", "5m", "15m", "1h", "4h", "1d", "1w", "1M"} {
+			interval, err := ParseInterval(value)
+			require.NoError(t, err)
+			assert.Equal(t, Interval(value), interval)
+		}
+	})
+
+	t.Run("unsupported interval", func(t *testing.T) {
+		_, err := ParseInterval("2h")
+		assert.ErrorIs(t, err, ErrUnsupportedInterval)
+
+		// Intervals are case-sensitive: 1m is a minute, 1M is a month
+		_, err = ParseInterval("1H")
+		assert.ErrorIs(t, err, ErrUnsupportedInterval)
+	})
+}
+
+// TestInterval_CloseTime tests the inclusive close time of candles
+func TestInterval_CloseTime(t *testing.T) {
+	open := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, open.Add(time.Hour-time.Millisecond), Interval1h.CloseTime(open))
+	assert.Equal(t, time.Date(2024, 2, 29, 23, 59, 59, 999000000, time.UTC), Interval1M.CloseTime(open))
+}
@@ -0,0 +1,590 @@
+package exchanges
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// krakenIntervals maps API intervals to Kraken OHLC intervals in minutes. Kraken
+// has no monthly interval.
+var krakenIntervals = map[Interval]string{
+	Interval1m:  "1",
+	Interval5m:  "5",
+	Interval15m: "15",
+	Interval1h:  "60",
+	Interval4h:  "240",
+	Interval1d:  "1440",
+	Interval1w:  "10080",
+}
+
+// krakenMarkets lists the markets Kraken candles are available for
+var krakenMarkets = map[Market]string{
+	MarketSpot: "spot",
+}
+
+// krakenOHLCWindow is the number of most recent candles Kraken keeps for its OHLC endpoint
+const krakenOHLCWindow = 720
+
+// krakenTradesPageSize is the largest number of trades Kraken returns per request
+const krakenTradesPageSize = 1000
+
+// krakenBaseURL is the Kraken REST API address
+const krakenBaseURL = "https://api.kraken.com"
+
+// krakenAssetAliases maps Kraken asset codes to the codes our clients use
+var krakenAssetAliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// krakenOHLCUnavailableFields lists the candle fields Kraken OHLC candles do not
+// carry; candles rebuilt from trades carry every field
+var krakenOHLCUnavailableFields = []pb.CandleField{
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+}
+
+// errKrakenLimitReached stops paging once the requested number of candles was handed over
+var errKrakenLimitReached = errors.New("limit reached")
+
+// KrakenAdapter implements the adapter for Kraken exchange
+type KrakenAdapter struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewKrakenAdapter creates a new adapter for Kraken
+func NewKrakenAdapter() *KrakenAdapter {
+	return &KrakenAdapter{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: krakenBaseURL,
+	}
+}
+
+// GetName returns the name of the exchange
+func (a *KrakenAdapter) GetName() string {
+	return "kraken"
+}
+
+// krakenResponse is the envelope of Kraken public endpoints. The result holds
+// the rows under the canonical pair name and a "last" cursor.
+type krakenResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]json.RawMessage `json:"result"`
+}
+
+// GetHistoricalPrices retrieves historical price data from Kraken. Kraken only
+// serves the latest 720 candles of an interval, so older candles are rebuilt
+// from the trades endpoint.
+func (a *KrakenAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	log.Printf("Getting historical prices from Kraken for %s (%s, %s)", query.Ticker, query.Market, query.Interval)
+
+	krakenInterval, err := mapInterval(a.GetName(), krakenIntervals, query.Interval)
+	if err != nil {
+		return err
+	}
+	if err := requireLastPrices(a.GetName(), query); err != nil {
+		return err
+	}
+	if _, err := mapMarket(a.GetName(), krakenMarkets, query.Market); err != nil {
+		return err
+	}
+
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	end := query.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	// The latest candles are the range of limit intervals that ends at end
+	start := query.StartTime
+	if start.IsZero() {
+		start = end.Add(-time.Duration(query.Limit-1) * query.Interval.Duration()).Truncate(query.Interval.Duration())
+	}
+
+	pair := krakenPair(query.Ticker)
+	ohlc, err := a.fetchOHLC(ctx, pair, query.Interval, krakenInterval)
+	if err != nil {
+		return err
+	}
+
+	// Hand over at most limit candles
+	sent := int64(0)
+	emit := func(page []*pb.PricesResponse) error {
+		page = normalizePage(page, start, end)
+		if query.Limit > 0 && sent+int64(len(page)) > query.Limit {
+			page = page[:query.Limit-sent]
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		if err := handle(page); err != nil {
+			return err
+		}
+		sent += int64(len(page))
+		if query.Limit > 0 && sent >= query.Limit {
+			return errKrakenLimitReached
+		}
+		return nil
+	}
+
+	// A full OHLC window means older candles exist that Kraken no longer serves
+	if len(ohlc) >= krakenOHLCWindow && start.UnixMilli() < ohlc[0].OpenTime {
+		tradesEnd := time.UnixMilli(ohlc[0].OpenTime - 1)
+		if end.Before(tradesEnd) {
+			tradesEnd = end
+		}
+		err := a.rebuildFromTrades(ctx, pair, query.Interval, start, tradesEnd, emit)
+		if errors.Is(err, errKrakenLimitReached) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := emit(ohlc); err != nil && !errors.Is(err, errKrakenLimitReached) {
+		return err
+	}
+	return nil
+}
+
+// fetchOHLC requests the OHLC window Kraken keeps for the interval, oldest first
+func (a *KrakenAdapter) fetchOHLC(ctx context.Context, pair string, interval Interval, krakenInterval string) ([]*pb.PricesResponse, error) {
+	params := url.Values{}
+	params.Set("pair", pair)
+	params.Set("interval", krakenInterval)
+
+	// Fetch data from Kraken API
+	var rows [][]interface{}
+	if _, err := a.get(ctx, "/0/public/OHLC", params, pair, &rows); err != nil {
+		return nil, fmt.Errorf("error fetching data from Kraken: %v", err)
+	}
+
+	// Convert data to response format
+	prices := make([]*pb.PricesResponse, 0, len(rows))
+	for _, row := range rows {
+		candle, err := krakenOHLCToCandle(row, interval)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, candle)
+	}
+
+	return prices, nil
+}
+
+// rebuildFromTrades pages through the trades in [start, end] and aggregates them
+// into candles, handing every completed candle over after each page. Intervals
+// without trades produce no candle.
+func (a *KrakenAdapter) rebuildFromTrades(ctx context.Context, pair string, interval Interval, start, end time.Time, emit PageHandler) error {
+	// Kraken returns the trades after since, so start just before the range
+	var current *tradeBucket
+	since := strconv.FormatInt(start.UnixNano()-1, 10)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		params := url.Values{}
+		params.Set("pair", pair)
+		params.Set("since", since)
+		params.Set("count", strconv.Itoa(krakenTradesPageSize))
+
+		var rows [][]interface{}
+		last, err := a.get(ctx, "/0/public/Trades", params, pair, &rows)
+		if err != nil {
+			return fmt.Errorf("error fetching trades from Kraken: %v", err)
+		}
+
+		var completed []*pb.PricesResponse
+		done := len(rows) == 0 || last == since
+		for _, row := range rows {
+			trade, err := krakenRowToTrade(row)
+			if err != nil {
+				return err
+			}
+			if trade.time.After(end) {
+				done = true
+				break
+			}
+			if trade.time.Before(start) {
+				continue
+			}
+
+			openTime := trade.time.UTC().Truncate(interval.Duration())
+			if current != nil && current.openTime != openTime.UnixMilli() {
+				completed = append(completed, current.candle(interval))
+				current = nil
+			}
+			if current == nil {
+				current = newTradeBucket(openTime.UnixMilli())
+			}
+			current.add(trade)
+		}
+
+		if done && current != nil {
+			completed = append(completed, current.candle(interval))
+		}
+		if len(completed) > 0 {
+			if err := emit(completed); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+		since = last
+	}
+}
+
+// get requests a Kraken public endpoint, decoding the rows of the requested pair
+// into out and returning the "last" cursor
+func (a *KrakenAdapter) get(ctx context.Context, path string, params url.Values, pair string, out interface{}) (string, error) {
+	var resp krakenResponse
+	if err := getJSON(ctx, a.client, a.baseURL+path, params, &resp); err != nil {
+		return "", err
+	}
+
+	// Check if request was successful
+	if len(resp.Error) > 0 {
+		return "", fmt.Errorf("kraken API error: %s", strings.Join(resp.Error, ", "))
+	}
+
+	// Rows are keyed by Kraken's canonical pair name, such as XXBTZUSD for XBTUSD
+	var last string
+	var rows json.RawMessage
+	for key, value := range resp.Result {
+		if key == "last" {
+			last = strings.Trim(string(value), `"`)
+			continue
+		}
+		if rows == nil || krakenTicker(key) == krakenTicker(pair) {
+			rows = value
+		}
+	}
+	if rows == nil {
+		return "", fmt.Errorf("kraken response holds no data for %s", pair)
+	}
+
+	// Numbers are kept as written so that no precision is lost before parsing
+	decoder := json.NewDecoder(bytes.NewReader(rows))
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return "", fmt.Errorf("error decoding response: %v", err)
+	}
+	return last, nil
+}
+
+// krakenOHLCToCandle converts a Kraken OHLC row, [time, open, high, low, close,
+// vwap, volume, count] with the time in epoch seconds, into a candle
+func krakenOHLCToCandle(row []interface{}, interval Interval) (*pb.PricesResponse, error) {
+	if len(row) < 8 {
+		return nil, fmt.Errorf("%w: kraken sent a candle with %d fields", ErrMalformedCandle, len(row))
+	}
+
+	values := make([]string, len(row))
+	for i, value := range row {
+		values[i] = fmt.Sprint(value)
+	}
+
+	parser := decimalParser{exchange: "kraken"}
+	seconds := parser.int("time", values[0])
+	open := parser.float("open", values[1])
+	high := parser.float("high", values[2])
+	low := parser.float("low", values[3])
+	close := parser.float("close", values[4])
+	vwap := parser.float("vwap", values[5])
+	volume := parser.float("volume", values[6])
+	count := parser.int("count", values[7])
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (candle at %s)", parser.err, values[0])
+	}
+
+	candle := newCandle(seconds*1000, interval)
+	candle.Open = open
+	candle.High = high
+	candle.Low = low
+	candle.Close = close
+	candle.Volume = volume
+	candle.QuoteVolume = vwap * volume
+	candle.TradeCount = count
+	candle.UnavailableFields = krakenOHLCUnavailableFields
+	candle.Decimals = &pb.DecimalValues{
+		Open:   values[1],
+		High:   values[2],
+		Low:    values[3],
+		Close:  values[4],
+		Volume: values[6],
+	}
+
+	return candle, nil
+}
+
+// krakenTrade is a single public trade
+type krakenTrade struct {
+	price     string
+	volume    string
+	priceRat  *big.Rat
+	volumeRat *big.Rat
+	time      time.Time
+	takerBuys bool
+}
+
+// krakenRowToTrade converts a Kraken trade row, [price, volume, time, side,
+// type, misc, id] with the time in fractional epoch seconds, into a trade
+func krakenRowToTrade(row []interface{}) (krakenTrade, error) {
+	if len(row) < 4 {
+		return krakenTrade{}, fmt.Errorf("%w: kraken sent a trade with %d fields", ErrMalformedCandle, len(row))
+	}
+
+	price, _ := row[0].(string)
+	volume, _ := row[1].(string)
+	timestamp, _ := row[2].(json.Number)
+	seconds, err := timestamp.Float64()
+	side, _ := row[3].(string)
+	priceRat, priceOK := new(big.Rat).SetString(price)
+	volumeRat, volumeOK := new(big.Rat).SetString(volume)
+	if err != nil || !priceOK || !volumeOK {
+		return krakenTrade{}, fmt.Errorf("%w: kraken sent trade %v", ErrMalformedCandle, row)
+	}
+
+	return krakenTrade{
+		price:     price,
+		volume:    volume,
+		priceRat:  priceRat,
+		volumeRat: volumeRat,
+		time:      time.UnixMilli(int64(seconds * 1000)),
+		takerBuys: side == "b",
+	}, nil
+}
+
+// tradeBucket aggregates the trades of one interval into a candle, summing
+// volumes exactly
+type tradeBucket struct {
+	openTime int64
+	open     string
+	high     string
+	low      string
+	close    string
+	highRat  *big.Rat
+	lowRat   *big.Rat
+	volume   decimalSum
+	quote    decimalSum
+	takerBuy decimalSum
+	takerQ   decimalSum
+	count    int64
+}
+
+func newTradeBucket(openTime int64) *tradeBucket {
+	return &tradeBucket{openTime: openTime}
+}
+
+// add aggregates a trade into the bucket
+func (b *tradeBucket) add(trade krakenTrade) {
+	price, volume := trade.priceRat, trade.volumeRat
+	if b.count == 0 {
+		b.open = trade.price
+	}
+	if b.highRat == nil || price.Cmp(b.highRat) > 0 {
+		b.high, b.highRat = trade.price, price
+	}
+	if b.lowRat == nil || price.Cmp(b.lowRat) < 0 {
+		b.low, b.lowRat = trade.price, price
+	}
+	b.close = trade.price
+	b.count++
+
+	// The product of two decimals has as many fraction digits as both together
+	quote := new(big.Rat).Mul(price, volume)
+	quoteScale := decimalScale(trade.price) + decimalScale(trade.volume)
+	b.volume.add(volume, decimalScale(trade.volume))
+	b.quote.add(quote, quoteScale)
+	if trade.takerBuys {
+		b.takerBuy.add(volume, decimalScale(trade.volume))
+		b.takerQ.add(quote, quoteScale)
+	}
+}
+
+// candle converts the bucket into a candle
+func (b *tradeBucket) candle(interval Interval) *pb.PricesResponse {
+	candle := newCandle(b.openTime, interval)
+	candle.Open, _ = strconv.ParseFloat(b.open, 64)
+	candle.High, _ = strconv.ParseFloat(b.high, 64)
+	candle.Low, _ = strconv.ParseFloat(b.low, 64)
+	candle.Close, _ = strconv.ParseFloat(b.close, 64)
+	candle.Volume = b.volume.float()
+	candle.QuoteVolume = b.quote.float()
+	candle.TradeCount = b.count
+	candle.TakerBuyBaseVolume = b.takerBuy.float()
+	candle.TakerBuyQuoteVolume = b.takerQ.float()
+	candle.Decimals = &pb.DecimalValues{
+		Open:                b.open,
+		High:                b.high,
+		Low:                 b.low,
+		Close:               b.close,
+		Volume:              b.volume.String(),
+		QuoteVolume:         b.quote.String(),
+		TakerBuyBaseVolume:  b.takerBuy.String(),
+		TakerBuyQuoteVolume: b.takerQ.String(),
+	}
+	return candle
+}
+
+// krakenPair converts a ticker into a Kraken pair, using Kraken's asset codes
+// (XBT for BTC, XDG for DOGE). Kraken pair names such as XXBTZUSD are passed through.
+func krakenPair(ticker string) string {
+	ticker = strings.ToUpper(strings.ReplaceAll(ticker, "-", ""))
+	if _, _, ok := splitKrakenPairName(ticker); ok {
+		return ticker
+	}
+
+	base, quote, ok := splitTicker(ticker)
+	if !ok {
+		return ticker
+	}
+	for krakenAsset, asset := range krakenAssetAliases {
+		if base == asset {
+			base = krakenAsset
+		}
+		if quote == asset {
+			quote = krakenAsset
+		}
+	}
+	return base + quote
+}
+
+// krakenTicker converts a Kraken pair name such as XXBTZUSD or XBTUSDT into the
+// plain ticker our clients send, such as BTCUSD or BTCUSDT
+func krakenTicker(pair string) string {
+	pair = strings.ToUpper(pair)
+
+	base, quote, ok := splitKrakenPairName(pair)
+	if !ok {
+		// Newer pairs carry no X/Z prefixes, only the Kraken asset codes
+		if base, quote, ok = splitTicker(pair); !ok {
+			for krakenAsset := range krakenAssetAliases {
+				if strings.HasSuffix(pair, krakenAsset) && len(pair) > len(krakenAsset) {
+					base, quote, ok = strings.TrimSuffix(pair, krakenAsset), krakenAsset, true
+				}
+			}
+			if !ok {
+				return pair
+			}
+		}
+	}
+
+	if alias, exists := krakenAssetAliases[base]; exists {
+		base = alias
+	}
+	if alias, exists := krakenAssetAliases[quote]; exists {
+		quote = alias
+	}
+	return base + quote
+}
+
+// splitKrakenPairName splits a legacy Kraken pair name such as XXBTZUSD or
+// XETHXXBT, where both assets carry an X (crypto) or Z (fiat) prefix
+func splitKrakenPairName(pair string) (base, quote string, ok bool) {
+	isPrefix := func(c byte) bool { return c == 'X' || c == 'Z' }
+	if len(pair) != 8 || !isPrefix(pair[0]) || !isPrefix(pair[4]) {
+		return "", "", false
+	}
+	return pair[1:4], pair[5:8], true
+}
+
+// SupportedMarkets returns the markets Kraken serves candles for
+func (a *KrakenAdapter) SupportedMarkets() []Market {
+	return marketsOf(krakenMarkets)
+}
+
+// SupportedIntervals returns the OHLC intervals Kraken serves
+func (a *KrakenAdapter) SupportedIntervals() []Interval {
+	return intervalsOf(krakenIntervals)
+}
+
+// MaxPageSize returns the number of most recent candles the Kraken OHLC endpoint serves
+func (a *KrakenAdapter) MaxPageSize() int {
+	return krakenOHLCWindow
+}
+
+// krakenSymbolStatuses maps Kraken pair statuses; pairs restricted to some order
+// types cannot be traded freely
+var krakenSymbolStatuses = map[string]SymbolStatus{
+	"online":      SymbolStatusTrading,
+	"cancel_only": SymbolStatusHalted,
+	"post_only":   SymbolStatusHalted,
+	"limit_only":  SymbolStatusHalted,
+	"reduce_only": SymbolStatusHalted,
+	"delisted":    SymbolStatusDelisted,
+}
+
+// krakenAssetPair is a pair of the Kraken asset pairs endpoint. The websocket
+// name holds both assets, such as XBT/USD.
+type krakenAssetPair struct {
+	Altname  string `json:"altname"`
+	WSName   string `json:"wsname"`
+	TickSize string `json:"tick_size"`
+	Status   string `json:"status"`
+}
+
+// GetSymbols lists the pairs of Kraken under the tickers our clients use, such as
+// BTCUSD for XBTUSD. Kraken does not publish when a pair was listed.
+func (a *KrakenAdapter) GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error) {
+	if _, err := mapMarket(a.GetName(), krakenMarkets, market); err != nil {
+		return nil, err
+	}
+
+	var resp krakenResponse
+	if err := getJSON(ctx, a.client, a.baseURL+"/0/public/AssetPairs", nil, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching symbols from Kraken: %v", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("kraken API error: %s", strings.Join(resp.Error, ", "))
+	}
+
+	symbols := make([]SymbolInfo, 0, len(resp.Result))
+	for _, raw := range resp.Result {
+		var pair krakenAssetPair
+		if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, fmt.Errorf("error decoding Kraken asset pair: %v", err)
+		}
+
+		symbol := SymbolInfo{
+			Symbol:   krakenTicker(pair.Altname),
+			Status:   mapSymbolStatus(krakenSymbolStatuses, pair.Status),
+			TickSize: pair.TickSize,
+		}
+		if base, quote, ok := strings.Cut(pair.WSName, "/"); ok {
+			if alias, exists := krakenAssetAliases[base]; exists {
+				base = alias
+			}
+			if alias, exists := krakenAssetAliases[quote]; exists {
+				quote = alias
+			}
+			symbol.Symbol, symbol.Base, symbol.Quote = base+quote, base, quote
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
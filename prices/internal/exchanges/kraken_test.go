@@ -0,0 +1,298 @@
+package exchanges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKrakenAdapter_GetName tests the GetName method
+func TestKrakenAdapter_GetName(t *testing.T) {
+	adapter := NewKrakenAdapter()
+	assert.Equal(t, "kraken", adapter.GetName())
+}
+
+// TestNewKrakenAdapter tests the creation of a new adapter
+func TestNewKrakenAdapter(t *testing.T) {
+	adapter := NewKrakenAdapter()
+	assert.NotNil(t, adapter)
+	assert.NotNil(t, adapter.client)
+	assert.Equal(t, krakenBaseURL, adapter.baseURL)
+}
+
+// TestKrakenAdapter_IntervalMapping tests translation of API intervals to Kraken intervals
+func TestKrakenAdapter_IntervalMapping(t *testing.T) {
+	krakenInterval, err := mapInterval("kraken", krakenIntervals, Interval1d)
+	require.NoError(t, err)
+	assert.Equal(t, "1440", krakenInterval)
+
+	_, err = mapInterval("kraken", krakenIntervals, Interval1M)
+	assert.ErrorIs(t, err, ErrUnsupportedInterval)
+}
+
+// TestKrakenPairAliasing tests mapping between plain tickers and Kraken pair names
+func TestKrakenPairAliasing(t *testing.T) {
+	assert.Equal(t, "XBTUSD", krakenPair("BTCUSD"))
+	assert.Equal(t, "XBTUSDT", krakenPair("btcusdt"))
+	assert.Equal(t, "ETHXBT", krakenPair("ETHBTC"))
+	assert.Equal(t, "XDGUSD", krakenPair("DOGEUSD"))
+	assert.Equal(t, "ETHUSD", krakenPair("ETH-USD"))
+	assert.Equal(t, "XXBTZUSD", krakenPair("XXBTZUSD"))
+
+	assert.Equal(t, "BTCUSD", krakenTicker("XXBTZUSD"))
+	assert.Equal(t, "ETHEUR", krakenTicker("XETHZEUR"))
+	assert.Equal(t, "ETHBTC", krakenTicker("XETHXXBT"))
+	assert.Equal(t, "BTCUSDT", krakenTicker("XBTUSDT"))
+	assert.Equal(t, "DOGEUSD", krakenTicker("XDGUSD"))
+	assert.Equal(t, "SOLBTC", krakenTicker("SOLXBT"))
+	assert.Equal(t, "SOLUSD", krakenTicker("SOLUSD"))
+}
+
+// TestKrakenOHLCToCandle tests the candle fields filled from a Kraken OHLC row
+func TestKrakenOHLCToCandle(t *testing.T) {
+	row := []interface{}{json.Number("1672531200"), "16500.1", "16600.0", "16400.0", "16550.5", "16520.0", "10.5", json.Number("321")}
+
+	candle, err := krakenOHLCToCandle(row, Interval1d)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1672531200000), candle.OpenTime)
+	assert.Equal(t, 16550.5, candle.Close)
+	assert.Equal(t, 10.5, candle.Volume)
+	assert.Equal(t, 16520.0*10.5, candle.QuoteVolume)
+	assert.Equal(t, int64(321), candle.TradeCount)
+	assert.Equal(t, "16500.1", candle.Decimals.Open)
+	assert.Len(t, candle.UnavailableFields, 2)
+
+	_, err = krakenOHLCToCandle(row[:4], Interval1d)
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+}
+
+// TestTradeBucket tests aggregating trades into a candle with exact volumes
+func TestTradeBucket(t *testing.T) {
+	bucket := newTradeBucket(1672531200000)
+	for _, row := range [][]interface{}{
+		{"100.5", "0.1", json.Number("1672531210.1"), "b"},
+		{"101.25", "0.2", json.Number("1672531220.2"), "s"},
+		{"99.75", "0.3", json.Number("1672531230.3"), "b"},
+	} {
+		trade, err := krakenRowToTrade(row)
+		require.NoError(t, err)
+		bucket.add(trade)
+	}
+
+	candle := bucket.candle(Interval1m)
+	assert.Equal(t, "100.5", candle.Decimals.Open)
+	assert.Equal(t, "101.25", candle.Decimals.High)
+	assert.Equal(t, "99.75", candle.Decimals.Low)
+	assert.Equal(t, "99.75", candle.Decimals.Close)
+	assert.Equal(t, "0.6", candle.Decimals.Volume)
+	assert.Equal(t, "60.225", candle.Decimals.QuoteVolume)
+	assert.Equal(t, "0.4", candle.Decimals.TakerBuyBaseVolume)
+	assert.Equal(t, "39.975", candle.Decimals.TakerBuyQuoteVolume)
+	assert.Equal(t, int64(3), candle.TradeCount)
+	assert.Empty(t, candle.UnavailableFields)
+
+	_, err := krakenRowToTrade([]interface{}{"abc", "0.1", json.Number("1672531210.1"), "b"})
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+}
+
+// krakenStandIn is a local stand-in for the Kraken OHLC and trades endpoints over
+// an hourly history. Every hour holds three trades; the OHLC endpoint only serves
+// the newest 720 candles.
+type krakenStandIn struct {
+	start       time.Time
+	hours       int
+	ohlcCalls   int
+	tradesCalls int
+}
+
+func newKrakenStandIn(t *testing.T, start time.Time, hours int) (*httptest.Server, *krakenStandIn) {
+	standIn := &krakenStandIn{start: start, hours: hours}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		require.Equal(t, "XBTUSD", query.Get("pair"))
+
+		var rows [][]interface{}
+		result := map[string]interface{}{}
+		switch r.URL.Path {
+		case "/0/public/OHLC":
+			standIn.ohlcCalls++
+			require.Equal(t, "60", query.Get("interval"))
+			for i := max(0, hours-krakenOHLCWindow); i < hours; i++ {
+				openTime := start.Add(time.Duration(i) * time.Hour).Unix()
+				rows = append(rows, []interface{}{
+					openTime, strconv.Itoa(100 + i), strconv.Itoa(101 + i), strconv.Itoa(99 + i), strconv.Itoa(99 + i),
+					strconv.Itoa(100 + i), "2.0", 3,
+				})
+			}
+			result["last"] = start.Add(time.Duration(hours-1) * time.Hour).Unix()
+		case "/0/public/Trades":
+			standIn.tradesCalls++
+			since, err := strconv.ParseInt(query.Get("since"), 10, 64)
+			require.NoError(t, err)
+			count, _ := strconv.Atoi(query.Get("count"))
+
+			// Trades strictly after since, the last one's timestamp is the next cursor
+			last := since
+			for i := 0; i < hours && len(rows) < count; i++ {
+				for j, trade := range []struct {
+					price, volume, side string
+				}{
+					{strconv.Itoa(100 + i), "0.5", "b"},
+					{strconv.Itoa(101 + i), "0.25", "s"},
+					{strconv.Itoa(99 + i), "1.25", "b"},
+				} {
+					tradeTime := start.Add(time.Duration(i)*time.Hour + time.Duration(j+1)*10*time.Minute)
+					if tradeTime.UnixNano() <= since || len(rows) >= count {
+						continue
+					}
+					rows = append(rows, []interface{}{
+						trade.price, trade.volume, json.Number(fmt.Sprintf("%d.0000", tradeTime.Unix())), trade.side, "l", "", len(rows),
+					})
+					last = tradeTime.UnixNano()
+				}
+			}
+			result["last"] = strconv.FormatInt(last, 10)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+
+		result["XXBTZUSD"] = rows
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": []string{}, "result": result})
+	}))
+	t.Cleanup(server.Close)
+	return server, standIn
+}
+
+// TestKrakenAdapter_GetHistoricalPricesFullHistory tests rebuilding candles older
+// than the OHLC window from trades
+func TestKrakenAdapter_GetHistoricalPricesFullHistory(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, standIn := newKrakenStandIn(t, start, 1220)
+
+	adapter := NewKrakenAdapter()
+	adapter.baseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:    "BTCUSD",
+		Interval:  Interval1h,
+		StartTime: start,
+		EndTime:   start.Add(1219 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 1220)
+	assert.Equal(t, 1, standIn.ohlcCalls)
+	assert.Equal(t, 2, standIn.tradesCalls)
+
+	for i, price := range prices {
+		require.Equal(t, start.Add(time.Duration(i)*time.Hour).UnixMilli(), price.OpenTime)
+		assert.Equal(t, float64(100+i), price.Open)
+		assert.Equal(t, float64(101+i), price.High)
+		assert.Equal(t, float64(99+i), price.Low)
+		assert.Equal(t, float64(99+i), price.Close)
+		assert.Equal(t, 2.0, price.Volume)
+		assert.Equal(t, int64(3), price.TradeCount)
+	}
+
+	// Candles rebuilt from trades carry the taker volumes OHLC candles lack
+	assert.Equal(t, 1.75, prices[0].TakerBuyBaseVolume)
+	assert.Empty(t, prices[0].UnavailableFields)
+	assert.Len(t, prices[1219].UnavailableFields, 2)
+}
+
+// TestKrakenAdapter_GetHistoricalPricesLimit tests that rebuilding stops at the limit
+func TestKrakenAdapter_GetHistoricalPricesLimit(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, standIn := newKrakenStandIn(t, start, 1220)
+
+	adapter := NewKrakenAdapter()
+	adapter.baseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:    "BTCUSD",
+		Interval:  Interval1h,
+		StartTime: start,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 10)
+	assert.Equal(t, start.Add(9*time.Hour).UnixMilli(), prices[9].OpenTime)
+	assert.Equal(t, 1, standIn.tradesCalls)
+}
+
+// TestKrakenAdapter_GetHistoricalPricesLatest tests that recent candles come from OHLC alone
+func TestKrakenAdapter_GetHistoricalPricesLatest(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, standIn := newKrakenStandIn(t, start, 1220)
+
+	adapter := NewKrakenAdapter()
+	adapter.baseURL = server.URL
+
+	prices, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "BTCUSD",
+		Interval: Interval1h,
+		EndTime:  start.Add(1219 * time.Hour),
+		Limit:    100,
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 100)
+	assert.Equal(t, start.Add(1120*time.Hour).UnixMilli(), prices[0].OpenTime)
+	assert.Equal(t, start.Add(1219*time.Hour).UnixMilli(), prices[99].OpenTime)
+	assert.Equal(t, 1, standIn.ohlcCalls)
+	assert.Equal(t, 0, standIn.tradesCalls)
+}
+
+// TestKrakenAdapter_APIError tests that Kraken errors are reported
+func TestKrakenAdapter_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": []string{"EQuery:Unknown asset pair"}})
+	}))
+	defer server.Close()
+
+	adapter := NewKrakenAdapter()
+	adapter.baseURL = server.URL
+
+	_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{
+		Ticker:   "NOPEUSD",
+		Interval: Interval1h,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EQuery:Unknown asset pair")
+}
+
+// TestKrakenAdapter_Integration tests the real implementation
+// It's skipped by default to avoid network dependencies during unit testing
+func TestKrakenAdapter_Integration(t *testing.T) {
+	t.Skip("Skipping integration test - requires network access")
+}
+
+// TestKrakenAdapter_GetSymbols tests listing Kraken pairs under the tickers our clients use
+func TestKrakenAdapter_GetSymbols(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/0/public/AssetPairs", r.URL.Path)
+		fmt.Fprint(w, `{"error":[],"result":{
+			"XXBTZUSD":{"altname":"XBTUSD","wsname":"XBT/USD","tick_size":"0.1","status":"online"},
+			"ETHUSDT":{"altname":"ETHUSDT","wsname":"ETH/USDT","tick_size":"0.01","status":"cancel_only"}
+		}}`)
+	}))
+	defer server.Close()
+
+	adapter := NewKrakenAdapter()
+	adapter.baseURL = server.URL
+
+	symbols, err := adapter.GetSymbols(context.Background(), MarketSpot)
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []SymbolInfo{
+		{Symbol: "BTCUSD", Base: "BTC", Quote: "USD", Status: SymbolStatusTrading, TickSize: "0.1"},
+		{Symbol: "ETHUSDT", Base: "ETH", Quote: "USDT", Status: SymbolStatusHalted, TickSize: "0.01"},
+	}, symbols)
+}
@@ -0,0 +1,131 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrListingNotFound is returned when an exchange has no candles of a symbol
+var ErrListingNotFound = errors.New("symbol has no candles")
+
+// ErrListingNotProvided is returned when an exchange cannot tell when a symbol was listed
+var ErrListingNotProvided = errors.New("listing time not provided")
+
+// listingSearchStart is the earliest listing time searched for, before any
+// exchange in the factory was trading
+var listingSearchStart = time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// listingProbeSize is the number of candles asked for by each probe of a listing
+// search, which fits in a single request on every exchange
+const listingProbeSize = 100
+
+// ListingQuery describes the symbol whose listing time is requested
+type ListingQuery struct {
+	Ticker string
+	Market Market
+}
+
+// ListingProvider is implemented by adapters that can find when a symbol started trading
+type ListingProvider interface {
+	// GetListingTime returns the open time of the first daily candle of a symbol
+	GetListingTime(ctx context.Context, query ListingQuery) (time.Time, error)
+}
+
+// GetListingProvider returns the listing provider of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetListingProvider(exchange string) (ListingProvider, bool) {
+	provider, ok := f.adapters[exchange].(ListingProvider)
+	return provider, ok
+}
+
+// listingKey identifies a symbol whose listing time is cached
+type listingKey struct {
+	exchange string
+	ticker   string
+	market   Market
+}
+
+// ListingTime returns the open time of the first daily candle of a symbol. Listing
+// times never change, so each is looked up once and then served from memory.
+func (f *ExchangeFactory) ListingTime(ctx context.Context, exchange string, query ListingQuery) (time.Time, error) {
+	if query.Market == "" {
+		query.Market = DefaultMarket
+	}
+	key := listingKey{exchange: exchange, ticker: strings.ToUpper(query.Ticker), market: query.Market}
+
+	f.listingsMu.Lock()
+	listing, ok := f.listings[key]
+	f.listingsMu.Unlock()
+	if ok {
+		return listing, nil
+	}
+
+	provider, ok := f.GetListingProvider(exchange)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: %s does not provide listing times", ErrListingNotProvided, exchange)
+	}
+	listing, err := provider.GetListingTime(ctx, query)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	f.listingsMu.Lock()
+	if f.listings == nil {
+		f.listings = make(map[listingKey]time.Time)
+	}
+	f.listings[key] = listing
+	f.listingsMu.Unlock()
+
+	return listing, nil
+}
+
+// searchListingTime finds the first daily candle of a symbol by binary-searching
+// the day before which an adapter has no candles. Every probe asks for the latest
+// candles up to a day, so the search takes a few requests however long ago the
+// symbol was listed.
+func searchListingTime(ctx context.Context, adapter ExchangeAdapter, query ListingQuery) (time.Time, error) {
+	day := Interval1d.Duration()
+
+	// probe returns the earliest of the latest candles opening at or before end
+	probe := func(end time.Time) (time.Time, bool, error) {
+		prices, err := CollectHistoricalPrices(ctx, adapter, PriceQuery{
+			Ticker:    query.Ticker,
+			Market:    query.Market,
+			Interval:  Interval1d,
+			PriceType: PriceTypeLast,
+			Limit:     listingProbeSize,
+			EndTime:   end,
+		})
+		if err != nil || len(prices) == 0 {
+			return time.Time{}, false, err
+		}
+		return time.UnixMilli(prices[0].OpenTime).UTC(), true, nil
+	}
+
+	// Candles open at or before hi but not at or before lo
+	hi := time.Now().UTC().Truncate(day)
+	first, found, err := probe(hi)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !found {
+		return time.Time{}, fmt.Errorf("%w: %s %s (%s)", ErrListingNotFound, adapter.GetName(), query.Ticker, query.Market)
+	}
+
+	lo := listingSearchStart
+	for hi.Sub(lo) > day {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(day)
+		earliest, found, err := probe(mid)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if found {
+			hi, first = mid, earliest
+		} else {
+			lo = mid
+		}
+	}
+	return first, nil
+}
@@ -0,0 +1,121 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// listedAdapter serves a daily candle for every day since a symbol was listed,
+// answering each query with the latest candles up to its end time
+type listedAdapter struct {
+	listed  time.Time
+	probes  int
+	failure error
+}
+
+func (a *listedAdapter) GetName() string {
+	return "listed"
+}
+
+func (a *listedAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	a.probes++
+	if a.failure != nil {
+		return a.failure
+	}
+
+	day := Interval1d.Duration()
+	end := query.EndTime
+	if end.IsZero() {
+		end = time.Now().UTC()
+	}
+
+	var page []*pb.PricesResponse
+	for open := end.Truncate(day); !open.Before(a.listed); open = open.Add(-day) {
+		if int64(len(page)) >= query.Limit {
+			break
+		}
+		page = append([]*pb.PricesResponse{{OpenTime: open.UnixMilli()}}, page...)
+	}
+	if len(page) == 0 {
+		return nil
+	}
+	return handle(page)
+}
+
+func (a *listedAdapter) GetListingTime(ctx context.Context, query ListingQuery) (time.Time, error) {
+	return searchListingTime(ctx, a, query)
+}
+
+// TestSearchListingTime tests finding the first daily candle of a symbol
+func TestSearchListingTime(t *testing.T) {
+	query := ListingQuery{Ticker: "BTCUSDT", Market: MarketSpot}
+
+	for _, listed := range []time.Time{
+		time.Date(2017, 8, 17, 0, 0, 0, 0, time.UTC),
+		listingSearchStart.Add(24 * time.Hour),
+		time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3),
+	} {
+		adapter := &listedAdapter{listed: listed}
+
+		listing, err := searchListingTime(context.Background(), adapter, query)
+
+		require.NoError(t, err)
+		assert.Equal(t, listed, listing)
+		assert.LessOrEqual(t, adapter.probes, 15)
+	}
+
+	t.Run("a symbol without candles is not found", func(t *testing.T) {
+		adapter := &listedAdapter{listed: time.Now().Add(48 * time.Hour)}
+
+		_, err := searchListingTime(context.Background(), adapter, query)
+
+		assert.ErrorIs(t, err, ErrListingNotFound)
+		assert.Equal(t, 1, adapter.probes)
+	})
+
+	t.Run("exchange failures are returned", func(t *testing.T) {
+		adapter := &listedAdapter{failure: errors.New("rate limited")}
+
+		_, err := searchListingTime(context.Background(), adapter, query)
+
+		assert.EqualError(t, err, "rate limited")
+	})
+}
+
+// TestExchangeFactory_ListingTime tests that listing times are looked up once per symbol
+func TestExchangeFactory_ListingTime(t *testing.T) {
+	listed := time.Date(2019, 9, 8, 0, 0, 0, 0, time.UTC)
+	adapter := &listedAdapter{listed: listed}
+	factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"listed": adapter}}
+
+	listing, err := factory.ListingTime(context.Background(), "listed", ListingQuery{Ticker: "BTCUSDT"})
+	require.NoError(t, err)
+	assert.Equal(t, listed, listing)
+	probes := adapter.probes
+
+	listing, err = factory.ListingTime(context.Background(), "listed", ListingQuery{Ticker: "btcusdt", Market: MarketSpot})
+	require.NoError(t, err)
+	assert.Equal(t, listed, listing)
+	assert.Equal(t, probes, adapter.probes)
+
+	t.Run("other markets are looked up separately", func(t *testing.T) {
+		_, err := factory.ListingTime(context.Background(), "listed", ListingQuery{Ticker: "BTCUSDT", Market: MarketLinearPerp})
+
+		require.NoError(t, err)
+		assert.Greater(t, adapter.probes, probes)
+	})
+
+	t.Run("adapters without listing times are rejected", func(t *testing.T) {
+		factory.RegisterAdapter(NewKrakenAdapter())
+
+		_, err := factory.ListingTime(context.Background(), "kraken", ListingQuery{Ticker: "XBTUSD"})
+
+		assert.ErrorIs(t, err, ErrListingNotProvided)
+	})
+}
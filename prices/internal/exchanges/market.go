@@ -0,0 +1,54 @@
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Market is the kind of instrument candles are requested for
+type Market string
+
+// Supported markets
+const (
+	MarketSpot        Market = "spot"
+	MarketLinearPerp  Market = "linear_perp"  // perpetual swaps margined in the quote asset, such as USDT
+	MarketInversePerp Market = "inverse_perp" // perpetual swaps margined in the base asset
+	MarketDatedFuture Market = "dated_future" // futures with an expiry date
+)
+
+// allMarkets lists the supported markets in the order they are documented
+var allMarkets = []Market{MarketSpot, MarketLinearPerp, MarketInversePerp, MarketDatedFuture}
+
+// DefaultMarket is used when a request does not specify a market
+const DefaultMarket = MarketSpot
+
+// ErrUnsupportedMarket is returned when a market is unknown or an exchange cannot serve it
+var ErrUnsupportedMarket = errors.New("unsupported market")
+
+// ParseMarket validates a market string, falling back to the default when it is empty
+func ParseMarket(value string) (Market, error) {
+	if value == "" {
+		return DefaultMarket, nil
+	}
+
+	market := Market(value)
+	switch market {
+	case MarketSpot, MarketLinearPerp, MarketInversePerp, MarketDatedFuture:
+		return market, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedMarket, value)
+}
+
+// mapMarket translates a market into an exchange-specific notation. An empty
+// market means the default one.
+func mapMarket(exchange string, notation map[Market]string, market Market) (string, error) {
+	if market == "" {
+		market = DefaultMarket
+	}
+
+	value, ok := notation[market]
+	if !ok {
+		return "", fmt.Errorf("%w: %s does not support %s", ErrUnsupportedMarket, exchange, market)
+	}
+	return value, nil
+}
@@ -0,0 +1,36 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseMarket tests validation of API markets
+func TestParseMarket(t *testing.T) {
+	market, err := ParseMarket("")
+	require.NoError(t, err)
+	assert.Equal(t, MarketSpot, market)
+
+	for _, value := range []string{"spot", "linear_perp", "inverse_perp", "dated_future"} {
+		market, err := ParseMarket(value)
+		require.NoError(t, err)
+		assert.Equal(t, Market(value), market)
+	}
+
+	_, err = ParseMarket("options")
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
+
+// TestMapMarket tests translation of markets into exchange notations
+func TestMapMarket(t *testing.T) {
+	notation := map[Market]string{MarketSpot: "spot"}
+
+	value, err := mapMarket("test", notation, "")
+	require.NoError(t, err)
+	assert.Equal(t, "spot", value)
+
+	_, err = mapMarket("test", notation, MarketLinearPerp)
+	assert.ErrorIs(t, err, ErrUnsupportedMarket)
+}
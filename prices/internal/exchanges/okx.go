@@ -0,0 +1,317 @@
+package exchanges
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// okxIntervals maps API intervals to OKX candle bars, using the UTC-aligned
+// variants where OKX otherwise aligns bars to Hong Kong time
+var okxIntervals = map[Interval]string{
+	Interval1m:  "1m",
+	Interval5m:  "5m",
+	Interval15m: "15m",
+	Interval1h:  "1H",
+	Interval4h:  "4H",
+	Interval1d:  "1Dutc",
+	Interval1w:  "1Wutc",
+	Interval1M:  "1Mutc",
+}
+
+// okxMarkets maps markets to the suffix of OKX instrument IDs. Dated futures
+// carry their expiry date, so they need a full instrument ID such as BTC-USD-250328.
+var okxMarkets = map[Market]string{
+	MarketSpot:        "",
+	MarketLinearPerp:  "-SWAP",
+	MarketInversePerp: "-SWAP",
+	MarketDatedFuture: "",
+}
+
+// okxMaxPageSize is the largest number of candles the OKX history endpoint returns per request
+const okxMaxPageSize = 100
+
+// okxBaseURL is the OKX REST API address
+const okxBaseURL = "https://www.okx.com"
+
+// okxUnavailableFields lists the candle fields OKX candles do not carry
+var okxUnavailableFields = []pb.CandleField{
+	pb.CandleField_CANDLE_FIELD_TRADE_COUNT,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME,
+	pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME,
+}
+
+// OKXAdapter implements the adapter for OKX exchange
+type OKXAdapter struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewOKXAdapter creates a new adapter for OKX
+func NewOKXAdapter() *OKXAdapter {
+	return &OKXAdapter{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: okxBaseURL,
+	}
+}
+
+// GetName returns the name of the exchange
+func (a *OKXAdapter) GetName() string {
+	return "okx"
+}
+
+// okxCandlesResponse is the envelope of the OKX candles endpoints. Every candle is
+// [ts, open, high, low, close, vol, volCcy, volCcyQuote, confirm], newest first.
+type okxCandlesResponse struct {
+	Code string     `json:"code"`
+	Msg  string     `json:"msg"`
+	Data [][]string `json:"data"`
+}
+
+// GetHistoricalPrices retrieves historical price data from OKX
+func (a *OKXAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	log.Printf("Getting historical prices from OKX for %s (%s, %s)", query.Ticker, query.Market, query.Interval)
+
+	bar, err := mapInterval(a.GetName(), okxIntervals, query.Interval)
+	if err != nil {
+		return err
+	}
+	if err := requireLastPrices(a.GetName(), query); err != nil {
+		return err
+	}
+	instID, err := okxInstrumentID(query.Ticker, query.Market)
+	if err != nil {
+		return err
+	}
+
+	// Set default limit if not specified
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	// OKX returns the newest candles before the after cursor, so ranges are
+	// walked in windows that fit in a single page
+	p := pager{
+		interval: query.Interval,
+		pageSize: okxMaxPageSize,
+		windowed: true,
+		fetch:    a.fetchCandles(instID, query.Interval, bar),
+	}
+	return p.walk(ctx, query, handle)
+}
+
+// fetchCandles returns a page fetcher for the OKX history-candles endpoint
+func (a *OKXAdapter) fetchCandles(instID string, interval Interval, bar string) pageFetcher {
+	return func(ctx context.Context, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+		// The after and before cursors are exclusive: after returns candles older
+		// than the timestamp, before returns candles newer than it
+		params := url.Values{}
+		params.Set("instId", instID)
+		params.Set("bar", bar)
+		params.Set("limit", strconv.Itoa(limit))
+		params.Set("after", strconv.FormatInt(end.UnixMilli()+1, 10))
+		if !start.IsZero() {
+			params.Set("before", strconv.FormatInt(start.UnixMilli()-1, 10))
+		}
+
+		// Fetch data from OKX API
+		var resp okxCandlesResponse
+		if err := getJSON(ctx, a.client, a.baseURL+"/api/v5/market/history-candles", params, &resp); err != nil {
+			return nil, fmt.Errorf("error fetching data from OKX: %v", err)
+		}
+
+		// Check if request was successful
+		if resp.Code != "0" {
+			return nil, fmt.Errorf("okx API error %s: %s", resp.Code, resp.Msg)
+		}
+
+		// Convert data to response format
+		// Spot instruments are BASE-QUOTE, derivatives carry a -SWAP or expiry suffix
+		derivative := strings.Count(instID, "-") >= 2
+		prices := make([]*pb.PricesResponse, 0, len(resp.Data))
+		for _, row := range resp.Data {
+			candle, err := okxCandleToCandle(row, interval, derivative)
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, candle)
+		}
+
+		return prices, nil
+	}
+}
+
+// okxCandleToCandle converts an OKX candle row into a candle. Spot volume is in
+// the base currency, derivative volume in contracts with the base currency volume in volCcy.
+func okxCandleToCandle(row []string, interval Interval, derivative bool) (*pb.PricesResponse, error) {
+	if len(row) < 8 {
+		return nil, fmt.Errorf("%w: okx sent a candle with %d fields", ErrMalformedCandle, len(row))
+	}
+
+	volume, quoteVolume := row[5], row[6]
+	if derivative {
+		volume, quoteVolume = row[6], row[7]
+	}
+
+	parser := decimalParser{exchange: "okx"}
+	timestamp := parser.int("timestamp", row[0])
+	open := parser.float("open", row[1])
+	high := parser.float("high", row[2])
+	low := parser.float("low", row[3])
+	close := parser.float("close", row[4])
+	baseVolume := parser.float("volume", volume)
+	quote := parser.float("quote volume", quoteVolume)
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (candle at %s)", parser.err, row[0])
+	}
+
+	candle := newCandle(timestamp, interval)
+	candle.Open = open
+	candle.High = high
+	candle.Low = low
+	candle.Close = close
+	candle.Volume = baseVolume
+	candle.QuoteVolume = quote
+	candle.UnavailableFields = okxUnavailableFields
+	candle.Decimals = &pb.DecimalValues{
+		Open:        row[1],
+		High:        row[2],
+		Low:         row[3],
+		Close:       row[4],
+		Volume:      volume,
+		QuoteVolume: quoteVolume,
+	}
+
+	return candle, nil
+}
+
+// okxInstrumentID converts a ticker into an OKX instrument ID for the market.
+// Plain tickers such as BTCUSDT become BTC-USDT, or BTC-USDT-SWAP for perpetuals;
+// OKX instrument IDs are passed through.
+func okxInstrumentID(ticker string, market Market) (string, error) {
+	suffix, err := mapMarket("okx", okxMarkets, market)
+	if err != nil {
+		return "", err
+	}
+
+	instID := hyphenateTicker(ticker)
+	if strings.Contains(ticker, "-") {
+		return instID, nil
+	}
+	if market == MarketDatedFuture {
+		return "", fmt.Errorf("%w: okx dated futures need an instrument ID such as BTC-USD-250328, got %s", ErrUnsupportedMarket, ticker)
+	}
+	return instID + suffix, nil
+}
+
+// GetListingTime finds the open time of the first daily candle of an instrument
+func (a *OKXAdapter) GetListingTime(ctx context.Context, query ListingQuery) (time.Time, error) {
+	return searchListingTime(ctx, a, query)
+}
+
+// SupportedMarkets returns the markets OKX serves candles for
+func (a *OKXAdapter) SupportedMarkets() []Market {
+	return marketsOf(okxMarkets)
+}
+
+// SupportedIntervals returns the candle intervals OKX serves
+func (a *OKXAdapter) SupportedIntervals() []Interval {
+	return intervalsOf(okxIntervals)
+}
+
+// MaxPageSize returns the largest number of candles the OKX history endpoint returns per request
+func (a *OKXAdapter) MaxPageSize() int {
+	return okxMaxPageSize
+}
+
+// okxSymbolStatuses maps OKX instrument states
+var okxSymbolStatuses = map[string]SymbolStatus{
+	"live":    SymbolStatusTrading,
+	"suspend": SymbolStatusHalted,
+	"preopen": SymbolStatusPending,
+	"test":    SymbolStatusPending,
+}
+
+// okxInstrumentSource is an OKX instrument type and the contract type of the
+// instruments listed for a market; spot and futures are not split by contract type
+type okxInstrumentSource struct {
+	instType string
+	ctType   string
+}
+
+// okxInstrumentSources maps markets to the OKX instruments listed for them
+var okxInstrumentSources = map[Market]okxInstrumentSource{
+	MarketSpot:        {instType: "SPOT"},
+	MarketLinearPerp:  {instType: "SWAP", ctType: "linear"},
+	MarketInversePerp: {instType: "SWAP", ctType: "inverse"},
+	MarketDatedFuture: {instType: "FUTURES"},
+}
+
+// okxInstrumentsResponse is the envelope of the OKX instruments endpoint.
+// Derivatives carry their assets in the underlying, such as BTC-USDT.
+type okxInstrumentsResponse struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		InstID   string `json:"instId"`
+		Uly      string `json:"uly"`
+		BaseCcy  string `json:"baseCcy"`
+		QuoteCcy string `json:"quoteCcy"`
+		CtType   string `json:"ctType"`
+		State    string `json:"state"`
+		TickSz   string `json:"tickSz"`
+		ListTime string `json:"listTime"`
+	} `json:"data"`
+}
+
+// GetSymbols lists the instruments of a market from the OKX instruments endpoint.
+// Symbols are instrument IDs, which the prices API accepts as they are.
+func (a *OKXAdapter) GetSymbols(ctx context.Context, market Market) ([]SymbolInfo, error) {
+	if market == "" {
+		market = DefaultMarket
+	}
+	source, ok := okxInstrumentSources[market]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support %s", ErrUnsupportedMarket, a.GetName(), market)
+	}
+
+	params := url.Values{}
+	params.Set("instType", source.instType)
+
+	var resp okxInstrumentsResponse
+	if err := getJSON(ctx, a.client, a.baseURL+"/api/v5/public/instruments", params, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching symbols from OKX: %v", err)
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("okx API error %s: %s", resp.Code, resp.Msg)
+	}
+
+	symbols := make([]SymbolInfo, 0, len(resp.Data))
+	for _, instrument := range resp.Data {
+		if source.ctType != "" && instrument.CtType != source.ctType {
+			continue
+		}
+
+		base, quote := instrument.BaseCcy, instrument.QuoteCcy
+		if base == "" {
+			base, quote, _ = strings.Cut(instrument.Uly, "-")
+		}
+		listTime, _ := strconv.ParseInt(instrument.ListTime, 10, 64)
+		symbols = append(symbols, SymbolInfo{
+			Symbol:      instrument.InstID,
+			Base:        base,
+			Quote:       quote,
+			Status:      mapSymbolStatus(okxSymbolStatuses, instrument.State),
+			TickSize:    instrument.TickSz,
+			ListingTime: unixMilliOrZero(listTime),
+		})
+	}
+	return symbols, nil
+}
@@ -0,0 +1,85 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// DefaultOrderBookDepth is used when an order book query does not specify a depth
+const DefaultOrderBookDepth = 20
+
+// MaxOrderBookDepth is the largest number of levels per side an order book query may ask for
+const MaxOrderBookDepth = 200
+
+// ErrUnsupportedOrderBookDepth is returned when an order book depth is out of range
+var ErrUnsupportedOrderBookDepth = errors.New("unsupported order book depth")
+
+// OrderBookQuery describes the order book requested from an exchange
+type OrderBookQuery struct {
+	Ticker string
+	Market Market
+
+	// Depth is the number of price levels returned per side; zero means the default
+	Depth int
+}
+
+// OrderBookProvider is implemented by adapters that serve current order book depth.
+// Exchanges do not serve historical order books, so history is recorded from it.
+type OrderBookProvider interface {
+	// GetOrderBook retrieves the best levels of each side of an order book, best first
+	GetOrderBook(ctx context.Context, query OrderBookQuery) (*pb.OrderBookSnapshot, error)
+}
+
+// GetOrderBookProvider returns the order book provider of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetOrderBookProvider(exchange string) (OrderBookProvider, bool) {
+	provider, ok := f.adapters[exchange].(OrderBookProvider)
+	return provider, ok
+}
+
+// ParseOrderBookDepth validates a depth, falling back to the default when it is zero
+func ParseOrderBookDepth(depth int) (int, error) {
+	if depth == 0 {
+		return DefaultOrderBookDepth, nil
+	}
+	if depth < 0 || depth > MaxOrderBookDepth {
+		return 0, fmt.Errorf("%w: %d levels, at most %d are served", ErrUnsupportedOrderBookDepth, depth, MaxOrderBookDepth)
+	}
+	return depth, nil
+}
+
+// orderBookLimit returns the smallest of the depths an exchange serves that covers
+// the requested one
+func orderBookLimit(exchange string, limits []int, depth int) (int, error) {
+	for _, limit := range limits {
+		if limit >= depth {
+			return limit, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s serves at most %d levels", ErrUnsupportedOrderBookDepth, exchange, limits[len(limits)-1])
+}
+
+// orderBookSide collects the price levels of one side of an order book
+type orderBookSide struct {
+	parser *decimalParser
+	depth  int
+	levels []*pb.OrderBookLevel
+}
+
+// add parses a price level, ignoring those past the depth
+func (s *orderBookSide) add(price, quantity string) {
+	if len(s.levels) >= s.depth {
+		return
+	}
+	s.levels = append(s.levels, &pb.OrderBookLevel{
+		Price:    s.parser.float("price", price),
+		Quantity: s.parser.float("quantity", quantity),
+	})
+}
+
+// newOrderBookSides returns collectors for the bids and asks of an order book of a depth
+func newOrderBookSides(parser *decimalParser, depth int) (*orderBookSide, *orderBookSide) {
+	return &orderBookSide{parser: parser, depth: depth}, &orderBookSide{parser: parser, depth: depth}
+}
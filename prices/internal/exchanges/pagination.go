@@ -0,0 +1,179 @@
+package exchanges
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// pageFetcher requests a single page of up to limit candles with open times in
+// [start, end]. A zero start asks for the most recent candles up to end.
+type pageFetcher func(ctx context.Context, start, end time.Time, limit int) ([]*pb.PricesResponse, error)
+
+// pager walks a time range through an exchange's kline endpoint one page at a time
+type pager struct {
+	interval Interval
+	pageSize int
+
+	// windowed bounds every request to as many intervals as fit in one page, for
+	// exchanges that return the newest candles of a range that holds more than a page
+	windowed bool
+
+	fetch pageFetcher
+}
+
+// walk passes the candles matching the query to handle in chronological order
+func (p pager) walk(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	end := query.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	if query.StartTime.IsZero() {
+		return p.latest(ctx, end, query.Limit, handle)
+	}
+	return p.forward(ctx, query.StartTime, end, query.Limit, handle)
+}
+
+// forward pages from start towards end until the range or the limit is exhausted,
+// handing every page over as soon as it is fetched
+func (p pager) forward(ctx context.Context, start, end time.Time, limit int64, handle PageHandler) error {
+	sent := int64(0)
+
+	cursor := start
+	for !cursor.After(end) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Windows always span a full page, so that history before a symbol was
+		// listed is skipped a page at a time; the page is trimmed to the limit below
+		size := p.pageSize
+		if !p.windowed && limit > 0 && limit-sent < int64(size) {
+			size = int(limit - sent)
+		}
+
+		pageEnd := end
+		if p.windowed {
+			windowEnd := cursor.Add(time.Duration(size)*p.interval.Duration() - time.Millisecond)
+			if windowEnd.Before(end) {
+				pageEnd = windowEnd
+			}
+		}
+
+		raw, err := p.fetch(ctx, cursor, pageEnd, size)
+		if err != nil {
+			return err
+		}
+
+		page := normalizePage(raw, cursor, pageEnd)
+		if limit > 0 && sent+int64(len(page)) > limit {
+			page = page[:limit-sent]
+		}
+		if len(page) > 0 {
+			if err := handle(page); err != nil {
+				return err
+			}
+			sent += int64(len(page))
+		}
+
+		if limit > 0 && sent >= limit {
+			return nil
+		}
+
+		switch {
+		case p.windowed:
+			// The whole window fit in one page, so continue right after it
+			cursor = pageEnd.Add(time.Millisecond)
+		case len(raw) < size || len(page) == 0:
+			// The exchange has nothing more in the range
+			return nil
+		default:
+			cursor = time.UnixMilli(page[len(page)-1].OpenTime + 1)
+		}
+	}
+
+	return nil
+}
+
+// latest pages backwards from end until limit candles are collected. Pages
+// arrive newest first, so they are buffered and handed over in chronological
+// order at the end; memory is bounded by the limit.
+func (p pager) latest(ctx context.Context, end time.Time, limit int64, handle PageHandler) error {
+	var pages [][]*pb.PricesResponse
+	collected := int64(0)
+
+	cursor := end
+	for collected < limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size := p.pageSize
+		if limit-collected < int64(size) {
+			size = int(limit - collected)
+		}
+
+		raw, err := p.fetch(ctx, time.Time{}, cursor, size)
+		if err != nil {
+			return err
+		}
+
+		page := normalizePage(raw, time.Time{}, cursor)
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		collected += int64(len(page))
+
+		if len(raw) < size {
+			break
+		}
+		cursor = time.UnixMilli(page[0].OpenTime - 1)
+	}
+
+	// Pages were collected newest first; the oldest one may exceed the limit
+	excess := collected - limit
+	for i := len(pages) - 1; i >= 0; i-- {
+		page := pages[i]
+		if excess > 0 {
+			drop := min(excess, int64(len(page)))
+			page = page[drop:]
+			excess -= drop
+		}
+		if len(page) == 0 {
+			continue
+		}
+		if err := handle(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizePage orders a page chronologically, drops candles outside [start, end]
+// and removes duplicate open times. A zero start leaves the range open.
+func normalizePage(page []*pb.PricesResponse, start, end time.Time) []*pb.PricesResponse {
+	sort.SliceStable(page, func(i, j int) bool {
+		return page[i].OpenTime < page[j].OpenTime
+	})
+
+	result := page[:0]
+	for _, price := range page {
+		if !start.IsZero() && price.OpenTime < start.UnixMilli() {
+			continue
+		}
+		if price.OpenTime > end.UnixMilli() {
+			continue
+		}
+		if len(result) > 0 && result[len(result)-1].OpenTime == price.OpenTime {
+			continue
+		}
+		result = append(result, price)
+	}
+
+	return result
+}
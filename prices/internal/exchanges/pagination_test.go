@@ -0,0 +1,242 @@
+package exchanges
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// fakeKlineSource simulates an exchange kline endpoint over a fixed candle history
+type fakeKlineSource struct {
+	openTimes []int64
+	calls     int
+	// newestFirst makes a page hold the newest candles of the range, like Bybit
+	newestFirst bool
+}
+
+func newFakeKlineSource(start time.Time, interval time.Duration, count int) *fakeKlineSource {
+	source := &fakeKlineSource{}
+	for i := 0; i < count; i++ {
+		source.openTimes = append(source.openTimes, start.Add(time.Duration(i)*interval).UnixMilli())
+	}
+	return source
+}
+
+func (s *fakeKlineSource) fetch(ctx context.Context, start, end time.Time, limit int) ([]*pb.PricesResponse, error) {
+	s.calls++
+
+	var matching []int64
+	for _, openTime := range s.openTimes {
+		if (!start.IsZero() && openTime < start.UnixMilli()) || openTime > end.UnixMilli() {
+			continue
+		}
+		matching = append(matching, openTime)
+	}
+
+	// Without a start time, or for newest-first exchanges, return the latest candles
+	if start.IsZero() || s.newestFirst {
+		if len(matching) > limit {
+			matching = matching[len(matching)-limit:]
+		}
+		sort.Slice(matching, func(i, j int) bool { return matching[i] > matching[j] })
+	} else if len(matching) > limit {
+		matching = matching[:limit]
+	}
+
+	page := make([]*pb.PricesResponse, 0, len(matching))
+	for _, openTime := range matching {
+		page = append(page, &pb.PricesResponse{OpenTime: openTime})
+	}
+	return page, nil
+}
+
+// collect walks the query and gathers every handed over page
+func collect(ctx context.Context, p pager, query PriceQuery) ([]*pb.PricesResponse, error) {
+	var prices []*pb.PricesResponse
+	err := p.walk(ctx, query, func(page []*pb.PricesResponse) error {
+		prices = append(prices, page...)
+		return nil
+	})
+	return prices, err
+}
+
+func openTimes(prices []*pb.PricesResponse) []int64 {
+	result := make([]int64, 0, len(prices))
+	for _, price := range prices {
+		result = append(result, price.OpenTime)
+	}
+	return result
+}
+
+// TestPager_Forward tests walking a range that spans several pages
+func TestPager_Forward(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 2500)
+
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	prices, err := collect(context.Background(), p, PriceQuery{
+		Interval:  Interval1d,
+		StartTime: start,
+		EndTime:   start.Add(2499 * 24 * time.Hour),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes, openTimes(prices))
+	assert.Equal(t, 3, source.calls)
+}
+
+// TestPager_ForwardWindowed tests walking a range in page-sized windows
+func TestPager_ForwardWindowed(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, time.Hour, 2500)
+	source.newestFirst = true
+
+	p := pager{interval: Interval1h, pageSize: 1000, windowed: true, fetch: source.fetch}
+	prices, err := collect(context.Background(), p, PriceQuery{
+		Interval:  Interval1h,
+		StartTime: start,
+		EndTime:   start.Add(2499 * time.Hour),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes, openTimes(prices))
+	assert.Equal(t, 3, source.calls)
+
+	t.Run("a small limit keeps full windows over history before the listing", func(t *testing.T) {
+		listed := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		source := newFakeKlineSource(listed, 24*time.Hour, 10)
+		source.newestFirst = true
+
+		p := pager{interval: Interval1d, pageSize: 1000, windowed: true, fetch: source.fetch}
+		prices, err := collect(context.Background(), p, PriceQuery{
+			Interval:  Interval1d,
+			StartTime: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:   listed.Add(9 * 24 * time.Hour),
+			Limit:     5,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, source.openTimes[:5], openTimes(prices))
+		assert.Equal(t, 4, source.calls)
+	})
+}
+
+// TestPager_ForwardLimit tests that range queries stop at the limit
+func TestPager_ForwardLimit(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 2500)
+
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	prices, err := collect(context.Background(), p, PriceQuery{
+		Interval:  Interval1d,
+		StartTime: start,
+		EndTime:   start.Add(2499 * 24 * time.Hour),
+		Limit:     1500,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes[:1500], openTimes(prices))
+	assert.Equal(t, 2, source.calls)
+}
+
+// TestPager_Latest tests collecting the most recent candles across pages
+func TestPager_Latest(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 2500)
+
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	prices, err := collect(context.Background(), p, PriceQuery{
+		Interval: Interval1d,
+		EndTime:  start.Add(2499 * 24 * time.Hour),
+		Limit:    1200,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes[1300:], openTimes(prices))
+	assert.Equal(t, 2, source.calls)
+}
+
+// TestPager_LatestShortHistory tests asking for more candles than the exchange has
+func TestPager_LatestShortHistory(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 50)
+
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	prices, err := collect(context.Background(), p, PriceQuery{
+		Interval: Interval1d,
+		EndTime:  start.Add(100 * 24 * time.Hour),
+		Limit:    100,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes, openTimes(prices))
+	assert.Equal(t, 1, source.calls)
+}
+
+// TestPager_Cancelled tests that no pages are fetched after the context is cancelled
+func TestPager_Cancelled(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 2500)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	_, err := collect(ctx, p, PriceQuery{Interval: Interval1d, StartTime: start})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, source.calls)
+}
+
+// TestPager_StreamsPages tests that pages are handed over before the next one is fetched
+func TestPager_StreamsPages(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 2500)
+
+	var callsAtPage []int
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	err := p.walk(context.Background(), PriceQuery{Interval: Interval1d, StartTime: start}, func(page []*pb.PricesResponse) error {
+		callsAtPage = append(callsAtPage, source.calls)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, callsAtPage)
+}
+
+// TestPager_StopsOnCancel tests that cancelling between pages stops further requests
+func TestPager_StopsOnCancel(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 24*time.Hour, 2500)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := pager{interval: Interval1d, pageSize: 1000, fetch: source.fetch}
+	err := p.walk(ctx, PriceQuery{Interval: Interval1d, StartTime: start}, func(page []*pb.PricesResponse) error {
+		cancel()
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, source.calls)
+}
+
+// TestNormalizePage tests ordering, range filtering and deduplication of a page
+func TestNormalizePage(t *testing.T) {
+	page := []*pb.PricesResponse{
+		{OpenTime: 4000},
+		{OpenTime: 2000},
+		{OpenTime: 3000},
+		{OpenTime: 2000},
+		{OpenTime: 1000},
+	}
+
+	result := normalizePage(page, time.UnixMilli(2000), time.UnixMilli(3500))
+	assert.Equal(t, []int64{2000, 3000}, openTimes(result))
+}
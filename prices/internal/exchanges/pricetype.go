@@ -0,0 +1,69 @@
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PriceType is the price series candles are built from
+type PriceType string
+
+// Supported price types
+const (
+	PriceTypeLast         PriceType = "last" // last traded prices
+	PriceTypeMark         PriceType = "mark"
+	PriceTypeIndex        PriceType = "index"
+	PriceTypePremiumIndex PriceType = "premium_index"
+)
+
+// DefaultPriceType is used when a request does not specify a price type
+const DefaultPriceType = PriceTypeLast
+
+// ErrUnsupportedPriceType is returned when a price type is unknown or an exchange cannot serve it
+var ErrUnsupportedPriceType = errors.New("unsupported price type")
+
+// ParsePriceType validates a price type string, falling back to the default when it is empty
+func ParsePriceType(value string) (PriceType, error) {
+	if value == "" {
+		return DefaultPriceType, nil
+	}
+
+	priceType := PriceType(value)
+	switch priceType {
+	case PriceTypeLast, PriceTypeMark, PriceTypeIndex, PriceTypePremiumIndex:
+		return priceType, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedPriceType, value)
+}
+
+// PriceTypeProvider is implemented by adapters that serve price series other than
+// last traded prices. Adapters without it only serve last traded prices.
+type PriceTypeProvider interface {
+	// SupportedPriceTypes returns every price type the adapter serves for at least one market
+	SupportedPriceTypes() []PriceType
+}
+
+// supportedPriceTypes returns the price types an adapter serves
+func supportedPriceTypes(adapter ExchangeAdapter) []PriceType {
+	if provider, ok := adapter.(PriceTypeProvider); ok {
+		return provider.SupportedPriceTypes()
+	}
+	return []PriceType{PriceTypeLast}
+}
+
+// unsupportedPriceType reports a price type an exchange does not serve for a market
+func unsupportedPriceType(exchange string, priceType PriceType, market Market) error {
+	if market == "" {
+		market = DefaultMarket
+	}
+	return fmt.Errorf("%w: %s does not serve %s prices for %s", ErrUnsupportedPriceType, exchange, priceType, market)
+}
+
+// requireLastPrices rejects queries for price series other than last traded
+// prices, for adapters that serve no others
+func requireLastPrices(exchange string, query PriceQuery) error {
+	if query.PriceType != "" && query.PriceType != PriceTypeLast {
+		return unsupportedPriceType(exchange, query.PriceType, query.Market)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParsePriceType tests validation of API price types
+func TestParsePriceType(t *testing.T) {
+	priceType, err := ParsePriceType("")
+	require.NoError(t, err)
+	assert.Equal(t, PriceTypeLast, priceType)
+
+	for _, value := range []string{"last", "mark", "index", "premium_index"} {
+		priceType, err := ParsePriceType(value)
+		require.NoError(t, err)
+		assert.Equal(t, PriceType(value), priceType)
+	}
+
+	_, err = ParsePriceType("funding")
+	assert.ErrorIs(t, err, ErrUnsupportedPriceType)
+}
+
+// TestExchangeFactory_SupportsPriceType tests the price types reported per exchange
+func TestExchangeFactory_SupportsPriceType(t *testing.T) {
+	factory := NewExchangeFactory()
+
+	for _, exchange := range []string{"binance", "bybit"} {
+		assert.ElementsMatch(t, []PriceType{PriceTypeLast, PriceTypeMark, PriceTypeIndex, PriceTypePremiumIndex}, factory.SupportedPriceTypes(exchange))
+		assert.True(t, factory.SupportsPriceType(exchange, PriceTypePremiumIndex))
+	}
+
+	// Adapters without the capability serve last traded prices only
+	for _, exchange := range []string{"okx", "coinbase", "kraken"} {
+		assert.Equal(t, []PriceType{PriceTypeLast}, factory.SupportedPriceTypes(exchange))
+		assert.False(t, factory.SupportsPriceType(exchange, PriceTypeMark))
+	}
+
+	assert.Nil(t, factory.SupportedPriceTypes("unknown"))
+	assert.False(t, factory.SupportsPriceType("unknown", PriceTypeLast))
+}
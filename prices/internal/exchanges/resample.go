@@ -0,0 +1,408 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// ErrInvalidUTCOffset is returned for a malformed or out of range UTC offset
+var ErrInvalidUTCOffset = errors.New("invalid UTC offset")
+
+// maxUTCOffset is the largest offset of a time zone from UTC
+const maxUTCOffset = 14 * time.Hour
+
+// ParseUTCOffset parses a time zone offset written as ±HH:MM, such as +05:30; an
+// empty offset or Z is UTC
+func ParseUTCOffset(value string) (time.Duration, error) {
+	if value == "" || value == "Z" {
+		return 0, nil
+	}
+
+	invalid := fmt.Errorf("%w: %s, expected ±HH:MM such as +05:30", ErrInvalidUTCOffset, value)
+	if len(value) != 6 || (value[0] != '+' && value[0] != '-') || value[3] != ':' {
+		return 0, invalid
+	}
+	hours, err := strconv.Atoi(value[1:3])
+	if err != nil {
+		return 0, invalid
+	}
+	minutes, err := strconv.Atoi(value[4:6])
+	if err != nil || minutes >= 60 {
+		return 0, invalid
+	}
+
+	offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if offset > maxUTCOffset {
+		return 0, invalid
+	}
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// weekAnchor is the Monday candles of weeks are counted from, as exchanges open
+// weekly candles on Mondays
+var weekAnchor = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// buckets places times into the candles of an interval, which open at midnight
+// of the first day of months, midnight of Mondays or multiples of their length
+// since the epoch in the time zone of the offset
+type buckets struct {
+	interval Interval
+	count    int
+	unit     byte
+	offset   time.Duration
+}
+
+func newBuckets(interval Interval, offset time.Duration) buckets {
+	count, unit, _ := interval.split()
+	return buckets{interval: interval, count: count, unit: unit, offset: offset}
+}
+
+// open returns the open time of the candle holding t
+func (b buckets) open(t time.Time) time.Time {
+	local := t.UTC().Add(b.offset)
+	if b.unit == 'M' {
+		months := floorDiv(int64(local.Year()-1970)*12+int64(local.Month()-1), int64(b.count)) * int64(b.count)
+		return time.Date(1970, time.Month(months+1), 1, 0, 0, 0, 0, time.UTC).Add(-b.offset)
+	}
+
+	anchor := time.Unix(0, 0).UTC()
+	if b.unit == 'w' {
+		anchor = weekAnchor
+	}
+	length := b.interval.Duration()
+	elapsed := time.Duration(floorDiv(int64(local.Sub(anchor)), int64(length)) * int64(length))
+	return anchor.Add(elapsed).Add(-b.offset)
+}
+
+// add returns the open time of the candle n candles after the one opening at open
+func (b buckets) add(open time.Time, n int) time.Time {
+	if b.unit == 'M' {
+		return open.Add(b.offset).AddDate(0, n*b.count, 0).Add(-b.offset)
+	}
+	return open.Add(time.Duration(n) * b.interval.Duration())
+}
+
+// OpenTimeBefore returns the open time of the candle n candles before the one
+// holding t, with candles aligned to the offset as resampled ones are
+func OpenTimeBefore(interval Interval, offset time.Duration, t time.Time, n int) time.Time {
+	b := newBuckets(interval, offset)
+	return b.add(b.open(t), -n)
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// ResamplingAdapter builds candles of intervals an exchange does not serve, or
+// serves aligned to another time zone, from the candles of the longest interval
+// it serves that fits evenly into them
+type ResamplingAdapter struct {
+	ExchangeAdapter
+
+	// intervals are the intervals the exchange serves
+	intervals []Interval
+
+	// offset is the time zone offset resampled candles are aligned to
+	offset time.Duration
+
+	// now tells the time, bounding the range of the candles resampled
+	now func() time.Time
+}
+
+// NewResamplingAdapter wraps an adapter serving the given intervals to serve any
+// interval, aligned to a time zone offset from UTC
+func NewResamplingAdapter(adapter ExchangeAdapter, intervals []Interval, offset time.Duration) *ResamplingAdapter {
+	return &ResamplingAdapter{ExchangeAdapter: adapter, intervals: intervals, offset: offset, now: time.Now}
+}
+
+// sourceInterval returns the longest interval the exchange serves whose candles
+// fit evenly into those of the interval, which is the interval itself when the
+// exchange serves it aligned to the offset
+func (a *ResamplingAdapter) sourceInterval(interval Interval) (Interval, error) {
+	for i := len(a.intervals) - 1; i >= 0; i-- {
+		if a.fits(a.intervals[i], interval) {
+			return a.intervals[i], nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s cannot build %s candles from the intervals it serves", ErrUnsupportedInterval, a.GetName(), interval)
+}
+
+// fits reports whether candles of interval are made of whole candles of source
+func (a *ResamplingAdapter) fits(source, interval Interval) bool {
+	sourceCount, sourceUnit, ok := source.split()
+	if !ok {
+		return false
+	}
+	count, unit, ok := interval.split()
+	if !ok {
+		return false
+	}
+
+	// Exchanges align months to UTC, and weeks to different weekdays, so longer
+	// weeks are built from days
+	switch sourceUnit {
+	case 'M':
+		return unit == 'M' && count%sourceCount == 0 && a.offset == 0
+	case 'w':
+		return source == interval && a.offset == 0
+	}
+
+	length := source.Duration()
+	if a.offset%length != 0 {
+		return false
+	}
+	if unit == 'M' {
+		return intervalUnits['d']%length == 0
+	}
+	return interval.Duration()%length == 0
+}
+
+// errResampleLimit stops fetching source candles once enough candles are built
+var errResampleLimit = errors.New("resample limit reached")
+
+// GetHistoricalPrices passes the candles matching the query to handle in
+// chronological order, resampling them from another interval when the exchange
+// does not serve the interval aligned to the offset
+func (a *ResamplingAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	source, err := a.sourceInterval(query.Interval)
+	if err != nil {
+		return err
+	}
+	if source == query.Interval {
+		return a.ExchangeAdapter.GetHistoricalPrices(ctx, query, handle)
+	}
+
+	b := newBuckets(query.Interval, a.offset)
+	end := query.EndTime
+	if end.IsZero() {
+		end = a.now()
+	}
+
+	// Candles open within the range, so the source range starts at the first
+	// candle opening at or after the start and ends with the candle holding the end
+	first := b.open(query.StartTime)
+	if query.StartTime.IsZero() {
+		first = b.add(b.open(end), -int(max(query.Limit, 1)-1))
+	} else if first.Before(query.StartTime) {
+		first = b.add(first, 1)
+	}
+	last := b.open(end)
+	if last.Before(first) {
+		return nil
+	}
+
+	sourceQuery := query
+	sourceQuery.Interval = source
+	sourceQuery.Limit = 0
+	sourceQuery.StartTime = first
+	sourceQuery.EndTime = b.add(last, 1).Add(-time.Millisecond)
+	if now := a.now(); sourceQuery.EndTime.After(now) {
+		sourceQuery.EndTime = now
+	}
+
+	resampler := &resampler{buckets: b, limit: query.Limit, handle: handle}
+	err = a.ExchangeAdapter.GetHistoricalPrices(ctx, sourceQuery, resampler.add)
+	if err == nil {
+		err = resampler.flush()
+	}
+	if errors.Is(err, errResampleLimit) {
+		return nil
+	}
+	return err
+}
+
+// resampler aggregates chronological source candles into the candles of the
+// buckets, passing every page of completed candles on
+type resampler struct {
+	buckets buckets
+	limit   int64
+	handle  PageHandler
+
+	current *candleBucket
+	sent    int64
+}
+
+// add aggregates a page of source candles, passing on the candles it completes
+func (r *resampler) add(prices []*pb.PricesResponse) error {
+	var page []*pb.PricesResponse
+	for _, price := range prices {
+		open := r.buckets.open(time.UnixMilli(price.OpenTime))
+		if r.current != nil && r.current.openTime != open.UnixMilli() {
+			page = append(page, r.candle())
+			r.current = nil
+		}
+		if r.current == nil {
+			r.current = &candleBucket{openTime: open.UnixMilli()}
+		}
+		r.current.add(price)
+	}
+	return r.send(page)
+}
+
+// flush passes on the last candle, which may still be in progress
+func (r *resampler) flush() error {
+	if r.current == nil {
+		return nil
+	}
+	candle := r.candle()
+	r.current = nil
+	return r.send([]*pb.PricesResponse{candle})
+}
+
+// candle converts the current bucket into a candle, which closes where the next
+// bucket opens; months in the time zone of the offset differ in length from UTC ones
+func (r *resampler) candle() *pb.PricesResponse {
+	candle := r.current.candle(r.buckets.interval)
+	next := r.buckets.add(time.UnixMilli(r.current.openTime), 1)
+	candle.CloseTime = next.Add(-time.Millisecond).UnixMilli()
+	return candle
+}
+
+// send passes a page of candles on, stopping once the limit of a range is reached
+func (r *resampler) send(page []*pb.PricesResponse) error {
+	if len(page) == 0 {
+		return nil
+	}
+	if r.limit > 0 && r.sent+int64(len(page)) > r.limit {
+		page = page[:r.limit-r.sent]
+	}
+	r.sent += int64(len(page))
+	if err := r.handle(page); err != nil {
+		return err
+	}
+	if r.limit > 0 && r.sent >= r.limit {
+		return errResampleLimit
+	}
+	return nil
+}
+
+// candleBucket aggregates the candles of one resampled interval, summing volumes
+// exactly while every candle carries its decimals
+type candleBucket struct {
+	openTime int64
+	candles  int
+
+	open, high, low, close        float64
+	volume, quote                 float64
+	takerBuyBase, takerBuyQuote   float64
+	tradeCount                    int64
+	unavailable                   map[pb.CandleField]bool
+	unavailableOrder              []pb.CandleField
+	decimals                      *pb.DecimalValues
+	exact                         bool
+	volumeSum, quoteSum           decimalSum
+	takerBuyBaseSum, takerBuyQSum decimalSum
+}
+
+// add aggregates a candle into the bucket
+func (b *candleBucket) add(price *pb.PricesResponse) {
+	if b.candles == 0 {
+		b.open, b.high, b.low = price.Open, price.High, price.Low
+		b.exact = price.Decimals != nil
+		if b.exact {
+			b.decimals = &pb.DecimalValues{Open: price.Decimals.Open, High: price.Decimals.High, Low: price.Decimals.Low}
+		}
+	}
+	b.candles++
+	b.exact = b.exact && price.Decimals != nil
+
+	if price.High > b.high {
+		b.high = price.High
+		if b.exact {
+			b.decimals.High = price.Decimals.High
+		}
+	}
+	if price.Low < b.low {
+		b.low = price.Low
+		if b.exact {
+			b.decimals.Low = price.Decimals.Low
+		}
+	}
+	b.close = price.Close
+	if b.exact {
+		b.decimals.Close = price.Decimals.Close
+	}
+
+	b.volume += price.Volume
+	b.quote += price.QuoteVolume
+	b.takerBuyBase += price.TakerBuyBaseVolume
+	b.takerBuyQuote += price.TakerBuyQuoteVolume
+	b.tradeCount += price.TradeCount
+
+	for _, field := range price.UnavailableFields {
+		if b.unavailable == nil {
+			b.unavailable = make(map[pb.CandleField]bool)
+		}
+		if !b.unavailable[field] {
+			b.unavailable[field] = true
+			b.unavailableOrder = append(b.unavailableOrder, field)
+		}
+	}
+
+	if b.exact {
+		b.exact = addDecimal(&b.volumeSum, price.Decimals.Volume) &&
+			addDecimal(&b.quoteSum, price.Decimals.QuoteVolume) &&
+			addDecimal(&b.takerBuyBaseSum, price.Decimals.TakerBuyBaseVolume) &&
+			addDecimal(&b.takerBuyQSum, price.Decimals.TakerBuyQuoteVolume)
+	}
+}
+
+// addDecimal adds a decimal string to a sum; an empty value is a field the
+// candle does not carry. It reports false for values it cannot parse.
+func addDecimal(sum *decimalSum, value string) bool {
+	if value == "" {
+		return true
+	}
+	parsed, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return false
+	}
+	sum.add(parsed, decimalScale(value))
+	return true
+}
+
+// candle converts the bucket into a candle of the interval
+func (b *candleBucket) candle(interval Interval) *pb.PricesResponse {
+	candle := newCandle(b.openTime, interval)
+	candle.Open = b.open
+	candle.High = b.high
+	candle.Low = b.low
+	candle.Close = b.close
+	candle.Volume = b.volume
+	candle.QuoteVolume = b.quote
+	candle.TradeCount = b.tradeCount
+	candle.TakerBuyBaseVolume = b.takerBuyBase
+	candle.TakerBuyQuoteVolume = b.takerBuyQuote
+	candle.UnavailableFields = b.unavailableOrder
+
+	if b.exact {
+		candle.Decimals = b.decimals
+		candle.Decimals.Volume = exactSum(&b.volumeSum, b.unavailable[pb.CandleField_CANDLE_FIELD_VOLUME])
+		candle.Decimals.QuoteVolume = exactSum(&b.quoteSum, b.unavailable[pb.CandleField_CANDLE_FIELD_QUOTE_VOLUME])
+		candle.Decimals.TakerBuyBaseVolume = exactSum(&b.takerBuyBaseSum, b.unavailable[pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME])
+		candle.Decimals.TakerBuyQuoteVolume = exactSum(&b.takerBuyQSum, b.unavailable[pb.CandleField_CANDLE_FIELD_TAKER_BUY_QUOTE_VOLUME])
+	}
+	return candle
+}
+
+// exactSum prints a decimal sum, leaving fields the candles do not carry empty
+func exactSum(sum *decimalSum, unavailable bool) string {
+	if unavailable {
+		return ""
+	}
+	return sum.String()
+}
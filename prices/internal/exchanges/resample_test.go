@@ -0,0 +1,285 @@
+package exchanges
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// syntheticAdapter serves a candle for every period of the queried interval in
+// pages of five, recording the queries it receives. Prices rise by one every
+// minute since the epoch, so aggregated values can be told from the open time.
+type syntheticAdapter struct {
+	queries []PriceQuery
+}
+
+func (a *syntheticAdapter) GetName() string {
+	return "synthetic"
+}
+
+func (a *syntheticAdapter) GetHistoricalPrices(ctx context.Context, query PriceQuery, handle PageHandler) error {
+	a.queries = append(a.queries, query)
+	end := query.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	var page []*pb.PricesResponse
+	for open := query.StartTime; !open.After(end); open = query.Interval.CloseTime(open).Add(time.Millisecond) {
+		page = append(page, syntheticCandle(open, query.Interval))
+		if len(page) == 5 {
+			if err := handle(page); err != nil {
+				return err
+			}
+			page = nil
+		}
+	}
+	if len(page) == 0 {
+		return nil
+	}
+	return handle(page)
+}
+
+func syntheticPrice(open time.Time) float64 {
+	return float64(open.Unix() / 60)
+}
+
+func syntheticCandle(open time.Time, interval Interval) *pb.PricesResponse {
+	candle := newCandle(open.UnixMilli(), interval)
+	price := syntheticPrice(open)
+	candle.Open, candle.High, candle.Low, candle.Close = price, price+0.5, price-0.5, price+0.25
+	candle.Volume, candle.QuoteVolume, candle.TradeCount = 0.1, 1.5, 2
+	candle.UnavailableFields = []pb.CandleField{pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME}
+	candle.Decimals = &pb.DecimalValues{Volume: "0.1", QuoteVolume: "1.5"}
+	return candle
+}
+
+// TestParseCustomInterval tests validation of intervals that may be resampled
+func TestParseCustomInterval(t *testing.T) {
+	for _, value := range []string{"1m", "10m", "2h", "3d", "2w", "1M", "3M", "90m"} {
+		interval, err := ParseCustomInterval(value)
+		require.NoError(t, err)
+		assert.Equal(t, Interval(value), interval)
+	}
+
+	interval, err := ParseCustomInterval("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultInterval, interval)
+
+	for _, value := range []string{"h", "0h", "02h", "-1h", "2x", "2H", "1.5h", "100000d"} {
+		_, err := ParseCustomInterval(value)
+		assert.ErrorIs(t, err, ErrUnsupportedInterval, value)
+	}
+
+	assert.Equal(t, 2*time.Hour, Interval("2h").Duration())
+	assert.Equal(t, time.Date(2024, 3, 31, 23, 59, 59, 999000000, time.UTC),
+		Interval("3M").CloseTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+// TestParseUTCOffset tests parsing time zone offsets
+func TestParseUTCOffset(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"":       0,
+		"Z":      0,
+		"+00:00": 0,
+		"+05:30": 5*time.Hour + 30*time.Minute,
+		"-04:00": -4 * time.Hour,
+		"+14:00": 14 * time.Hour,
+	} {
+		offset, err := ParseUTCOffset(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, offset, value)
+	}
+
+	for _, value := range []string{"05:30", "+5:30", "+0530", "+15:00", "+05:60", "+aa:00"} {
+		_, err := ParseUTCOffset(value)
+		assert.ErrorIs(t, err, ErrInvalidUTCOffset, value)
+	}
+}
+
+// TestOpenTimeBefore tests stepping back whole candles from a time
+func TestOpenTimeBefore(t *testing.T) {
+	at := time.Date(2024, 3, 15, 10, 20, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 15, 7, 0, 0, 0, time.UTC), OpenTimeBefore(Interval1h, 0, at, 3))
+	assert.Equal(t, time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), OpenTimeBefore("2d", 0, at, 1))
+	assert.Equal(t, time.Date(2024, 3, 13, 18, 30, 0, 0, time.UTC), OpenTimeBefore(Interval1d, 5*time.Hour+30*time.Minute, at, 1))
+	assert.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), OpenTimeBefore(Interval1M, 0, at, 3))
+	assert.Equal(t, at, OpenTimeBefore(Interval1m, 0, at, 0))
+}
+
+// TestResamplingAdapter_GetHistoricalPrices tests building candles of intervals
+// an exchange does not serve
+func TestResamplingAdapter_GetHistoricalPrices(t *testing.T) {
+	intervals := []Interval{Interval1m, Interval15m, Interval1h, Interval1d, Interval1w, Interval1M}
+	resample := func(offset time.Duration, query PriceQuery) ([]*pb.PricesResponse, *syntheticAdapter, error) {
+		source := &syntheticAdapter{}
+		adapter := NewResamplingAdapter(source, intervals, offset)
+		adapter.now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+		prices, err := CollectHistoricalPrices(context.Background(), adapter, query)
+		return prices, source, err
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("candles are aggregated from the nearest finer interval", func(t *testing.T) {
+		prices, source, err := resample(0, PriceQuery{
+			Interval:  "2h",
+			StartTime: start,
+			EndTime:   start.Add(5 * time.Hour),
+		})
+
+		require.NoError(t, err)
+		require.Len(t, source.queries, 1)
+		assert.Equal(t, Interval1h, source.queries[0].Interval)
+		require.Len(t, prices, 3)
+
+		first, second := start, start.Add(time.Hour)
+		candle := prices[0]
+		assert.Equal(t, first.UnixMilli(), candle.OpenTime)
+		assert.Equal(t, start.Add(2*time.Hour-time.Millisecond).UnixMilli(), candle.CloseTime)
+		assert.Equal(t, "2024-01-01", candle.Date)
+		assert.Equal(t, syntheticPrice(first), candle.Open)
+		assert.Equal(t, syntheticPrice(second)+0.5, candle.High)
+		assert.Equal(t, syntheticPrice(first)-0.5, candle.Low)
+		assert.Equal(t, syntheticPrice(second)+0.25, candle.Close)
+		assert.InDelta(t, 0.2, candle.Volume, 1e-9)
+		assert.Equal(t, 3.0, candle.QuoteVolume)
+		assert.Equal(t, int64(4), candle.TradeCount)
+		assert.Equal(t, []pb.CandleField{pb.CandleField_CANDLE_FIELD_TAKER_BUY_BASE_VOLUME}, candle.UnavailableFields)
+		assert.Equal(t, start.Add(4*time.Hour).UnixMilli(), prices[2].OpenTime)
+	})
+
+	t.Run("volumes are summed exactly", func(t *testing.T) {
+		prices, _, err := resample(0, PriceQuery{Interval: "10m", StartTime: start, EndTime: start})
+
+		require.NoError(t, err)
+		require.Len(t, prices, 1)
+		assert.Equal(t, "1.0", prices[0].Decimals.Volume)
+		assert.Equal(t, "15.0", prices[0].Decimals.QuoteVolume)
+		assert.Empty(t, prices[0].Decimals.TakerBuyBaseVolume)
+	})
+
+	t.Run("candles open within the range", func(t *testing.T) {
+		prices, source, err := resample(0, PriceQuery{
+			Interval:  "3d",
+			StartTime: start,
+			EndTime:   start.AddDate(0, 0, 7),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, Interval1d, source.queries[0].Interval)
+
+		// Days are counted from the epoch, so the first candle in range opens on the 3rd
+		require.Len(t, prices, 2)
+		assert.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC).UnixMilli(), prices[0].OpenTime)
+		assert.Equal(t, time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC).UnixMilli(), prices[1].OpenTime)
+		assert.Equal(t, syntheticPrice(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC))+0.25, prices[1].Close)
+	})
+
+	t.Run("weeks open on Mondays", func(t *testing.T) {
+		prices, source, err := resample(0, PriceQuery{
+			Interval:  "2w",
+			StartTime: start,
+			EndTime:   start.AddDate(0, 0, 27),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, Interval1d, source.queries[0].Interval)
+		require.Len(t, prices, 2)
+		for _, price := range prices {
+			assert.Equal(t, time.Monday, time.UnixMilli(price.OpenTime).UTC().Weekday())
+		}
+		assert.Equal(t, time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC).UnixMilli(), prices[1].OpenTime)
+	})
+
+	t.Run("months are built from months", func(t *testing.T) {
+		prices, source, err := resample(0, PriceQuery{
+			Interval:  "3M",
+			StartTime: start,
+			EndTime:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, Interval1M, source.queries[0].Interval)
+		require.Len(t, prices, 2)
+		assert.Equal(t, time.Date(2024, 3, 31, 23, 59, 59, 999000000, time.UTC).UnixMilli(), prices[0].CloseTime)
+		assert.Equal(t, syntheticPrice(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))+0.25, prices[0].Close)
+	})
+
+	t.Run("candles are aligned to the offset", func(t *testing.T) {
+		offset := 5*time.Hour + 30*time.Minute
+		prices, source, err := resample(offset, PriceQuery{
+			Interval:  Interval1d,
+			StartTime: start,
+			EndTime:   start.AddDate(0, 0, 1),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, Interval15m, source.queries[0].Interval)
+
+		// Midnight at +05:30 is 18:30 UTC of the day before
+		require.Len(t, prices, 1)
+		assert.Equal(t, time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC).UnixMilli(), prices[0].OpenTime)
+		assert.Equal(t, time.Date(2024, 1, 2, 18, 29, 59, 999000000, time.UTC).UnixMilli(), prices[0].CloseTime)
+		assert.Equal(t, int64(96*2), prices[0].TradeCount)
+
+		t.Run("months follow the calendar of the offset", func(t *testing.T) {
+			prices, source, err := resample(offset, PriceQuery{
+				Interval:  Interval1M,
+				StartTime: start,
+				EndTime:   time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, Interval15m, source.queries[0].Interval)
+			require.Len(t, prices, 1)
+			assert.Equal(t, time.Date(2024, 1, 31, 18, 30, 0, 0, time.UTC).UnixMilli(), prices[0].OpenTime)
+			assert.Equal(t, time.Date(2024, 2, 29, 18, 29, 59, 999000000, time.UTC).UnixMilli(), prices[0].CloseTime)
+		})
+	})
+
+	t.Run("the most recent candles without a start", func(t *testing.T) {
+		prices, source, err := resample(0, PriceQuery{Interval: "4d", Limit: 3})
+
+		require.NoError(t, err)
+		require.Len(t, prices, 3)
+		assert.Zero(t, source.queries[0].Limit)
+
+		// The last candle holds now and is still in progress
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, now, source.queries[0].EndTime)
+		last := time.UnixMilli(prices[2].OpenTime)
+		assert.False(t, last.After(now))
+		assert.True(t, now.Before(time.UnixMilli(prices[2].CloseTime)))
+		assert.Equal(t, (4 * 24 * time.Hour).Milliseconds(), prices[2].OpenTime-prices[1].OpenTime)
+	})
+
+	t.Run("the limit caps a range", func(t *testing.T) {
+		prices, _, err := resample(0, PriceQuery{Interval: "2h", StartTime: start, EndTime: start.AddDate(0, 0, 10), Limit: 4})
+
+		require.NoError(t, err)
+		assert.Len(t, prices, 4)
+	})
+
+	t.Run("intervals the exchange serves are passed through", func(t *testing.T) {
+		prices, source, err := resample(0, PriceQuery{Interval: Interval1h, StartTime: start, EndTime: start.Add(time.Hour)})
+
+		require.NoError(t, err)
+		assert.Len(t, prices, 2)
+		assert.Equal(t, PriceQuery{Interval: Interval1h, StartTime: start, EndTime: start.Add(time.Hour)}, source.queries[0])
+	})
+
+	t.Run("intervals that cannot be built are rejected", func(t *testing.T) {
+		source := &syntheticAdapter{}
+		adapter := NewResamplingAdapter(source, []Interval{Interval1h, Interval1d}, 0)
+
+		_, err := CollectHistoricalPrices(context.Background(), adapter, PriceQuery{Interval: "10m", StartTime: start})
+
+		assert.ErrorIs(t, err, ErrUnsupportedInterval)
+		assert.Empty(t, source.queries)
+	})
+}
@@ -0,0 +1,222 @@
+package exchanges
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// seriesFetcher requests a single page of up to limit records with timestamps in
+// [start, end]. A zero start asks for the most recent records up to end.
+type seriesFetcher[T any] func(ctx context.Context, start, end time.Time, limit int) ([]T, error)
+
+// seriesPager walks a time range of timestamped records other than candles, such
+// as funding rates, through an exchange endpoint one page at a time
+type seriesPager[T any] struct {
+	pageSize int
+
+	// newestFirst is set for exchanges that return the newest records of a range
+	// holding more than a page. Such ranges are walked forwards in windows of
+	// spacing times the page size, each window walked backwards and handed over
+	// once complete, so that memory is bounded by a window rather than the range.
+	newestFirst bool
+
+	// spacing is the usual time between records of a newestFirst series; without
+	// it a range is walked as a single window
+	spacing time.Duration
+
+	// period is set for records published at a fixed period, bounding every
+	// request of a range walk to as many periods as fit in one page. Ranges are
+	// then walked forwards whichever end of a range the exchange returns.
+	period time.Duration
+
+	// timestamp returns the epoch milliseconds a record is ordered by
+	timestamp func(T) int64
+
+	fetch seriesFetcher[T]
+}
+
+// walk passes the records with timestamps in [start, end] to handle in
+// chronological order. A zero start returns the most recent limit records, a
+// zero end means now and a zero limit leaves a range uncapped.
+func (p seriesPager[T]) walk(ctx context.Context, start, end time.Time, limit int64, handle func([]T) error) error {
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	switch {
+	case start.IsZero():
+		return p.backward(ctx, start, end, limit, handle)
+	case p.period > 0 || !p.newestFirst:
+		return p.forward(ctx, start, end, limit, handle)
+	}
+	return p.windows(ctx, start, end, limit, handle)
+}
+
+// forward pages from start towards end until the range or the limit is exhausted,
+// handing every page over as soon as it is fetched
+func (p seriesPager[T]) forward(ctx context.Context, start, end time.Time, limit int64, handle func([]T) error) error {
+	sent := int64(0)
+
+	cursor := start
+	for !cursor.After(end) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size := p.pageSize
+		if limit > 0 && limit-sent < int64(size) {
+			size = int(limit - sent)
+		}
+
+		pageEnd := end
+		if p.period > 0 {
+			windowEnd := cursor.Add(time.Duration(size)*p.period - time.Millisecond)
+			if windowEnd.Before(end) {
+				pageEnd = windowEnd
+			}
+		}
+
+		raw, err := p.fetch(ctx, cursor, pageEnd, size)
+		if err != nil {
+			return err
+		}
+
+		page := p.normalize(raw, cursor, pageEnd)
+		if limit > 0 && sent+int64(len(page)) > limit {
+			page = page[:limit-sent]
+		}
+		if len(page) > 0 {
+			if err := handle(page); err != nil {
+				return err
+			}
+			sent += int64(len(page))
+		}
+
+		switch {
+		case limit > 0 && sent >= limit:
+			return nil
+		case p.period > 0:
+			// The whole window fit in one page, so continue right after it
+			cursor = pageEnd.Add(time.Millisecond)
+		case len(raw) < size || len(page) == 0:
+			// The exchange has nothing more in the range
+			return nil
+		default:
+			cursor = time.UnixMilli(p.timestamp(page[len(page)-1]) + 1)
+		}
+	}
+
+	return nil
+}
+
+// windows pages from start towards end in windows of spacing times the page
+// size, walking every window backwards and handing it over once complete. The
+// walk stops as soon as limit records are handed over.
+func (p seriesPager[T]) windows(ctx context.Context, start, end time.Time, limit int64, handle func([]T) error) error {
+	span := time.Duration(p.pageSize) * p.spacing
+	if span <= 0 {
+		span = end.Sub(start) + time.Millisecond
+	}
+	sent := int64(0)
+
+	for cursor := start; !cursor.After(end); {
+		windowEnd := cursor.Add(span - time.Millisecond)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+
+		err := p.backward(ctx, cursor, windowEnd, 0, func(records []T) error {
+			if limit > 0 && sent+int64(len(records)) > limit {
+				records = records[:limit-sent]
+			}
+			sent += int64(len(records))
+			return handle(records)
+		})
+		if err != nil {
+			return err
+		}
+		if limit > 0 && sent >= limit {
+			return nil
+		}
+		cursor = windowEnd.Add(time.Millisecond)
+	}
+
+	return nil
+}
+
+// backward pages from end towards start, handing the records over once all are
+// collected. Without a start it stops once limit records are collected; with one
+// it walks the whole range, which windows keeps to a window.
+func (p seriesPager[T]) backward(ctx context.Context, start, end time.Time, limit int64, handle func([]T) error) error {
+	var pages [][]T
+	collected := int64(0)
+
+	cursor := end
+	for start.IsZero() || !cursor.Before(start) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size := p.pageSize
+		if start.IsZero() && limit-collected < int64(size) {
+			size = int(limit - collected)
+		}
+
+		raw, err := p.fetch(ctx, start, cursor, size)
+		if err != nil {
+			return err
+		}
+
+		page := p.normalize(raw, start, cursor)
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		collected += int64(len(page))
+
+		if len(raw) < size || (start.IsZero() && collected >= limit) {
+			break
+		}
+		cursor = time.UnixMilli(p.timestamp(page[0]) - 1)
+	}
+
+	// Pages were collected newest first
+	records := make([]T, 0, collected)
+	for i := len(pages) - 1; i >= 0; i-- {
+		records = append(records, pages[i]...)
+	}
+	if start.IsZero() && int64(len(records)) > limit {
+		records = records[int64(len(records))-limit:]
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	return handle(records)
+}
+
+// normalize orders a page chronologically, drops records outside [start, end]
+// and removes duplicate timestamps. A zero start leaves the range open.
+func (p seriesPager[T]) normalize(page []T, start, end time.Time) []T {
+	sort.SliceStable(page, func(i, j int) bool {
+		return p.timestamp(page[i]) < p.timestamp(page[j])
+	})
+
+	result := page[:0]
+	for _, record := range page {
+		timestamp := p.timestamp(record)
+		if !start.IsZero() && timestamp < start.UnixMilli() {
+			continue
+		}
+		if timestamp > end.UnixMilli() {
+			continue
+		}
+		if len(result) > 0 && p.timestamp(result[len(result)-1]) == timestamp {
+			continue
+		}
+		result = append(result, record)
+	}
+
+	return result
+}
@@ -0,0 +1,131 @@
+package exchanges
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// newFakeSeriesPager walks the fake kline source as a plain record series
+func newFakeSeriesPager(source *fakeKlineSource) seriesPager[*pb.PricesResponse] {
+	return seriesPager[*pb.PricesResponse]{
+		pageSize:    200,
+		newestFirst: source.newestFirst,
+		timestamp:   func(price *pb.PricesResponse) int64 { return price.OpenTime },
+		fetch:       source.fetch,
+	}
+}
+
+// collectSeries walks a range and gathers every handed over page, counting the pages
+func collectSeries(p seriesPager[*pb.PricesResponse], start, end time.Time, limit int64) ([]*pb.PricesResponse, int, error) {
+	var records []*pb.PricesResponse
+	pages := 0
+	err := p.walk(context.Background(), start, end, limit, func(page []*pb.PricesResponse) error {
+		records = append(records, page...)
+		pages++
+		return nil
+	})
+	return records, pages, err
+}
+
+// TestSeriesPager_Forward tests walking a range oldest first, page by page
+func TestSeriesPager_Forward(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 8*time.Hour, 500)
+
+	records, pages, err := collectSeries(newFakeSeriesPager(source), start, start.Add(499*8*time.Hour), 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes, openTimes(records))
+	assert.Equal(t, 3, source.calls)
+	assert.Equal(t, 3, pages)
+}
+
+// TestSeriesPager_NewestFirst tests walking a range forwards in windows, each
+// walked backwards, for exchanges that return the newest records of a range
+func TestSeriesPager_NewestFirst(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 8*time.Hour, 500)
+	source.newestFirst = true
+	p := newFakeSeriesPager(source)
+	p.spacing = 8 * time.Hour
+
+	records, pages, err := collectSeries(p, start, start.Add(499*8*time.Hour), 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes, openTimes(records))
+	assert.Equal(t, 3, source.calls)
+	assert.Equal(t, 3, pages)
+
+	// A limit stops the walk once the oldest records of the range are known
+	source.calls = 0
+	records, _, err = collectSeries(p, start.Add(8*time.Hour), start.Add(499*8*time.Hour), 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes[1:11], openTimes(records))
+	assert.Equal(t, 1, source.calls)
+
+	t.Run("records denser than the spacing", func(t *testing.T) {
+		source := newFakeKlineSource(start, time.Hour, 500)
+		source.newestFirst = true
+		p := newFakeSeriesPager(source)
+		p.spacing = 8 * time.Hour
+
+		records, pages, err := collectSeries(p, start, start.Add(499*time.Hour), 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, source.openTimes, openTimes(records))
+		assert.Equal(t, 3, source.calls)
+		assert.Equal(t, 1, pages)
+	})
+}
+
+// TestSeriesPager_Latest tests collecting the most recent records across pages
+func TestSeriesPager_Latest(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 8*time.Hour, 500)
+
+	records, _, err := collectSeries(newFakeSeriesPager(source), time.Time{}, start.Add(499*8*time.Hour), 300)
+
+	require.NoError(t, err)
+	assert.Equal(t, source.openTimes[200:], openTimes(records))
+	assert.Equal(t, 2, source.calls)
+}
+
+// TestSeriesPager_Cancelled tests that no pages are fetched after the context is cancelled
+func TestSeriesPager_Cancelled(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newFakeKlineSource(start, 8*time.Hour, 500)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := newFakeSeriesPager(source).walk(ctx, start, time.Time{}, 0, func([]*pb.PricesResponse) error { return nil })
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, source.calls)
+}
+
+// TestSeriesPager_Periodic tests walking a range in page-sized windows for
+// records published at a fixed period
+func TestSeriesPager_Periodic(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, newestFirst := range []bool{false, true} {
+		source := newFakeKlineSource(start, 5*time.Minute, 500)
+		source.newestFirst = newestFirst
+
+		p := newFakeSeriesPager(source)
+		p.period = 5 * time.Minute
+		records, pages, err := collectSeries(p, start, start.Add(499*5*time.Minute), 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, source.openTimes, openTimes(records))
+		assert.Equal(t, 3, source.calls)
+		assert.Equal(t, 3, pages)
+	}
+}
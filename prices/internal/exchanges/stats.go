@@ -0,0 +1,200 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// DerivativesStat is a positioning statistic of a derivatives contract
+type DerivativesStat string
+
+// Supported derivatives statistics
+const (
+	StatOpenInterest            DerivativesStat = "open_interest"
+	StatTopTraderLongShortRatio DerivativesStat = "top_trader_long_short_ratio" // long/short account ratio of the largest traders
+	StatGlobalLongShortRatio    DerivativesStat = "global_long_short_ratio"     // long/short account ratio of all traders
+)
+
+// DefaultDerivativesStat is used when a request does not specify a statistic
+const DefaultDerivativesStat = StatOpenInterest
+
+// DefaultStatsMarket is used when a statistics request does not specify a market
+const DefaultStatsMarket = MarketLinearPerp
+
+// ErrUnsupportedDerivativesStat is returned when a statistic is unknown or an exchange cannot serve it
+var ErrUnsupportedDerivativesStat = errors.New("unsupported derivatives statistic")
+
+// ParseDerivativesStat validates a statistic string, falling back to the default when it is empty
+func ParseDerivativesStat(value string) (DerivativesStat, error) {
+	if value == "" {
+		return DefaultDerivativesStat, nil
+	}
+
+	stat := DerivativesStat(value)
+	switch stat {
+	case StatOpenInterest, StatTopTraderLongShortRatio, StatGlobalLongShortRatio:
+		return stat, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedDerivativesStat, value)
+}
+
+// StatPeriod is the period derivatives statistics are sampled at
+type StatPeriod string
+
+// Supported statistic periods
+const (
+	StatPeriod5m  StatPeriod = "5m"
+	StatPeriod15m StatPeriod = "15m"
+	StatPeriod30m StatPeriod = "30m"
+	StatPeriod1h  StatPeriod = "1h"
+	StatPeriod2h  StatPeriod = "2h"
+	StatPeriod4h  StatPeriod = "4h"
+	StatPeriod6h  StatPeriod = "6h"
+	StatPeriod12h StatPeriod = "12h"
+	StatPeriod1d  StatPeriod = "1d"
+)
+
+// DefaultStatPeriod is used when a request does not specify a period
+const DefaultStatPeriod = StatPeriod1h
+
+// ErrUnsupportedStatPeriod is returned when a period is unknown or an exchange cannot serve it
+var ErrUnsupportedStatPeriod = errors.New("unsupported statistic period")
+
+// supportedStatPeriods holds the length of each statistic period
+var supportedStatPeriods = map[StatPeriod]time.Duration{
+	StatPeriod5m:  5 * time.Minute,
+	StatPeriod15m: 15 * time.Minute,
+	StatPeriod30m: 30 * time.Minute,
+	StatPeriod1h:  time.Hour,
+	StatPeriod2h:  2 * time.Hour,
+	StatPeriod4h:  4 * time.Hour,
+	StatPeriod6h:  6 * time.Hour,
+	StatPeriod12h: 12 * time.Hour,
+	StatPeriod1d:  24 * time.Hour,
+}
+
+// ParseStatPeriod validates a period string, falling back to the default when it is empty
+func ParseStatPeriod(value string) (StatPeriod, error) {
+	if value == "" {
+		return DefaultStatPeriod, nil
+	}
+
+	period := StatPeriod(value)
+	if _, ok := supportedStatPeriods[period]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedStatPeriod, value)
+	}
+	return period, nil
+}
+
+// Duration returns the length of the period
+func (p StatPeriod) Duration() time.Duration {
+	return supportedStatPeriods[p]
+}
+
+// mapStatPeriod translates a period into an exchange-specific notation
+func mapStatPeriod(exchange string, notation map[StatPeriod]string, period StatPeriod) (string, error) {
+	value, ok := notation[period]
+	if !ok {
+		return "", fmt.Errorf("%w: %s does not support %s", ErrUnsupportedStatPeriod, exchange, period)
+	}
+	return value, nil
+}
+
+// DerivativesStatsQuery describes the derivatives statistics requested from an exchange
+type DerivativesStatsQuery struct {
+	Ticker string
+	Market Market
+	Stat   DerivativesStat
+	Period StatPeriod
+
+	// Limit caps the number of samples returned. Without a start time the most
+	// recent ones are returned; zero means no cap for range queries.
+	Limit int64
+
+	// StartTime and EndTime bound sample times, inclusive. A zero EndTime means now.
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// DerivativesStatsHandler receives a page of statistic samples in chronological order
+type DerivativesStatsHandler func(stats []*pb.DerivativesStat) error
+
+// DerivativesStatsProvider is implemented by adapters that serve the history of
+// open interest and long/short ratios of derivatives contracts
+type DerivativesStatsProvider interface {
+	// GetDerivativesStats retrieves statistic samples in chronological order,
+	// passing every page to handle as soon as it is fetched
+	GetDerivativesStats(ctx context.Context, query DerivativesStatsQuery, handle DerivativesStatsHandler) error
+}
+
+// CollectDerivativesStats retrieves all statistic samples matching the query into a single slice
+func CollectDerivativesStats(ctx context.Context, provider DerivativesStatsProvider, query DerivativesStatsQuery) ([]*pb.DerivativesStat, error) {
+	var stats []*pb.DerivativesStat
+	err := provider.GetDerivativesStats(ctx, query, func(page []*pb.DerivativesStat) error {
+		stats = append(stats, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetDerivativesStatsProvider returns the derivatives statistics provider of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetDerivativesStatsProvider(exchange string) (DerivativesStatsProvider, bool) {
+	provider, ok := f.adapters[exchange].(DerivativesStatsProvider)
+	return provider, ok
+}
+
+// unsupportedDerivativesStat reports a statistic an exchange does not serve
+func unsupportedDerivativesStat(exchange string, stat DerivativesStat) error {
+	return fmt.Errorf("%w: %s does not serve %s", ErrUnsupportedDerivativesStat, exchange, stat)
+}
+
+// derivativesStatTimestamp orders statistic samples by sample time
+func derivativesStatTimestamp(stat *pb.DerivativesStat) int64 {
+	return stat.Timestamp
+}
+
+// newOpenInterestStat parses an open interest sample; an empty value is reported as unavailable
+func newOpenInterestStat(exchange string, timestamp int64, openInterest, value string) (*pb.DerivativesStat, error) {
+	parser := decimalParser{exchange: exchange}
+	stat := &pb.DerivativesStat{
+		Timestamp:    timestamp,
+		OpenInterest: parser.float("open interest", openInterest),
+	}
+	if value != "" {
+		stat.OpenInterestValue = parser.float("open interest value", value)
+		stat.HasOpenInterestValue = true
+	}
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (sample at %d)", parser.err, timestamp)
+	}
+	return stat, nil
+}
+
+// newLongShortRatioStat parses a long/short account ratio sample. Exchanges that
+// only send the shares of long and short accounts leave the ratio empty, and it
+// is derived from the shares.
+func newLongShortRatioStat(exchange string, timestamp int64, ratio, longAccount, shortAccount string) (*pb.DerivativesStat, error) {
+	parser := decimalParser{exchange: exchange}
+	stat := &pb.DerivativesStat{
+		Timestamp:    timestamp,
+		LongAccount:  parser.float("long account share", longAccount),
+		ShortAccount: parser.float("short account share", shortAccount),
+	}
+	switch {
+	case ratio != "":
+		stat.LongShortRatio = parser.float("long/short ratio", ratio)
+	case stat.ShortAccount > 0:
+		stat.LongShortRatio = stat.LongAccount / stat.ShortAccount
+	}
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (sample at %d)", parser.err, timestamp)
+	}
+	return stat, nil
+}
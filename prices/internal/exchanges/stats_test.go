@@ -0,0 +1,70 @@
+package exchanges
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseDerivativesStat tests validation of API derivatives statistics
+func TestParseDerivativesStat(t *testing.T) {
+	stat, err := ParseDerivativesStat("")
+	require.NoError(t, err)
+	assert.Equal(t, StatOpenInterest, stat)
+
+	for _, value := range []string{"open_interest", "top_trader_long_short_ratio", "global_long_short_ratio"} {
+		stat, err := ParseDerivativesStat(value)
+		require.NoError(t, err)
+		assert.Equal(t, DerivativesStat(value), stat)
+	}
+
+	_, err = ParseDerivativesStat("liquidations")
+	assert.ErrorIs(t, err, ErrUnsupportedDerivativesStat)
+}
+
+// TestParseStatPeriod tests validation of statistic periods
+func TestParseStatPeriod(t *testing.T) {
+	period, err := ParseStatPeriod("")
+	require.NoError(t, err)
+	assert.Equal(t, StatPeriod1h, period)
+
+	period, err = ParseStatPeriod("12h")
+	require.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, period.Duration())
+
+	for _, value := range []string{"1m", "1w", "1H"} {
+		_, err := ParseStatPeriod(value)
+		assert.ErrorIs(t, err, ErrUnsupportedStatPeriod, value)
+	}
+}
+
+// TestNewLongShortRatioStat tests taking the ratio as sent or deriving it from account shares
+func TestNewLongShortRatioStat(t *testing.T) {
+	stat, err := newLongShortRatioStat("binance", 1000, "1.8904", "0.6540", "0.3460")
+	require.NoError(t, err)
+	assert.Equal(t, 1.8904, stat.LongShortRatio)
+	assert.Equal(t, 0.654, stat.LongAccount)
+
+	stat, err = newLongShortRatioStat("bybit", 1000, "", "0.6", "0.4")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.5, stat.LongShortRatio, 1e-12)
+
+	_, err = newLongShortRatioStat("bybit", 1000, "", "0.6", "")
+	assert.ErrorIs(t, err, ErrMalformedCandle)
+}
+
+// TestExchangeFactory_GetDerivativesStatsProvider tests which adapters serve derivatives statistics
+func TestExchangeFactory_GetDerivativesStatsProvider(t *testing.T) {
+	factory := NewExchangeFactory()
+
+	for _, exchange := range []string{"binance", "bybit"} {
+		_, ok := factory.GetDerivativesStatsProvider(exchange)
+		assert.True(t, ok, exchange)
+	}
+	for _, exchange := range []string{"okx", "coinbase", "kraken", "unknown"} {
+		_, ok := factory.GetDerivativesStatsProvider(exchange)
+		assert.False(t, ok, exchange)
+	}
+}
@@ -0,0 +1,173 @@
+package exchanges
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// CandleUpdate is a live candle pushed by an exchange. In-progress candles are
+// pushed as they change, and Closed marks the final update of a candle.
+type CandleUpdate struct {
+	Candle *pb.PricesResponse
+	Closed bool
+}
+
+// CandleUpdateHandler receives live candle updates in the order they happen
+type CandleUpdateHandler func(update CandleUpdate) error
+
+// StreamQuery describes the live candles subscribed to
+type StreamQuery struct {
+	Ticker   string
+	Market   Market
+	Interval Interval
+}
+
+// CandleStreamer is implemented by adapters that push live candles over the
+// websocket of their exchange
+type CandleStreamer interface {
+	// StreamCandles passes every update of a candle series to handle until the
+	// context is cancelled or handle returns an error. Lost connections are
+	// re-established, and the candles that closed while disconnected are fetched
+	// from the history and passed on as closed updates first.
+	StreamCandles(ctx context.Context, query StreamQuery, handle CandleUpdateHandler) error
+}
+
+// GetCandleStreamer returns the candle streamer of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetCandleStreamer(exchange string) (CandleStreamer, bool) {
+	streamer, ok := f.adapters[exchange].(CandleStreamer)
+	return streamer, ok
+}
+
+// streamReadTimeout is the longest silence of a candle stream before its
+// connection is considered lost; exchanges push kline updates every few seconds
+const streamReadTimeout = time.Minute
+
+// Delays before reconnecting a lost candle stream, doubling from the first up to
+// the longest while connections keep failing
+var (
+	streamRetryDelay    = time.Second
+	streamMaxRetryDelay = 30 * time.Second
+)
+
+// streamDialer opens the websocket connections of candle streams
+var streamDialer = &websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+
+// candleSubscription connects to the websocket of an exchange, subscribes to a
+// candle series and passes its updates to handle until the connection fails
+type candleSubscription func(ctx context.Context, handle CandleUpdateHandler) error
+
+// streamCandles runs a subscription until the context is cancelled or handle
+// fails, reconnecting whenever the connection is lost. Closed candles are passed
+// on once each: the first update of a new connection past the last closed candle
+// triggers a backfill of the candles that closed in between, and closed updates
+// that were already passed on are dropped.
+func streamCandles(ctx context.Context, adapter ExchangeAdapter, query StreamQuery, subscribe candleSubscription, handle CandleUpdateHandler) error {
+	lastClosed := int64(-1)
+	var handleErr error
+	pass := func(update CandleUpdate) error {
+		if update.Closed {
+			if update.Candle.OpenTime <= lastClosed {
+				return nil
+			}
+			lastClosed = update.Candle.OpenTime
+		}
+		if err := handle(update); err != nil {
+			handleErr = err
+			return err
+		}
+		return nil
+	}
+
+	delay := streamRetryDelay
+	for {
+		received, backfilled := false, lastClosed < 0
+		err := subscribe(ctx, func(update CandleUpdate) error {
+			received = true
+			if !backfilled && update.Candle.OpenTime > lastClosed {
+				backfilled = true
+				if err := backfillCandles(ctx, adapter, query, lastClosed, update.Candle.OpenTime, pass); err != nil {
+					return err
+				}
+			}
+			return pass(update)
+		})
+		if handleErr != nil {
+			return handleErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Connections that delivered updates were healthy, so the next attempt
+		// starts over from the shortest delay
+		if received {
+			delay = streamRetryDelay
+		}
+		log.Printf("Candle stream of %s %s (%s, %s) lost, reconnecting in %s: %v", adapter.GetName(), query.Ticker, query.Market, query.Interval, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, streamMaxRetryDelay)
+	}
+}
+
+// backfillCandles fetches the candles opening after lastClosed and before the
+// candle in progress from the history of an exchange, passing them on as closed
+func backfillCandles(ctx context.Context, adapter ExchangeAdapter, query StreamQuery, lastClosed, inProgress int64, pass CandleUpdateHandler) error {
+	if inProgress <= lastClosed+1 {
+		return nil
+	}
+
+	return adapter.GetHistoricalPrices(ctx, PriceQuery{
+		Ticker:    query.Ticker,
+		Market:    query.Market,
+		Interval:  query.Interval,
+		PriceType: PriceTypeLast,
+		StartTime: time.UnixMilli(lastClosed + 1).UTC(),
+		EndTime:   time.UnixMilli(inProgress - 1).UTC(),
+	}, func(prices []*pb.PricesResponse) error {
+		for _, price := range prices {
+			if price.OpenTime <= lastClosed || price.OpenTime >= inProgress {
+				continue
+			}
+			if err := pass(CandleUpdate{Candle: price, Closed: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// dialStream opens a websocket connection to a candle stream endpoint
+func dialStream(ctx context.Context, endpoint string) (*websocket.Conn, error) {
+	conn, _, err := streamDialer.DialContext(ctx, endpoint, nil)
+	return conn, err
+}
+
+// readStream passes every message of a websocket connection to handle until the
+// connection fails, handle returns an error or the context is cancelled
+func readStream(ctx context.Context, conn *websocket.Conn, handle func(message []byte) error) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := handle(message); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package exchanges
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/gorilla/websocket"
+	"github.com/hirokisan/bybit/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamStandIn starts a local websocket stand-in for a candle stream. Every
+// connection is served by serve with its index, and the connection is dropped
+// when serve returns. It returns the websocket address of the stand-in.
+func newStreamStandIn(t *testing.T, serve func(conn *websocket.Conn, index int)) string {
+	upgrader := websocket.Upgrader{}
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		serve(conn, int(connections.Add(1)-1))
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// shortenStreamRetries makes lost candle streams reconnect right away for a test
+func shortenStreamRetries(t *testing.T) {
+	retryDelay, maxRetryDelay := streamRetryDelay, streamMaxRetryDelay
+	streamRetryDelay, streamMaxRetryDelay = 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		streamRetryDelay, streamMaxRetryDelay = retryDelay, maxRetryDelay
+	})
+}
+
+// collectUpdates returns a handler recording the updates it receives, which
+// cancels the stream once the candle opening at last has closed
+func collectUpdates(cancel context.CancelFunc, last time.Time, updates *[]CandleUpdate) CandleUpdateHandler {
+	return func(update CandleUpdate) error {
+		*updates = append(*updates, update)
+		if update.Closed && update.Candle.OpenTime == last.UnixMilli() {
+			cancel()
+		}
+		return nil
+	}
+}
+
+// updateSummary is the open time and state of a candle update
+type updateSummary struct {
+	Open   time.Time
+	Closed bool
+}
+
+func summarizeUpdates(updates []CandleUpdate) []updateSummary {
+	summaries := make([]updateSummary, 0, len(updates))
+	for _, update := range updates {
+		summaries = append(summaries, updateSummary{time.UnixMilli(update.Candle.OpenTime).UTC(), update.Closed})
+	}
+	return summaries
+}
+
+// binanceKlineMessage is a kline event of the Binance websocket streams
+func binanceKlineMessage(open time.Time, price string, final bool) map[string]interface{} {
+	return map[string]interface{}{
+		"e": "kline",
+		"k": map[string]interface{}{
+			"t": open.UnixMilli(), "T": open.UnixMilli() + 86399999,
+			"o": price, "h": price, "l": price, "c": price, "v": "1.5",
+			"q": "15000", "n": 100, "V": "0.7", "Q": "7000", "x": final,
+		},
+	}
+}
+
+// TestBinanceAdapter_StreamCandles tests streaming klines across a lost
+// connection, backfilling the candles that closed while disconnected
+func TestBinanceAdapter_StreamCandles(t *testing.T) {
+	shortenStreamRetries(t)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return start.AddDate(0, 0, i) }
+
+	rest, calls := newBinanceStandIn(t, start, 10)
+	subscriptions := make(chan []string, 2)
+	streamURL := newStreamStandIn(t, func(conn *websocket.Conn, index int) {
+		var subscribe struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		require.NoError(t, conn.ReadJSON(&subscribe))
+		require.Equal(t, "SUBSCRIBE", subscribe.Method)
+		subscriptions <- subscribe.Params
+		conn.WriteJSON(map[string]interface{}{"result": nil, "id": 1})
+
+		if index == 0 {
+			// The first connection drops after the first day closed
+			conn.WriteJSON(binanceKlineMessage(day(0), "10000", false))
+			conn.WriteJSON(binanceKlineMessage(day(0), "10000", true))
+			return
+		}
+		// Days 1 and 2 closed while disconnected
+		conn.WriteJSON(binanceKlineMessage(day(3), "10003", false))
+		conn.WriteJSON(binanceKlineMessage(day(3), "10003", true))
+		conn.ReadMessage()
+	})
+
+	adapter := NewBinanceAdapter()
+	adapter.client.BaseURL = rest.URL
+	adapter.streamURLs = map[string]string{binanceSpot: streamURL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var updates []CandleUpdate
+
+	err := adapter.StreamCandles(ctx, StreamQuery{Ticker: "BTCUSDT", Market: MarketSpot, Interval: Interval1d},
+		collectUpdates(cancel, day(3), &updates))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []updateSummary{
+		{day(0), false},
+		{day(0), true},
+		{day(1), true},
+		{day(2), true},
+		{day(3), false},
+		{day(3), true},
+	}, summarizeUpdates(updates))
+	assert.Equal(t, 1, *calls)
+
+	// Every connection subscribes again
+	assert.Equal(t, []string{"btcusdt@kline_1d"}, <-subscriptions)
+	assert.Equal(t, []string{"btcusdt@kline_1d"}, <-subscriptions)
+
+	// Streamed candles are converted like historical ones
+	expected, err := binanceKlineToCandle(&binance.Kline{
+		OpenTime: day(3).UnixMilli(), CloseTime: day(3).UnixMilli() + 86399999,
+		Open: "10003", High: "10003", Low: "10003", Close: "10003", Volume: "1.5",
+		QuoteAssetVolume: "15000", TradeNum: 100, TakerBuyBaseAssetVolume: "0.7", TakerBuyQuoteAssetVolume: "7000",
+	}, Interval1d)
+	require.NoError(t, err)
+	assert.Equal(t, expected, updates[5].Candle)
+
+	t.Run("unsupported intervals are rejected", func(t *testing.T) {
+		err := adapter.StreamCandles(context.Background(), StreamQuery{Ticker: "BTCUSDT", Interval: "2d"}, nil)
+
+		assert.ErrorIs(t, err, ErrUnsupportedInterval)
+	})
+}
+
+// bybitKlineMessage is a kline update of the Bybit public websocket streams
+func bybitKlineMessage(topic string, open time.Time, price string, confirm bool) map[string]interface{} {
+	return map[string]interface{}{
+		"topic": topic,
+		"type":  "snapshot",
+		"data": []map[string]interface{}{{
+			"start": open.UnixMilli(), "end": open.UnixMilli() + 3599999, "interval": "60",
+			"open": price, "close": price, "high": price, "low": price,
+			"volume": "2.5", "turnover": "50000", "confirm": confirm,
+		}},
+	}
+}
+
+// TestBybitAdapter_StreamCandles tests streaming klines across a lost
+// connection, backfilling the candles that closed while disconnected
+func TestBybitAdapter_StreamCandles(t *testing.T) {
+	shortenStreamRetries(t)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	hour := func(i int) time.Time { return start.Add(time.Duration(i) * time.Hour) }
+	topic := "kline.60.BTCUSDT"
+
+	rest, calls := newBybitStandIn(t, start, 10)
+	streamURL := newStreamStandIn(t, func(conn *websocket.Conn, index int) {
+		var subscribe struct {
+			Op   string   `json:"op"`
+			Args []string `json:"args"`
+		}
+		require.NoError(t, conn.ReadJSON(&subscribe))
+		require.Equal(t, "subscribe", subscribe.Op)
+		require.Equal(t, []string{topic}, subscribe.Args)
+		conn.WriteJSON(map[string]interface{}{"op": "subscribe", "success": true})
+
+		if index == 0 {
+			conn.WriteJSON(bybitKlineMessage(topic, hour(4), "20004", false))
+			conn.WriteJSON(bybitKlineMessage(topic, hour(4), "20004", true))
+			return
+		}
+		// A closed candle repeated after reconnecting is passed on once
+		conn.WriteJSON(bybitKlineMessage(topic, hour(4), "20004", true))
+		conn.WriteJSON(bybitKlineMessage(topic, hour(7), "20007", false))
+		conn.WriteJSON(bybitKlineMessage(topic, hour(7), "20007", true))
+		conn.ReadMessage()
+	})
+
+	adapter := NewBybitAdapter()
+	adapter.client = bybit.NewClient().WithBaseURL(rest.URL)
+	adapter.streamURL = streamURL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var updates []CandleUpdate
+
+	err := adapter.StreamCandles(ctx, StreamQuery{Ticker: "BTCUSDT", Market: MarketSpot, Interval: Interval1h},
+		collectUpdates(cancel, hour(7), &updates))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []updateSummary{
+		{hour(4), false},
+		{hour(4), true},
+		{hour(5), true},
+		{hour(6), true},
+		{hour(7), false},
+		{hour(7), true},
+	}, summarizeUpdates(updates))
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, 20005.0, updates[2].Candle.Close)
+	assert.Equal(t, 2.5, updates[5].Candle.Volume)
+
+	t.Run("failed subscriptions are retried", func(t *testing.T) {
+		failures := 0
+		streamURL := newStreamStandIn(t, func(conn *websocket.Conn, index int) {
+			var subscribe json.RawMessage
+			require.NoError(t, conn.ReadJSON(&subscribe))
+			if index == 0 {
+				failures++
+				conn.WriteJSON(map[string]interface{}{"op": "subscribe", "success": false, "ret_msg": "busy"})
+				conn.ReadMessage()
+				return
+			}
+			conn.WriteJSON(bybitKlineMessage(topic, hour(9), "20009", true))
+			conn.ReadMessage()
+		})
+		adapter.streamURL = streamURL
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var updates []CandleUpdate
+
+		err := adapter.StreamCandles(ctx, StreamQuery{Ticker: "BTCUSDT", Interval: Interval1h},
+			collectUpdates(cancel, hour(9), &updates))
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, failures)
+		assert.Equal(t, []updateSummary{{hour(9), true}}, summarizeUpdates(updates))
+	})
+}
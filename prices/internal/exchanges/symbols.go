@@ -0,0 +1,126 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownSymbol is returned when an exchange lists no symbol for a canonical pair
+var ErrUnknownSymbol = errors.New("unknown symbol")
+
+// ErrAmbiguousSymbol is returned when an exchange lists several tradable symbols
+// for a canonical pair, such as dated futures of different expiries
+var ErrAmbiguousSymbol = errors.New("ambiguous symbol")
+
+// canonicalAssetAliases maps asset codes some exchanges use to the common ones
+var canonicalAssetAliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// IsCanonicalSymbol reports whether a ticker is written in the exchange-independent
+// BASE/QUOTE form, such as BTC/USDT, rather than in the notation of an exchange
+func IsCanonicalSymbol(ticker string) bool {
+	return strings.Contains(ticker, "/")
+}
+
+// ParseCanonicalSymbol splits a BASE/QUOTE symbol into its assets
+func ParseCanonicalSymbol(symbol string) (base, quote string, err error) {
+	base, quote, found := strings.Cut(symbol, "/")
+	if !found || base == "" || quote == "" || strings.Contains(quote, "/") {
+		return "", "", fmt.Errorf("%w: %s, expected BASE/QUOTE such as BTC/USDT", ErrUnknownSymbol, symbol)
+	}
+	return canonicalAsset(base), canonicalAsset(quote), nil
+}
+
+// canonicalAsset returns the common upper-case code of an asset
+func canonicalAsset(asset string) string {
+	asset = strings.ToUpper(asset)
+	if alias, ok := canonicalAssetAliases[asset]; ok {
+		return alias
+	}
+	return asset
+}
+
+// CanonicalSymbol returns the BASE/QUOTE form of a listed symbol, or an empty
+// string when the exchange does not name its assets
+func (s SymbolInfo) CanonicalSymbol() string {
+	if s.Base == "" || s.Quote == "" {
+		return ""
+	}
+	return canonicalAsset(s.Base) + "/" + canonicalAsset(s.Quote)
+}
+
+// ResolveSymbol translates a BASE/QUOTE symbol into the ticker an exchange uses
+// for it in a market, looked up in the cached symbol list of the exchange. Tickers
+// already in the notation of the exchange, and any ticker of an exchange that does
+// not list its symbols, are returned as they are. When several symbols match, such
+// as a delisted and a trading one, the only trading one wins.
+func (f *ExchangeFactory) ResolveSymbol(ctx context.Context, exchange string, market Market, ticker string) (string, error) {
+	if _, ok := f.GetSymbolProvider(exchange); !ok || !IsCanonicalSymbol(ticker) {
+		return ticker, nil
+	}
+	base, quote, err := ParseCanonicalSymbol(ticker)
+	if err != nil {
+		return "", err
+	}
+
+	symbols, err := f.Symbols(ctx, exchange, market)
+	if err != nil {
+		return "", err
+	}
+
+	canonical := base + "/" + quote
+	var matches, trading []SymbolInfo
+	for _, symbol := range symbols {
+		if symbol.CanonicalSymbol() != canonical {
+			continue
+		}
+		matches = append(matches, symbol)
+		if symbol.Status == SymbolStatusTrading {
+			trading = append(trading, symbol)
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches[0].Symbol, nil
+	case len(trading) == 1:
+		return trading[0].Symbol, nil
+	case len(matches) == 0:
+		if market == "" {
+			market = DefaultMarket
+		}
+		return "", fmt.Errorf("%w: %s does not list %s for %s", ErrUnknownSymbol, exchange, canonical, market)
+	}
+
+	candidates := make([]string, 0, len(matches))
+	for _, symbol := range matches {
+		candidates = append(candidates, symbol.Symbol)
+	}
+	return "", fmt.Errorf("%w: %s lists %s as %s; use one of them instead", ErrAmbiguousSymbol, exchange, canonical, strings.Join(candidates, ", "))
+}
+
+// CanonicalSymbolOf translates the ticker an exchange uses in a market into its
+// BASE/QUOTE form, looked up in the cached symbol list of the exchange
+func (f *ExchangeFactory) CanonicalSymbolOf(ctx context.Context, exchange string, market Market, ticker string) (string, error) {
+	symbols, err := f.Symbols(ctx, exchange, market)
+	if err != nil {
+		return "", err
+	}
+
+	for _, symbol := range symbols {
+		if strings.EqualFold(symbol.Symbol, ticker) {
+			if canonical := symbol.CanonicalSymbol(); canonical != "" {
+				return canonical, nil
+			}
+		}
+	}
+
+	if market == "" {
+		market = DefaultMarket
+	}
+	return "", fmt.Errorf("%w: %s does not list %s for %s", ErrUnknownSymbol, exchange, ticker, market)
+}
@@ -0,0 +1,87 @@
+package exchanges
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseCanonicalSymbol tests splitting BASE/QUOTE symbols
+func TestParseCanonicalSymbol(t *testing.T) {
+	base, quote, err := ParseCanonicalSymbol("xbt/usd")
+	require.NoError(t, err)
+	assert.Equal(t, "BTC", base)
+	assert.Equal(t, "USD", quote)
+
+	for _, symbol := range []string{"BTC/", "/USDT", "BTC/USDT/PERP", "BTCUSDT"} {
+		_, _, err := ParseCanonicalSymbol(symbol)
+		assert.ErrorIs(t, err, ErrUnknownSymbol, symbol)
+	}
+
+	assert.True(t, IsCanonicalSymbol("BTC/USDT"))
+	assert.False(t, IsCanonicalSymbol("BTC-USDT"))
+}
+
+// TestExchangeFactory_ResolveSymbol tests translating canonical symbols in both directions
+func TestExchangeFactory_ResolveSymbol(t *testing.T) {
+	adapter := &listingSymbolsAdapter{symbols: []SymbolInfo{
+		{Symbol: "XBTUSD", Base: "XBT", Quote: "USD", Status: SymbolStatusTrading},
+		{Symbol: "BTCUSDT_250328", Base: "BTC", Quote: "USDT", Status: SymbolStatusTrading},
+		{Symbol: "BTCUSDT_250627", Base: "BTC", Quote: "USDT", Status: SymbolStatusTrading},
+		{Symbol: "LUNAUSDT", Base: "LUNA", Quote: "USDT", Status: SymbolStatusDelisted},
+		{Symbol: "LUNA2USDT", Base: "LUNA", Quote: "USDT", Status: SymbolStatusTrading},
+		{Symbol: "INDEX"},
+	}}
+	factory := &ExchangeFactory{adapters: map[string]ExchangeAdapter{"listed": adapter}}
+	ctx := context.Background()
+
+	ticker, err := factory.ResolveSymbol(ctx, "listed", MarketSpot, "btc/usd")
+	require.NoError(t, err)
+	assert.Equal(t, "XBTUSD", ticker)
+
+	canonical, err := factory.CanonicalSymbolOf(ctx, "listed", MarketSpot, "xbtusd")
+	require.NoError(t, err)
+	assert.Equal(t, "BTC/USD", canonical)
+
+	t.Run("native tickers are passed through without a lookup", func(t *testing.T) {
+		ticker, err := factory.ResolveSymbol(ctx, "listed", MarketSpot, "ETHUSDT")
+
+		require.NoError(t, err)
+		assert.Equal(t, "ETHUSDT", ticker)
+	})
+
+	t.Run("the only trading symbol wins", func(t *testing.T) {
+		ticker, err := factory.ResolveSymbol(ctx, "listed", MarketSpot, "LUNA/USDT")
+
+		require.NoError(t, err)
+		assert.Equal(t, "LUNA2USDT", ticker)
+	})
+
+	t.Run("several trading symbols are ambiguous", func(t *testing.T) {
+		_, err := factory.ResolveSymbol(ctx, "listed", MarketSpot, "BTC/USDT")
+
+		assert.ErrorIs(t, err, ErrAmbiguousSymbol)
+		assert.Contains(t, err.Error(), "BTCUSDT_250328, BTCUSDT_250627")
+	})
+
+	t.Run("unlisted pairs are unknown", func(t *testing.T) {
+		_, err := factory.ResolveSymbol(ctx, "listed", MarketSpot, "ETH/EUR")
+		assert.ErrorIs(t, err, ErrUnknownSymbol)
+
+		_, err = factory.CanonicalSymbolOf(ctx, "listed", MarketSpot, "INDEX")
+		assert.ErrorIs(t, err, ErrUnknownSymbol)
+	})
+
+	t.Run("exchanges without symbol lists receive the ticker as written", func(t *testing.T) {
+		factory.RegisterAdapter(&listedAdapter{})
+
+		ticker, err := factory.ResolveSymbol(ctx, "listed", MarketSpot, "BTC/USD")
+
+		require.NoError(t, err)
+		assert.Equal(t, "BTC/USD", ticker)
+	})
+
+	assert.Equal(t, 1, adapter.calls)
+}
@@ -0,0 +1,34 @@
+package exchanges
+
+import "strings"
+
+// knownQuoteAssets lists the quote assets recognised when splitting a plain ticker,
+// longest first so that BTCUSDT is split into BTC and USDT rather than BTCUS and DT
+var knownQuoteAssets = []string{
+	"FDUSD", "USDT", "USDC", "TUSD", "BUSD", "DAI", "USD", "EUR", "GBP", "JPY", "TRY", "BTC", "ETH",
+}
+
+// splitTicker splits a plain ticker such as BTCUSDT into its base and quote assets
+func splitTicker(ticker string) (base, quote string, ok bool) {
+	ticker = strings.ToUpper(ticker)
+	for _, quote := range knownQuoteAssets {
+		if strings.HasSuffix(ticker, quote) && len(ticker) > len(quote) {
+			return strings.TrimSuffix(ticker, quote), quote, true
+		}
+	}
+	return "", "", false
+}
+
+// hyphenateTicker converts a plain ticker such as BTCUSDT into the BASE-QUOTE form
+// used by OKX and Coinbase. Tickers that already hold a hyphen, such as OKX swap
+// instruments (BTC-USDT-SWAP), are passed through.
+func hyphenateTicker(ticker string) string {
+	ticker = strings.ToUpper(ticker)
+	if strings.Contains(ticker, "-") {
+		return ticker
+	}
+	if base, quote, ok := splitTicker(ticker); ok {
+		return base + "-" + quote
+	}
+	return ticker
+}
@@ -0,0 +1,37 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSplitTicker tests splitting plain tickers into base and quote assets
+func TestSplitTicker(t *testing.T) {
+	cases := map[string][2]string{
+		"BTCUSDT":  {"BTC", "USDT"},
+		"BTCUSD":   {"BTC", "USD"},
+		"ethbtc":   {"ETH", "BTC"},
+		"BTCFDUSD": {"BTC", "FDUSD"},
+		"SOLEUR":   {"SOL", "EUR"},
+	}
+	for ticker, expected := range cases {
+		base, quote, ok := splitTicker(ticker)
+		assert.True(t, ok, ticker)
+		assert.Equal(t, expected[0], base, ticker)
+		assert.Equal(t, expected[1], quote, ticker)
+	}
+
+	_, _, ok := splitTicker("USDT")
+	assert.False(t, ok)
+	_, _, ok = splitTicker("FOOBAR")
+	assert.False(t, ok)
+}
+
+// TestHyphenateTicker tests conversion of tickers into BASE-QUOTE symbols
+func TestHyphenateTicker(t *testing.T) {
+	assert.Equal(t, "BTC-USDT", hyphenateTicker("BTCUSDT"))
+	assert.Equal(t, "ETH-BTC", hyphenateTicker("ethbtc"))
+	assert.Equal(t, "BTC-USD", hyphenateTicker("BTC-USD"))
+	assert.Equal(t, "BTC-USDT-SWAP", hyphenateTicker("btc-usdt-swap"))
+}
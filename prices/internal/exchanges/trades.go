@@ -0,0 +1,123 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// Taker sides of a trade
+const (
+	TradeSideBuy  = "buy"
+	TradeSideSell = "sell"
+)
+
+// ErrTradesStartRequired is returned when trades are requested without a start time
+var ErrTradesStartRequired = errors.New("trades require a start time")
+
+// ErrMalformedTrade is returned when an exchange sends a trade that cannot be parsed
+var ErrMalformedTrade = errors.New("malformed trade")
+
+// ErrTradesNotArchived is returned when an exchange serving trades from archives
+// has not published the archives of a window yet
+var ErrTradesNotArchived = errors.New("trades not archived yet")
+
+// TradeQuery describes the trades requested from an exchange
+type TradeQuery struct {
+	Ticker string
+	Market Market
+
+	// Limit caps the number of trades returned; zero means no cap
+	Limit int64
+
+	// StartTime and EndTime bound trade times, inclusive. StartTime is required;
+	// a zero EndTime means now.
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// TradeHandler receives a page of trades in chronological order
+type TradeHandler func(trades []*pb.Trade) error
+
+// TradeProvider is implemented by adapters that serve historical trades. Trades
+// are handed over page by page, so memory stays bounded whatever the window.
+type TradeProvider interface {
+	// GetTrades retrieves the trades of a time window in chronological order,
+	// passing every page to handle as soon as it is fetched
+	GetTrades(ctx context.Context, query TradeQuery, handle TradeHandler) error
+}
+
+// CollectTrades retrieves all trades matching the query into a single slice
+func CollectTrades(ctx context.Context, provider TradeProvider, query TradeQuery) ([]*pb.Trade, error) {
+	var trades []*pb.Trade
+	err := provider.GetTrades(ctx, query, func(page []*pb.Trade) error {
+		trades = append(trades, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// GetTradeProvider returns the trade provider of an exchange, if its adapter is one
+func (f *ExchangeFactory) GetTradeProvider(exchange string) (TradeProvider, bool) {
+	provider, ok := f.adapters[exchange].(TradeProvider)
+	return provider, ok
+}
+
+// tradeWindow validates the window of a trade query, defaulting its end to now
+func tradeWindow(query TradeQuery) (time.Time, time.Time, error) {
+	if query.StartTime.IsZero() {
+		return time.Time{}, time.Time{}, ErrTradesStartRequired
+	}
+	end := query.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return query.StartTime, end, nil
+}
+
+// newTrade parses a trade; side is the taker side in any case
+func newTrade(exchange, id string, timestamp int64, price, quantity, side string, tradeCount int64) (*pb.Trade, error) {
+	parser := decimalParser{exchange: exchange, malformed: ErrMalformedTrade}
+	trade := &pb.Trade{
+		TradeId:    id,
+		Timestamp:  timestamp,
+		Price:      parser.float("price", price),
+		Quantity:   parser.float("quantity", quantity),
+		Side:       strings.ToLower(side),
+		TradeCount: tradeCount,
+	}
+	if parser.err != nil {
+		return nil, fmt.Errorf("%w (trade %s)", parser.err, id)
+	}
+	if trade.Side != TradeSideBuy && trade.Side != TradeSideSell {
+		return nil, fmt.Errorf("%w: %s sent side %q (trade %s)", ErrMalformedTrade, exchange, side, id)
+	}
+	return trade, nil
+}
+
+// tradeLimiter caps the number of trades handed over and reports when the cap is reached
+type tradeLimiter struct {
+	limit int64
+	sent  int64
+}
+
+// take trims a page to the remaining allowance and counts it as sent
+func (l *tradeLimiter) take(page []*pb.Trade) []*pb.Trade {
+	if l.limit > 0 && l.sent+int64(len(page)) > l.limit {
+		page = page[:l.limit-l.sent]
+	}
+	l.sent += int64(len(page))
+	return page
+}
+
+// done reports whether the cap is reached
+func (l *tradeLimiter) done() bool {
+	return l.limit > 0 && l.sent >= l.limit
+}
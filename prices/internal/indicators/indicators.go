@@ -0,0 +1,212 @@
+// Package indicators computes technical indicators, such as moving averages and
+// the RSI, over a chronological series of candles.
+package indicators
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// ErrInvalidIndicator is returned for an unknown indicator or invalid parameters
+var ErrInvalidIndicator = errors.New("invalid indicator")
+
+// maxPeriod bounds the periods of indicators, and with them the number of warm-up
+// candles fetched
+const maxPeriod = 500
+
+// Spec names an indicator and its parameters. Empty parameters take the defaults
+// of the indicator.
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// Value is an output of an indicator at a candle
+type Value struct {
+	// Name is the indicator spec, such as sma(20), for the main output, followed
+	// by the output name for further ones, such as macd(12,26,9).signal
+	Name  string
+	Value float64
+}
+
+// Indicator computes an indicator over candles fed in chronological order
+type Indicator interface {
+	// Name returns the spec of the indicator with its parameters, such as sma(20)
+	Name() string
+
+	// WarmUp returns the number of candles fed before the values of the indicator
+	// no longer depend on where the series started
+	WarmUp() int
+
+	// Update feeds the next candle and returns the values of the indicator at it,
+	// or nil while it has not seen enough candles
+	Update(candle *pb.PricesResponse) []Value
+}
+
+// definition describes an indicator: its default parameters and how to build it
+type definition struct {
+	defaults []float64
+	usage    string
+	build    func(params []float64) (Indicator, error)
+}
+
+// definitions holds the supported indicators by name
+var definitions = map[string]definition{
+	"sma": {
+		defaults: []float64{20},
+		usage:    "sma(period)",
+		build: func(params []float64) (Indicator, error) {
+			period, err := periodParam("sma", "period", params[0])
+			if err != nil {
+				return nil, err
+			}
+			return newSMA(period), nil
+		},
+	},
+	"ema": {
+		defaults: []float64{20},
+		usage:    "ema(period)",
+		build: func(params []float64) (Indicator, error) {
+			period, err := periodParam("ema", "period", params[0])
+			if err != nil {
+				return nil, err
+			}
+			return newEMA(period), nil
+		},
+	},
+	"rsi": {
+		defaults: []float64{14},
+		usage:    "rsi(period)",
+		build: func(params []float64) (Indicator, error) {
+			period, err := periodParam("rsi", "period", params[0])
+			if err != nil {
+				return nil, err
+			}
+			return newRSI(period), nil
+		},
+	},
+	"macd": {
+		defaults: []float64{12, 26, 9},
+		usage:    "macd(fast, slow, signal)",
+		build: func(params []float64) (Indicator, error) {
+			var periods [3]int
+			for i, name := range []string{"fast", "slow", "signal"} {
+				period, err := periodParam("macd", name, params[i])
+				if err != nil {
+					return nil, err
+				}
+				periods[i] = period
+			}
+			if periods[0] >= periods[1] {
+				return nil, fmt.Errorf("%w: macd fast period must be shorter than its slow period", ErrInvalidIndicator)
+			}
+			return newMACD(periods[0], periods[1], periods[2]), nil
+		},
+	},
+	"bollinger": {
+		defaults: []float64{20, 2},
+		usage:    "bollinger(period, deviations)",
+		build: func(params []float64) (Indicator, error) {
+			period, err := periodParam("bollinger", "period", params[0])
+			if err != nil {
+				return nil, err
+			}
+			if params[1] <= 0 {
+				return nil, fmt.Errorf("%w: bollinger deviations must be positive", ErrInvalidIndicator)
+			}
+			return newBollinger(period, params[1]), nil
+		},
+	},
+	"atr": {
+		defaults: []float64{14},
+		usage:    "atr(period)",
+		build: func(params []float64) (Indicator, error) {
+			period, err := periodParam("atr", "period", params[0])
+			if err != nil {
+				return nil, err
+			}
+			return newATR(period), nil
+		},
+	},
+	"vwap": {
+		defaults: []float64{20},
+		usage:    "vwap(period)",
+		build: func(params []float64) (Indicator, error) {
+			period, err := periodParam("vwap", "period", params[0])
+			if err != nil {
+				return nil, err
+			}
+			return newVWAP(period), nil
+		},
+	},
+}
+
+// Parse builds the indicator of a spec
+func Parse(spec Spec) (Indicator, error) {
+	name := strings.ToLower(spec.Name)
+	def, ok := definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown indicator %q, expected one of %s", ErrInvalidIndicator, spec.Name, strings.Join(Names(), ", "))
+	}
+
+	params := spec.Params
+	if len(params) == 0 {
+		params = def.defaults
+	}
+	if len(params) != len(def.defaults) {
+		return nil, fmt.Errorf("%w: %s takes %d parameters: %s", ErrInvalidIndicator, name, len(def.defaults), def.usage)
+	}
+	return def.build(params)
+}
+
+// Names returns the names of the supported indicators in alphabetical order
+func Names() []string {
+	return []string{"atr", "bollinger", "ema", "macd", "rsi", "sma", "vwap"}
+}
+
+// periodParam validates a parameter counting candles
+func periodParam(indicator, name string, value float64) (int, error) {
+	period := int(value)
+	if float64(period) != value || period < 1 || period > maxPeriod {
+		return 0, fmt.Errorf("%w: %s %s must be a whole number from 1 to %d", ErrInvalidIndicator, indicator, name, maxPeriod)
+	}
+	return period, nil
+}
+
+// specName formats an indicator spec with its parameters, such as macd(12,26,9)
+func specName(name string, params ...float64) string {
+	formatted := make([]string, len(params))
+	for i, param := range params {
+		formatted[i] = strconv.FormatFloat(param, 'g', -1, 64)
+	}
+	return name + "(" + strings.Join(formatted, ",") + ")"
+}
+
+// Set computes several indicators over the same candles
+type Set []Indicator
+
+// WarmUp returns the number of candles the slowest indicator of the set needs
+// before its values are settled
+func (s Set) WarmUp() int {
+	warmUp := 0
+	for _, indicator := range s {
+		warmUp = max(warmUp, indicator.WarmUp())
+	}
+	return warmUp
+}
+
+// Update feeds the next candle to every indicator, returning their values at it
+// by name; indicators that have not seen enough candles are left out
+func (s Set) Update(candle *pb.PricesResponse) map[string]float64 {
+	values := make(map[string]float64)
+	for _, indicator := range s {
+		for _, value := range indicator.Update(candle) {
+			values[value.Name] = value.Value
+		}
+	}
+	return values
+}
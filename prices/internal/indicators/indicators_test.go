@@ -0,0 +1,122 @@
+package indicators
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// golden holds candles and the indicator values a reference implementation of
+// the textbook definitions computes over them
+type golden struct {
+	Specs   []Spec `json:"specs"`
+	Candles []struct {
+		High   float64 `json:"high"`
+		Low    float64 `json:"low"`
+		Close  float64 `json:"close"`
+		Volume float64 `json:"volume"`
+	} `json:"candles"`
+	Values map[string][]*float64 `json:"values"`
+}
+
+// TestIndicators_Golden tests every indicator against golden values
+func TestIndicators_Golden(t *testing.T) {
+	data, err := os.ReadFile("testdata/golden.json")
+	require.NoError(t, err)
+	var expected golden
+	require.NoError(t, json.Unmarshal(data, &expected))
+
+	var set Set
+	for _, spec := range expected.Specs {
+		indicator, err := Parse(spec)
+		require.NoError(t, err)
+		set = append(set, indicator)
+	}
+
+	for i, c := range expected.Candles {
+		values := set.Update(&pb.PricesResponse{High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume})
+
+		for name, series := range expected.Values {
+			value, ok := values[name]
+			if series[i] == nil {
+				assert.False(t, ok, "%s has no value at candle %d", name, i)
+				continue
+			}
+			if assert.True(t, ok, "%s has a value at candle %d", name, i) {
+				assert.InDelta(t, *series[i], value, 1e-8, "%s at candle %d", name, i)
+			}
+		}
+	}
+}
+
+// TestRSI_StockCharts tests the RSI against the example StockCharts publishes
+func TestRSI_StockCharts(t *testing.T) {
+	closes := []float64{
+		44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826, 45.8931,
+		46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439, 46.2122, 46.2521,
+		45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672, 43.4205, 42.6628, 43.1314,
+	}
+	expected := []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}
+
+	indicator := newRSI(14)
+	var values []float64
+	for _, close := range closes {
+		for _, value := range indicator.Update(&pb.PricesResponse{Close: close}) {
+			values = append(values, value.Value)
+		}
+	}
+
+	require.Len(t, values, len(expected))
+	for i := range expected {
+		assert.InDelta(t, expected[i], values[i], 0.005, "RSI %d", i)
+	}
+}
+
+// TestParse tests building indicators from their specs
+func TestParse(t *testing.T) {
+	for spec, name := range map[*Spec]string{
+		{Name: "SMA"}:                        "sma(20)",
+		{Name: "ema", Params: []float64{50}}: "ema(50)",
+		{Name: "macd"}:                       "macd(12,26,9)",
+		{Name: "bollinger", Params: []float64{20, 2.5}}: "bollinger(20,2.5)",
+		{Name: "atr"}:  "atr(14)",
+		{Name: "vwap"}: "vwap(20)",
+	} {
+		indicator, err := Parse(*spec)
+		require.NoError(t, err)
+		assert.Equal(t, name, indicator.Name())
+	}
+
+	for name, spec := range map[string]Spec{
+		"unknown indicator":     {Name: "stochastic"},
+		"too many parameters":   {Name: "sma", Params: []float64{20, 2}},
+		"fractional period":     {Name: "ema", Params: []float64{2.5}},
+		"zero period":           {Name: "rsi", Params: []float64{0}},
+		"too long period":       {Name: "sma", Params: []float64{maxPeriod + 1}},
+		"fast slower than slow": {Name: "macd", Params: []float64{26, 12, 9}},
+		"no deviations":         {Name: "bollinger", Params: []float64{20, 0}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(spec)
+
+			assert.ErrorIs(t, err, ErrInvalidIndicator)
+		})
+	}
+}
+
+// TestSet_WarmUp tests that exponential indicators settle longer than their period
+func TestSet_WarmUp(t *testing.T) {
+	set := Set{newSMA(50), newEMA(20), newRSI(14)}
+
+	assert.Equal(t, 140, set.WarmUp())
+	assert.Equal(t, 49, Set{newSMA(50)}.WarmUp())
+	assert.Equal(t, 5*(26+9), newMACD(12, 26, 9).WarmUp())
+	assert.Zero(t, Set{}.WarmUp())
+}
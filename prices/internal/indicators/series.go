@@ -0,0 +1,309 @@
+package indicators
+
+import (
+	"math"
+
+	pb "github.com/timakaa/historical-common/proto"
+)
+
+// Exponential averages never forget their first value entirely. Indicators built
+// on them are fed this many periods more than they need before their first value,
+// leaving the start of the series a weight below 0.01%.
+const (
+	// emaSettlePeriods settles averages weighting the latest value 2/(period+1)
+	emaSettlePeriods = 5
+
+	// wilderSettlePeriods settles Wilder's averages weighting it 1/period
+	wilderSettlePeriods = 10
+)
+
+// window keeps the latest values of a series
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+// push adds a value, dropping the oldest one once the window is full
+func (w *window) push(value float64) {
+	w.values[w.next] = value
+	w.next = (w.next + 1) % len(w.values)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+// sum adds up the values of the window; summing afresh keeps rounding errors from
+// piling up over a long series
+func (w *window) sum() float64 {
+	sum := 0.0
+	for _, value := range w.values {
+		sum += value
+	}
+	return sum
+}
+
+// smoother is an exponential moving average seeded with the simple average of
+// its first period values
+type smoother struct {
+	period int
+	alpha  float64
+	seen   int
+	value  float64
+}
+
+// newEMASmoother weights the latest value 2/(period+1)
+func newEMASmoother(period int) *smoother {
+	return &smoother{period: period, alpha: 2 / float64(period+1)}
+}
+
+// newWilderSmoother weights the latest value 1/period, as Wilder's RSI and ATR do
+func newWilderSmoother(period int) *smoother {
+	return &smoother{period: period, alpha: 1 / float64(period)}
+}
+
+// update adds a value, reporting whether the average has seen a full period
+func (s *smoother) update(value float64) (float64, bool) {
+	s.seen++
+	switch {
+	case s.seen < s.period:
+		s.value += value
+		return 0, false
+	case s.seen == s.period:
+		s.value = (s.value + value) / float64(s.period)
+	default:
+		s.value += s.alpha * (value - s.value)
+	}
+	return s.value, true
+}
+
+// sma is the simple moving average of closes
+type sma struct {
+	period int
+	closes *window
+}
+
+func newSMA(period int) *sma {
+	return &sma{period: period, closes: newWindow(period)}
+}
+
+func (i *sma) Name() string { return specName("sma", float64(i.period)) }
+func (i *sma) WarmUp() int  { return i.period - 1 }
+
+func (i *sma) Update(candle *pb.PricesResponse) []Value {
+	i.closes.push(candle.Close)
+	if !i.closes.full {
+		return nil
+	}
+	return []Value{{Name: i.Name(), Value: i.closes.sum() / float64(i.period)}}
+}
+
+// ema is the exponential moving average of closes
+type ema struct {
+	period int
+	avg    *smoother
+}
+
+func newEMA(period int) *ema {
+	return &ema{period: period, avg: newEMASmoother(period)}
+}
+
+func (i *ema) Name() string { return specName("ema", float64(i.period)) }
+func (i *ema) WarmUp() int  { return emaSettlePeriods * i.period }
+
+func (i *ema) Update(candle *pb.PricesResponse) []Value {
+	value, ok := i.avg.update(candle.Close)
+	if !ok {
+		return nil
+	}
+	return []Value{{Name: i.Name(), Value: value}}
+}
+
+// rsi is Wilder's relative strength index of closes, from 0 to 100
+type rsi struct {
+	period  int
+	gains   *smoother
+	losses  *smoother
+	prev    float64
+	started bool
+}
+
+func newRSI(period int) *rsi {
+	return &rsi{period: period, gains: newWilderSmoother(period), losses: newWilderSmoother(period)}
+}
+
+func (i *rsi) Name() string { return specName("rsi", float64(i.period)) }
+func (i *rsi) WarmUp() int  { return wilderSettlePeriods * i.period }
+
+func (i *rsi) Update(candle *pb.PricesResponse) []Value {
+	if !i.started {
+		i.prev, i.started = candle.Close, true
+		return nil
+	}
+	change := candle.Close - i.prev
+	i.prev = candle.Close
+
+	gain, ok := i.gains.update(math.Max(change, 0))
+	loss, _ := i.losses.update(math.Max(-change, 0))
+	if !ok {
+		return nil
+	}
+
+	// A series that did not move is neutral
+	value := 50.0
+	if gain+loss > 0 {
+		value = 100 * gain / (gain + loss)
+	}
+	return []Value{{Name: i.Name(), Value: value}}
+}
+
+// macd is the difference between a fast and a slow exponential average of closes,
+// with the signal line averaging it and the histogram of their difference
+type macd struct {
+	fast, slow, signal int
+	fastAvg, slowAvg   *smoother
+	signalAvg          *smoother
+}
+
+func newMACD(fast, slow, signal int) *macd {
+	return &macd{
+		fast:      fast,
+		slow:      slow,
+		signal:    signal,
+		fastAvg:   newEMASmoother(fast),
+		slowAvg:   newEMASmoother(slow),
+		signalAvg: newEMASmoother(signal),
+	}
+}
+
+func (i *macd) Name() string {
+	return specName("macd", float64(i.fast), float64(i.slow), float64(i.signal))
+}
+
+func (i *macd) WarmUp() int { return emaSettlePeriods * (i.slow + i.signal) }
+
+func (i *macd) Update(candle *pb.PricesResponse) []Value {
+	fast, _ := i.fastAvg.update(candle.Close)
+	slow, ok := i.slowAvg.update(candle.Close)
+	if !ok {
+		return nil
+	}
+	line := fast - slow
+	signal, ok := i.signalAvg.update(line)
+	if !ok {
+		return nil
+	}
+
+	name := i.Name()
+	return []Value{
+		{Name: name, Value: line},
+		{Name: name + ".signal", Value: signal},
+		{Name: name + ".histogram", Value: line - signal},
+	}
+}
+
+// bollinger are the bands a number of standard deviations of closes around their
+// simple moving average
+type bollinger struct {
+	period     int
+	deviations float64
+	closes     *window
+}
+
+func newBollinger(period int, deviations float64) *bollinger {
+	return &bollinger{period: period, deviations: deviations, closes: newWindow(period)}
+}
+
+func (i *bollinger) Name() string {
+	return specName("bollinger", float64(i.period), i.deviations)
+}
+
+func (i *bollinger) WarmUp() int { return i.period - 1 }
+
+func (i *bollinger) Update(candle *pb.PricesResponse) []Value {
+	i.closes.push(candle.Close)
+	if !i.closes.full {
+		return nil
+	}
+
+	// The population standard deviation, as Bollinger defined the bands
+	mean := i.closes.sum() / float64(i.period)
+	variance := 0.0
+	for _, value := range i.closes.values {
+		variance += (value - mean) * (value - mean)
+	}
+	width := i.deviations * math.Sqrt(variance/float64(i.period))
+
+	name := i.Name()
+	return []Value{
+		{Name: name, Value: mean},
+		{Name: name + ".upper", Value: mean + width},
+		{Name: name + ".lower", Value: mean - width},
+	}
+}
+
+// atr is Wilder's average true range, the average price range of a candle
+// including any gap from the close before it
+type atr struct {
+	period  int
+	ranges  *smoother
+	prev    float64
+	started bool
+}
+
+func newATR(period int) *atr {
+	return &atr{period: period, ranges: newWilderSmoother(period)}
+}
+
+func (i *atr) Name() string { return specName("atr", float64(i.period)) }
+func (i *atr) WarmUp() int  { return wilderSettlePeriods * i.period }
+
+func (i *atr) Update(candle *pb.PricesResponse) []Value {
+	if !i.started {
+		i.prev, i.started = candle.Close, true
+		return nil
+	}
+	trueRange := math.Max(candle.High-candle.Low, math.Max(math.Abs(candle.High-i.prev), math.Abs(candle.Low-i.prev)))
+	i.prev = candle.Close
+
+	value, ok := i.ranges.update(trueRange)
+	if !ok {
+		return nil
+	}
+	return []Value{{Name: i.Name(), Value: value}}
+}
+
+// vwap is the volume weighted average of the typical prices, (high+low+close)/3,
+// of a rolling window of candles
+type vwap struct {
+	period   int
+	weighted *window
+	volumes  *window
+}
+
+func newVWAP(period int) *vwap {
+	return &vwap{period: period, weighted: newWindow(period), volumes: newWindow(period)}
+}
+
+func (i *vwap) Name() string { return specName("vwap", float64(i.period)) }
+func (i *vwap) WarmUp() int  { return i.period - 1 }
+
+func (i *vwap) Update(candle *pb.PricesResponse) []Value {
+	typical := (candle.High + candle.Low + candle.Close) / 3
+	i.weighted.push(typical * candle.Volume)
+	i.volumes.push(candle.Volume)
+	if !i.weighted.full {
+		return nil
+	}
+
+	// Without any volume there is no average to take
+	volume := i.volumes.sum()
+	if volume == 0 {
+		return nil
+	}
+	return []Value{{Name: i.Name(), Value: i.weighted.sum() / volume}}
+}
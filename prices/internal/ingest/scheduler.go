@@ -0,0 +1,278 @@
+// Package ingest keeps the candles of watched series up to date in the candle
+// store, fetching every newly closed candle shortly after it closes.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/candles"
+	"github.com/timakaa/historical-prices/internal/clock"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// Defaults used when the scheduler options leave them unset
+const (
+	DefaultDelay       = 5 * time.Second
+	DefaultJitter      = 10 * time.Second
+	DefaultConcurrency = 2
+)
+
+// pollInterval is the longest time between readings of the watchlist, so that
+// added series are picked up before the boundary of a longer interval
+const pollInterval = time.Minute
+
+// Options tunes when and how fast the scheduler fetches candles
+type Options struct {
+	// Delay is waited after an interval boundary, giving exchanges time to publish
+	// the candle that closed at it
+	Delay time.Duration
+
+	// Jitter is the longest random wait added per series, spreading the requests of
+	// a round over time
+	Jitter time.Duration
+
+	// Concurrency caps the series fetched at once from one exchange, and
+	// ExchangeConcurrency overrides it per exchange
+	Concurrency         int
+	ExchangeConcurrency map[string]int
+}
+
+// ParseConcurrency parses a comma-separated list of concurrency caps, where a bare
+// number sets the cap of every exchange and exchange:number overrides it for one,
+// such as 2,binance:4
+func ParseConcurrency(value string, options *Options) error {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		exchange, limit, found := strings.Cut(entry, ":")
+		if !found {
+			exchange, limit = "", entry
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid concurrency %q, expected a positive number or exchange:number", entry)
+		}
+
+		if exchange == "" {
+			options.Concurrency = n
+			continue
+		}
+		if options.ExchangeConcurrency == nil {
+			options.ExchangeConcurrency = make(map[string]int)
+		}
+		options.ExchangeConcurrency[strings.ToLower(exchange)] = n
+	}
+	return nil
+}
+
+// Scheduler fetches the newly closed candles of the watched series after every
+// interval boundary and stores them
+type Scheduler struct {
+	factory   *exchanges.ExchangeFactory
+	watchlist Watchlist
+	store     candles.Store
+	clock     clock.Clock
+	options   Options
+
+	// jitter returns the random wait before fetching a series
+	jitter func(max time.Duration) time.Duration
+}
+
+// NewScheduler creates a scheduler of the series of a watchlist, filling in the
+// defaults of unset options
+func NewScheduler(factory *exchanges.ExchangeFactory, watchlist Watchlist, store candles.Store, clock clock.Clock, options Options) *Scheduler {
+	if options.Delay <= 0 {
+		options.Delay = DefaultDelay
+	}
+	if options.Jitter < 0 {
+		options.Jitter = 0
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+
+	return &Scheduler{
+		factory:   factory,
+		watchlist: watchlist,
+		store:     store,
+		clock:     clock,
+		options:   options,
+		jitter: func(max time.Duration) time.Duration {
+			if max <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(max)))
+		},
+	}
+}
+
+// Run fetches candles after every interval boundary of the watched series until
+// the context is cancelled. Failures are logged and caught up on at the next
+// boundary of the series.
+func (s *Scheduler) Run(ctx context.Context) error {
+	log.Printf("Ingesting watched candles %s after every interval boundary", s.options.Delay)
+
+	for {
+		entries, err := s.watchlist.List(ctx)
+		if err != nil {
+			log.Printf("Error reading watchlist: %v", err)
+		}
+
+		now := s.clock.Now()
+		boundary := nextBoundary(entries, now)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(boundary.Add(s.options.Delay).Sub(now)):
+		}
+
+		if err := s.RunOnce(ctx, boundary); err != nil {
+			log.Printf("Error ingesting candles: %v", err)
+		}
+	}
+}
+
+// nextBoundary returns the earliest interval boundary of the entries after now,
+// or the next poll of the watchlist when that comes first
+func nextBoundary(entries []Entry, now time.Time) time.Time {
+	next := now.Truncate(pollInterval).Add(pollInterval)
+	for _, entry := range entries {
+		step := entry.Interval.Duration()
+		if boundary := now.Truncate(step).Add(step); boundary.Before(next) {
+			next = boundary
+		}
+	}
+	return next
+}
+
+// RunOnce fetches the candles of every series with an interval boundary at
+// boundary, up to the candle that closed at it. A failing series does not keep
+// the others from being fetched; all failures are returned together.
+func (s *Scheduler) RunOnce(ctx context.Context, boundary time.Time) error {
+	entries, err := s.watchlist.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	slots := make(map[string]chan struct{})
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, entry := range entries {
+		if !boundary.Truncate(entry.Interval.Duration()).Equal(boundary) {
+			continue
+		}
+
+		slot, ok := slots[entry.Exchange]
+		if !ok {
+			slot = make(chan struct{}, s.concurrency(entry.Exchange))
+			slots[entry.Exchange] = slot
+		}
+
+		wg.Add(1)
+		go func(entry Entry) {
+			defer wg.Done()
+
+			err := s.ingestWhenReady(ctx, entry, boundary, slot)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s %s %s (%s): %w", entry.Exchange, entry.Ticker, entry.Interval, entry.Market, err))
+				mu.Unlock()
+			}
+		}(entry)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// concurrency returns the number of series fetched at once from an exchange
+func (s *Scheduler) concurrency(exchange string) int {
+	if n, ok := s.options.ExchangeConcurrency[exchange]; ok {
+		return n
+	}
+	return s.options.Concurrency
+}
+
+// ingestWhenReady waits out the jitter of a series and a free slot of its
+// exchange, then fetches it
+func (s *Scheduler) ingestWhenReady(ctx context.Context, entry Entry, boundary time.Time, slot chan struct{}) error {
+	if wait := s.jitter(s.options.Jitter); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(wait):
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case slot <- struct{}{}:
+	}
+	defer func() { <-slot }()
+
+	return s.ingest(ctx, entry, boundary)
+}
+
+// ingest fetches the candles of a series that closed since it was last ingested,
+// up to the one that closed at boundary, and records how far it got
+func (s *Scheduler) ingest(ctx context.Context, entry Entry, boundary time.Time) error {
+	adapter, ok := s.factory.GetAdapter(entry.Exchange)
+	if !ok {
+		return fmt.Errorf("unsupported exchange: %s", entry.Exchange)
+	}
+
+	last := boundary.Add(-entry.Interval.Duration())
+	if entry.IngestedUntil.After(last) {
+		return nil
+	}
+
+	now := s.clock.Now().UnixMilli()
+	until := entry.IngestedUntil
+	err := adapter.GetHistoricalPrices(ctx, exchanges.PriceQuery{
+		Ticker:    entry.Ticker,
+		Market:    entry.Market,
+		Interval:  entry.Interval,
+		PriceType: entry.PriceType,
+		StartTime: entry.IngestedUntil,
+		EndTime:   last,
+	}, func(prices []*pb.PricesResponse) error {
+		var closed []*pb.PricesResponse
+		for _, price := range prices {
+			if price.CloseTime < now {
+				closed = append(closed, price)
+			}
+		}
+		if err := s.store.Save(ctx, entry.Series, closed); err != nil {
+			return err
+		}
+		if len(closed) > 0 {
+			until = time.UnixMilli(closed[len(closed)-1].CloseTime + 1).UTC()
+		}
+		return nil
+	})
+
+	// Candles stored before a failure are not fetched again
+	if until.After(entry.IngestedUntil) {
+		if advanceErr := s.watchlist.Advance(ctx, entry.ID, until); advanceErr != nil {
+			return errors.Join(err, advanceErr)
+		}
+	}
+	return err
+}
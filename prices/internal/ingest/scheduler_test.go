@@ -0,0 +1,276 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/candles"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// fakeClock is a clock whose time only moves when advanced, signalling every wait it is asked for
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+	waiting chan time.Duration
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiting: make(chan time.Duration, 16)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	c.mu.Unlock()
+
+	c.waiting <- d
+	return ch
+}
+
+// Advance moves the time forward, firing the waits that are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(c.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// fakeCandleAdapter serves a candle for every interval in the queried range,
+// tracking how many queries run at once and failing for the tickers in failing
+type fakeCandleAdapter struct {
+	name    string
+	failing map[string]bool
+
+	mu        sync.Mutex
+	queries   []exchanges.PriceQuery
+	active    int
+	maxActive int
+}
+
+func (a *fakeCandleAdapter) GetName() string {
+	return a.name
+}
+
+func (a *fakeCandleAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	a.mu.Lock()
+	a.queries = append(a.queries, query)
+	a.active++
+	if a.active > a.maxActive {
+		a.maxActive = a.active
+	}
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.active--
+		a.mu.Unlock()
+	}()
+
+	// Give other queries the chance to overlap this one
+	time.Sleep(10 * time.Millisecond)
+
+	if a.failing[query.Ticker] {
+		return errors.New("exchange unavailable")
+	}
+
+	var page []*pb.PricesResponse
+	step := query.Interval.Duration()
+	for open := query.StartTime; !open.After(query.EndTime); open = open.Add(step) {
+		page = append(page, &pb.PricesResponse{
+			OpenTime:      open.UnixMilli(),
+			CloseTime:     query.Interval.CloseTime(open).UnixMilli(),
+			Close:         float64(open.Unix()),
+			SchemaVersion: exchanges.CandleSchemaVersion,
+		})
+	}
+	if len(page) == 0 {
+		return nil
+	}
+	return handle(page)
+}
+
+// watch adds a series to a watchlist, ingested from a time on
+func watch(t *testing.T, watchlist *GormWatchlist, series candles.Series, from time.Time) Entry {
+	entry, err := watchlist.Add(context.Background(), series)
+	require.NoError(t, err)
+	require.NoError(t, watchlist.Advance(context.Background(), entry.ID, from))
+	entry.IngestedUntil = from
+	return entry
+}
+
+// storedOpenTimes returns the open times of the stored candles of a series
+func storedOpenTimes(t *testing.T, store candles.Store, series candles.Series, start, end time.Time) []time.Time {
+	stored, err := store.Candles(context.Background(), series, start, end, 1000)
+	require.NoError(t, err)
+
+	var times []time.Time
+	for _, candle := range stored {
+		times = append(times, time.UnixMilli(candle.OpenTime).UTC())
+	}
+	return times
+}
+
+// TestScheduler_RunOnce tests ingesting the series due at a boundary
+func TestScheduler_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("due series are caught up to the boundary", func(t *testing.T) {
+		watchlist, store := newTestDB(t)
+		adapter := &fakeCandleAdapter{name: "alpha"}
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+
+		hourly := candles.Series{Exchange: "alpha", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}.Normalize()
+		daily := candles.Series{Exchange: "alpha", Ticker: "BTCUSDT", Interval: exchanges.Interval1d}.Normalize()
+		hourlyEntry := watch(t, watchlist, hourly, day.Add(-3*time.Hour))
+		watch(t, watchlist, daily, day.Add(-24*time.Hour))
+
+		clock := newFakeClock(day.Add(time.Hour + 5*time.Second))
+		scheduler := NewScheduler(factory, watchlist, store, clock, Options{Jitter: 0})
+
+		// Only the hourly series has a boundary at 01:00
+		require.NoError(t, scheduler.RunOnce(ctx, day.Add(time.Hour)))
+		assert.Equal(t, []time.Time{day.Add(-3 * time.Hour), day.Add(-2 * time.Hour), day.Add(-time.Hour), day},
+			storedOpenTimes(t, store, hourly, day.Add(-24*time.Hour), day.Add(24*time.Hour)))
+		assert.Empty(t, storedOpenTimes(t, store, daily, day.Add(-48*time.Hour), day.Add(24*time.Hour)))
+
+		// Both have one at midnight
+		clock.Advance(23 * time.Hour)
+		require.NoError(t, scheduler.RunOnce(ctx, day.Add(24*time.Hour)))
+		assert.Equal(t, []time.Time{day.Add(-24 * time.Hour), day},
+			storedOpenTimes(t, store, daily, day.Add(-48*time.Hour), day.Add(24*time.Hour)))
+		assert.Len(t, storedOpenTimes(t, store, hourly, day.Add(-24*time.Hour), day.Add(24*time.Hour)), 27)
+
+		entries, err := watchlist.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, hourlyEntry.ID, entries[0].ID)
+		assert.Equal(t, day.Add(24*time.Hour), entries[0].IngestedUntil)
+		assert.Equal(t, day.Add(24*time.Hour), entries[1].IngestedUntil)
+	})
+
+	t.Run("concurrency is capped per exchange", func(t *testing.T) {
+		watchlist, store := newTestDB(t)
+		alpha := &fakeCandleAdapter{name: "alpha"}
+		beta := &fakeCandleAdapter{name: "beta"}
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(alpha)
+		factory.RegisterAdapter(beta)
+
+		for _, ticker := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT"} {
+			watch(t, watchlist, candles.Series{Exchange: "alpha", Ticker: ticker, Interval: exchanges.Interval1h}, day.Add(-time.Hour))
+			watch(t, watchlist, candles.Series{Exchange: "beta", Ticker: ticker, Interval: exchanges.Interval1h}, day.Add(-time.Hour))
+		}
+
+		clock := newFakeClock(day.Add(5 * time.Second))
+		scheduler := NewScheduler(factory, watchlist, store, clock, Options{
+			Concurrency:         3,
+			ExchangeConcurrency: map[string]int{"alpha": 1},
+		})
+
+		require.NoError(t, scheduler.RunOnce(ctx, day))
+		assert.Len(t, alpha.queries, 4)
+		assert.Len(t, beta.queries, 4)
+		assert.Equal(t, 1, alpha.maxActive)
+		assert.LessOrEqual(t, beta.maxActive, 3)
+	})
+
+	t.Run("a failing series does not stop the others", func(t *testing.T) {
+		watchlist, store := newTestDB(t)
+		adapter := &fakeCandleAdapter{name: "alpha", failing: map[string]bool{"ETHUSDT": true}}
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+
+		btc := watch(t, watchlist, candles.Series{Exchange: "alpha", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}, day.Add(-time.Hour))
+		eth := watch(t, watchlist, candles.Series{Exchange: "alpha", Ticker: "ETHUSDT", Interval: exchanges.Interval1h}, day.Add(-time.Hour))
+
+		clock := newFakeClock(day.Add(5 * time.Second))
+		scheduler := NewScheduler(factory, watchlist, store, clock, Options{})
+
+		err := scheduler.RunOnce(ctx, day)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ETHUSDT")
+		assert.NotContains(t, err.Error(), "BTCUSDT")
+
+		entries, err := watchlist.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, btc.ID, entries[0].ID)
+		assert.Equal(t, day, entries[0].IngestedUntil)
+		assert.Equal(t, eth.IngestedUntil, entries[1].IngestedUntil)
+	})
+}
+
+// TestScheduler_Run tests that series are ingested after the boundary, delay and jitter
+func TestScheduler_Run(t *testing.T) {
+	watchlist, store := newTestDB(t)
+	adapter := &fakeCandleAdapter{name: "alpha"}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+
+	day := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	series := candles.Series{Exchange: "alpha", Ticker: "BTCUSDT", Interval: exchanges.Interval1h}.Normalize()
+	watch(t, watchlist, series, day.Add(-time.Hour))
+
+	clock := newFakeClock(day.Add(-30 * time.Second))
+	scheduler := NewScheduler(factory, watchlist, store, clock, Options{Delay: 5 * time.Second, Jitter: 10 * time.Second})
+	scheduler.jitter = func(max time.Duration) time.Duration { return max / 2 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	// The first round waits for the boundary and the delay, then the jitter
+	assert.Equal(t, 35*time.Second, <-clock.waiting)
+	assert.Empty(t, adapter.queries)
+	clock.Advance(35 * time.Second)
+	assert.Equal(t, 5*time.Second, <-clock.waiting)
+	clock.Advance(5 * time.Second)
+
+	// The watchlist is polled again a minute later, after the candle that closed at the boundary is stored
+	assert.Equal(t, 55*time.Second, <-clock.waiting)
+	assert.Equal(t, []time.Time{day.Add(-time.Hour)}, storedOpenTimes(t, store, series, day.Add(-24*time.Hour), day))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+// TestParseConcurrency tests parsing the concurrency caps
+func TestParseConcurrency(t *testing.T) {
+	var options Options
+	require.NoError(t, ParseConcurrency("3, Binance:4,okx:1", &options))
+	assert.Equal(t, 3, options.Concurrency)
+	assert.Equal(t, map[string]int{"binance": 4, "okx": 1}, options.ExchangeConcurrency)
+
+	for _, value := range []string{"zero", "0", "binance:", "binance:-1"} {
+		assert.Error(t, ParseConcurrency(value, &Options{}), value)
+	}
+}
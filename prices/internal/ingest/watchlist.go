@@ -0,0 +1,144 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/timakaa/historical-common/database/models"
+	"github.com/timakaa/historical-prices/internal/candles"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+	"gorm.io/gorm"
+)
+
+// ErrEntryExists is returned when a series is added to the watchlist twice
+var ErrEntryExists = errors.New("series is already watched")
+
+// ErrEntryNotFound is returned when a watchlist entry does not exist
+var ErrEntryNotFound = errors.New("watchlist entry not found")
+
+// Entry is a watched candle series
+type Entry struct {
+	ID uint
+	candles.Series
+
+	// IngestedUntil is the open time of the next candle to ingest
+	IngestedUntil time.Time
+	CreatedAt     time.Time
+}
+
+// Watchlist persists the watched candle series
+type Watchlist interface {
+	// List returns every watched series in the order they were added
+	List(ctx context.Context) ([]Entry, error)
+
+	// Add watches a series, ingesting it from the candle open at the time it is added
+	Add(ctx context.Context, series candles.Series) (Entry, error)
+
+	// Remove stops watching the series of an entry
+	Remove(ctx context.Context, id uint) error
+
+	// Advance records that the candles of an entry opening before until are ingested
+	Advance(ctx context.Context, id uint, until time.Time) error
+}
+
+// GormWatchlist stores the watchlist in a relational database
+type GormWatchlist struct {
+	db *gorm.DB
+}
+
+// NewGormWatchlist creates a watchlist on a database connection
+func NewGormWatchlist(db *gorm.DB) *GormWatchlist {
+	return &GormWatchlist{db: db}
+}
+
+// Migrate creates or updates the watchlist table
+func (w *GormWatchlist) Migrate() error {
+	return w.db.AutoMigrate(&models.WatchlistEntry{})
+}
+
+// List returns every watched series in the order they were added
+func (w *GormWatchlist) List(ctx context.Context) ([]Entry, error) {
+	var records []models.WatchlistEntry
+	if err := w.db.WithContext(ctx).Order("id ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("error reading watchlist: %v", err)
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, toEntry(record))
+	}
+	return entries, nil
+}
+
+// Add watches a series, ingesting it from the candle open at the time it is added
+func (w *GormWatchlist) Add(ctx context.Context, series candles.Series) (Entry, error) {
+	series = series.Normalize()
+
+	var existing int64
+	err := w.db.WithContext(ctx).Model(&models.WatchlistEntry{}).
+		Where("exchange = ? AND market = ? AND ticker = ? AND candle_interval = ? AND price_type = ?",
+			series.Exchange, string(series.Market), series.Ticker, string(series.Interval), string(series.PriceType)).
+		Count(&existing).Error
+	if err != nil {
+		return Entry{}, fmt.Errorf("error reading watchlist: %v", err)
+	}
+	if existing > 0 {
+		return Entry{}, fmt.Errorf("%w: %s %s %s (%s)", ErrEntryExists, series.Exchange, series.Ticker, series.Interval, series.Market)
+	}
+
+	now := time.Now().UTC()
+	record := &models.WatchlistEntry{
+		Exchange:      series.Exchange,
+		Market:        string(series.Market),
+		Ticker:        series.Ticker,
+		Interval:      string(series.Interval),
+		PriceType:     string(series.PriceType),
+		IngestedUntil: now.Truncate(series.Interval.Duration()).UnixMilli(),
+		CreatedAt:     now,
+	}
+	if err := w.db.WithContext(ctx).Create(record).Error; err != nil {
+		return Entry{}, fmt.Errorf("error saving watchlist entry: %v", err)
+	}
+	return toEntry(*record), nil
+}
+
+// Remove stops watching the series of an entry
+func (w *GormWatchlist) Remove(ctx context.Context, id uint) error {
+	result := w.db.WithContext(ctx).Delete(&models.WatchlistEntry{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("error removing watchlist entry: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %d", ErrEntryNotFound, id)
+	}
+	return nil
+}
+
+// Advance records that the candles of an entry opening before until are ingested
+func (w *GormWatchlist) Advance(ctx context.Context, id uint, until time.Time) error {
+	err := w.db.WithContext(ctx).Model(&models.WatchlistEntry{}).
+		Where("id = ?", id).
+		Update("ingested_until", until.UnixMilli()).Error
+	if err != nil {
+		return fmt.Errorf("error updating watchlist entry: %v", err)
+	}
+	return nil
+}
+
+// toEntry converts a stored watchlist entry into its domain form
+func toEntry(record models.WatchlistEntry) Entry {
+	return Entry{
+		ID: record.ID,
+		Series: candles.Series{
+			Exchange:  record.Exchange,
+			Market:    exchanges.Market(record.Market),
+			Ticker:    record.Ticker,
+			Interval:  exchanges.Interval(record.Interval),
+			PriceType: exchanges.PriceType(record.PriceType),
+		},
+		IngestedUntil: time.UnixMilli(record.IngestedUntil).UTC(),
+		CreatedAt:     record.CreatedAt.UTC(),
+	}
+}
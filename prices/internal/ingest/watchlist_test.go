@@ -0,0 +1,73 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timakaa/historical-prices/internal/candles"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database private to the test, with the
+// watchlist and candles tables created
+func newTestDB(t *testing.T) (*GormWatchlist, *candles.GormStore) {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	watchlist := NewGormWatchlist(db)
+	require.NoError(t, watchlist.Migrate())
+	store := candles.NewGormStore(db)
+	require.NoError(t, store.Migrate())
+	return watchlist, store
+}
+
+// TestGormWatchlist tests adding, listing, advancing and removing watched series
+func TestGormWatchlist(t *testing.T) {
+	watchlist, _ := newTestDB(t)
+	ctx := context.Background()
+
+	added, err := watchlist.Add(ctx, candles.Series{Exchange: "binance", Ticker: "btcusdt", Interval: exchanges.Interval1h})
+	require.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", added.Ticker)
+	assert.Equal(t, exchanges.MarketSpot, added.Market)
+	assert.Equal(t, exchanges.PriceTypeLast, added.PriceType)
+	assert.Equal(t, added.CreatedAt.Truncate(time.Hour), added.IngestedUntil)
+
+	_, err = watchlist.Add(ctx, candles.Series{Exchange: "binance", Ticker: "BTCUSDT", Interval: exchanges.Interval1h})
+	assert.ErrorIs(t, err, ErrEntryExists)
+
+	other, err := watchlist.Add(ctx, candles.Series{Exchange: "binance", Ticker: "BTCUSDT", Interval: exchanges.Interval1d, Market: exchanges.MarketLinearPerp})
+	require.NoError(t, err)
+
+	until := time.Date(2023, 1, 1, 5, 0, 0, 0, time.UTC)
+	require.NoError(t, watchlist.Advance(ctx, added.ID, until))
+
+	entries, err := watchlist.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, added.ID, entries[0].ID)
+	assert.Equal(t, until, entries[0].IngestedUntil)
+	assert.Equal(t, other.Series, entries[1].Series)
+
+	require.NoError(t, watchlist.Remove(ctx, added.ID))
+	assert.ErrorIs(t, watchlist.Remove(ctx, added.ID), ErrEntryNotFound)
+
+	entries, err = watchlist.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, other.ID, entries[0].ID)
+}
@@ -0,0 +1,181 @@
+// Package live fans the live candles of exchange websocket streams out to
+// subscribers, keeping a single upstream stream per series however many
+// subscribers follow it.
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// ErrSlowSubscriber ends a subscription that fell too far behind its stream
+var ErrSlowSubscriber = errors.New("subscriber too slow")
+
+// ErrStreamEnded ends the subscriptions of a stream that stopped by itself
+var ErrStreamEnded = errors.New("candle stream ended")
+
+// subscriptionBuffer is the number of updates queued for a subscriber before it
+// is considered too slow and dropped, so it cannot hold back the others
+const subscriptionBuffer = 256
+
+// Key identifies a live candle series of an exchange
+type Key struct {
+	Exchange string
+	exchanges.StreamQuery
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s %s (%s, %s)", k.Exchange, k.Ticker, k.Market, k.Interval)
+}
+
+// Hub shares the upstream candle streams between subscribers. A stream is opened
+// by the first subscriber of a series and stopped when its last subscriber leaves.
+type Hub struct {
+	mu    sync.Mutex
+	feeds map[Key]*feed
+}
+
+// feed is a running upstream stream and its subscribers
+type feed struct {
+	cancel      context.CancelFunc
+	subscribers map[*Subscription]struct{}
+
+	// last is the latest update, replayed to new subscribers; nil before the first
+	last *exchanges.CandleUpdate
+}
+
+// NewHub creates a hub without streams
+func NewHub() *Hub {
+	return &Hub{feeds: make(map[Key]*feed)}
+}
+
+// Subscription receives the updates of a live candle series
+type Subscription struct {
+	hub     *Hub
+	key     Key
+	feed    *feed
+	updates chan exchanges.CandleUpdate
+	err     error
+	closed  bool
+}
+
+// Updates returns the updates of the series, closed when the subscription ends
+func (s *Subscription) Updates() <-chan exchanges.CandleUpdate {
+	return s.updates
+}
+
+// Err returns why the subscription ended once its updates are closed: nil when
+// it was closed by the subscriber, ErrSlowSubscriber when it fell behind, or the
+// failure of the upstream stream
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription, stopping the upstream stream when it was the last
+// subscriber of its series
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.unsubscribe(s, nil)
+}
+
+// Subscribe follows a live candle series, starting its upstream stream unless
+// another subscriber already did. The latest update of an already running stream
+// is delivered first.
+func (h *Hub) Subscribe(key Key, streamer exchanges.CandleStreamer) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscription := &Subscription{
+		hub:     h,
+		key:     key,
+		updates: make(chan exchanges.CandleUpdate, subscriptionBuffer),
+	}
+
+	f, ok := h.feeds[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		f = &feed{cancel: cancel, subscribers: make(map[*Subscription]struct{})}
+		h.feeds[key] = f
+		go h.run(ctx, key, f, streamer)
+	}
+	subscription.feed = f
+	f.subscribers[subscription] = struct{}{}
+	if f.last != nil {
+		subscription.updates <- *f.last
+	}
+
+	return subscription
+}
+
+// run streams a series until its last subscriber leaves or the stream fails,
+// which ends every subscription of the series
+func (h *Hub) run(ctx context.Context, key Key, f *feed, streamer exchanges.CandleStreamer) {
+	err := streamer.StreamCandles(ctx, key.StreamQuery, func(update exchanges.CandleUpdate) error {
+		h.broadcast(f, update)
+		return nil
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		err = ErrStreamEnded
+	}
+	log.Printf("Live candle stream of %s stopped: %v", key, err)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for subscription := range f.subscribers {
+		h.unsubscribe(subscription, err)
+	}
+}
+
+// broadcast queues an update for every subscriber of a feed, dropping the ones
+// whose queue is full
+func (h *Hub) broadcast(f *feed, update exchanges.CandleUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f.last = &update
+	for subscription := range f.subscribers {
+		select {
+		case subscription.updates <- update:
+		default:
+			h.unsubscribe(subscription, ErrSlowSubscriber)
+		}
+	}
+}
+
+// unsubscribe ends a subscription, stopping its feed when no subscriber is left.
+// The hub must be locked.
+func (h *Hub) unsubscribe(subscription *Subscription, err error) {
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	subscription.err = err
+	close(subscription.updates)
+
+	f := subscription.feed
+	delete(f.subscribers, subscription)
+	if len(f.subscribers) == 0 {
+		f.cancel()
+		if h.feeds[subscription.key] == f {
+			delete(h.feeds, subscription.key)
+		}
+	}
+}
+
+// Streams returns the number of running upstream streams
+func (h *Hub) Streams() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.feeds)
+}
@@ -0,0 +1,172 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// fakeStreamer pushes the updates sent to it until its stream is cancelled or
+// fails, counting the streams it runs
+type fakeStreamer struct {
+	mu      sync.Mutex
+	started int
+	stopped int
+
+	updates chan exchanges.CandleUpdate
+	failure chan error
+}
+
+func newFakeStreamer() *fakeStreamer {
+	return &fakeStreamer{updates: make(chan exchanges.CandleUpdate), failure: make(chan error)}
+}
+
+func (s *fakeStreamer) StreamCandles(ctx context.Context, query exchanges.StreamQuery, handle exchanges.CandleUpdateHandler) error {
+	s.mu.Lock()
+	s.started++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.stopped++
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-s.failure:
+			return err
+		case update := <-s.updates:
+			if err := handle(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *fakeStreamer) counts() (started, stopped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started, s.stopped
+}
+
+func update(openTime int64, closed bool) exchanges.CandleUpdate {
+	return exchanges.CandleUpdate{Candle: &pb.PricesResponse{OpenTime: openTime}, Closed: closed}
+}
+
+func receive(t *testing.T, subscription *Subscription) exchanges.CandleUpdate {
+	t.Helper()
+	select {
+	case update, ok := <-subscription.Updates():
+		require.True(t, ok, "subscription ended: %v", subscription.Err())
+		return update
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no update received")
+		return exchanges.CandleUpdate{}
+	}
+}
+
+var btcKey = Key{Exchange: "binance", StreamQuery: exchanges.StreamQuery{Ticker: "BTCUSDT", Market: exchanges.MarketSpot, Interval: exchanges.Interval1m}}
+
+// TestHub_Subscribe tests that subscribers of a series share one upstream stream
+func TestHub_Subscribe(t *testing.T) {
+	streamer := newFakeStreamer()
+	hub := NewHub()
+
+	first := hub.Subscribe(btcKey, streamer)
+	streamer.updates <- update(1, false)
+	assert.Equal(t, update(1, false), receive(t, first))
+
+	// Later subscribers start from the latest update
+	second := hub.Subscribe(btcKey, streamer)
+	assert.Equal(t, update(1, false), receive(t, second))
+
+	streamer.updates <- update(1, true)
+	assert.Equal(t, update(1, true), receive(t, first))
+	assert.Equal(t, update(1, true), receive(t, second))
+
+	started, _ := streamer.counts()
+	assert.Equal(t, 1, started)
+	assert.Equal(t, 1, hub.Streams())
+
+	// The stream stops with its last subscriber
+	first.Close()
+	assert.Equal(t, 1, hub.Streams())
+	second.Close()
+	assert.Equal(t, 0, hub.Streams())
+	assert.Eventually(t, func() bool {
+		_, stopped := streamer.counts()
+		return stopped == 1
+	}, 5*time.Second, time.Millisecond)
+
+	_, ok := <-first.Updates()
+	assert.False(t, ok)
+	assert.NoError(t, first.Err())
+
+	t.Run("other series are streamed separately", func(t *testing.T) {
+		streamer := newFakeStreamer()
+		hub := NewHub()
+		eth := btcKey
+		eth.Ticker = "ETHUSDT"
+
+		defer hub.Subscribe(btcKey, streamer).Close()
+		defer hub.Subscribe(eth, streamer).Close()
+
+		assert.Equal(t, 2, hub.Streams())
+		assert.Eventually(t, func() bool {
+			started, _ := streamer.counts()
+			return started == 2
+		}, 5*time.Second, time.Millisecond)
+	})
+}
+
+// TestHub_StreamFailure tests that a failed stream ends every subscription of its series
+func TestHub_StreamFailure(t *testing.T) {
+	streamer := newFakeStreamer()
+	hub := NewHub()
+	first := hub.Subscribe(btcKey, streamer)
+	second := hub.Subscribe(btcKey, streamer)
+
+	streamer.failure <- errors.New("connection refused")
+
+	for _, subscription := range []*Subscription{first, second} {
+		_, ok := <-subscription.Updates()
+		assert.False(t, ok)
+		assert.EqualError(t, subscription.Err(), "connection refused")
+	}
+	assert.Equal(t, 0, hub.Streams())
+
+	// The next subscriber opens a new stream
+	third := hub.Subscribe(btcKey, streamer)
+	defer third.Close()
+	streamer.updates <- update(2, false)
+	assert.Equal(t, update(2, false), receive(t, third))
+}
+
+// TestHub_SlowSubscriber tests that a subscriber not keeping up is dropped
+// without holding back the others
+func TestHub_SlowSubscriber(t *testing.T) {
+	streamer := newFakeStreamer()
+	hub := NewHub()
+	slow := hub.Subscribe(btcKey, streamer)
+	fast := hub.Subscribe(btcKey, streamer)
+	defer fast.Close()
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		streamer.updates <- update(int64(i), false)
+		assert.Equal(t, update(int64(i), false), receive(t, fast))
+	}
+
+	for range slow.Updates() {
+	}
+	assert.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+	assert.Equal(t, 1, hub.Streams())
+}
@@ -0,0 +1,154 @@
+// Package orderbook records order book snapshots of configured symbols, which
+// exchanges do not serve historically, and queries the recorded history.
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timakaa/historical-prices/internal/clock"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// DefaultInterval is the time between snapshots when none is configured
+const DefaultInterval = time.Minute
+
+// Target is a symbol whose order book is recorded
+type Target struct {
+	Symbol
+
+	// Depth is the number of price levels recorded per side
+	Depth int
+}
+
+// ParseTargets parses a comma-separated list of targets written as
+// exchange:ticker[:market[:depth]], such as binance:BTCUSDT:linear_perp:50
+func ParseTargets(value string) ([]Target, error) {
+	var targets []Target
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid order book target %q, expected exchange:ticker[:market[:depth]]", entry)
+		}
+
+		target := Target{Symbol: Symbol{Exchange: strings.ToLower(parts[0]), Ticker: parts[1]}}
+		if len(parts) > 2 {
+			market, err := exchanges.ParseMarket(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid order book target %q: %v", entry, err)
+			}
+			target.Market = market
+		}
+		if len(parts) > 3 {
+			depth, err := strconv.Atoi(parts[3])
+			if err != nil {
+				return nil, fmt.Errorf("invalid order book target %q: depth must be a number", entry)
+			}
+			target.Depth = depth
+		}
+
+		depth, err := exchanges.ParseOrderBookDepth(target.Depth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order book target %q: %v", entry, err)
+		}
+		target.Depth = depth
+		target.Symbol = target.Symbol.normalize()
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// Recorder periodically snapshots the order books of its targets and stores them
+type Recorder struct {
+	factory  *exchanges.ExchangeFactory
+	store    Store
+	clock    clock.Clock
+	interval time.Duration
+	targets  []Target
+}
+
+// NewRecorder creates a recorder of targets every interval, checking that the
+// exchange of every target serves order books
+func NewRecorder(factory *exchanges.ExchangeFactory, store Store, clock clock.Clock, interval time.Duration, targets []Target) (*Recorder, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("order book recording interval must be positive")
+	}
+	for _, target := range targets {
+		if _, ok := factory.GetOrderBookProvider(target.Exchange); !ok {
+			return nil, fmt.Errorf("%s does not provide order books", target.Exchange)
+		}
+	}
+
+	return &Recorder{
+		factory:  factory,
+		store:    store,
+		clock:    clock,
+		interval: interval,
+		targets:  targets,
+	}, nil
+}
+
+// Run records snapshots at every multiple of the interval until the context is
+// cancelled. Failures are logged and retried at the next round.
+func (r *Recorder) Run(ctx context.Context) error {
+	log.Printf("Recording order books of %d symbols every %s", len(r.targets), r.interval)
+
+	for {
+		now := r.clock.Now()
+		next := now.Truncate(r.interval).Add(r.interval)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.clock.After(next.Sub(now)):
+		}
+
+		if err := r.RecordOnce(ctx); err != nil {
+			log.Printf("Error recording order books: %v", err)
+		}
+	}
+}
+
+// RecordOnce snapshots every target at the current time. A failing target does
+// not keep the others from being recorded; all failures are returned together.
+func (r *Recorder) RecordOnce(ctx context.Context) error {
+	recordedAt := r.clock.Now()
+
+	var errs []error
+	for _, target := range r.targets {
+		if err := r.record(ctx, target, recordedAt); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s (%s): %w", target.Exchange, target.Ticker, target.Market, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// record snapshots the order book of a target and stores it
+func (r *Recorder) record(ctx context.Context, target Target, recordedAt time.Time) error {
+	provider, ok := r.factory.GetOrderBookProvider(target.Exchange)
+	if !ok {
+		return fmt.Errorf("%s does not provide order books", target.Exchange)
+	}
+
+	snapshot, err := provider.GetOrderBook(ctx, exchanges.OrderBookQuery{
+		Ticker: target.Ticker,
+		Market: target.Market,
+		Depth:  target.Depth,
+	})
+	if err != nil {
+		return err
+	}
+	snapshot.Timestamp = recordedAt.UnixMilli()
+
+	return r.store.Save(ctx, target.Symbol, snapshot)
+}
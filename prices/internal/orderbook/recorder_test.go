@@ -0,0 +1,195 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/clock"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+)
+
+// fakeClock is a clock whose time only moves when advanced, signalling every wait it is asked for
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+	waiting chan time.Duration
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiting: make(chan time.Duration, 16)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	c.mu.Unlock()
+
+	c.waiting <- d
+	return ch
+}
+
+// Advance moves the time forward, firing the waits that are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(c.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// fakeOrderBookAdapter serves numbered one-level order books, failing for the tickers in failing
+type fakeOrderBookAdapter struct {
+	name    string
+	calls   int
+	queries []exchanges.OrderBookQuery
+	failing map[string]bool
+}
+
+func (a *fakeOrderBookAdapter) GetName() string {
+	return a.name
+}
+
+func (a *fakeOrderBookAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	return nil
+}
+
+func (a *fakeOrderBookAdapter) GetOrderBook(ctx context.Context, query exchanges.OrderBookQuery) (*pb.OrderBookSnapshot, error) {
+	a.calls++
+	a.queries = append(a.queries, query)
+	if a.failing[query.Ticker] {
+		return nil, errors.New("API error")
+	}
+	return &pb.OrderBookSnapshot{
+		UpdateId: int64(a.calls),
+		Bids:     []*pb.OrderBookLevel{{Price: 100, Quantity: 1}},
+		Asks:     []*pb.OrderBookLevel{{Price: 101, Quantity: 2}},
+	}, nil
+}
+
+func newFakeFactory(adapter *fakeOrderBookAdapter) *exchanges.ExchangeFactory {
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	return factory
+}
+
+// TestRecorder_Run tests recording snapshots at every multiple of the interval
+func TestRecorder_Run(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 20, 0, time.UTC)
+	clock := newFakeClock(start)
+	store := newTestStore(t)
+	adapter := &fakeOrderBookAdapter{name: "fake"}
+	target := Target{Symbol: Symbol{Exchange: "fake", Ticker: "BTCUSDT", Market: exchanges.MarketSpot}, Depth: 5}
+
+	recorder, err := NewRecorder(newFakeFactory(adapter), store, clock, time.Minute, []Target{target})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- recorder.Run(ctx) }()
+
+	// The first snapshot waits for the next whole minute
+	assert.Equal(t, 40*time.Second, <-clock.waiting)
+	assert.Equal(t, 0, adapter.calls)
+
+	clock.Advance(40 * time.Second)
+	assert.Equal(t, time.Minute, <-clock.waiting)
+	clock.Advance(time.Minute)
+	assert.Equal(t, time.Minute, <-clock.waiting)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	assert.Equal(t, 2, adapter.calls)
+	assert.Equal(t, exchanges.OrderBookQuery{Ticker: "BTCUSDT", Market: exchanges.MarketSpot, Depth: 5}, adapter.queries[0])
+
+	var recorded []*pb.OrderBookSnapshot
+	err = store.Range(context.Background(), target.Symbol, start, start.Add(time.Hour), 0, func(snapshots []*pb.OrderBookSnapshot) error {
+		recorded = append(recorded, snapshots...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC).UnixMilli(), recorded[0].Timestamp)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 2, 0, 0, time.UTC).UnixMilli(), recorded[1].Timestamp)
+	assert.Equal(t, int64(2), recorded[1].UpdateId)
+	assert.Equal(t, []*pb.OrderBookLevel{{Price: 101, Quantity: 2}}, recorded[1].Asks)
+}
+
+// TestRecorder_RecordOnce tests that a failing target does not stop the others
+func TestRecorder_RecordOnce(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t)
+	adapter := &fakeOrderBookAdapter{name: "fake", failing: map[string]bool{"ETHUSDT": true}}
+	targets := []Target{
+		{Symbol: Symbol{Exchange: "fake", Ticker: "ETHUSDT", Market: exchanges.MarketSpot}, Depth: 20},
+		{Symbol: Symbol{Exchange: "fake", Ticker: "BTCUSDT", Market: exchanges.MarketSpot}, Depth: 20},
+	}
+
+	recorder, err := NewRecorder(newFakeFactory(adapter), store, newFakeClock(now), time.Minute, targets)
+	require.NoError(t, err)
+
+	err = recorder.RecordOnce(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fake ETHUSDT (spot): API error")
+
+	snapshot, err := store.Nearest(context.Background(), targets[1].Symbol, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.UnixMilli(), snapshot.Timestamp)
+
+	_, err = store.Nearest(context.Background(), targets[0].Symbol, now)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
+
+// TestNewRecorder tests validation of the recorder configuration
+func TestNewRecorder(t *testing.T) {
+	factory := exchanges.NewExchangeFactory()
+	store := newTestStore(t)
+
+	_, err := NewRecorder(factory, store, clock.System{}, 0, nil)
+	assert.Error(t, err)
+
+	_, err = NewRecorder(factory, store, clock.System{}, time.Minute, []Target{{Symbol: Symbol{Exchange: "coinbase", Ticker: "BTC-USD"}}})
+	assert.EqualError(t, err, "coinbase does not provide order books")
+}
+
+// TestParseTargets tests parsing the list of recorded symbols
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets("binance:btcusdt, Bybit:BTCUSDT:linear_perp:50,")
+	require.NoError(t, err)
+	assert.Equal(t, []Target{
+		{Symbol: Symbol{Exchange: "binance", Ticker: "BTCUSDT", Market: exchanges.MarketSpot}, Depth: exchanges.DefaultOrderBookDepth},
+		{Symbol: Symbol{Exchange: "bybit", Ticker: "BTCUSDT", Market: exchanges.MarketLinearPerp}, Depth: 50},
+	}, targets)
+
+	for _, value := range []string{"binance", "binance:BTCUSDT:margin", "binance:BTCUSDT:spot:deep", "binance:BTCUSDT:spot:1000", "binance:BTCUSDT:spot:5:x"} {
+		_, err := ParseTargets(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,218 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timakaa/historical-common/database/models"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+	"gorm.io/gorm"
+)
+
+// ErrSnapshotNotFound is returned when no snapshot of a symbol has been recorded
+var ErrSnapshotNotFound = errors.New("no order book snapshot recorded")
+
+// storePageSize is the number of snapshots read from the database at once
+const storePageSize = 100
+
+// Symbol identifies the order book of a symbol on an exchange market
+type Symbol struct {
+	Exchange string
+	Ticker   string
+	Market   exchanges.Market
+}
+
+// normalize upper-cases the ticker and defaults the market, so that a symbol is
+// stored under one key however it was requested
+func (s Symbol) normalize() Symbol {
+	s.Ticker = strings.ToUpper(s.Ticker)
+	if s.Market == "" {
+		s.Market = exchanges.DefaultMarket
+	}
+	return s
+}
+
+// Store persists recorded order book snapshots
+type Store interface {
+	// Save stores a snapshot of a symbol recorded at its timestamp
+	Save(ctx context.Context, symbol Symbol, snapshot *pb.OrderBookSnapshot) error
+
+	// Nearest returns the snapshot of a symbol recorded closest to a time
+	Nearest(ctx context.Context, symbol Symbol, at time.Time) (*pb.OrderBookSnapshot, error)
+
+	// Range passes the snapshots of a symbol recorded in [start, end] to handle in
+	// chronological order. A zero start returns the most recent limit snapshots
+	// and a zero limit leaves a range uncapped.
+	Range(ctx context.Context, symbol Symbol, start, end time.Time, limit int64, handle func([]*pb.OrderBookSnapshot) error) error
+}
+
+// GormStore stores snapshots in a relational database
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a store on a database connection
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Migrate creates or updates the snapshots table
+func (s *GormStore) Migrate() error {
+	return s.db.AutoMigrate(&models.OrderBookSnapshot{})
+}
+
+// Save stores a snapshot of a symbol recorded at its timestamp
+func (s *GormStore) Save(ctx context.Context, symbol Symbol, snapshot *pb.OrderBookSnapshot) error {
+	symbol = symbol.normalize()
+	record := &models.OrderBookSnapshot{
+		Exchange:     symbol.Exchange,
+		Ticker:       symbol.Ticker,
+		Market:       string(symbol.Market),
+		RecordedAt:   time.UnixMilli(snapshot.Timestamp).UTC(),
+		ExchangeTime: snapshot.ExchangeTime,
+		UpdateID:     snapshot.UpdateId,
+		Bids:         fromLevels(snapshot.Bids),
+		Asks:         fromLevels(snapshot.Asks),
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("error saving order book snapshot: %v", err)
+	}
+	return nil
+}
+
+// Nearest returns the snapshot of a symbol recorded closest to a time, the earlier
+// one when two are equally close
+func (s *GormStore) Nearest(ctx context.Context, symbol Symbol, at time.Time) (*pb.OrderBookSnapshot, error) {
+	at = at.UTC()
+
+	var before, after []models.OrderBookSnapshot
+	err := s.symbolQuery(ctx, symbol).Where("recorded_at <= ?", at).Order("recorded_at DESC").Limit(1).Find(&before).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading order book snapshots: %v", err)
+	}
+	err = s.symbolQuery(ctx, symbol).Where("recorded_at > ?", at).Order("recorded_at ASC").Limit(1).Find(&after).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading order book snapshots: %v", err)
+	}
+
+	switch {
+	case len(before) == 0 && len(after) == 0:
+		return nil, ErrSnapshotNotFound
+	case len(after) == 0:
+		return toSnapshot(before[0]), nil
+	case len(before) == 0:
+		return toSnapshot(after[0]), nil
+	case after[0].RecordedAt.Sub(at) < at.Sub(before[0].RecordedAt):
+		return toSnapshot(after[0]), nil
+	default:
+		return toSnapshot(before[0]), nil
+	}
+}
+
+// Range passes the snapshots of a symbol recorded in [start, end] to handle in
+// chronological order, reading them a page at a time
+func (s *GormStore) Range(ctx context.Context, symbol Symbol, start, end time.Time, limit int64, handle func([]*pb.OrderBookSnapshot) error) error {
+	if end.IsZero() {
+		end = time.Now()
+	}
+	end = end.UTC()
+
+	// The most recent snapshots are read newest first and handed over at once
+	if start.IsZero() {
+		query := s.symbolQuery(ctx, symbol).Where("recorded_at <= ?", end).Order("recorded_at DESC")
+		if limit > 0 {
+			query = query.Limit(int(limit))
+		}
+
+		var records []models.OrderBookSnapshot
+		if err := query.Find(&records).Error; err != nil {
+			return fmt.Errorf("error reading order book snapshots: %v", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		snapshots := make([]*pb.OrderBookSnapshot, len(records))
+		for i, record := range records {
+			snapshots[len(records)-1-i] = toSnapshot(record)
+		}
+		return handle(snapshots)
+	}
+
+	sent := int64(0)
+	lastID := uint(0)
+	cursor := start.UTC()
+	for limit <= 0 || sent < limit {
+		size := int64(storePageSize)
+		if limit > 0 && limit-sent < size {
+			size = limit - sent
+		}
+
+		// Snapshots recorded at the same time are told apart by their id
+		var records []models.OrderBookSnapshot
+		err := s.symbolQuery(ctx, symbol).
+			Where("(recorded_at > ? OR (recorded_at = ? AND id > ?)) AND recorded_at <= ?", cursor, cursor, lastID, end).
+			Order("recorded_at ASC, id ASC").
+			Limit(int(size)).
+			Find(&records).Error
+		if err != nil {
+			return fmt.Errorf("error reading order book snapshots: %v", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		snapshots := make([]*pb.OrderBookSnapshot, 0, len(records))
+		for _, record := range records {
+			snapshots = append(snapshots, toSnapshot(record))
+		}
+		if err := handle(snapshots); err != nil {
+			return err
+		}
+		sent += int64(len(records))
+
+		if int64(len(records)) < size {
+			return nil
+		}
+		last := records[len(records)-1]
+		cursor, lastID = last.RecordedAt, last.ID
+	}
+
+	return nil
+}
+
+// symbolQuery starts a query on the snapshots of a symbol
+func (s *GormStore) symbolQuery(ctx context.Context, symbol Symbol) *gorm.DB {
+	symbol = symbol.normalize()
+	return s.db.WithContext(ctx).Model(&models.OrderBookSnapshot{}).
+		Where("exchange = ? AND ticker = ? AND market = ?", symbol.Exchange, symbol.Ticker, string(symbol.Market))
+}
+
+// fromLevels converts price levels into their stored form
+func fromLevels(levels []*pb.OrderBookLevel) []models.OrderBookLevel {
+	stored := make([]models.OrderBookLevel, 0, len(levels))
+	for _, level := range levels {
+		stored = append(stored, models.OrderBookLevel{Price: level.Price, Quantity: level.Quantity})
+	}
+	return stored
+}
+
+// toSnapshot converts a stored snapshot back into its response form
+func toSnapshot(record models.OrderBookSnapshot) *pb.OrderBookSnapshot {
+	snapshot := &pb.OrderBookSnapshot{
+		Timestamp:    record.RecordedAt.UnixMilli(),
+		ExchangeTime: record.ExchangeTime,
+		UpdateId:     record.UpdateID,
+	}
+	for _, level := range record.Bids {
+		snapshot.Bids = append(snapshot.Bids, &pb.OrderBookLevel{Price: level.Price, Quantity: level.Quantity})
+	}
+	for _, level := range record.Asks {
+		snapshot.Asks = append(snapshot.Asks, &pb.OrderBookLevel{Price: level.Price, Quantity: level.Quantity})
+	}
+	return snapshot
+}
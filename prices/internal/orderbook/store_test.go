@@ -0,0 +1,126 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/exchanges"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestStore creates a store on an in-memory SQLite database private to the test
+func newTestStore(t *testing.T) *GormStore {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := NewGormStore(db)
+	require.NoError(t, store.Migrate())
+	return store
+}
+
+// snapshotAt returns a one-level snapshot recorded at a time
+func snapshotAt(at time.Time, updateID int64) *pb.OrderBookSnapshot {
+	return &pb.OrderBookSnapshot{
+		Timestamp: at.UnixMilli(),
+		UpdateId:  updateID,
+		Bids:      []*pb.OrderBookLevel{{Price: 16500, Quantity: 1.5}},
+		Asks:      []*pb.OrderBookLevel{{Price: 16500.5, Quantity: 0.25}},
+	}
+}
+
+// TestGormStore_Nearest tests finding the snapshot recorded closest to a time
+func TestGormStore_Nearest(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	symbol := Symbol{Exchange: "binance", Ticker: "BTCUSDT", Market: exchanges.MarketSpot}
+
+	_, err := store.Nearest(ctx, symbol, start)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Save(ctx, symbol, snapshotAt(start.Add(time.Duration(i)*time.Minute), int64(i))))
+	}
+	// Another market of the same ticker is kept apart
+	require.NoError(t, store.Save(ctx, Symbol{Exchange: "binance", Ticker: "BTCUSDT", Market: exchanges.MarketLinearPerp}, snapshotAt(start.Add(70*time.Second), 99)))
+
+	tests := []struct {
+		at       time.Time
+		updateID int64
+	}{
+		{start.Add(-time.Hour), 0},
+		{start.Add(20 * time.Second), 0},
+		{start.Add(30 * time.Second), 0}, // equally close, the earlier one wins
+		{start.Add(70 * time.Second), 1},
+		{start.Add(100 * time.Second), 2},
+		{start.Add(time.Hour), 2},
+	}
+	for _, tt := range tests {
+		snapshot, err := store.Nearest(ctx, symbol, tt.at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.updateID, snapshot.UpdateId, tt.at)
+	}
+
+	// Tickers are matched in any case and the market defaults to spot
+	snapshot, err := store.Nearest(ctx, Symbol{Exchange: "binance", Ticker: "btcusdt"}, start)
+	require.NoError(t, err)
+	assert.Equal(t, snapshotAt(start, 0), snapshot)
+}
+
+// TestGormStore_Range tests reading the snapshots of a range page by page
+func TestGormStore_Range(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	symbol := Symbol{Exchange: "bybit", Ticker: "BTCUSDT", Market: exchanges.MarketLinearPerp}
+
+	const count = 250
+	for i := 0; i < count; i++ {
+		require.NoError(t, store.Save(ctx, symbol, snapshotAt(start.Add(time.Duration(i)*time.Minute), int64(i))))
+	}
+
+	collect := func(start, end time.Time, limit int64) ([]int64, int) {
+		var ids []int64
+		pages := 0
+		err := store.Range(ctx, symbol, start, end, limit, func(snapshots []*pb.OrderBookSnapshot) error {
+			pages++
+			for _, snapshot := range snapshots {
+				ids = append(ids, snapshot.UpdateId)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return ids, pages
+	}
+
+	ids, pages := collect(start, start.Add(time.Hour*24), 0)
+	require.Len(t, ids, count)
+	assert.Equal(t, int64(0), ids[0])
+	assert.Equal(t, int64(count-1), ids[count-1])
+	assert.Equal(t, 3, pages)
+
+	ids, _ = collect(start.Add(10*time.Minute), start.Add(19*time.Minute), 0)
+	assert.Equal(t, []int64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, ids)
+
+	ids, _ = collect(start.Add(10*time.Minute), time.Time{}, 3)
+	assert.Equal(t, []int64{10, 11, 12}, ids)
+
+	// Without a start the most recent snapshots are returned
+	ids, pages = collect(time.Time{}, start.Add(100*time.Minute), 3)
+	assert.Equal(t, []int64{98, 99, 100}, ids)
+	assert.Equal(t, 1, pages)
+}
@@ -1,27 +1,55 @@
 package prices
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/timakaa/historical-common/database"
 	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/candles"
+	"github.com/timakaa/historical-prices/internal/clock"
 	"github.com/timakaa/historical-prices/internal/exchanges"
+	"github.com/timakaa/historical-prices/internal/indicators"
+	"github.com/timakaa/historical-prices/internal/ingest"
+	"github.com/timakaa/historical-prices/internal/live"
+	"github.com/timakaa/historical-prices/internal/orderbook"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 type Server struct {
 	pb.UnimplementedPricesServer
 	exchangeFactory *exchanges.ExchangeFactory
+
+	// snapshots serves recorded order books; nil when recording is not enabled
+	snapshots orderbook.Store
+
+	// candles keeps the closed candles fetched from exchanges; nil when caching is not enabled
+	candles candles.Store
+
+	// watchlist lists the series kept up to date by ingestion; nil when ingestion is not enabled
+	watchlist ingest.Watchlist
+
+	// live shares the upstream candle streams between subscribers
+	live *live.Hub
 }
 
 // NewServer creates a new server with the exchange factory
 func NewServer() *Server {
 	return &Server{
 		exchangeFactory: exchanges.NewExchangeFactory(),
+		live:            live.NewHub(),
 	}
 }
 
@@ -34,37 +62,1112 @@ func (s *Server) GetPrices(req *pb.PricesRequest, stream pb.Prices_GetPricesServ
 		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
 	}
 
-	// Use limit from request or default
+	query, err := priceQueryFromRequest(req)
+	if err != nil {
+		return err
+	}
+	if !s.exchangeFactory.SupportsPriceType(req.GetExchange(), query.PriceType) {
+		return status.Errorf(codes.InvalidArgument, "%s does not support %s prices", req.GetExchange(), query.PriceType)
+	}
+	offset, err := exchanges.ParseUTCOffset(req.GetUtcOffset())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Canonical BASE/QUOTE symbols are translated into the ticker of the exchange
+	query.Ticker, err = s.exchangeFactory.ResolveSymbol(stream.Context(), req.GetExchange(), query.Market, query.Ticker)
+	if err != nil {
+		return adapterError(req.GetExchange(), "symbols", err)
+	}
+
+	// The full history starts at the first candle the exchange has of the symbol
+	if req.GetFromListing() {
+		query.StartTime, err = s.exchangeFactory.ListingTime(stream.Context(), req.GetExchange(), exchanges.ListingQuery{
+			Ticker: query.Ticker,
+			Market: query.Market,
+		})
+		if err != nil {
+			return adapterError(req.GetExchange(), "listing time", err)
+		}
+	}
+
+	adapter = s.candleSource(adapter, req.GetExchange(), offset)
+
+	// Prices are converted into another quote asset through a spot rate of the exchange
+	if req.GetConvertTo() != "" {
+		conversion, err := s.exchangeFactory.ResolveConversion(stream.Context(), req.GetExchange(), query.Market, req.GetTicker(), req.GetConvertTo())
+		if err != nil {
+			return adapterError(req.GetExchange(), "conversion rate", err)
+		}
+		adapter = exchanges.NewConvertingAdapter(adapter, conversion)
+	}
+
+	// Stream pages to the client as the adapter fetches them
+	var sendErr error
+	err = adapter.GetHistoricalPrices(stream.Context(), query, func(prices []*pb.PricesResponse) error {
+		for _, price := range prices {
+			// Exact decimal strings are only sent to clients that ask for them
+			if !req.GetIncludeDecimals() {
+				price.Decimals = nil
+			}
+			if err := stream.Send(price); err != nil {
+				sendErr = fmt.Errorf("error sending price data: %v", err)
+				return sendErr
+			}
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return adapterError(req.GetExchange(), "prices", err)
+	}
+
+	return nil
+}
+
+// candleSource wraps the adapter of an exchange into the source candles are served from
+func (s *Server) candleSource(adapter exchanges.ExchangeAdapter, exchange string, offset time.Duration) exchanges.ExchangeAdapter {
+	// Stored candles are served first, fetching only what the store is missing.
+	// Composite candles are not stored, as the store keeps no venue breakdown.
+	if s.candles != nil && exchange != exchanges.CompositeExchange {
+		adapter = candles.NewCachedAdapter(adapter, s.candles)
+	}
+
+	// Intervals the exchange does not serve, or serves aligned differently, are
+	// built from the candles of a shorter interval it serves
+	return exchanges.NewResamplingAdapter(adapter, s.exchangeFactory.SupportedIntervals(exchange), offset)
+}
+
+// adapterError converts an adapter failure to retrieve data, such as prices, into a gRPC status error
+func adapterError(exchange, data string, err error) error {
+	switch {
+	case errors.Is(err, exchanges.ErrUnsupportedInterval), errors.Is(err, exchanges.ErrUnsupportedMarket),
+		errors.Is(err, exchanges.ErrUnsupportedPriceType), errors.Is(err, exchanges.ErrUnsupportedDerivativesStat),
+		errors.Is(err, exchanges.ErrUnsupportedStatPeriod), errors.Is(err, exchanges.ErrTradesStartRequired),
+		errors.Is(err, exchanges.ErrListingNotProvided), errors.Is(err, exchanges.ErrSymbolsNotProvided),
+		errors.Is(err, exchanges.ErrUnknownSymbol), errors.Is(err, exchanges.ErrAmbiguousSymbol),
+		errors.Is(err, exchanges.ErrNoConversionRate):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, exchanges.ErrListingNotFound), errors.Is(err, exchanges.ErrTradesNotArchived):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request cancelled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request deadline exceeded")
+	}
+
+	log.Printf("Error getting %s from %s: %v", data, exchange, err)
+	return status.Errorf(codes.Internal, "failed to get %s: %v", data, err)
+}
+
+// priceQueryFromRequest validates a prices request and converts it into an adapter query
+func priceQueryFromRequest(req *pb.PricesRequest) (exchanges.PriceQuery, error) {
+	// Validate the requested candle interval, which may be resampled
+	interval, err := exchanges.ParseCustomInterval(req.GetInterval())
+	if err != nil {
+		return exchanges.PriceQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Validate the requested market
+	market, err := exchanges.ParseMarket(req.GetMarket())
+	if err != nil {
+		return exchanges.PriceQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Validate the requested price type
+	priceType, err := exchanges.ParsePriceType(req.GetPriceType())
+	if err != nil {
+		return exchanges.PriceQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	query := exchanges.PriceQuery{
+		Ticker:    req.GetTicker(),
+		Market:    market,
+		Interval:  interval,
+		PriceType: priceType,
+		Limit:     req.GetLimit(),
+	}
+
+	query.StartTime, query.EndTime, err = timeRangeFromRequest(req.GetStartTime(), req.GetEndTime())
+	if err != nil {
+		return exchanges.PriceQuery{}, err
+	}
+	if req.GetFromListing() && req.GetStartTime() != 0 {
+		return exchanges.PriceQuery{}, status.Error(codes.InvalidArgument, "start_time cannot be combined with from_listing")
+	}
+
+	// Converted prices are computed in floating point, so there are no exact
+	// decimal strings to send
+	if req.GetIncludeDecimals() && req.GetConvertTo() != "" {
+		return exchanges.PriceQuery{}, status.Error(codes.InvalidArgument, "include_decimals cannot be combined with convert_to")
+	}
+
+	// Use limit from request or default; range queries are only bounded by the range
+	if query.StartTime.IsZero() && !req.GetFromListing() && query.Limit <= 0 {
+		query.Limit = 100 // Default limit
+	}
+
+	return query, nil
+}
+
+// timeRangeFromRequest validates an epoch milliseconds range; zero bounds stay open
+func timeRangeFromRequest(startTime, endTime int64) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if startTime < 0 || endTime < 0 {
+		return start, end, status.Error(codes.InvalidArgument, "start_time and end_time must not be negative")
+	}
+	if startTime > 0 {
+		start = time.UnixMilli(startTime)
+	}
+	if endTime > 0 {
+		end = time.UnixMilli(endTime)
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "end_time must not be before start_time")
+	}
+	return start, end, nil
+}
+
+// GetFundingRates streams the funding rate history of a perpetual contract
+func (s *Server) GetFundingRates(req *pb.FundingRatesRequest, stream pb.Prices_GetFundingRatesServer) error {
+	log.Printf("Received funding rates request for ticker: %s from exchange: %s", req.GetTicker(), req.GetExchange())
+
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	provider, ok := s.exchangeFactory.GetFundingRateProvider(req.GetExchange())
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "%s does not provide funding rates", req.GetExchange())
+	}
+
+	query, err := fundingRateQueryFromRequest(req)
+	if err != nil {
+		return err
+	}
+
+	// Stream pages to the client as the adapter fetches them
+	var sendErr error
+	err = provider.GetFundingRates(stream.Context(), query, func(rates []*pb.FundingRate) error {
+		for _, rate := range rates {
+			if err := stream.Send(rate); err != nil {
+				sendErr = fmt.Errorf("error sending funding rate: %v", err)
+				return sendErr
+			}
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return adapterError(req.GetExchange(), "funding rates", err)
+	}
+
+	return nil
+}
+
+// fundingRateQueryFromRequest validates a funding rates request and converts it into an adapter query
+func fundingRateQueryFromRequest(req *pb.FundingRatesRequest) (exchanges.FundingRateQuery, error) {
+	// Funding rates exist for perpetual contracts only, so the market defaults to linear ones
+	market := exchanges.DefaultFundingMarket
+	if req.GetMarket() != "" {
+		parsed, err := exchanges.ParseMarket(req.GetMarket())
+		if err != nil {
+			return exchanges.FundingRateQuery{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		market = parsed
+	}
+
+	query := exchanges.FundingRateQuery{
+		Ticker: req.GetTicker(),
+		Market: market,
+		Limit:  req.GetLimit(),
+	}
+
+	var err error
+	query.StartTime, query.EndTime, err = timeRangeFromRequest(req.GetStartTime(), req.GetEndTime())
+	if err != nil {
+		return exchanges.FundingRateQuery{}, err
+	}
+
+	// Use limit from request or default; range queries are only bounded by the range
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100 // Default limit
+	}
+
+	return query, nil
+}
+
+// GetDerivativesStats streams the open interest or long/short ratio history of a derivatives contract
+func (s *Server) GetDerivativesStats(req *pb.DerivativesStatsRequest, stream pb.Prices_GetDerivativesStatsServer) error {
+	log.Printf("Received %s request for ticker: %s from exchange: %s", req.GetStat(), req.GetTicker(), req.GetExchange())
+
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	provider, ok := s.exchangeFactory.GetDerivativesStatsProvider(req.GetExchange())
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "%s does not provide derivatives statistics", req.GetExchange())
+	}
+
+	query, err := derivativesStatsQueryFromRequest(req)
+	if err != nil {
+		return err
+	}
+
+	// Stream pages to the client as the adapter fetches them
+	var sendErr error
+	err = provider.GetDerivativesStats(stream.Context(), query, func(stats []*pb.DerivativesStat) error {
+		for _, stat := range stats {
+			if err := stream.Send(stat); err != nil {
+				sendErr = fmt.Errorf("error sending derivatives statistic: %v", err)
+				return sendErr
+			}
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return adapterError(req.GetExchange(), string(query.Stat), err)
+	}
+
+	return nil
+}
+
+// derivativesStatsQueryFromRequest validates a derivatives statistics request and converts it into an adapter query
+func derivativesStatsQueryFromRequest(req *pb.DerivativesStatsRequest) (exchanges.DerivativesStatsQuery, error) {
+	stat, err := exchanges.ParseDerivativesStat(req.GetStat())
+	if err != nil {
+		return exchanges.DerivativesStatsQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	period, err := exchanges.ParseStatPeriod(req.GetPeriod())
+	if err != nil {
+		return exchanges.DerivativesStatsQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Statistics exist for derivatives only, so the market defaults to linear perpetuals
+	market := exchanges.DefaultStatsMarket
+	if req.GetMarket() != "" {
+		market, err = exchanges.ParseMarket(req.GetMarket())
+		if err != nil {
+			return exchanges.DerivativesStatsQuery{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	query := exchanges.DerivativesStatsQuery{
+		Ticker: req.GetTicker(),
+		Market: market,
+		Stat:   stat,
+		Period: period,
+		Limit:  req.GetLimit(),
+	}
+
+	query.StartTime, query.EndTime, err = timeRangeFromRequest(req.GetStartTime(), req.GetEndTime())
+	if err != nil {
+		return exchanges.DerivativesStatsQuery{}, err
+	}
+
+	// Use limit from request or default; range queries are only bounded by the range
+	if query.StartTime.IsZero() && query.Limit <= 0 {
+		query.Limit = 100 // Default limit
+	}
+
+	return query, nil
+}
+
+// GetTrades streams the public trades of a symbol for a time window
+func (s *Server) GetTrades(req *pb.TradesRequest, stream pb.Prices_GetTradesServer) error {
+	log.Printf("Received trades request for ticker: %s from exchange: %s", req.GetTicker(), req.GetExchange())
+
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	provider, ok := s.exchangeFactory.GetTradeProvider(req.GetExchange())
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "%s does not provide trades", req.GetExchange())
+	}
+
+	query, err := tradeQueryFromRequest(req)
+	if err != nil {
+		return err
+	}
+
+	// Stream pages to the client as the adapter fetches them
+	var sendErr error
+	err = provider.GetTrades(stream.Context(), query, func(trades []*pb.Trade) error {
+		for _, trade := range trades {
+			if err := stream.Send(trade); err != nil {
+				sendErr = fmt.Errorf("error sending trade: %v", err)
+				return sendErr
+			}
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return adapterError(req.GetExchange(), "trades", err)
+	}
+
+	return nil
+}
+
+// tradeQueryFromRequest validates a trades request and converts it into an adapter query
+func tradeQueryFromRequest(req *pb.TradesRequest) (exchanges.TradeQuery, error) {
+	market, err := exchanges.ParseMarket(req.GetMarket())
+	if err != nil {
+		return exchanges.TradeQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.GetLimit() < 0 {
+		return exchanges.TradeQuery{}, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	query := exchanges.TradeQuery{
+		Ticker: req.GetTicker(),
+		Market: market,
+		Limit:  req.GetLimit(),
+	}
+
+	query.StartTime, query.EndTime, err = timeRangeFromRequest(req.GetStartTime(), req.GetEndTime())
+	if err != nil {
+		return exchanges.TradeQuery{}, err
+	}
+
+	// Trades are far too dense to return the most recent ones without a window
+	if query.StartTime.IsZero() {
+		return exchanges.TradeQuery{}, status.Error(codes.InvalidArgument, exchanges.ErrTradesStartRequired.Error())
+	}
+
+	return query, nil
+}
+
+// GetOrderBookSnapshots streams recorded order book snapshots: the one closest to
+// a time, or those of a time range
+func (s *Server) GetOrderBookSnapshots(req *pb.OrderBookSnapshotsRequest, stream pb.Prices_GetOrderBookSnapshotsServer) error {
+	log.Printf("Received order book snapshots request for ticker: %s from exchange: %s", req.GetTicker(), req.GetExchange())
+
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	if s.snapshots == nil {
+		return status.Error(codes.Unavailable, "order book recording is not enabled")
+	}
+
+	market, err := exchanges.ParseMarket(req.GetMarket())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.GetDepth() < 0 {
+		return status.Error(codes.InvalidArgument, "depth must not be negative")
+	}
+	symbol := orderbook.Symbol{Exchange: req.GetExchange(), Ticker: req.GetTicker(), Market: market}
+
+	send := func(snapshot *pb.OrderBookSnapshot) error {
+		if depth := int(req.GetDepth()); depth > 0 {
+			snapshot.Bids = snapshot.Bids[:min(depth, len(snapshot.Bids))]
+			snapshot.Asks = snapshot.Asks[:min(depth, len(snapshot.Asks))]
+		}
+		if err := stream.Send(snapshot); err != nil {
+			return fmt.Errorf("error sending order book snapshot: %v", err)
+		}
+		return nil
+	}
+
+	// A single snapshot closest to the requested time
+	if req.GetAt() != 0 {
+		if req.GetAt() < 0 {
+			return status.Error(codes.InvalidArgument, "at must not be negative")
+		}
+		if req.GetStartTime() != 0 || req.GetEndTime() != 0 {
+			return status.Error(codes.InvalidArgument, "at cannot be combined with start_time or end_time")
+		}
+
+		snapshot, err := s.snapshots.Nearest(stream.Context(), symbol, time.UnixMilli(req.GetAt()))
+		if errors.Is(err, orderbook.ErrSnapshotNotFound) {
+			return status.Errorf(codes.NotFound, "no order book of %s recorded on %s", req.GetTicker(), req.GetExchange())
+		}
+		if err != nil {
+			return adapterError(req.GetExchange(), "order book snapshots", err)
+		}
+		return send(snapshot)
+	}
+
+	start, end, err := timeRangeFromRequest(req.GetStartTime(), req.GetEndTime())
+	if err != nil {
+		return err
+	}
+
+	// Use limit from request or default; range queries are only bounded by the range
 	limit := req.GetLimit()
-	if limit <= 0 {
+	if start.IsZero() && limit <= 0 {
 		limit = 100 // Default limit
 	}
 
-	// Get historical data from the exchange
-	prices, err := adapter.GetHistoricalPrices(stream.Context(), req.GetTicker(), limit)
+	var sendErr error
+	err = s.snapshots.Range(stream.Context(), symbol, start, end, limit, func(snapshots []*pb.OrderBookSnapshot) error {
+		for _, snapshot := range snapshots {
+			if err := send(snapshot); err != nil {
+				sendErr = err
+				return sendErr
+			}
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
 	if err != nil {
-		log.Printf("Error getting prices from %s: %v", req.GetExchange(), err)
-		return status.Errorf(codes.Internal, "failed to get prices: %v", err)
+		return adapterError(req.GetExchange(), "order book snapshots", err)
 	}
 
-	// Send data to the stream
-	for _, price := range prices {
-		if err := stream.Send(price); err != nil {
-			return fmt.Errorf("error sending price data: %v", err)
+	return nil
+}
+
+// AddWatchlistEntry starts ingesting the newly closed candles of a series
+func (s *Server) AddWatchlistEntry(ctx context.Context, req *pb.WatchlistEntry) (*pb.WatchlistEntry, error) {
+	log.Printf("Received request to watch ticker: %s from exchange: %s", req.GetTicker(), req.GetExchange())
+
+	if s.watchlist == nil {
+		return nil, status.Error(codes.Unavailable, "candle ingestion is not enabled")
+	}
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	if req.GetTicker() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ticker is required")
+	}
+
+	interval, err := exchanges.ParseInterval(req.GetInterval())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !candles.Cacheable(interval) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s candles are not stored", interval)
+	}
+	// Ingestion fetches from the exchange directly, without resampling
+	if !slices.Contains(s.exchangeFactory.SupportedIntervals(req.GetExchange()), interval) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s does not serve %s candles", req.GetExchange(), interval)
+	}
+	market, err := exchanges.ParseMarket(req.GetMarket())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	priceType, err := exchanges.ParsePriceType(req.GetPriceType())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !s.exchangeFactory.SupportsPriceType(req.GetExchange(), priceType) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s does not support %s prices", req.GetExchange(), priceType)
+	}
+
+	entry, err := s.watchlist.Add(ctx, candles.Series{
+		Exchange:  req.GetExchange(),
+		Market:    market,
+		Ticker:    req.GetTicker(),
+		Interval:  interval,
+		PriceType: priceType,
+	})
+	if errors.Is(err, ingest.ErrEntryExists) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add watchlist entry: %v", err)
+	}
+
+	return watchlistEntryResponse(entry), nil
+}
+
+// RemoveWatchlistEntry stops ingesting a series
+func (s *Server) RemoveWatchlistEntry(ctx context.Context, req *pb.RemoveWatchlistEntryRequest) (*pb.RemoveWatchlistEntryResponse, error) {
+	if s.watchlist == nil {
+		return nil, status.Error(codes.Unavailable, "candle ingestion is not enabled")
+	}
+
+	err := s.watchlist.Remove(ctx, uint(req.GetId()))
+	if errors.Is(err, ingest.ErrEntryNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove watchlist entry: %v", err)
+	}
+
+	return &pb.RemoveWatchlistEntryResponse{}, nil
+}
+
+// ListWatchlist returns the series being ingested
+func (s *Server) ListWatchlist(ctx context.Context, req *pb.ListWatchlistRequest) (*pb.ListWatchlistResponse, error) {
+	if s.watchlist == nil {
+		return nil, status.Error(codes.Unavailable, "candle ingestion is not enabled")
+	}
+
+	entries, err := s.watchlist.List(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list watchlist: %v", err)
+	}
+
+	response := &pb.ListWatchlistResponse{}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, watchlistEntryResponse(entry))
+	}
+	return response, nil
+}
+
+// watchlistEntryResponse converts a watchlist entry into its response form
+func watchlistEntryResponse(entry ingest.Entry) *pb.WatchlistEntry {
+	return &pb.WatchlistEntry{
+		Id:            uint64(entry.ID),
+		Exchange:      entry.Exchange,
+		Ticker:        entry.Ticker,
+		Market:        string(entry.Market),
+		Interval:      string(entry.Interval),
+		PriceType:     string(entry.PriceType),
+		IngestedUntil: entry.IngestedUntil.UnixMilli(),
+		CreatedAt:     entry.CreatedAt.UnixMilli(),
+	}
+}
+
+// GetCoverage reports which candles of the stored series are stored, known to be
+// missing on the exchange or still to be repaired
+func (s *Server) GetCoverage(ctx context.Context, req *pb.CoverageRequest) (*pb.CoverageResponse, error) {
+	auditor, ok := s.candles.(candles.Auditor)
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "candle store is not enabled")
+	}
+
+	filter, err := coverageFilterFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := auditor.Series(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list stored series: %v", err)
+	}
+
+	response := &pb.CoverageResponse{}
+	for _, series := range stored {
+		if !filter.matches(series) {
+			continue
+		}
+
+		coverage, err := candles.Coverage(ctx, auditor, series)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get coverage: %v", err)
+		}
+		response.Series = append(response.Series, &pb.SeriesCoverage{
+			Exchange:    series.Exchange,
+			Ticker:      series.Ticker,
+			Market:      string(series.Market),
+			Interval:    string(series.Interval),
+			PriceType:   string(series.PriceType),
+			CandleCount: coverage.Candles,
+			Windows:     candleRanges(coverage.Windows),
+			Holes:       candleRanges(coverage.Holes),
+			Missing:     candleRanges(coverage.Missing),
+		})
+	}
+	return response, nil
+}
+
+// coverageFilter selects stored series; empty fields match every series
+type coverageFilter candles.Series
+
+// coverageFilterFromRequest validates the fields a coverage request filters on
+func coverageFilterFromRequest(req *pb.CoverageRequest) (coverageFilter, error) {
+	filter := coverageFilter{Exchange: req.GetExchange(), Ticker: strings.ToUpper(req.GetTicker())}
+	if req.GetMarket() != "" {
+		market, err := exchanges.ParseMarket(req.GetMarket())
+		if err != nil {
+			return coverageFilter{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Market = market
+	}
+	if req.GetInterval() != "" {
+		interval, err := exchanges.ParseInterval(req.GetInterval())
+		if err != nil {
+			return coverageFilter{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Interval = interval
+	}
+	if req.GetPriceType() != "" {
+		priceType, err := exchanges.ParsePriceType(req.GetPriceType())
+		if err != nil {
+			return coverageFilter{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.PriceType = priceType
+	}
+	return filter, nil
+}
+
+// matches reports whether a series has every field the filter sets
+func (f coverageFilter) matches(series candles.Series) bool {
+	return (f.Exchange == "" || f.Exchange == series.Exchange) &&
+		(f.Ticker == "" || f.Ticker == series.Ticker) &&
+		(f.Market == "" || f.Market == series.Market) &&
+		(f.Interval == "" || f.Interval == series.Interval) &&
+		(f.PriceType == "" || f.PriceType == series.PriceType)
+}
+
+// candleRanges converts candle ranges into their response form
+func candleRanges(ranges []candles.Range) []*pb.CandleRange {
+	converted := make([]*pb.CandleRange, 0, len(ranges))
+	for _, r := range ranges {
+		converted = append(converted, &pb.CandleRange{
+			StartTime:   r.Start.UnixMilli(),
+			EndTime:     r.End.UnixMilli(),
+			CandleCount: r.Count,
+		})
+	}
+	return converted
+}
+
+// ListExchanges describes every supported exchange
+func (s *Server) ListExchanges(ctx context.Context, req *pb.ListExchangesRequest) (*pb.ListExchangesResponse, error) {
+	response := &pb.ListExchangesResponse{}
+	for _, info := range s.exchangeFactory.Exchanges() {
+		exchange := &pb.ExchangeInfo{Name: info.Name, MaxPageSize: int32(info.MaxPageSize)}
+		for _, market := range info.Markets {
+			exchange.Markets = append(exchange.Markets, string(market))
+		}
+		for _, interval := range info.Intervals {
+			exchange.Intervals = append(exchange.Intervals, string(interval))
+		}
+		for _, priceType := range info.PriceTypes {
+			exchange.PriceTypes = append(exchange.PriceTypes, string(priceType))
+		}
+		response.Exchanges = append(response.Exchanges, exchange)
+	}
+	return response, nil
+}
+
+// ListSymbols lists the symbols of an exchange market
+func (s *Server) ListSymbols(ctx context.Context, req *pb.ListSymbolsRequest) (*pb.ListSymbolsResponse, error) {
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	if _, ok := s.exchangeFactory.GetSymbolProvider(req.GetExchange()); !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%s does not provide symbols", req.GetExchange())
+	}
+	market, err := exchanges.ParseMarket(req.GetMarket())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	symbols, err := s.exchangeFactory.Symbols(ctx, req.GetExchange(), market)
+	if err != nil {
+		return nil, adapterError(req.GetExchange(), "symbols", err)
+	}
+
+	response := &pb.ListSymbolsResponse{Symbols: make([]*pb.SymbolInfo, 0, len(symbols))}
+	for _, symbol := range symbols {
+		info := &pb.SymbolInfo{
+			Symbol:          symbol.Symbol,
+			Base:            symbol.Base,
+			Quote:           symbol.Quote,
+			Status:          string(symbol.Status),
+			TickSize:        symbol.TickSize,
+			CanonicalSymbol: symbol.CanonicalSymbol(),
+		}
+		if !symbol.ListingTime.IsZero() {
+			info.ListingTime = symbol.ListingTime.UnixMilli()
+		}
+		response.Symbols = append(response.Symbols, info)
+	}
+	return response, nil
+}
+
+// SubscribeCandles streams the live candles of a symbol, pushing the candle in
+// progress as it changes and every candle once more when it closes
+func (s *Server) SubscribeCandles(req *pb.SubscribeCandlesRequest, stream pb.Prices_SubscribeCandlesServer) error {
+	log.Printf("Received candle subscription for ticker: %s from exchange: %s", req.GetTicker(), req.GetExchange())
+
+	if _, exists := s.exchangeFactory.GetAdapter(req.GetExchange()); !exists {
+		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+	streamer, ok := s.exchangeFactory.GetCandleStreamer(req.GetExchange())
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "%s does not stream live candles", req.GetExchange())
+	}
+	if req.GetTicker() == "" {
+		return status.Error(codes.InvalidArgument, "ticker is required")
+	}
+
+	interval, err := exchanges.ParseInterval(req.GetInterval())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	market, err := exchanges.ParseMarket(req.GetMarket())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Canonical BASE/QUOTE symbols are translated into the ticker of the exchange
+	ticker, err := s.exchangeFactory.ResolveSymbol(stream.Context(), req.GetExchange(), market, req.GetTicker())
+	if err != nil {
+		return adapterError(req.GetExchange(), "symbols", err)
+	}
+
+	if s.live == nil {
+		return status.Error(codes.Unavailable, "live candles are not enabled")
+	}
+	subscription := s.live.Subscribe(live.Key{
+		Exchange: req.GetExchange(),
+		StreamQuery: exchanges.StreamQuery{
+			Ticker:   strings.ToUpper(ticker),
+			Market:   market,
+			Interval: interval,
+		},
+	}, streamer)
+	defer subscription.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case update, ok := <-subscription.Updates():
+			if !ok {
+				return subscriptionError(req.GetExchange(), subscription.Err())
+			}
+			if err := stream.Send(&pb.CandleUpdate{Candle: update.Candle, Closed: update.Closed}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscriptionError converts the end of a live candle subscription into a gRPC status error
+func subscriptionError(exchange string, err error) error {
+	if errors.Is(err, live.ErrSlowSubscriber) {
+		return status.Error(codes.ResourceExhausted, "candle updates were not received fast enough")
+	}
+	return adapterError(exchange, "live candles", err)
+}
+
+// maxIndicators bounds the number of indicators computed for a single request
+const maxIndicators = 20
+
+// GetIndicators streams technical indicators at the candles of a symbol. The
+// candles before the requested ones that the indicators need to settle are
+// fetched too, so that every streamed candle carries all indicator values.
+func (s *Server) GetIndicators(req *pb.IndicatorsRequest, stream pb.Prices_GetIndicatorsServer) error {
+	log.Printf("Received indicators request for ticker: %s from exchange: %s", req.GetTicker(), req.GetExchange())
+
+	adapter, exists := s.exchangeFactory.GetAdapter(req.GetExchange())
+	if !exists {
+		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.GetExchange())
+	}
+
+	set, err := indicatorSetFromRequest(req)
+	if err != nil {
+		return err
+	}
+	query, err := priceQueryFromRequest(&pb.PricesRequest{
+		Ticker:    req.GetTicker(),
+		Market:    req.GetMarket(),
+		Interval:  req.GetInterval(),
+		Limit:     req.GetLimit(),
+		StartTime: req.GetStartTime(),
+		EndTime:   req.GetEndTime(),
+	})
+	if err != nil {
+		return err
+	}
+	offset, err := exchanges.ParseUTCOffset(req.GetUtcOffset())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Canonical BASE/QUOTE symbols are translated into the ticker of the exchange
+	query.Ticker, err = s.exchangeFactory.ResolveSymbol(stream.Context(), req.GetExchange(), query.Market, query.Ticker)
+	if err != nil {
+		return adapterError(req.GetExchange(), "symbols", err)
+	}
+
+	adapter = s.candleSource(adapter, req.GetExchange(), offset)
+
+	// Warm-up candles are fetched ahead of the requested ones
+	start, limit := query.StartTime, query.Limit
+	warmUp := set.WarmUp()
+	if !start.IsZero() {
+		query.StartTime = exchanges.OpenTimeBefore(query.Interval, offset, start, warmUp)
+	}
+	if limit > 0 {
+		query.Limit += int64(warmUp)
+	}
+
+	var latest []*pb.IndicatorValues
+	var sent int64
+	var sendErr error
+	err = adapter.GetHistoricalPrices(stream.Context(), query, func(prices []*pb.PricesResponse) error {
+		for _, price := range prices {
+			values := &pb.IndicatorValues{
+				OpenTime:  price.GetOpenTime(),
+				CloseTime: price.GetCloseTime(),
+				Close:     price.GetClose(),
+				Values:    set.Update(price),
+			}
+
+			// Without a start time the latest candles are requested, which are only
+			// known once every candle is fetched
+			if start.IsZero() {
+				latest = append(latest, values)
+				continue
+			}
+			if price.GetOpenTime() < start.UnixMilli() || (limit > 0 && sent == limit) {
+				continue
+			}
+			if err := stream.Send(values); err != nil {
+				sendErr = fmt.Errorf("error sending indicator values: %v", err)
+				return sendErr
+			}
+			sent++
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return adapterError(req.GetExchange(), "prices", err)
+	}
+
+	for _, values := range latest[max(0, int64(len(latest))-limit):] {
+		if err := stream.Send(values); err != nil {
+			return fmt.Errorf("error sending indicator values: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// indicatorSetFromRequest validates the indicators of a request, dropping repeated ones
+func indicatorSetFromRequest(req *pb.IndicatorsRequest) (indicators.Set, error) {
+	specs := req.GetIndicators()
+	if len(specs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one indicator is required")
+	}
+	if len(specs) > maxIndicators {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d indicators can be requested at once", maxIndicators)
+	}
+
+	set := make(indicators.Set, 0, len(specs))
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		indicator, err := indicators.Parse(indicators.Spec{Name: spec.GetName(), Params: spec.GetParams()})
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if seen[indicator.Name()] {
+			continue
+		}
+		seen[indicator.Name()] = true
+		set = append(set, indicator)
+	}
+	return set, nil
+}
+
+// configureComposite replaces the composite index with one over the constituents
+// listed as exchange[:weight], leaving out venues whose close strays further than
+// maxDeviation from the median; empty values keep the defaults
+func (s *Server) configureComposite(constituentsValue, maxDeviationValue string) error {
+	constituents := exchanges.DefaultConstituents()
+	if constituentsValue != "" {
+		var err error
+		constituents, err = exchanges.ParseConstituents(constituentsValue)
+		if err != nil {
+			return err
+		}
+	}
+	for _, constituent := range constituents {
+		if _, exists := s.exchangeFactory.GetAdapter(constituent.Exchange); !exists {
+			return fmt.Errorf("unsupported exchange: %s", constituent.Exchange)
+		}
+	}
+
+	maxDeviation := exchanges.DefaultMaxDeviation
+	if maxDeviationValue != "" {
+		var err error
+		maxDeviation, err = strconv.ParseFloat(maxDeviationValue, 64)
+		if err != nil || maxDeviation <= 0 {
+			return fmt.Errorf("invalid COMPOSITE_MAX_DEVIATION %q: must be a positive fraction such as 0.05", maxDeviationValue)
+		}
+	}
+
+	s.exchangeFactory.RegisterAdapter(exchanges.NewCompositeAdapter(s.exchangeFactory, constituents, maxDeviation))
+	return nil
+}
+
+// startOrderBookRecorder records the order books of the symbols listed in
+// ORDERBOOK_TARGETS into the database, every ORDERBOOK_INTERVAL
+func (s *Server) startOrderBookRecorder(targetsValue string) error {
+	targets, err := orderbook.ParseTargets(targetsValue)
+	if err != nil {
+		return err
+	}
+
+	interval := orderbook.DefaultInterval
+	if value := os.Getenv("ORDERBOOK_INTERVAL"); value != "" {
+		interval, err = time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid ORDERBOOK_INTERVAL: %v", err)
+		}
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	store := orderbook.NewGormStore(db)
+	if err := store.Migrate(); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	recorder, err := orderbook.NewRecorder(s.exchangeFactory, store, clock.System{}, interval, targets)
+	if err != nil {
+		return err
+	}
+	s.snapshots = store
+	go recorder.Run(context.Background())
+
+	return nil
+}
+
+// startCandleStore keeps the closed candles fetched from exchanges in the database,
+// repairing the stored series every CANDLE_REPAIR_INTERVAL
+func (s *Server) startCandleStore() error {
+	interval := candles.DefaultRepairInterval
+	if value := os.Getenv("CANDLE_REPAIR_INTERVAL"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid CANDLE_REPAIR_INTERVAL: %v", err)
+		}
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	store := candles.NewGormStore(db)
+	if err := store.Migrate(); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	repairer, err := candles.NewRepairer(s.exchangeFactory, store, clock.System{}, interval)
+	if err != nil {
+		return err
+	}
+	s.candles = store
+	go repairer.Run(context.Background())
+
+	return nil
+}
+
+// startIngestion keeps the series of the watchlist up to date in the candle store,
+// tuned by INGEST_DELAY, INGEST_JITTER and INGEST_CONCURRENCY
+func (s *Server) startIngestion() error {
+	var err error
+	options := ingest.Options{Delay: ingest.DefaultDelay, Jitter: ingest.DefaultJitter}
+	if value := os.Getenv("INGEST_DELAY"); value != "" {
+		if options.Delay, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid INGEST_DELAY: %v", err)
+		}
+	}
+	if value := os.Getenv("INGEST_JITTER"); value != "" {
+		if options.Jitter, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid INGEST_JITTER: %v", err)
+		}
+	}
+	if value := os.Getenv("INGEST_CONCURRENCY"); value != "" {
+		if err := ingest.ParseConcurrency(value, &options); err != nil {
+			return fmt.Errorf("invalid INGEST_CONCURRENCY: %v", err)
 		}
 	}
 
+	if s.candles == nil {
+		if err := s.startCandleStore(); err != nil {
+			return err
+		}
+	}
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	watchlist := ingest.NewGormWatchlist(db)
+	if err := watchlist.Migrate(); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	scheduler := ingest.NewScheduler(s.exchangeFactory, watchlist, s.candles, clock.System{}, options)
+	s.watchlist = watchlist
+	go scheduler.Run(context.Background())
+
 	return nil
 }
 
+// openDatabase returns the database connection, connecting on first use
+func openDatabase() (*gorm.DB, error) {
+	if db := database.GetDB(); db != nil {
+		return db, nil
+	}
+	return database.InitDatabase()
+}
+
 func Start(port int) error {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 
+	server := NewServer()
+
+	// Order book snapshots are recorded and served when symbols to record are configured
+	if targets := os.Getenv("ORDERBOOK_TARGETS"); targets != "" {
+		if err := server.startOrderBookRecorder(targets); err != nil {
+			return fmt.Errorf("failed to start order book recorder: %v", err)
+		}
+	}
+
+	// The composite index merges COMPOSITE_CONSTITUENTS when configured
+	constituents, maxDeviation := os.Getenv("COMPOSITE_CONSTITUENTS"), os.Getenv("COMPOSITE_MAX_DEVIATION")
+	if constituents != "" || maxDeviation != "" {
+		if err := server.configureComposite(constituents, maxDeviation); err != nil {
+			return fmt.Errorf("failed to configure composite index: %v", err)
+		}
+	}
+
+	// Candles are read through the database when CANDLE_CACHE is enabled
+	if value := os.Getenv("CANDLE_CACHE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid CANDLE_CACHE: %v", err)
+		}
+		if enabled {
+			if err := server.startCandleStore(); err != nil {
+				return fmt.Errorf("failed to start candle store: %v", err)
+			}
+		}
+	}
+
+	// Watched series are ingested when INGEST_ENABLED is set, which also enables the candle store
+	if value := os.Getenv("INGEST_ENABLED"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid INGEST_ENABLED: %v", err)
+		}
+		if enabled {
+			if err := server.startIngestion(); err != nil {
+				return fmt.Errorf("failed to start candle ingestion: %v", err)
+			}
+		}
+	}
+
 	s := grpc.NewServer()
-	pb.RegisterPricesServer(s, NewServer())
+	pb.RegisterPricesServer(s, server)
 
 	log.Printf("Server listening on port %d", port)
 	if err := s.Serve(lis); err != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,12 +14,19 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	pb "github.com/timakaa/historical-common/proto"
+	"github.com/timakaa/historical-prices/internal/candles"
 	"github.com/timakaa/historical-prices/internal/exchanges"
+	"github.com/timakaa/historical-prices/internal/ingest"
+	"github.com/timakaa/historical-prices/internal/live"
+	"github.com/timakaa/historical-prices/internal/orderbook"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 const bufSize = 1024 * 1024
@@ -33,12 +41,14 @@ func (m *MockExchangeAdapter) GetName() string {
 	return args.String(0)
 }
 
-func (m *MockExchangeAdapter) GetHistoricalPrices(ctx context.Context, ticker string, limit int64) ([]*pb.PricesResponse, error) {
-	args := m.Called(ctx, ticker, limit)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+func (m *MockExchangeAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	args := m.Called(ctx, query)
+	if prices, ok := args.Get(0).([]*pb.PricesResponse); ok && prices != nil {
+		if err := handle(prices); err != nil {
+			return err
+		}
 	}
-	return args.Get(0).([]*pb.PricesResponse), args.Error(1)
+	return args.Error(1)
 }
 
 // MockExchangeFactory is a mock implementation of the exchange factory
@@ -127,23 +137,32 @@ func (s *TestServer) GetPrices(req *pb.PricesRequest, stream pb.Prices_GetPrices
 		return status.Errorf(codes.InvalidArgument, "unsupported exchange: %s", req.Exchange)
 	}
 
-	// Set default limit if not provided
-	limit := req.Limit
-	if limit == 0 {
-		limit = 100
-	}
-
-	// Get historical prices
-	prices, err := adapter.GetHistoricalPrices(stream.Context(), req.Ticker, limit)
+	// Validate the request
+	query, err := priceQueryFromRequest(req)
 	if err != nil {
-		return status.Errorf(codes.Internal, "failed to get prices: %v", err)
+		return err
 	}
 
-	// Send prices to the client
-	for _, price := range prices {
-		if err := stream.Send(price); err != nil {
-			return fmt.Errorf("error sending price data: %w", err)
+	// Stream prices to the client page by page
+	var sendErr error
+	err = adapter.GetHistoricalPrices(stream.Context(), query, func(prices []*pb.PricesResponse) error {
+		for _, price := range prices {
+			// Exact decimal strings are only sent to clients that ask for them
+			if !req.GetIncludeDecimals() {
+				price.Decimals = nil
+			}
+			if err := stream.Send(price); err != nil {
+				sendErr = fmt.Errorf("error sending price data: %w", err)
+				return sendErr
+			}
 		}
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return adapterError(req.Exchange, "prices", err)
 	}
 
 	return nil
@@ -192,7 +211,7 @@ func TestGetPrices(t *testing.T) {
 		}
 
 		// Setup expectations
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, limit).Return(prices, nil)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return(prices, nil)
 		mockFactory.On("GetAdapter", exchange).Return(mockAdapter, true)
 
 		// Setup stream expectations
@@ -266,7 +285,7 @@ func TestGetPrices(t *testing.T) {
 		expectedError := errors.New("API error")
 
 		// Setup expectations
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, limit).Return(nil, expectedError)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return(nil, expectedError)
 		mockFactory.On("GetAdapter", exchange).Return(mockAdapter, true)
 
 		// Create test server with mock factory
@@ -325,7 +344,7 @@ func TestGetPrices(t *testing.T) {
 		}
 
 		// Setup expectations
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, limit).Return(prices, nil)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return(prices, nil)
 		mockFactory.On("GetAdapter", exchange).Return(mockAdapter, true)
 
 		// Setup stream to return error on first Send
@@ -366,7 +385,7 @@ func TestGetPrices(t *testing.T) {
 		prices := []*pb.PricesResponse{}
 
 		// Setup expectations
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, defaultLimit).Return(prices, nil)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: defaultLimit}).Return(prices, nil)
 		mockFactory.On("GetAdapter", exchange).Return(mockAdapter, true)
 
 		// Create test server with mock factory
@@ -514,7 +533,7 @@ func TestDirectServerGetPrices(t *testing.T) {
 
 		// Setup mock adapter
 		mockAdapter.On("GetName").Return(exchange)
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, limit).Return(prices, nil)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return(prices, nil)
 
 		// Register our mock adapter with the factory
 		mockExchangeFactory.RegisterAdapter(mockAdapter)
@@ -593,7 +612,7 @@ func TestDirectServerGetPrices(t *testing.T) {
 
 		// Setup mock adapter
 		mockAdapter.On("GetName").Return(exchange)
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, limit).Return(nil, expectedError)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return(nil, expectedError)
 
 		// Register our mock adapter with the factory
 		mockExchangeFactory.RegisterAdapter(mockAdapter)
@@ -651,7 +670,7 @@ func TestDirectServerGetPrices(t *testing.T) {
 
 		// Setup mock adapter
 		mockAdapter.On("GetName").Return(exchange)
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, limit).Return(prices, nil)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return(prices, nil)
 
 		// Register our mock adapter with the factory
 		mockExchangeFactory.RegisterAdapter(mockAdapter)
@@ -699,7 +718,7 @@ func TestDirectServerGetPrices(t *testing.T) {
 
 		// Setup mock adapter
 		mockAdapter.On("GetName").Return(exchange)
-		mockAdapter.On("GetHistoricalPrices", mock.Anything, ticker, defaultLimit).Return(prices, nil)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: defaultLimit}).Return(prices, nil)
 
 		// Register our mock adapter with the factory
 		mockExchangeFactory.RegisterAdapter(mockAdapter)
@@ -718,4 +737,1673 @@ func TestDirectServerGetPrices(t *testing.T) {
 		assert.NoError(t, err)
 		mockAdapter.AssertExpectations(t)
 	})
+
+	t.Run("requested interval", func(t *testing.T) {
+		// Create mock objects
+		mockAdapter := new(MockExchangeAdapter)
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Setup test data
+		exchange := "binance"
+		ticker := "BTC/USDT"
+		limit := int64(10)
+
+		// Create a real server
+		server := NewServer()
+
+		// Create a properly initialized exchange factory
+		mockExchangeFactory := exchanges.NewExchangeFactory()
+
+		// Setup mock adapter to expect the hourly interval
+		mockAdapter.On("GetName").Return(exchange)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1h, Limit: limit}).Return([]*pb.PricesResponse{}, nil)
+
+		// Register our mock adapter with the factory
+		mockExchangeFactory.RegisterAdapter(mockAdapter)
+
+		// Replace the server's exchange factory
+		server.exchangeFactory = mockExchangeFactory
+
+		// Call the method being tested with an hourly interval
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange: exchange,
+			Ticker:   ticker,
+			Limit:    limit,
+			Interval: "1h",
+		}, mockStream)
+
+		// Verify results
+		assert.NoError(t, err)
+		mockAdapter.AssertExpectations(t)
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		// Create mock objects
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Create a real server
+		server := NewServer()
+
+		// Call the method being tested with an unknown interval
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange: "binance",
+			Ticker:   "BTCUSDT",
+			Limit:    10,
+			Interval: "3x",
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Contains(t, statusErr.Message(), "unsupported interval")
+	})
+
+	t.Run("interval rejected by adapter", func(t *testing.T) {
+		// Create mock objects
+		mockAdapter := new(MockExchangeAdapter)
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Setup test data
+		exchange := "binance"
+		ticker := "BTC/USDT"
+		limit := int64(10)
+		expectedError := fmt.Errorf("%w: binance does not support 1M", exchanges.ErrUnsupportedInterval)
+
+		// Create a real server
+		server := NewServer()
+
+		// Create a properly initialized exchange factory
+		mockExchangeFactory := exchanges.NewExchangeFactory()
+
+		// Setup mock adapter to reject the interval
+		mockAdapter.On("GetName").Return(exchange)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketSpot, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1M, Limit: limit}).Return(nil, expectedError)
+
+		// Register our mock adapter with the factory
+		mockExchangeFactory.RegisterAdapter(mockAdapter)
+
+		// Replace the server's exchange factory
+		server.exchangeFactory = mockExchangeFactory
+
+		// Call the method being tested
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange: exchange,
+			Ticker:   ticker,
+			Limit:    limit,
+			Interval: "1M",
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		mockAdapter.AssertExpectations(t)
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		// Create mock objects
+		mockAdapter := new(MockExchangeAdapter)
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Setup test data
+		exchange := "binance"
+		ticker := "BTCUSDT"
+		start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)
+
+		// Create a real server
+		server := NewServer()
+
+		// Create a properly initialized exchange factory
+		mockExchangeFactory := exchanges.NewExchangeFactory()
+
+		// Range queries are not capped by the default limit
+		mockAdapter.On("GetName").Return(exchange)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{
+			Ticker:    ticker,
+			Market:    exchanges.MarketSpot,
+			PriceType: exchanges.PriceTypeLast,
+			Interval:  exchanges.Interval1d,
+			StartTime: time.UnixMilli(start.UnixMilli()),
+			EndTime:   time.UnixMilli(end.UnixMilli()),
+		}).Return([]*pb.PricesResponse{}, nil)
+
+		// Register our mock adapter with the factory
+		mockExchangeFactory.RegisterAdapter(mockAdapter)
+
+		// Replace the server's exchange factory
+		server.exchangeFactory = mockExchangeFactory
+
+		// Call the method being tested with a time range
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange:  exchange,
+			Ticker:    ticker,
+			StartTime: start.UnixMilli(),
+			EndTime:   end.UnixMilli(),
+		}, mockStream)
+
+		// Verify results
+		assert.NoError(t, err)
+		mockAdapter.AssertExpectations(t)
+	})
+
+	t.Run("end before start", func(t *testing.T) {
+		// Create mock objects
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Create a real server
+		server := NewServer()
+
+		// Call the method being tested with an inverted range
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange:  "binance",
+			Ticker:    "BTCUSDT",
+			StartTime: 1688083200000,
+			EndTime:   1546300800000,
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+
+	t.Run("requested market", func(t *testing.T) {
+		// Create mock objects
+		mockAdapter := new(MockExchangeAdapter)
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Setup test data
+		exchange := "binance"
+		ticker := "BTCUSDT"
+		limit := int64(10)
+
+		// Create a real server
+		server := NewServer()
+
+		// Create a properly initialized exchange factory
+		mockExchangeFactory := exchanges.NewExchangeFactory()
+
+		// Setup mock adapter to expect the perpetual market
+		mockAdapter.On("GetName").Return(exchange)
+		mockAdapter.On("GetHistoricalPrices", mock.Anything, exchanges.PriceQuery{Ticker: ticker, Market: exchanges.MarketLinearPerp, PriceType: exchanges.PriceTypeLast, Interval: exchanges.Interval1d, Limit: limit}).Return([]*pb.PricesResponse{}, nil)
+
+		// Register our mock adapter with the factory
+		mockExchangeFactory.RegisterAdapter(mockAdapter)
+
+		// Replace the server's exchange factory
+		server.exchangeFactory = mockExchangeFactory
+
+		// Call the method being tested with a derivatives market
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange: exchange,
+			Ticker:   ticker,
+			Limit:    limit,
+			Market:   "linear_perp",
+		}, mockStream)
+
+		// Verify results
+		assert.NoError(t, err)
+		mockAdapter.AssertExpectations(t)
+	})
+
+	t.Run("invalid market", func(t *testing.T) {
+		// Create mock objects
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Create a real server
+		server := NewServer()
+
+		// Call the method being tested with an unknown market
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange: "binance",
+			Ticker:   "BTCUSDT",
+			Market:   "options",
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Contains(t, statusErr.Message(), "unsupported market")
+	})
+
+	t.Run("invalid price type", func(t *testing.T) {
+		// Create mock objects
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Create a real server
+		server := NewServer()
+
+		// Call the method being tested with an unknown price type
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange:  "binance",
+			Ticker:    "BTCUSDT",
+			PriceType: "funding",
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Contains(t, statusErr.Message(), "unsupported price type")
+	})
+
+	t.Run("price type not served by exchange", func(t *testing.T) {
+		// Create mock objects
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Create a real server
+		server := NewServer()
+
+		// Call the method being tested with a price type Coinbase does not serve
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange:  "coinbase",
+			Ticker:    "BTCUSD",
+			PriceType: "mark",
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Equal(t, "coinbase does not support mark prices", statusErr.Message())
+	})
+
+	t.Run("market rejected by adapter", func(t *testing.T) {
+		// Create mock objects
+		mockStream := &MockPricesServer_GetPricesServer{
+			ctx: context.Background(),
+		}
+
+		// Create a real server
+		server := NewServer()
+
+		// Call the method being tested with a market Coinbase does not list
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange: "coinbase",
+			Ticker:   "BTCUSD",
+			Market:   "inverse_perp",
+		}, mockStream)
+
+		// Verify results
+		assert.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+}
+
+// pagedAdapter is an exchange adapter that serves fixed pages and records how many it fetched
+type pagedAdapter struct {
+	pages   [][]*pb.PricesResponse
+	fetched int
+}
+
+func (a *pagedAdapter) GetName() string {
+	return "paged"
+}
+
+func (a *pagedAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	for _, page := range a.pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		a.fetched++
+		if err := handle(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordingStream is a GetPrices stream that runs a callback for every sent candle
+type recordingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	onSend func(*pb.PricesResponse) error
+}
+
+func (s *recordingStream) Send(response *pb.PricesResponse) error {
+	return s.onSend(response)
+}
+
+func (s *recordingStream) Context() context.Context {
+	return s.ctx
+}
+
+// TestGetPricesStreaming tests that pages reach the client while later pages are still being fetched
+func TestGetPricesStreaming(t *testing.T) {
+	newPagedServer := func(adapter *pagedAdapter) *Server {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+		return &Server{exchangeFactory: factory}
+	}
+
+	pages := [][]*pb.PricesResponse{
+		{{OpenTime: 1000}, {OpenTime: 2000}},
+		{{OpenTime: 3000}, {OpenTime: 4000}},
+		{{OpenTime: 5000}},
+	}
+
+	t.Run("pages are sent as they are fetched", func(t *testing.T) {
+		adapter := &pagedAdapter{pages: pages}
+		server := newPagedServer(adapter)
+
+		var sent []int64
+		var fetchedAtSend []int
+		stream := &recordingStream{
+			ctx: context.Background(),
+			onSend: func(response *pb.PricesResponse) error {
+				sent = append(sent, response.OpenTime)
+				fetchedAtSend = append(fetchedAtSend, adapter.fetched)
+				return nil
+			},
+		}
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT"}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1000, 2000, 3000, 4000, 5000}, sent)
+		assert.Equal(t, []int{1, 1, 2, 2, 3}, fetchedAtSend)
+	})
+
+	t.Run("client cancellation stops fetching", func(t *testing.T) {
+		adapter := &pagedAdapter{pages: pages}
+		server := newPagedServer(adapter)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream := &recordingStream{
+			ctx: ctx,
+			onSend: func(response *pb.PricesResponse) error {
+				// The client goes away after the first candle
+				cancel()
+				return nil
+			},
+		}
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT"}, stream)
+
+		require.Error(t, err)
+		statusErr, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.Canceled, statusErr.Code())
+		assert.Equal(t, 1, adapter.fetched)
+	})
+
+	t.Run("send failure stops fetching", func(t *testing.T) {
+		adapter := &pagedAdapter{pages: pages}
+		server := newPagedServer(adapter)
+
+		stream := &recordingStream{
+			ctx: context.Background(),
+			onSend: func(response *pb.PricesResponse) error {
+				return errors.New("transport closed")
+			},
+		}
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT"}, stream)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error sending price data")
+		assert.Equal(t, 1, adapter.fetched)
+	})
+}
+
+// TestGetPricesDecimals tests that exact decimal strings are only sent when requested
+func TestGetPricesDecimals(t *testing.T) {
+	newPrices := func() [][]*pb.PricesResponse {
+		return [][]*pb.PricesResponse{{
+			{OpenTime: 1000, Close: 0.00000125, Decimals: &pb.DecimalValues{Close: "0.00000125"}},
+		}}
+	}
+
+	for _, includeDecimals := range []bool{false, true} {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(&pagedAdapter{pages: newPrices()})
+		server := &Server{exchangeFactory: factory}
+
+		var sent []*pb.PricesResponse
+		stream := &recordingStream{
+			ctx: context.Background(),
+			onSend: func(response *pb.PricesResponse) error {
+				sent = append(sent, response)
+				return nil
+			},
+		}
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "SHIBUSDT", IncludeDecimals: includeDecimals}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+		if includeDecimals {
+			assert.Equal(t, "0.00000125", sent[0].GetDecimals().GetClose())
+		} else {
+			assert.Nil(t, sent[0].GetDecimals())
+		}
+	}
+}
+
+// TestGetPricesCandleStore tests that candles are read through the candle store,
+// keeping their decimals for later clients that ask for them
+func TestGetPricesCandleStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:TestGetPricesCandleStore?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := candles.NewGormStore(db)
+	require.NoError(t, store.Migrate())
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	adapter := &pagedAdapter{pages: [][]*pb.PricesResponse{{{
+		OpenTime:      start.UnixMilli(),
+		CloseTime:     start.Add(24*time.Hour - time.Millisecond).UnixMilli(),
+		Close:         0.00000125,
+		SchemaVersion: exchanges.CandleSchemaVersion,
+		Decimals:      &pb.DecimalValues{Close: "0.00000125"},
+	}}}}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	server := &Server{exchangeFactory: factory, candles: store}
+
+	for _, includeDecimals := range []bool{false, true} {
+		var sent []*pb.PricesResponse
+		stream := &recordingStream{
+			ctx: context.Background(),
+			onSend: func(response *pb.PricesResponse) error {
+				sent = append(sent, response)
+				return nil
+			},
+		}
+
+		err := server.GetPrices(&pb.PricesRequest{
+			Exchange:        "paged",
+			Ticker:          "SHIBUSDT",
+			StartTime:       start.UnixMilli(),
+			EndTime:         start.UnixMilli(),
+			IncludeDecimals: includeDecimals,
+		}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+		assert.Equal(t, 0.00000125, sent[0].Close)
+		if includeDecimals {
+			assert.Equal(t, "0.00000125", sent[0].GetDecimals().GetClose())
+		}
+	}
+	assert.Equal(t, 1, adapter.fetched)
+}
+
+// listingAdapter is a paged adapter that also knows when symbols were listed,
+// recording the price query it receives
+type listingAdapter struct {
+	pagedAdapter
+	listed time.Time
+	query  exchanges.PriceQuery
+}
+
+func (a *listingAdapter) GetHistoricalPrices(ctx context.Context, query exchanges.PriceQuery, handle exchanges.PageHandler) error {
+	a.query = query
+	return a.pagedAdapter.GetHistoricalPrices(ctx, query, handle)
+}
+
+func (a *listingAdapter) GetListingTime(ctx context.Context, query exchanges.ListingQuery) (time.Time, error) {
+	if a.listed.IsZero() {
+		return time.Time{}, exchanges.ErrListingNotFound
+	}
+	return a.listed, nil
+}
+
+// TestGetPricesFromListing tests that full history requests start at the listing time
+func TestGetPricesFromListing(t *testing.T) {
+	listed := time.Date(2017, 8, 17, 0, 0, 0, 0, time.UTC)
+	newListingServer := func(adapter *listingAdapter) *Server {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+		return &Server{exchangeFactory: factory}
+	}
+	stream := &recordingStream{
+		ctx:    context.Background(),
+		onSend: func(response *pb.PricesResponse) error { return nil },
+	}
+
+	t.Run("the range starts at the listing time", func(t *testing.T) {
+		adapter := &listingAdapter{listed: listed}
+		server := newListingServer(adapter)
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT", Interval: "1d", FromListing: true}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, listed, adapter.query.StartTime)
+		assert.Zero(t, adapter.query.Limit)
+	})
+
+	t.Run("start time cannot be combined with the listing", func(t *testing.T) {
+		server := newListingServer(&listingAdapter{listed: listed})
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT", StartTime: listed.UnixMilli(), FromListing: true}, stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("unknown symbols are not found", func(t *testing.T) {
+		server := newListingServer(&listingAdapter{})
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "NOPEUSDT", FromListing: true}, stream)
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("exchanges without listing times are rejected", func(t *testing.T) {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(&pagedAdapter{})
+		server := &Server{exchangeFactory: factory}
+
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT", FromListing: true}, stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// TestGetPricesResampled tests that intervals the exchange does not serve are
+// built from a shorter one
+func TestGetPricesResampled(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var hourly []*pb.PricesResponse
+	for i := 0; i < 4; i++ {
+		open := start.Add(time.Duration(i) * time.Hour)
+		hourly = append(hourly, &pb.PricesResponse{OpenTime: open.UnixMilli(), Open: float64(i), High: float64(i), Low: float64(i), Close: float64(i), Volume: 1})
+	}
+	adapter := &listingAdapter{pagedAdapter: pagedAdapter{pages: [][]*pb.PricesResponse{hourly}}}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	server := &Server{exchangeFactory: factory}
+
+	var sent []*pb.PricesResponse
+	stream := &recordingStream{
+		ctx:    context.Background(),
+		onSend: func(response *pb.PricesResponse) error { sent = append(sent, response); return nil },
+	}
+
+	err := server.GetPrices(&pb.PricesRequest{
+		Exchange:  "paged",
+		Ticker:    "BTCUSDT",
+		Interval:  "2h",
+		StartTime: start.UnixMilli(),
+		EndTime:   start.Add(3 * time.Hour).UnixMilli(),
+	}, stream)
+
+	require.NoError(t, err)
+	assert.Equal(t, exchanges.Interval1h, adapter.query.Interval)
+	require.Len(t, sent, 2)
+	assert.Equal(t, 0.0, sent[0].Open)
+	assert.Equal(t, 1.0, sent[0].Close)
+	assert.Equal(t, 2.0, sent[0].Volume)
+	assert.Equal(t, start.Add(2*time.Hour).UnixMilli(), sent[1].OpenTime)
+
+	t.Run("candles aligned to an offset", func(t *testing.T) {
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT", Interval: "1d", UtcOffset: "+02:00", StartTime: start.UnixMilli()}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, exchanges.Interval1h, adapter.query.Interval)
+		assert.Equal(t, start.Add(22*time.Hour), adapter.query.StartTime.UTC())
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTCUSDT", UtcOffset: "+25:00"}, stream)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// indicatorsStream records the indicator values sent by the server
+type indicatorsStream struct {
+	grpc.ServerStream
+	sent []*pb.IndicatorValues
+}
+
+func (s *indicatorsStream) Send(values *pb.IndicatorValues) error {
+	s.sent = append(s.sent, values)
+	return nil
+}
+
+func (s *indicatorsStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestGetIndicators tests streaming indicators with their warm-up candles fetched ahead
+func TestGetIndicators(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var hourly []*pb.PricesResponse
+	for i := 0; i < 30; i++ {
+		open := start.Add(time.Duration(i) * time.Hour)
+		hourly = append(hourly, &pb.PricesResponse{OpenTime: open.UnixMilli(), Open: float64(i), High: float64(i), Low: float64(i), Close: float64(i), Volume: 1})
+	}
+	adapter := &listingAdapter{pagedAdapter: pagedAdapter{pages: [][]*pb.PricesResponse{hourly}}}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	server := &Server{exchangeFactory: factory}
+	sma := []*pb.IndicatorSpec{{Name: "sma", Params: []float64{3}}, {Name: "SMA", Params: []float64{3}}}
+
+	t.Run("range", func(t *testing.T) {
+		stream := &indicatorsStream{}
+
+		err := server.GetIndicators(&pb.IndicatorsRequest{
+			Exchange:   "paged",
+			Ticker:     "BTCUSDT",
+			Interval:   "1h",
+			StartTime:  start.Add(10 * time.Hour).UnixMilli(),
+			Limit:      3,
+			Indicators: sma,
+		}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, start.Add(8*time.Hour), adapter.query.StartTime.UTC())
+		assert.Equal(t, int64(5), adapter.query.Limit)
+		require.Len(t, stream.sent, 3)
+		assert.Equal(t, start.Add(10*time.Hour).UnixMilli(), stream.sent[0].OpenTime)
+		assert.Equal(t, 10.0, stream.sent[0].Close)
+		assert.Equal(t, map[string]float64{"sma(3)": 9}, stream.sent[0].Values)
+		assert.Equal(t, map[string]float64{"sma(3)": 11}, stream.sent[2].Values)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		stream := &indicatorsStream{}
+
+		err := server.GetIndicators(&pb.IndicatorsRequest{Exchange: "paged", Ticker: "BTCUSDT", Interval: "1h", Limit: 2, Indicators: sma}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), adapter.query.Limit)
+		require.Len(t, stream.sent, 2)
+		assert.Equal(t, map[string]float64{"sma(3)": 27}, stream.sent[0].Values)
+		assert.Equal(t, map[string]float64{"sma(3)": 28}, stream.sent[1].Values)
+	})
+
+	for name, req := range map[string]*pb.IndicatorsRequest{
+		"unsupported exchange": {Exchange: "unknown", Ticker: "BTCUSDT", Indicators: sma},
+		"no indicators":        {Exchange: "paged", Ticker: "BTCUSDT"},
+		"unknown indicator":    {Exchange: "paged", Ticker: "BTCUSDT", Indicators: []*pb.IndicatorSpec{{Name: "stochastic"}}},
+		"invalid parameters":   {Exchange: "paged", Ticker: "BTCUSDT", Indicators: []*pb.IndicatorSpec{{Name: "rsi", Params: []float64{-1}}}},
+		"invalid interval":     {Exchange: "paged", Ticker: "BTCUSDT", Interval: "3x", Indicators: sma},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := server.GetIndicators(req, &indicatorsStream{})
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+// TestGetPricesComposite tests serving the composite index over configured constituents
+func TestGetPricesComposite(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var hourly []*pb.PricesResponse
+	for i := 0; i < 2; i++ {
+		open := start.Add(time.Duration(i) * time.Hour)
+		hourly = append(hourly, &pb.PricesResponse{OpenTime: open.UnixMilli(), Open: 100, High: 101, Low: 99, Close: 100, Volume: 2})
+	}
+	adapter := &listingAdapter{pagedAdapter: pagedAdapter{pages: [][]*pb.PricesResponse{hourly}}}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	server := &Server{exchangeFactory: factory}
+
+	require.NoError(t, server.configureComposite("paged:2", "0.1"))
+	composite, exists := factory.GetAdapter(exchanges.CompositeExchange)
+	require.True(t, exists)
+	assert.Equal(t, []exchanges.Constituent{{Exchange: "paged", Weight: 2}}, composite.(*exchanges.CompositeAdapter).Constituents())
+
+	var sent []*pb.PricesResponse
+	stream := &recordingStream{
+		ctx:    context.Background(),
+		onSend: func(response *pb.PricesResponse) error { sent = append(sent, response); return nil },
+	}
+
+	err := server.GetPrices(&pb.PricesRequest{
+		Exchange:  exchanges.CompositeExchange,
+		Ticker:    "BTC/USDT",
+		Interval:  "1h",
+		StartTime: start.UnixMilli(),
+		EndTime:   start.Add(time.Hour).UnixMilli(),
+	}, stream)
+
+	require.NoError(t, err)
+	assert.Equal(t, "BTC/USDT", adapter.query.Ticker)
+	require.Len(t, sent, 2)
+	assert.Equal(t, 100.0, sent[0].Close)
+	require.Len(t, sent[0].Venues, 1)
+	assert.Equal(t, "paged", sent[0].Venues[0].Exchange)
+	assert.Equal(t, 1.0, sent[0].Venues[0].Share)
+
+	for name, config := range map[string][2]string{
+		"unknown exchange":  {"paged,unknown", ""},
+		"invalid weight":    {"paged:0", ""},
+		"invalid deviation": {"", "-1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, server.configureComposite(config[0], config[1]))
+		})
+	}
+}
+
+// symbolsAdapter is a listing adapter that also lists symbols
+type symbolsAdapter struct {
+	listingAdapter
+	symbols []exchanges.SymbolInfo
+}
+
+func (a *symbolsAdapter) GetSymbols(ctx context.Context, market exchanges.Market) ([]exchanges.SymbolInfo, error) {
+	if market != exchanges.MarketSpot {
+		return nil, fmt.Errorf("%w: paged does not support %s", exchanges.ErrUnsupportedMarket, market)
+	}
+	return a.symbols, nil
+}
+
+// TestListExchanges tests describing the supported exchanges
+func TestListExchanges(t *testing.T) {
+	server := &Server{exchangeFactory: exchanges.NewExchangeFactory()}
+
+	resp, err := server.ListExchanges(context.Background(), &pb.ListExchangesRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Exchanges, 6)
+	assert.Equal(t, exchanges.CompositeExchange, resp.Exchanges[3].Name)
+	okx := resp.Exchanges[5]
+	assert.Equal(t, "okx", okx.Name)
+	assert.Equal(t, []string{"spot", "linear_perp", "inverse_perp", "dated_future"}, okx.Markets)
+	assert.Equal(t, []string{"1m", "5m", "15m", "1h", "4h", "1d", "1w", "1M"}, okx.Intervals)
+	assert.Equal(t, []string{"last"}, okx.PriceTypes)
+	assert.Equal(t, int32(100), okx.MaxPageSize)
+}
+
+// TestListSymbols tests listing the symbols of an exchange market
+func TestListSymbols(t *testing.T) {
+	listed := time.Date(2017, 8, 17, 0, 0, 0, 0, time.UTC)
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(&symbolsAdapter{symbols: []exchanges.SymbolInfo{
+		{Symbol: "BTCUSDT", Base: "BTC", Quote: "USDT", Status: exchanges.SymbolStatusTrading, TickSize: "0.01", ListingTime: listed},
+		{Symbol: "ETHUSDT", Base: "ETH", Quote: "USDT", Status: exchanges.SymbolStatusHalted, TickSize: "0.01"},
+	}})
+	server := &Server{exchangeFactory: factory}
+
+	resp, err := server.ListSymbols(context.Background(), &pb.ListSymbolsRequest{Exchange: "paged"})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Symbols, 2)
+	assert.Equal(t, "BTCUSDT", resp.Symbols[0].Symbol)
+	assert.Equal(t, "trading", resp.Symbols[0].Status)
+	assert.Equal(t, "0.01", resp.Symbols[0].TickSize)
+	assert.Equal(t, listed.UnixMilli(), resp.Symbols[0].ListingTime)
+	assert.Equal(t, "BTC/USDT", resp.Symbols[0].CanonicalSymbol)
+	assert.Zero(t, resp.Symbols[1].ListingTime)
+
+	for name, req := range map[string]*pb.ListSymbolsRequest{
+		"unknown exchange":   {Exchange: "nope"},
+		"unknown market":     {Exchange: "paged", Market: "options"},
+		"unsupported market": {Exchange: "paged", Market: "linear_perp"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := server.ListSymbols(context.Background(), req)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+
+	t.Run("exchanges without symbol lists are rejected", func(t *testing.T) {
+		factory.RegisterAdapter(&listingAdapter{})
+		_, err := server.ListSymbols(context.Background(), &pb.ListSymbolsRequest{Exchange: "paged", Market: "inverse_perp"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "does not provide symbols")
+	})
+}
+
+// TestGetPricesCanonicalSymbol tests that canonical symbols are translated into the ticker of the exchange
+func TestGetPricesCanonicalSymbol(t *testing.T) {
+	adapter := &symbolsAdapter{symbols: []exchanges.SymbolInfo{
+		{Symbol: "XBTUSD", Base: "XBT", Quote: "USD", Status: exchanges.SymbolStatusTrading},
+	}}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	server := &Server{exchangeFactory: factory}
+	stream := &recordingStream{
+		ctx:    context.Background(),
+		onSend: func(response *pb.PricesResponse) error { return nil },
+	}
+
+	err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "BTC/USD"}, stream)
+	require.NoError(t, err)
+	assert.Equal(t, "XBTUSD", adapter.query.Ticker)
+
+	err = server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "ETH/USD"}, stream)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "does not list ETH/USD for spot")
+}
+
+// TestGetPricesConverted tests converting prices into another quote asset
+func TestGetPricesConverted(t *testing.T) {
+	open := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	adapter := &symbolsAdapter{
+		listingAdapter: listingAdapter{pagedAdapter: pagedAdapter{pages: [][]*pb.PricesResponse{{
+			{OpenTime: open.UnixMilli(), Open: 2, High: 4, Low: 1, Close: 2, Volume: 1},
+		}}}},
+		symbols: []exchanges.SymbolInfo{
+			{Symbol: "ETHUSDT", Base: "ETH", Quote: "USDT", Status: exchanges.SymbolStatusTrading},
+			{Symbol: "BTCUSDT", Base: "BTC", Quote: "USDT", Status: exchanges.SymbolStatusTrading},
+		},
+	}
+	factory := exchanges.NewExchangeFactory()
+	factory.RegisterAdapter(adapter)
+	server := &Server{exchangeFactory: factory}
+
+	var sent []*pb.PricesResponse
+	stream := &recordingStream{
+		ctx:    context.Background(),
+		onSend: func(response *pb.PricesResponse) error { sent = append(sent, response); return nil },
+	}
+
+	// The adapter serves the same candle for every ticker, so the cross rate is one
+	err := server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "ETHUSDT", Interval: "1h", ConvertTo: "btc"}, stream)
+
+	require.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", adapter.query.Ticker)
+	require.Len(t, sent, 1)
+	assert.Equal(t, 1.0, sent[0].Close)
+	assert.Equal(t, 4.0, sent[0].High)
+	assert.Equal(t, 0.25, sent[0].Low)
+	assert.Equal(t, &pb.Conversion{To: "BTC", RateTicker: "BTCUSDT", Inverted: true, RateClose: 0.5, ApproximateHighLow: true}, sent[0].Conversion)
+
+	err = server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "ETHUSDT", ConvertTo: "EUR"}, stream)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "paged lists neither USDT/EUR nor EUR/USDT")
+
+	// Converted prices have no exact decimal strings
+	err = server.GetPrices(&pb.PricesRequest{Exchange: "paged", Ticker: "ETHUSDT", ConvertTo: "btc", IncludeDecimals: true}, stream)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "include_decimals cannot be combined with convert_to")
+}
+
+// streamingAdapter is a symbols adapter that also streams live candles, pushing
+// its updates and then failing with err, or waiting for cancellation without one
+type streamingAdapter struct {
+	symbolsAdapter
+	updates []exchanges.CandleUpdate
+	err     error
+
+	mu     sync.Mutex
+	stream exchanges.StreamQuery
+}
+
+func (a *streamingAdapter) StreamCandles(ctx context.Context, query exchanges.StreamQuery, handle exchanges.CandleUpdateHandler) error {
+	a.mu.Lock()
+	a.stream = query
+	a.mu.Unlock()
+
+	for _, update := range a.updates {
+		if err := handle(update); err != nil {
+			return err
+		}
+	}
+	if a.err != nil {
+		return a.err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// candleUpdatesStream is a SubscribeCandles stream that records every sent update,
+// cancelling its context once it has received limit updates
+type candleUpdatesStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	limit  int
+	sent   []*pb.CandleUpdate
+}
+
+func newCandleUpdatesStream(limit int) *candleUpdatesStream {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return &candleUpdatesStream{ctx: ctx, cancel: cancel, limit: limit}
+}
+
+func (s *candleUpdatesStream) Send(update *pb.CandleUpdate) error {
+	s.sent = append(s.sent, update)
+	if len(s.sent) == s.limit {
+		s.cancel()
+	}
+	return nil
+}
+
+func (s *candleUpdatesStream) Context() context.Context {
+	return s.ctx
+}
+
+// TestSubscribeCandles tests streaming live candles
+func TestSubscribeCandles(t *testing.T) {
+	newStreamingServer := func(adapter *streamingAdapter) *Server {
+		adapter.symbols = []exchanges.SymbolInfo{{Symbol: "XBTUSD", Base: "XBT", Quote: "USD", Status: exchanges.SymbolStatusTrading}}
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+		return &Server{exchangeFactory: factory, live: live.NewHub()}
+	}
+
+	t.Run("updates are streamed until the client leaves", func(t *testing.T) {
+		adapter := &streamingAdapter{updates: []exchanges.CandleUpdate{
+			{Candle: &pb.PricesResponse{OpenTime: 60000, Close: 100}},
+			{Candle: &pb.PricesResponse{OpenTime: 60000, Close: 101}, Closed: true},
+		}}
+		server := newStreamingServer(adapter)
+		stream := newCandleUpdatesStream(2)
+		defer stream.cancel()
+
+		err := server.SubscribeCandles(&pb.SubscribeCandlesRequest{Exchange: "paged", Ticker: "BTC/USD", Interval: "1m"}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, stream.sent, 2)
+		assert.False(t, stream.sent[0].Closed)
+		assert.Equal(t, 101.0, stream.sent[1].Candle.Close)
+		assert.True(t, stream.sent[1].Closed)
+		adapter.mu.Lock()
+		assert.Equal(t, exchanges.StreamQuery{Ticker: "XBTUSD", Market: exchanges.MarketSpot, Interval: exchanges.Interval1m}, adapter.stream)
+		adapter.mu.Unlock()
+		assert.Eventually(t, func() bool { return server.live.Streams() == 0 }, 5*time.Second, time.Millisecond)
+	})
+
+	t.Run("stream failures end the subscription", func(t *testing.T) {
+		server := newStreamingServer(&streamingAdapter{err: errors.New("connection refused")})
+		stream := newCandleUpdatesStream(0)
+		defer stream.cancel()
+
+		err := server.SubscribeCandles(&pb.SubscribeCandlesRequest{Exchange: "paged", Ticker: "XBTUSD"}, stream)
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "failed to get live candles")
+	})
+
+	for name, req := range map[string]*pb.SubscribeCandlesRequest{
+		"unknown exchange": {Exchange: "nope", Ticker: "XBTUSD"},
+		"missing ticker":   {Exchange: "paged"},
+		"unknown interval": {Exchange: "paged", Ticker: "XBTUSD", Interval: "2m"},
+		"unknown market":   {Exchange: "paged", Ticker: "XBTUSD", Market: "options"},
+		"unknown symbol":   {Exchange: "paged", Ticker: "ETH/USD"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := newStreamingServer(&streamingAdapter{})
+
+			err := server.SubscribeCandles(req, newCandleUpdatesStream(0))
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+
+	t.Run("exchange without live candles", func(t *testing.T) {
+		err := NewServer().SubscribeCandles(&pb.SubscribeCandlesRequest{Exchange: "coinbase", Ticker: "BTC-USD"}, newCandleUpdatesStream(0))
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "coinbase does not stream live candles", status.Convert(err).Message())
+	})
+}
+
+// fundingAdapter is a paged adapter that also serves funding rates, recording the query it receives
+type fundingAdapter struct {
+	pagedAdapter
+	rates []*pb.FundingRate
+	query exchanges.FundingRateQuery
+	err   error
+}
+
+func (a *fundingAdapter) GetFundingRates(ctx context.Context, query exchanges.FundingRateQuery, handle exchanges.FundingRateHandler) error {
+	a.query = query
+	if a.err != nil {
+		return a.err
+	}
+	return handle(a.rates)
+}
+
+// fundingStream is a GetFundingRates stream that records every sent funding rate
+type fundingStream struct {
+	grpc.ServerStream
+	sent []*pb.FundingRate
+}
+
+func (s *fundingStream) Send(rate *pb.FundingRate) error {
+	s.sent = append(s.sent, rate)
+	return nil
+}
+
+func (s *fundingStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestGetFundingRates tests the funding rate history RPC
+func TestGetFundingRates(t *testing.T) {
+	newFundingServer := func(adapter *fundingAdapter) *Server {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+		return &Server{exchangeFactory: factory}
+	}
+
+	t.Run("successful funding rate retrieval", func(t *testing.T) {
+		adapter := &fundingAdapter{rates: []*pb.FundingRate{
+			{FundingTime: 1000, Rate: 0.0001, MarkPrice: 16500, HasMarkPrice: true},
+			{FundingTime: 2000, Rate: -0.0002},
+		}}
+		server := newFundingServer(adapter)
+		stream := &fundingStream{}
+
+		err := server.GetFundingRates(&pb.FundingRatesRequest{Exchange: "paged", Ticker: "BTCUSDT"}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, adapter.rates, stream.sent)
+		assert.Equal(t, exchanges.FundingRateQuery{Ticker: "BTCUSDT", Market: exchanges.MarketLinearPerp, Limit: 100}, adapter.query)
+	})
+
+	t.Run("time range and market", func(t *testing.T) {
+		adapter := &fundingAdapter{}
+		server := newFundingServer(adapter)
+
+		err := server.GetFundingRates(&pb.FundingRatesRequest{
+			Exchange:  "paged",
+			Ticker:    "BTCUSD",
+			Market:    "inverse_perp",
+			StartTime: 1672531200000,
+			EndTime:   1675209600000,
+		}, &fundingStream{})
+
+		require.NoError(t, err)
+		assert.Equal(t, exchanges.FundingRateQuery{
+			Ticker:    "BTCUSD",
+			Market:    exchanges.MarketInversePerp,
+			StartTime: time.UnixMilli(1672531200000),
+			EndTime:   time.UnixMilli(1675209600000),
+		}, adapter.query)
+	})
+
+	t.Run("market rejected by adapter", func(t *testing.T) {
+		adapter := &fundingAdapter{err: fmt.Errorf("%w: paged does not support spot", exchanges.ErrUnsupportedMarket)}
+		server := newFundingServer(adapter)
+
+		err := server.GetFundingRates(&pb.FundingRatesRequest{Exchange: "paged", Ticker: "BTCUSDT", Market: "spot"}, &fundingStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+
+	t.Run("exchange without funding rates", func(t *testing.T) {
+		err := NewServer().GetFundingRates(&pb.FundingRatesRequest{Exchange: "coinbase", Ticker: "BTCUSD"}, &fundingStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Equal(t, "coinbase does not provide funding rates", statusErr.Message())
+	})
+
+	t.Run("unsupported exchange", func(t *testing.T) {
+		err := NewServer().GetFundingRates(&pb.FundingRatesRequest{Exchange: "unknown", Ticker: "BTCUSDT"}, &fundingStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+
+	t.Run("adapter error", func(t *testing.T) {
+		adapter := &fundingAdapter{err: errors.New("API error")}
+		server := newFundingServer(adapter)
+
+		err := server.GetFundingRates(&pb.FundingRatesRequest{Exchange: "paged", Ticker: "BTCUSDT"}, &fundingStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Internal, statusErr.Code())
+		assert.Contains(t, statusErr.Message(), "failed to get funding rates")
+	})
+}
+
+// statsAdapter is a paged adapter that also serves derivatives statistics, recording the query it receives
+type statsAdapter struct {
+	pagedAdapter
+	stats []*pb.DerivativesStat
+	query exchanges.DerivativesStatsQuery
+	err   error
+}
+
+func (a *statsAdapter) GetDerivativesStats(ctx context.Context, query exchanges.DerivativesStatsQuery, handle exchanges.DerivativesStatsHandler) error {
+	a.query = query
+	if a.err != nil {
+		return a.err
+	}
+	return handle(a.stats)
+}
+
+// statsStream is a GetDerivativesStats stream that records every sent sample
+type statsStream struct {
+	grpc.ServerStream
+	sent []*pb.DerivativesStat
+}
+
+func (s *statsStream) Send(stat *pb.DerivativesStat) error {
+	s.sent = append(s.sent, stat)
+	return nil
+}
+
+func (s *statsStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestGetDerivativesStats tests the open interest and long/short ratio RPC
+func TestGetDerivativesStats(t *testing.T) {
+	newStatsServer := func(adapter *statsAdapter) *Server {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+		return &Server{exchangeFactory: factory}
+	}
+
+	t.Run("successful retrieval with defaults", func(t *testing.T) {
+		adapter := &statsAdapter{stats: []*pb.DerivativesStat{
+			{Timestamp: 1000, OpenInterest: 85000.5, OpenInterestValue: 1.4e9, HasOpenInterestValue: true},
+			{Timestamp: 2000, OpenInterest: 85100},
+		}}
+		server := newStatsServer(adapter)
+		stream := &statsStream{}
+
+		err := server.GetDerivativesStats(&pb.DerivativesStatsRequest{Exchange: "paged", Ticker: "BTCUSDT"}, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, adapter.stats, stream.sent)
+		assert.Equal(t, exchanges.DerivativesStatsQuery{
+			Ticker: "BTCUSDT",
+			Market: exchanges.MarketLinearPerp,
+			Stat:   exchanges.StatOpenInterest,
+			Period: exchanges.StatPeriod1h,
+			Limit:  100,
+		}, adapter.query)
+	})
+
+	t.Run("requested statistic, period and range", func(t *testing.T) {
+		adapter := &statsAdapter{}
+		server := newStatsServer(adapter)
+
+		err := server.GetDerivativesStats(&pb.DerivativesStatsRequest{
+			Exchange:  "paged",
+			Ticker:    "BTCUSD",
+			Market:    "inverse_perp",
+			Stat:      "global_long_short_ratio",
+			Period:    "4h",
+			StartTime: 1672531200000,
+			EndTime:   1675209600000,
+		}, &statsStream{})
+
+		require.NoError(t, err)
+		assert.Equal(t, exchanges.DerivativesStatsQuery{
+			Ticker:    "BTCUSD",
+			Market:    exchanges.MarketInversePerp,
+			Stat:      exchanges.StatGlobalLongShortRatio,
+			Period:    exchanges.StatPeriod4h,
+			StartTime: time.UnixMilli(1672531200000),
+			EndTime:   time.UnixMilli(1675209600000),
+		}, adapter.query)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		server := newStatsServer(&statsAdapter{})
+
+		for _, req := range []*pb.DerivativesStatsRequest{
+			{Exchange: "paged", Ticker: "BTCUSDT", Stat: "liquidations"},
+			{Exchange: "paged", Ticker: "BTCUSDT", Period: "1m"},
+			{Exchange: "paged", Ticker: "BTCUSDT", Market: "options"},
+			{Exchange: "paged", Ticker: "BTCUSDT", StartTime: 2000, EndTime: 1000},
+		} {
+			err := server.GetDerivativesStats(req, &statsStream{})
+
+			statusErr, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, codes.InvalidArgument, statusErr.Code(), req.String())
+		}
+	})
+
+	t.Run("statistic rejected by adapter", func(t *testing.T) {
+		adapter := &statsAdapter{err: fmt.Errorf("%w: paged does not serve top_trader_long_short_ratio", exchanges.ErrUnsupportedDerivativesStat)}
+		server := newStatsServer(adapter)
+
+		err := server.GetDerivativesStats(&pb.DerivativesStatsRequest{Exchange: "paged", Ticker: "BTCUSDT", Stat: "top_trader_long_short_ratio"}, &statsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+
+	t.Run("exchange without statistics", func(t *testing.T) {
+		err := NewServer().GetDerivativesStats(&pb.DerivativesStatsRequest{Exchange: "kraken", Ticker: "BTCUSD"}, &statsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Equal(t, "kraken does not provide derivatives statistics", statusErr.Message())
+	})
+
+	t.Run("adapter error", func(t *testing.T) {
+		adapter := &statsAdapter{err: errors.New("API error")}
+		server := newStatsServer(adapter)
+
+		err := server.GetDerivativesStats(&pb.DerivativesStatsRequest{Exchange: "paged", Ticker: "BTCUSDT"}, &statsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Internal, statusErr.Code())
+		assert.Contains(t, statusErr.Message(), "failed to get open_interest")
+	})
+}
+
+// tradesAdapter is a paged adapter that also serves trades, recording the query it receives
+type tradesAdapter struct {
+	pagedAdapter
+	pages [][]*pb.Trade
+	query exchanges.TradeQuery
+	err   error
+}
+
+func (a *tradesAdapter) GetTrades(ctx context.Context, query exchanges.TradeQuery, handle exchanges.TradeHandler) error {
+	a.query = query
+	for _, page := range a.pages {
+		if err := handle(page); err != nil {
+			return err
+		}
+	}
+	return a.err
+}
+
+// tradesStream is a GetTrades stream that records every sent trade
+type tradesStream struct {
+	grpc.ServerStream
+	sent []*pb.Trade
+}
+
+func (s *tradesStream) Send(trade *pb.Trade) error {
+	s.sent = append(s.sent, trade)
+	return nil
+}
+
+func (s *tradesStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestGetTrades tests the historical trades RPC
+func TestGetTrades(t *testing.T) {
+	newTradesServer := func(adapter *tradesAdapter) *Server {
+		factory := exchanges.NewExchangeFactory()
+		factory.RegisterAdapter(adapter)
+		return &Server{exchangeFactory: factory}
+	}
+
+	t.Run("trades streamed page by page", func(t *testing.T) {
+		adapter := &tradesAdapter{pages: [][]*pb.Trade{
+			{{TradeId: "1", Timestamp: 1000, Price: 16500, Quantity: 0.5, Side: "buy", TradeCount: 2}},
+			{{TradeId: "2", Timestamp: 2000, Price: 16501, Quantity: 0.1, Side: "sell", TradeCount: 1}},
+		}}
+		server := newTradesServer(adapter)
+		stream := &tradesStream{}
+
+		err := server.GetTrades(&pb.TradesRequest{
+			Exchange:  "paged",
+			Ticker:    "BTCUSDT",
+			Market:    "linear_perp",
+			Limit:     5000,
+			StartTime: 1672531200000,
+			EndTime:   1672534800000,
+		}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, stream.sent, 2)
+		assert.Equal(t, "1", stream.sent[0].TradeId)
+		assert.Equal(t, "2", stream.sent[1].TradeId)
+		assert.Equal(t, exchanges.TradeQuery{
+			Ticker:    "BTCUSDT",
+			Market:    exchanges.MarketLinearPerp,
+			Limit:     5000,
+			StartTime: time.UnixMilli(1672531200000),
+			EndTime:   time.UnixMilli(1672534800000),
+		}, adapter.query)
+	})
+
+	t.Run("start time required", func(t *testing.T) {
+		adapter := &tradesAdapter{}
+		server := newTradesServer(adapter)
+
+		err := server.GetTrades(&pb.TradesRequest{Exchange: "paged", Ticker: "BTCUSDT"}, &tradesStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Empty(t, adapter.query.Ticker)
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		server := newTradesServer(&tradesAdapter{})
+
+		err := server.GetTrades(&pb.TradesRequest{Exchange: "paged", Ticker: "BTCUSDT", StartTime: 1000, Limit: -1}, &tradesStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+
+	t.Run("exchange without trades", func(t *testing.T) {
+		err := NewServer().GetTrades(&pb.TradesRequest{Exchange: "coinbase", Ticker: "BTCUSD", StartTime: 1000}, &tradesStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+		assert.Equal(t, "coinbase does not provide trades", statusErr.Message())
+	})
+
+	t.Run("adapter error after first page", func(t *testing.T) {
+		adapter := &tradesAdapter{
+			pages: [][]*pb.Trade{{{TradeId: "1", Timestamp: 1000, Side: "buy", TradeCount: 1}}},
+			err:   errors.New("API error"),
+		}
+		server := newTradesServer(adapter)
+		stream := &tradesStream{}
+
+		err := server.GetTrades(&pb.TradesRequest{Exchange: "paged", Ticker: "BTCUSDT", StartTime: 1000}, stream)
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Internal, statusErr.Code())
+		assert.Contains(t, statusErr.Message(), "failed to get trades")
+		assert.Len(t, stream.sent, 1)
+	})
+}
+
+// snapshotsStream is a GetOrderBookSnapshots stream that records every sent snapshot
+type snapshotsStream struct {
+	grpc.ServerStream
+	sent []*pb.OrderBookSnapshot
+}
+
+func (s *snapshotsStream) Send(snapshot *pb.OrderBookSnapshot) error {
+	s.sent = append(s.sent, snapshot)
+	return nil
+}
+
+func (s *snapshotsStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestGetOrderBookSnapshots tests querying recorded order book snapshots
+func TestGetOrderBookSnapshots(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:TestGetOrderBookSnapshots?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := orderbook.NewGormStore(db)
+	require.NoError(t, store.Migrate())
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	symbol := orderbook.Symbol{Exchange: "binance", Ticker: "BTCUSDT", Market: exchanges.MarketSpot}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Save(context.Background(), symbol, &pb.OrderBookSnapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Minute).UnixMilli(),
+			UpdateId:  int64(i),
+			Bids:      []*pb.OrderBookLevel{{Price: 100, Quantity: 1}, {Price: 99, Quantity: 2}},
+			Asks:      []*pb.OrderBookLevel{{Price: 101, Quantity: 1}, {Price: 102, Quantity: 2}},
+		}))
+	}
+
+	server := NewServer()
+	server.snapshots = store
+
+	t.Run("nearest snapshot", func(t *testing.T) {
+		stream := &snapshotsStream{}
+
+		err := server.GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{
+			Exchange: "binance",
+			Ticker:   "BTCUSDT",
+			At:       start.Add(110 * time.Second).UnixMilli(),
+			Depth:    1,
+		}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, stream.sent, 1)
+		assert.Equal(t, int64(2), stream.sent[0].UpdateId)
+		assert.Equal(t, []*pb.OrderBookLevel{{Price: 100, Quantity: 1}}, stream.sent[0].Bids)
+		assert.Equal(t, []*pb.OrderBookLevel{{Price: 101, Quantity: 1}}, stream.sent[0].Asks)
+	})
+
+	t.Run("range of snapshots", func(t *testing.T) {
+		stream := &snapshotsStream{}
+
+		err := server.GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{
+			Exchange:  "binance",
+			Ticker:    "BTCUSDT",
+			StartTime: start.Add(time.Minute).UnixMilli(),
+			EndTime:   start.Add(3 * time.Minute).UnixMilli(),
+		}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, stream.sent, 3)
+		assert.Equal(t, int64(1), stream.sent[0].UpdateId)
+		assert.Equal(t, int64(3), stream.sent[2].UpdateId)
+		assert.Len(t, stream.sent[0].Bids, 2)
+	})
+
+	t.Run("latest snapshots", func(t *testing.T) {
+		stream := &snapshotsStream{}
+
+		err := server.GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{Exchange: "binance", Ticker: "BTCUSDT", Limit: 2}, stream)
+
+		require.NoError(t, err)
+		require.Len(t, stream.sent, 2)
+		assert.Equal(t, int64(3), stream.sent[0].UpdateId)
+		assert.Equal(t, int64(4), stream.sent[1].UpdateId)
+	})
+
+	t.Run("nothing recorded", func(t *testing.T) {
+		err := server.GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{Exchange: "bybit", Ticker: "BTCUSDT", At: start.UnixMilli()}, &snapshotsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.NotFound, statusErr.Code())
+	})
+
+	t.Run("at combined with a range", func(t *testing.T) {
+		err := server.GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{
+			Exchange:  "binance",
+			Ticker:    "BTCUSDT",
+			At:        start.UnixMilli(),
+			StartTime: start.UnixMilli(),
+		}, &snapshotsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+
+	t.Run("recording not enabled", func(t *testing.T) {
+		err := NewServer().GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{Exchange: "binance", Ticker: "BTCUSDT"}, &snapshotsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Unavailable, statusErr.Code())
+	})
+
+	t.Run("unsupported exchange", func(t *testing.T) {
+		err := server.GetOrderBookSnapshots(&pb.OrderBookSnapshotsRequest{Exchange: "unknown", Ticker: "BTCUSDT"}, &snapshotsStream{})
+
+		statusErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, statusErr.Code())
+	})
+}
+
+// TestWatchlist tests managing the series kept up to date by ingestion
+func TestWatchlist(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ingestion not enabled", func(t *testing.T) {
+		server := NewServer()
+
+		_, err := server.ListWatchlist(ctx, &pb.ListWatchlistRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	db, err := gorm.Open(sqlite.Open("file:TestWatchlist?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	watchlist := ingest.NewGormWatchlist(db)
+	require.NoError(t, watchlist.Migrate())
+	server := NewServer()
+	server.watchlist = watchlist
+
+	t.Run("invalid entries", func(t *testing.T) {
+		for _, req := range []*pb.WatchlistEntry{
+			{Exchange: "unknown", Ticker: "BTCUSDT"},
+			{Exchange: "binance"},
+			{Exchange: "binance", Ticker: "BTCUSDT", Interval: "1w"},
+			{Exchange: "binance", Ticker: "BTCUSDT", Market: "options"},
+			{Exchange: "coinbase", Ticker: "BTC-USD", PriceType: "mark"},
+		} {
+			_, err := server.AddWatchlistEntry(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), "%v", req)
+		}
+	})
+
+	t.Run("intervals the exchange does not serve", func(t *testing.T) {
+		_, err := server.AddWatchlistEntry(ctx, &pb.WatchlistEntry{Exchange: "coinbase", Ticker: "BTC-USD", Interval: "4h"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "coinbase does not serve 4h candles")
+	})
+
+	t.Run("add, list and remove", func(t *testing.T) {
+		added, err := server.AddWatchlistEntry(ctx, &pb.WatchlistEntry{Exchange: "binance", Ticker: "btcusdt", Interval: "1h"})
+		require.NoError(t, err)
+		assert.NotZero(t, added.Id)
+		assert.Equal(t, "BTCUSDT", added.Ticker)
+		assert.Equal(t, "spot", added.Market)
+		assert.Equal(t, "last", added.PriceType)
+		assert.Equal(t, time.UnixMilli(added.CreatedAt).Truncate(time.Hour).UnixMilli(), added.IngestedUntil)
+
+		_, err = server.AddWatchlistEntry(ctx, &pb.WatchlistEntry{Exchange: "binance", Ticker: "BTCUSDT", Interval: "1h"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		listed, err := server.ListWatchlist(ctx, &pb.ListWatchlistRequest{})
+		require.NoError(t, err)
+		require.Len(t, listed.Entries, 1)
+		assert.Equal(t, added.Id, listed.Entries[0].Id)
+
+		_, err = server.RemoveWatchlistEntry(ctx, &pb.RemoveWatchlistEntryRequest{Id: added.Id})
+		require.NoError(t, err)
+		_, err = server.RemoveWatchlistEntry(ctx, &pb.RemoveWatchlistEntryRequest{Id: added.Id})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+// TestGetCoverage tests reporting the coverage of stored series
+func TestGetCoverage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("candle store not enabled", func(t *testing.T) {
+		_, err := NewServer().GetCoverage(ctx, &pb.CoverageRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	db, err := gorm.Open(sqlite.Open("file:TestGetCoverage?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := candles.NewGormStore(db)
+	require.NoError(t, store.Migrate())
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	daily := func(day int) *pb.PricesResponse {
+		open := start.AddDate(0, 0, day)
+		return &pb.PricesResponse{
+			OpenTime:      open.UnixMilli(),
+			CloseTime:     exchanges.Interval1d.CloseTime(open).UnixMilli(),
+			SchemaVersion: exchanges.CandleSchemaVersion,
+		}
+	}
+	btc := candles.Series{Exchange: "binance", Ticker: "BTCUSDT", Interval: exchanges.Interval1d}
+	eth := candles.Series{Exchange: "binance", Ticker: "ETHUSDT", Interval: exchanges.Interval1d}
+	require.NoError(t, store.Save(ctx, btc, []*pb.PricesResponse{daily(0), daily(1), daily(4)}))
+	require.NoError(t, store.Save(ctx, eth, []*pb.PricesResponse{daily(0)}))
+
+	server := NewServer()
+	server.candles = store
+
+	t.Run("all series", func(t *testing.T) {
+		response, err := server.GetCoverage(ctx, &pb.CoverageRequest{})
+
+		require.NoError(t, err)
+		assert.Len(t, response.Series, 2)
+	})
+
+	t.Run("filtered series", func(t *testing.T) {
+		response, err := server.GetCoverage(ctx, &pb.CoverageRequest{Exchange: "binance", Ticker: "btcusdt", Interval: "1d"})
+
+		require.NoError(t, err)
+		require.Len(t, response.Series, 1)
+		coverage := response.Series[0]
+		assert.Equal(t, "BTCUSDT", coverage.Ticker)
+		assert.Equal(t, "spot", coverage.Market)
+		assert.Equal(t, int64(3), coverage.CandleCount)
+		require.Len(t, coverage.Windows, 2)
+		assert.Equal(t, int64(2), coverage.Windows[0].CandleCount)
+		require.Len(t, coverage.Missing, 1)
+		assert.Equal(t, start.AddDate(0, 0, 2).UnixMilli(), coverage.Missing[0].StartTime)
+		assert.Equal(t, start.AddDate(0, 0, 3).UnixMilli(), coverage.Missing[0].EndTime)
+		assert.Equal(t, int64(2), coverage.Missing[0].CandleCount)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := server.GetCoverage(ctx, &pb.CoverageRequest{Interval: "2h"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 }